@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/mrinalwahal/service/service"
+)
+
+// BatchItemBytes is the maximum size of the request bodies of the batches per item, in bytes.
+//
+// The bodies of the batches are limited to `BatchItemBytes` per item of the largest batch, so the oversized bodies
+// are rejected while they are read, before the size of the batch is checked.
+const BatchItemBytes = 8 << 10
+
+// BatchItemError is the error of an item of a batch, as returned by the batch handlers.
+type BatchItemError struct {
+
+	// Index of the item in the batch.
+	Index int `json:"index"`
+
+	// Error of the item.
+	Error string `json:"error"`
+}
+
+// writeBatchError writes the error of a batch operation, along with the errors of its items, if any.
+//
+// The batches are all-or-nothing, so none of the items were applied.
+func writeBatchError(w http.ResponseWriter, message string, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, service.ErrVersionMismatch) {
+		status = http.StatusPreconditionFailed
+	}
+
+	response := &Response{
+		Message: message,
+		Err:     err,
+	}
+	var batch *service.BatchError
+	if errors.As(err, &batch) {
+		items := make([]*BatchItemError, len(batch.Items))
+		for i, item := range batch.Items {
+			items[i] = &BatchItemError{
+				Index: item.Index,
+				Error: item.Err.Error(),
+			}
+		}
+		response.Data = items
+	}
+	write(w, status, response)
+}
+
+// itemErrors collects the errors of the items of a batch, by index.
+type itemErrors []*service.ItemError
+
+// add records the error of the item at the supplied index.
+func (e *itemErrors) add(index int, err error) {
+	*e = append(*e, &service.ItemError{Index: index, Err: err})
+}
+
+// err returns the batch error of the items, or nil if no item failed.
+func (e itemErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return &service.BatchError{Items: e}
+}
+
+// limitBatch limits the size of the request body of a batch of at most `size` items.
+func limitBatch(w http.ResponseWriter, r *http.Request, size int) {
+	r.Body = http.MaxBytesReader(w, r.Body, int64(size+1)*BatchItemBytes)
+}
+
+// writeDecodeError writes the error of decoding the request body of a batch, which is `413 Request Entity Too
+// Large` when the body exceeds its limit.
+func writeDecodeError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		write(w, http.StatusRequestEntityTooLarge, &Response{
+			Message: "The request body is too large.",
+			Err:     err,
+		})
+		return
+	}
+	write(w, http.StatusBadRequest, &Response{
+		Message: "Invalid request options.",
+		Err:     err,
+	})
+}
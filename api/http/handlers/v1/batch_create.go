@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/mrinalwahal/service/service"
+)
+
+// BatchCreateOptions represents the options for creating records in a batch.
+type BatchCreateOptions struct {
+
+	//	Requests are the records to create, in order.
+	Requests []*CreateOptions `json:"requests"`
+}
+
+// BatchCreate handler creates records in a batch, in a single transaction.
+//
+// Link: https://google.aip.dev/233
+type BatchCreateHandler struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	service service.Service
+
+	// log is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	log *slog.Logger
+
+	// maxBatchSize is the maximum number of items of the batches, which limits the size of the request bodies.
+	maxBatchSize int
+}
+
+type BatchCreateHandlerConfig struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	Service service.Service
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+
+	// MaxBatchSize is the maximum number of items of the batches, which limits the size of the request bodies to
+	// `BatchItemBytes` per item.
+	// Default: `service.DefaultMaxBatchSize`
+	//
+	// This field is optional.
+	MaxBatchSize int
+}
+
+// NewBatchCreateHandler creates a new instance of `BatchCreateHandler`.
+func NewBatchCreateHandler(config *BatchCreateHandlerConfig) Handler {
+	handler := BatchCreateHandler{
+		service:      config.Service,
+		log:          config.Logger,
+		maxBatchSize: config.MaxBatchSize,
+	}
+
+	// Set the default batch size if not provided.
+	if handler.maxBatchSize <= 0 {
+		handler.maxBatchSize = service.DefaultMaxBatchSize
+	}
+
+	// Set the default logger if not provided.
+	if handler.log == nil {
+		handler.log = slog.Default()
+	}
+	handler.log = handler.log.With("handler", "batch_create")
+
+	return &handler
+}
+
+// ServeHTTP handles the incoming HTTP request.
+func (h *BatchCreateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.log.DebugContext(r.Context(), "handling request")
+
+	// Decode the request options, up to the size of the largest batch.
+	limitBatch(w, r, h.maxBatchSize)
+	options, err := decode[BatchCreateOptions](r)
+	if err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// Load the context.
+	ctx := r.Context()
+
+	// Preset and validate the options of every record.
+	var errs itemErrors
+	requests := make([]*service.CreateOptions, len(options.Requests))
+	for i, request := range options.Requests {
+		if request == nil {
+			errs.add(i, ErrInvalidRequestOptions)
+			continue
+		}
+		if err := request.preset(ctx); err != nil {
+			write(w, http.StatusBadRequest, &Response{
+				Message: "Failed to preset options from request claims.",
+				Err:     err,
+			})
+			return
+		}
+		if err := request.validate(); err != nil {
+			errs.add(i, err)
+			continue
+		}
+		requests[i] = &service.CreateOptions{
+			Title:  request.Title,
+			UserID: request.UserID,
+		}
+	}
+	if err := errs.err(); err != nil {
+		writeBatchError(w, "Invalid request options.", err)
+		return
+	}
+
+	// Call the service method that performs the required operation.
+	records, err := h.service.BatchCreate(ctx, &service.BatchCreateOptions{
+		Requests: requests,
+	})
+	if err != nil {
+		writeBatchError(w, "Failed to create the records.", err)
+		return
+	}
+
+	write(w, http.StatusCreated, &Response{
+		Message: "The records were created successfully.",
+		Data:    records,
+	})
+}
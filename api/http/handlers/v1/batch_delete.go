@@ -0,0 +1,108 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/service"
+)
+
+// BatchDeleteOptions represents the options for deleting records in a batch.
+type BatchDeleteOptions struct {
+
+	//	IDs of the records to delete.
+	IDs []uuid.UUID `json:"ids"`
+
+	//	Permanently delete the records, instead of soft-deleting them.
+	//	Default: false
+	Force bool `json:"force"`
+}
+
+// BatchDelete handler deletes records in a batch, in a single transaction.
+//
+// Link: https://google.aip.dev/235
+type BatchDeleteHandler struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	service service.Service
+
+	// log is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	log *slog.Logger
+
+	// maxBatchSize is the maximum number of items of the batches, which limits the size of the request bodies.
+	maxBatchSize int
+}
+
+type BatchDeleteHandlerConfig struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	Service service.Service
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+
+	// MaxBatchSize is the maximum number of items of the batches, which limits the size of the request bodies to
+	// `BatchItemBytes` per item.
+	// Default: `service.DefaultMaxBatchSize`
+	//
+	// This field is optional.
+	MaxBatchSize int
+}
+
+// NewBatchDeleteHandler creates a new instance of `BatchDeleteHandler`.
+func NewBatchDeleteHandler(config *BatchDeleteHandlerConfig) Handler {
+	handler := BatchDeleteHandler{
+		service:      config.Service,
+		log:          config.Logger,
+		maxBatchSize: config.MaxBatchSize,
+	}
+
+	// Set the default batch size if not provided.
+	if handler.maxBatchSize <= 0 {
+		handler.maxBatchSize = service.DefaultMaxBatchSize
+	}
+
+	// Set the default logger if not provided.
+	if handler.log == nil {
+		handler.log = slog.Default()
+	}
+	handler.log = handler.log.With("handler", "batch_delete")
+
+	return &handler
+}
+
+// ServeHTTP handles the incoming HTTP request.
+func (h *BatchDeleteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.log.DebugContext(r.Context(), "handling request")
+
+	// Decode the request options, up to the size of the largest batch.
+	limitBatch(w, r, h.maxBatchSize)
+	options, err := decode[BatchDeleteOptions](r)
+	if err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	if err := h.service.BatchDelete(r.Context(), &service.BatchDeleteOptions{
+		IDs:   options.IDs,
+		Force: options.Force,
+	}); err != nil {
+		writeBatchError(w, "Failed to delete the records.", err)
+		return
+	}
+
+	write(w, http.StatusOK, &Response{
+		Message: "The records were deleted successfully.",
+	})
+}
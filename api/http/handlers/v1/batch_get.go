@@ -0,0 +1,123 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/dyninc/qstring"
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/service"
+)
+
+// BatchGetOptions represents the options for getting records in a batch.
+type BatchGetOptions struct {
+
+	//	IDs of the records to get, either as a comma separated list or as repeated parameters.
+	//
+	//	Example: "ids=6f0f6a8e-...,0e5f4c4d-..."
+	IDs []string `qstring:"ids"`
+
+	//	Return the records even if they have been soft-deleted.
+	//	Default: false
+	ShowDeleted bool `qstring:"show_deleted"`
+}
+
+// ids parses the IDs of the records, in order.
+func (o *BatchGetOptions) ids() ([]uuid.UUID, error) {
+	var (
+		ids  []uuid.UUID
+		errs itemErrors
+	)
+	for _, values := range o.IDs {
+		for _, value := range strings.Split(values, ",") {
+			id, err := uuid.Parse(strings.TrimSpace(value))
+			if err != nil {
+				errs.add(len(ids), ErrInvalidRecordID)
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, errs.err()
+}
+
+// BatchGet handler gets records in a batch.
+//
+// Link: https://google.aip.dev/231
+type BatchGetHandler struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	service service.Service
+
+	// log is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	log *slog.Logger
+}
+
+type BatchGetHandlerConfig struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	Service service.Service
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// NewBatchGetHandler creates a new instance of `BatchGetHandler`.
+func NewBatchGetHandler(config *BatchGetHandlerConfig) Handler {
+	handler := BatchGetHandler{
+		service: config.Service,
+		log:     config.Logger,
+	}
+
+	// Set the default logger if not provided.
+	if handler.log == nil {
+		handler.log = slog.Default()
+	}
+	handler.log = handler.log.With("handler", "batch_get")
+
+	return &handler
+}
+
+// ServeHTTP handles the incoming HTTP request.
+func (h *BatchGetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.log.DebugContext(r.Context(), "handling request")
+
+	// Decode the request options.
+	var options BatchGetOptions
+	if err := qstring.Unmarshal(r.URL.Query(), &options); err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid request options.",
+			Err:     err,
+		})
+		return
+	}
+	ids, err := options.ids()
+	if err != nil {
+		writeBatchError(w, "Invalid IDs.", err)
+		return
+	}
+
+	records, err := h.service.BatchGet(r.Context(), &service.BatchGetOptions{
+		IDs:         ids,
+		ShowDeleted: options.ShowDeleted,
+	})
+	if err != nil {
+		writeBatchError(w, "Failed to get the records.", err)
+		return
+	}
+
+	write(w, http.StatusOK, &Response{
+		Message: "The records were retrieved successfully.",
+		Data:    records,
+	})
+}
@@ -0,0 +1,307 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"github.com/mrinalwahal/service/service"
+	"go.uber.org/mock/gomock"
+)
+
+// batchItems decodes the errors of the items of a batch from the response.
+func batchItems(t *testing.T, w *httptest.ResponseRecorder) []BatchItemError {
+	var response struct {
+		Data []BatchItemError `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to decode the response: %v", err)
+	}
+	return response.Data
+}
+
+// oversized returns a JSON string larger than the request bodies of the batches of the handlers by default.
+func oversized() string {
+	return `"` + strings.Repeat("x", (service.DefaultMaxBatchSize+1)*BatchItemBytes) + `"`
+}
+
+func TestBatchCreateHandler_ServeHTTP(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Create the handler.
+	handler := NewBatchCreateHandler(&BatchCreateHandlerConfig{
+		Service: config.service,
+		Logger:  config.log,
+	})
+
+	userID := uuid.New()
+	ctx := context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+		XUserID: userID,
+	})
+
+	t.Run("batch create records", func(t *testing.T) {
+
+		config.service.EXPECT().BatchCreate(gomock.Any(), &service.BatchCreateOptions{
+			Requests: []*service.CreateOptions{
+				{Title: "First", UserID: userID},
+				{Title: "Second", UserID: userID},
+			},
+		}).Return([]*model.Record{{Title: "First"}, {Title: "Second"}}, nil).Times(1)
+
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"requests": [{"title": "First"}, {"title": "Second"}]}`)).WithContext(ctx)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusCreated {
+			t.Errorf("BatchCreateHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusCreated)
+		}
+	})
+
+	t.Run("batch create w/ invalid records", func(t *testing.T) {
+
+		// Make sure the service layer is not expecting a call.
+		config.service.EXPECT().BatchCreate(gomock.Any(), gomock.Any()).Times(0)
+
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"requests": [{"title": "First"}, {"title": ""}, null]}`)).WithContext(ctx)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("BatchCreateHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+		if items := batchItems(t, w); len(items) != 2 || items[0].Index != 1 || items[1].Index != 2 {
+			t.Errorf("BatchCreateHandler.ServeHTTP() items = %v, want the errors of items 1 and 2", items)
+		}
+	})
+
+	t.Run("batch create w/ too large body", func(t *testing.T) {
+
+		// Make sure the service layer is not expecting a call.
+		config.service.EXPECT().BatchCreate(gomock.Any(), gomock.Any()).Times(0)
+
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"requests": [{"title": `+oversized()+`}]}`)).WithContext(ctx)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("BatchCreateHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusRequestEntityTooLarge)
+		}
+	})
+
+	t.Run("batch create w/o claims", func(t *testing.T) {
+
+		// Make sure the service layer is not expecting a call.
+		config.service.EXPECT().BatchCreate(gomock.Any(), gomock.Any()).Times(0)
+
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"requests": [{"title": "First"}]}`))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("BatchCreateHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestBatchGetHandler_ServeHTTP(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Create the handler.
+	handler := NewBatchGetHandler(&BatchGetHandlerConfig{
+		Service: config.service,
+		Logger:  config.log,
+	})
+
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+
+	t.Run("batch get records", func(t *testing.T) {
+
+		// The IDs may be listed or repeated.
+		config.service.EXPECT().BatchGet(gomock.Any(), &service.BatchGetOptions{
+			IDs:         ids,
+			ShowDeleted: true,
+		}).Return([]*model.Record{{}, {}, {}}, nil).Times(1)
+
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/?ids=%s,%s&ids=%s&show_deleted=true", ids[0], ids[1], ids[2]), nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("BatchGetHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("batch get w/ invalid ids", func(t *testing.T) {
+
+		// Make sure the service layer is not expecting a call.
+		config.service.EXPECT().BatchGet(gomock.Any(), gomock.Any()).Times(0)
+
+		r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/?ids=%s,invalid", ids[0]), nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("BatchGetHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+		if items := batchItems(t, w); len(items) != 1 || items[0].Index != 1 {
+			t.Errorf("BatchGetHandler.ServeHTTP() items = %v, want the error of item 1", items)
+		}
+	})
+}
+
+func TestBatchUpdateHandler_ServeHTTP(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Create the handler.
+	handler := NewBatchUpdateHandler(&BatchUpdateHandlerConfig{
+		Service: config.service,
+		Logger:  config.log,
+	})
+
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+
+	t.Run("batch update records", func(t *testing.T) {
+
+		// The update masks are derived from the fields of the patches, unless they are set.
+		config.service.EXPECT().BatchUpdate(gomock.Any(), &service.BatchUpdateOptions{
+			Requests: []*service.BatchUpdateRequest{
+				{ID: ids[0], UpdateOptions: service.UpdateOptions{Title: "First", UpdateMask: []string{"title"}, Version: 3}},
+				{ID: ids[1], UpdateOptions: service.UpdateOptions{Title: "Second", UpdateMask: []string{"title"}}},
+			},
+		}).Return([]*model.Record{{}, {}}, nil).Times(1)
+
+		body := fmt.Sprintf(`{"requests": [{"id": %q, "etag": "\"3\"", "title": "First"}, {"id": %q, "update_mask": "title", "title": "Second", "user_id": null}]}`, ids[0], ids[1])
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("BatchUpdateHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("batch update w/ invalid requests", func(t *testing.T) {
+
+		// Make sure the service layer is not expecting a call.
+		config.service.EXPECT().BatchUpdate(gomock.Any(), gomock.Any()).Times(0)
+
+		body := fmt.Sprintf(`{"requests": [{"id": %q}, {"id": %q, "title": "Second", "etag": "W/\"1\""}, []]}`, ids[0], ids[1])
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("BatchUpdateHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+		if items := batchItems(t, w); len(items) != 3 {
+			t.Errorf("BatchUpdateHandler.ServeHTTP() items = %v, want the errors of the 3 items", items)
+		}
+	})
+
+	t.Run("batch update w/ too large body", func(t *testing.T) {
+
+		// Make sure the service layer is not expecting a call.
+		config.service.EXPECT().BatchUpdate(gomock.Any(), gomock.Any()).Times(0)
+
+		body := fmt.Sprintf(`{"requests": [{"id": %q, "title": %s}]}`, ids[0], oversized())
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("BatchUpdateHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusRequestEntityTooLarge)
+		}
+	})
+
+	t.Run("batch update w/ stale version", func(t *testing.T) {
+
+		config.service.EXPECT().BatchUpdate(gomock.Any(), gomock.Any()).Return(nil, &service.BatchError{
+			Items: []*service.ItemError{{Index: 0, Err: service.ErrVersionMismatch}},
+		}).Times(1)
+
+		body := fmt.Sprintf(`{"requests": [{"id": %q, "etag": "\"3\"", "title": "First"}]}`, ids[0])
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusPreconditionFailed {
+			t.Errorf("BatchUpdateHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusPreconditionFailed)
+		}
+		if items := batchItems(t, w); len(items) != 1 || items[0].Index != 0 {
+			t.Errorf("BatchUpdateHandler.ServeHTTP() items = %v, want the error of item 0", items)
+		}
+	})
+}
+
+func TestBatchDeleteHandler_ServeHTTP(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Create the handler.
+	handler := NewBatchDeleteHandler(&BatchDeleteHandlerConfig{
+		Service: config.service,
+		Logger:  config.log,
+	})
+
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+
+	t.Run("batch delete records", func(t *testing.T) {
+
+		config.service.EXPECT().BatchDelete(gomock.Any(), &service.BatchDeleteOptions{
+			IDs:   ids,
+			Force: true,
+		}).Return(nil).Times(1)
+
+		body := fmt.Sprintf(`{"ids": [%q, %q], "force": true}`, ids[0], ids[1])
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("BatchDeleteHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("batch delete w/ too large body", func(t *testing.T) {
+
+		// Make sure the service layer is not expecting a call.
+		config.service.EXPECT().BatchDelete(gomock.Any(), gomock.Any()).Times(0)
+
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"ids": [`+oversized()+`]}`))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("BatchDeleteHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusRequestEntityTooLarge)
+		}
+	})
+
+	t.Run("batch delete too many records", func(t *testing.T) {
+
+		config.service.EXPECT().BatchDelete(gomock.Any(), gomock.Any()).Return(service.ErrBatchTooLarge).Times(1)
+
+		body := fmt.Sprintf(`{"ids": [%q, %q]}`, ids[0], ids[1])
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("BatchDeleteHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+}
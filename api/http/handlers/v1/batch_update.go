@@ -0,0 +1,199 @@
+package v1
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/service"
+)
+
+// BatchUpdateRequest represents the update of a record in a batch.
+//
+// Like the body of an update, it is a JSON merge patch of the record: only the fields it contains are updated,
+// unless `update_mask` lists the fields to update.
+type BatchUpdateRequest struct {
+
+	//	ID of the record to update.
+	ID uuid.UUID `json:"id"`
+
+	//	UpdateMask is the comma separated list of the fields to update.
+	//
+	//	Example: "title"
+	UpdateMask string `json:"update_mask"`
+
+	//	ETag is the entity tag of the version of the record the update is based on, like the `If-Match` header.
+	//
+	//	Example: `"3"`
+	ETag string `json:"etag"`
+
+	UpdateOptions
+}
+
+// batchUpdateFields are the fields of the requests of a batch update which are not fields of the record.
+var batchUpdateFields = map[string]bool{
+	"id":          true,
+	"update_mask": true,
+	"etag":        true,
+}
+
+// BatchUpdateOptions represents the options for updating records in a batch.
+type BatchUpdateOptions struct {
+
+	//	Requests are the updates of the records, in order.
+	Requests []json.RawMessage `json:"requests"`
+}
+
+// requests decodes the updates of the records.
+func (o *BatchUpdateOptions) requests() ([]*service.BatchUpdateRequest, error) {
+	var errs itemErrors
+	requests := make([]*service.BatchUpdateRequest, len(o.Requests))
+	for i, data := range o.Requests {
+		request, err := decodeBatchUpdateRequest(data)
+		if err != nil {
+			errs.add(i, err)
+			continue
+		}
+		requests[i] = request
+	}
+	return requests, errs.err()
+}
+
+// decodeBatchUpdateRequest decodes the update of a record in a batch.
+func decodeBatchUpdateRequest(data []byte) (*service.BatchUpdateRequest, error) {
+	var request BatchUpdateRequest
+	fields, err := unmarshalPatch(data, &request)
+	if err != nil {
+		return nil, err
+	}
+
+	// Update the fields of the mask, or else the fields of the patch.
+	var mask []string
+	if strings.TrimSpace(request.UpdateMask) != "" {
+		mask = strings.Split(request.UpdateMask, ",")
+	} else {
+		for _, field := range fields {
+			if !batchUpdateFields[field] {
+				mask = append(mask, field)
+			}
+		}
+	}
+	if len(mask) == 0 {
+		return nil, fmt.Errorf("%w: no fields to update", ErrInvalidRequestOptions)
+	}
+
+	var version int64
+	if request.ETag != "" && request.ETag != "*" {
+		var ok bool
+		if version, ok = model.ParseETag(request.ETag); !ok {
+			return nil, fmt.Errorf("%w: invalid etag", ErrInvalidRequestOptions)
+		}
+	}
+
+	return &service.BatchUpdateRequest{
+		ID: request.ID,
+		UpdateOptions: service.UpdateOptions{
+			Title:      request.Title,
+			UpdateMask: mask,
+			Version:    version,
+		},
+	}, nil
+}
+
+// BatchUpdate handler updates records in a batch, in a single transaction.
+//
+// Link: https://google.aip.dev/234
+type BatchUpdateHandler struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	service service.Service
+
+	// log is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	log *slog.Logger
+
+	// maxBatchSize is the maximum number of items of the batches, which limits the size of the request bodies.
+	maxBatchSize int
+}
+
+type BatchUpdateHandlerConfig struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	Service service.Service
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+
+	// MaxBatchSize is the maximum number of items of the batches, which limits the size of the request bodies to
+	// `BatchItemBytes` per item.
+	// Default: `service.DefaultMaxBatchSize`
+	//
+	// This field is optional.
+	MaxBatchSize int
+}
+
+// NewBatchUpdateHandler creates a new instance of `BatchUpdateHandler`.
+func NewBatchUpdateHandler(config *BatchUpdateHandlerConfig) Handler {
+	handler := BatchUpdateHandler{
+		service:      config.Service,
+		log:          config.Logger,
+		maxBatchSize: config.MaxBatchSize,
+	}
+
+	// Set the default batch size if not provided.
+	if handler.maxBatchSize <= 0 {
+		handler.maxBatchSize = service.DefaultMaxBatchSize
+	}
+
+	// Set the default logger if not provided.
+	if handler.log == nil {
+		handler.log = slog.Default()
+	}
+	handler.log = handler.log.With("handler", "batch_update")
+
+	return &handler
+}
+
+// ServeHTTP handles the incoming HTTP request.
+func (h *BatchUpdateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.log.DebugContext(r.Context(), "handling request")
+
+	// Decode the request options, up to the size of the largest batch.
+	limitBatch(w, r, h.maxBatchSize)
+	options, err := decode[BatchUpdateOptions](r)
+	if err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	requests, err := options.requests()
+	if err != nil {
+		writeBatchError(w, "Invalid request options.", err)
+		return
+	}
+
+	records, err := h.service.BatchUpdate(r.Context(), &service.BatchUpdateOptions{
+		Requests: requests,
+	})
+	if err != nil {
+		writeBatchError(w, "Failed to update the records.", err)
+		return
+	}
+
+	write(w, http.StatusOK, &Response{
+		Message: "The records were updated successfully.",
+		Data:    records,
+	})
+}
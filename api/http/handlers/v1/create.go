@@ -138,6 +138,7 @@ func (h *CreateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, record)
 	write(w, http.StatusCreated, Response{
 		Message: "The record was created successfully.",
 		Data:    record,
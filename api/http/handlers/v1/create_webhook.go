@@ -0,0 +1,154 @@
+package v1
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"github.com/mrinalwahal/service/service"
+)
+
+// CreateWebhookOptions represents the options for creating a webhook.
+type CreateWebhookOptions struct {
+
+	//	URL the events are posted to.
+	URL string `json:"url"`
+
+	//	Types of the events delivered to the webhook. When it is empty, every event is delivered.
+	Events []string `json:"events"`
+
+	//	Secret which signs the deliveries. When it is empty, a random secret is generated.
+	Secret string `json:"secret"`
+
+	// ID of the user who is creating the webhook.
+	UserID uuid.UUID `json:"-"`
+}
+
+// validate the options.
+func (o *CreateWebhookOptions) validate() error {
+	checks := []bool{
+		o.URL != "",
+		o.UserID != uuid.Nil,
+	}
+	for _, check := range checks {
+		if !check {
+			return ErrInvalidRequestOptions
+		}
+	}
+	return nil
+}
+
+// preset presets options from claims in the context.
+func (o *CreateWebhookOptions) preset(ctx context.Context) error {
+	claims, exists := ctx.Value(middleware.XJWTClaims).(middleware.JWTClaims)
+	if !exists {
+		return ErrInvalidJWTClaims
+	}
+
+	o.UserID = claims.XUserID
+	return nil
+}
+
+// CreateWebhook handler creates a new webhook, owned by the caller.
+type CreateWebhookHandler struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	service service.Service
+
+	// log is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	log *slog.Logger
+}
+
+type CreateWebhookHandlerConfig struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	Service service.Service
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// NewCreateWebhookHandler creates a new instance of `CreateWebhookHandler`.
+func NewCreateWebhookHandler(config *CreateWebhookHandlerConfig) Handler {
+	handler := CreateWebhookHandler{
+		service: config.Service,
+		log:     config.Logger,
+	}
+
+	// Set the default logger if not provided.
+	if handler.log == nil {
+		handler.log = slog.Default()
+	}
+	handler.log = handler.log.With("handler", "create_webhook")
+
+	return &handler
+}
+
+// ServeHTTP handles the incoming HTTP request.
+//
+// The response holds the secret of the webhook, which is never returned again.
+func (h *CreateWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.log.DebugContext(r.Context(), "handling request")
+
+	// Decode the request options.
+	options, err := decode[CreateWebhookOptions](r)
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid request options.",
+			Err:     err,
+		})
+		return
+	}
+
+	// Load the context.
+	ctx := r.Context()
+
+	// Preset options from the request.
+	if err := options.preset(ctx); err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Failed to preset options from request claims.",
+			Err:     err,
+		})
+		return
+	}
+
+	// Validate the request options.
+	if err := options.validate(); err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Failed validate request options.",
+			Err:     err,
+		})
+		return
+	}
+
+	webhook, err := h.service.CreateWebhook(ctx, &service.CreateWebhookOptions{
+		UserID: options.UserID,
+		URL:    options.URL,
+		Events: options.Events,
+		Secret: options.Secret,
+	})
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Failed to create the webhook.",
+			Err:     err,
+		})
+		return
+	}
+
+	write(w, http.StatusCreated, &Response{
+		Message: "The webhook was created successfully.",
+		Data:    webhook,
+	})
+}
@@ -1,13 +1,24 @@
 package v1
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 
+	"github.com/dyninc/qstring"
 	"github.com/google/uuid"
 	"github.com/mrinalwahal/service/service"
 )
 
+// DeleteOptions represents the options for deleting a record.
+type DeleteOptions struct {
+
+	//	Permanently delete the record, instead of soft-deleting it.
+	//	Only the owner of the record can delete it, whether it is forced or not.
+	//	Default: false
+	Force bool `qstring:"force"`
+}
+
 // Delete handler deletes the record.
 type DeleteHandler struct {
 
@@ -67,7 +78,41 @@ func (h *DeleteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.Delete(r.Context(), id); err != nil {
+	var options DeleteOptions
+	if err := qstring.Unmarshal(r.URL.Query(), &options); err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid request options.",
+			Err:     err,
+		})
+		return
+	}
+
+	// Only delete the version of the record the client has read, if it sent its entity tag.
+	version, err := ifMatch(r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrPreconditionFailed) {
+			status = http.StatusPreconditionFailed
+		}
+		write(w, status, &Response{
+			Message: "Invalid If-Match header.",
+			Err:     err,
+		})
+		return
+	}
+
+	err = h.service.Delete(r.Context(), id, &service.DeleteOptions{
+		Force:   options.Force,
+		Version: version,
+	})
+	if errors.Is(err, service.ErrVersionMismatch) {
+		write(w, http.StatusPreconditionFailed, &Response{
+			Message: "The record has been modified since it was read. Get it again and retry.",
+			Err:     err,
+		})
+		return
+	}
+	if err != nil {
 		write(w, http.StatusBadRequest, &Response{
 			Message: "Failed to delete the record.",
 			Err:     err,
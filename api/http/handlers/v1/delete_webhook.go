@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/service"
+)
+
+// DeleteWebhook handler deletes a webhook of the caller, along with its deliveries.
+type DeleteWebhookHandler struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	service service.Service
+
+	// log is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	log *slog.Logger
+}
+
+type DeleteWebhookHandlerConfig struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	Service service.Service
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// NewDeleteWebhookHandler creates a new instance of `DeleteWebhookHandler`.
+func NewDeleteWebhookHandler(config *DeleteWebhookHandlerConfig) Handler {
+	handler := DeleteWebhookHandler{
+		service: config.Service,
+		log:     config.Logger,
+	}
+
+	// Set the default logger if not provided.
+	if handler.log == nil {
+		handler.log = slog.Default()
+	}
+	handler.log = handler.log.With("handler", "delete_webhook")
+
+	return &handler
+}
+
+// ServeHTTP handles the incoming HTTP request.
+func (h *DeleteWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.log.DebugContext(r.Context(), "handling request")
+
+	// Decode the request options.
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid ID.",
+			Err:     err,
+		})
+		return
+	}
+
+	if err := h.service.DeleteWebhook(r.Context(), id); err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Failed to delete the webhook.",
+			Err:     err,
+		})
+		return
+	}
+
+	write(w, http.StatusOK, &Response{
+		Message: "The webhook was deleted successfully.",
+	})
+}
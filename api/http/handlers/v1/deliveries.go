@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/dyninc/qstring"
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/service"
+)
+
+// ListDeliveriesOptions represents the options for listing the deliveries of the webhooks.
+type ListDeliveriesOptions struct {
+
+	//	ID of the webhook whose deliveries to return.
+	//	Default: the deliveries of every webhook of the caller.
+	WebhookID string `qstring:"webhook_id"`
+
+	//	Status of the deliveries to return: "pending", "succeeded" or "failed".
+	//	Default: every status.
+	Status string `qstring:"status"`
+
+	//	Maximum number of deliveries to return.
+	//	Default: 50. Page sizes larger than 100 are coerced to 100.
+	PageSize int `qstring:"page_size"`
+
+	//	Token of the page to return: the `next_page_token` of the previous page.
+	PageToken string `qstring:"page_token"`
+}
+
+// ListDeliveries handler lists the deliveries of the webhooks of the caller, most recent first.
+type ListDeliveriesHandler struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	service service.Service
+
+	// log is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	log *slog.Logger
+}
+
+type ListDeliveriesHandlerConfig struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	Service service.Service
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// NewListDeliveriesHandler creates a new instance of `ListDeliveriesHandler`.
+func NewListDeliveriesHandler(config *ListDeliveriesHandlerConfig) Handler {
+	handler := ListDeliveriesHandler{
+		service: config.Service,
+		log:     config.Logger,
+	}
+
+	// Set the default logger if not provided.
+	if handler.log == nil {
+		handler.log = slog.Default()
+	}
+	handler.log = handler.log.With("handler", "list_deliveries")
+
+	return &handler
+}
+
+// ServeHTTP handles the incoming HTTP request.
+func (h *ListDeliveriesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.log.DebugContext(r.Context(), "handling request")
+
+	// Decode the request options.
+	var options ListDeliveriesOptions
+	if err := qstring.Unmarshal(r.URL.Query(), &options); err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid request options.",
+			Err:     err,
+		})
+		return
+	}
+
+	var webhookID uuid.UUID
+	if options.WebhookID != "" {
+		id, err := uuid.Parse(options.WebhookID)
+		if err != nil {
+			write(w, http.StatusBadRequest, &Response{
+				Message: "Invalid webhook ID.",
+				Err:     err,
+			})
+			return
+		}
+		webhookID = id
+	}
+
+	deliveries, token, err := h.service.ListDeliveries(r.Context(), &service.ListDeliveriesOptions{
+		WebhookID: webhookID,
+		Status:    options.Status,
+		PageSize:  options.PageSize,
+		PageToken: options.PageToken,
+	})
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Failed to list the deliveries.",
+			Err:     err,
+		})
+		return
+	}
+
+	write(w, http.StatusOK, &Response{
+		Message:       "The deliveries were retrieved successfully.",
+		Data:          deliveries,
+		NextPageToken: token,
+	})
+}
@@ -0,0 +1,41 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/mrinalwahal/service/model"
+)
+
+// ErrPreconditionFailed is returned when the `If-Match` header does not match the current version of the record.
+var ErrPreconditionFailed = fmt.Errorf("the record has been modified since it was read")
+
+// ifMatch returns the version of the record required by the `If-Match` header of the request.
+//
+// It returns 0, which matches any version, if the header is missing or is `*`.
+// Only a single strong entity tag is supported: a weak or malformed tag can never match the current version of
+// a record, so it returns `ErrPreconditionFailed`.
+//
+// Link: https://www.rfc-editor.org/rfc/rfc9110#name-if-match
+func ifMatch(r *http.Request) (int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	if strings.Contains(header, ",") {
+		return 0, fmt.Errorf("%w: If-Match supports a single entity tag", ErrInvalidRequestOptions)
+	}
+	version, ok := model.ParseETag(header)
+	if !ok {
+		return 0, ErrPreconditionFailed
+	}
+	return version, nil
+}
+
+// setETag sets the `ETag` header of the response to the entity tag of the record.
+func setETag(w http.ResponseWriter, record *model.Record) {
+	if record != nil && record.ETag != "" {
+		w.Header().Set("ETag", record.ETag)
+	}
+}
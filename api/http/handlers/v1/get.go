@@ -4,10 +4,19 @@ import (
 	"log/slog"
 	"net/http"
 
+	"github.com/dyninc/qstring"
 	"github.com/google/uuid"
 	"github.com/mrinalwahal/service/service"
 )
 
+// GetOptions represents the options for getting a record.
+type GetOptions struct {
+
+	//	Return the record even if it has been soft-deleted.
+	//	Default: false
+	ShowDeleted bool `qstring:"show_deleted"`
+}
+
 // Get handler gets the record.
 type GetHandler struct {
 
@@ -65,7 +74,19 @@ func (h *GetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	record, err := h.service.Get(r.Context(), id)
+	// Decode the request options.
+	var options GetOptions
+	if err := qstring.Unmarshal(r.URL.Query(), &options); err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid request options.",
+			Err:     err,
+		})
+		return
+	}
+
+	record, err := h.service.Get(r.Context(), id, &service.GetOptions{
+		ShowDeleted: options.ShowDeleted,
+	})
 	if err != nil {
 		write(w, http.StatusBadRequest, &Response{
 			Message: "Failed to get the record.",
@@ -74,6 +95,7 @@ func (h *GetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, record)
 	write(w, http.StatusOK, &Response{
 		Message: "The record was retrieved successfully.",
 		Data:    record,
@@ -9,6 +9,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/service"
 	"go.uber.org/mock/gomock"
 )
 
@@ -61,7 +62,7 @@ func TestGetHandler_ServeHTTP(t *testing.T) {
 					return req
 				}(),
 			},
-			expectation: environment.service.EXPECT().Get(gomock.Any(), gomock.Any()).Return(&model.Record{
+			expectation: environment.service.EXPECT().Get(gomock.Any(), gomock.Any(), &service.GetOptions{}).Return(&model.Record{
 				Base: model.Base{
 					ID: recordID,
 				},
@@ -76,6 +77,26 @@ func TestGetHandler_ServeHTTP(t *testing.T) {
 			},
 			want: http.StatusOK,
 		},
+		{
+			name: "get soft-deleted record",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: func() *http.Request {
+					req := httptest.NewRequest(http.MethodGet, "/?show_deleted=true", nil)
+					req.SetPathValue("id", recordID.String())
+					return req
+				}(),
+			},
+			expectation: environment.service.EXPECT().Get(gomock.Any(), recordID, &service.GetOptions{
+				ShowDeleted: true,
+			}).Return(&model.Record{
+				Base: model.Base{
+					ID: recordID,
+				},
+				Title: "Record 1",
+			}, nil),
+			want: http.StatusOK,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -3,7 +3,9 @@ package v1
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"sort"
 )
 
 // Default HTTP Response structure.
@@ -12,6 +14,10 @@ type Response struct {
 	Data    interface{} `json:"data,omitempty"`
 	Message string      `json:"message,omitempty"`
 	Err     error       `json:"error,omitempty"`
+
+	// NextPageToken is the token of the next page of a list.
+	// It is empty on the last page.
+	NextPageToken string `json:"next_page_token,omitempty"`
 }
 
 // Error returns the error message.
@@ -30,28 +36,32 @@ func (r Response) MarshalJSON() ([]byte, error) {
 		errorMsg = r.Err.Error()
 	}
 	var structure = struct {
-		Data    interface{} `json:"data,omitempty"`
-		Message string      `json:"message,omitempty"`
-		Err     string      `json:"error,omitempty"`
+		Data          interface{} `json:"data,omitempty"`
+		Message       string      `json:"message,omitempty"`
+		Err           string      `json:"error,omitempty"`
+		NextPageToken string      `json:"next_page_token,omitempty"`
 	}{
-		Data:    r.Data,
-		Message: r.Message,
-		Err:     errorMsg,
+		Data:          r.Data,
+		Message:       r.Message,
+		Err:           errorMsg,
+		NextPageToken: r.NextPageToken,
 	}
 	return json.Marshal(structure)
 }
 
 func (r *Response) UnmarshalJSON(data []byte) error {
 	var structure = struct {
-		Data    interface{} `json:"data,omitempty"`
-		Message string      `json:"message,omitempty"`
-		Err     string      `json:"error,omitempty"`
+		Data          interface{} `json:"data,omitempty"`
+		Message       string      `json:"message,omitempty"`
+		Err           string      `json:"error,omitempty"`
+		NextPageToken string      `json:"next_page_token,omitempty"`
 	}{}
 	if err := json.Unmarshal(data, &structure); err != nil {
 		return err
 	}
 	r.Data = structure.Data
 	r.Message = structure.Message
+	r.NextPageToken = structure.NextPageToken
 	if structure.Err != "" {
 		r.Err = fmt.Errorf(structure.Err)
 	}
@@ -74,6 +84,43 @@ func decode[T any](r *http.Request) (T, error) {
 	return v, nil
 }
 
+// decodePatch decodes a JSON merge patch (RFC 7396) from the request body into the supplied type, and returns the
+// names of the fields it contains, sorted.
+//
+// Link: https://www.rfc-editor.org/rfc/rfc7396
+func decodePatch[T any](r *http.Request) (T, []string, error) {
+	defer r.Body.Close()
+	var v T
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return v, nil, fmt.Errorf("read body: %w", err)
+	}
+	fields, err := unmarshalPatch(data, &v)
+	return v, fields, err
+}
+
+// unmarshalPatch decodes a JSON merge patch into the supplied value, and returns the names of the fields it
+// contains, sorted.
+func unmarshalPatch(data []byte, v any) ([]string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+	if fields == nil {
+		return nil, fmt.Errorf("decode json: the patch must be an object")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 // encode encodes the supplied data into the response writer.
 func encode(w http.ResponseWriter, data any) error {
 	return json.NewEncoder(w).Encode(data)
@@ -11,20 +11,31 @@ import (
 // ListOptions represents the options for listing records.
 type ListOptions struct {
 
-	//	Number of records to skip.
-	Skip int `query:"skip" validate:"gte=0"`
+	//	Maximum number of records to return.
+	//	Default: 50. Page sizes larger than 100 are coerced to 100.
+	PageSize int `qstring:"page_size"`
 
-	//	Number of records to return.
-	Limit int `query:"limit" validate:"gte=0,lte=100"`
+	//	Token of the page to return: the `next_page_token` of the previous page.
+	//	The other options must be the same as the ones of the previous page.
+	PageToken string `qstring:"page_token"`
 
-	//	Order by field.
-	OrderBy string `query:"orderBy" validate:"oneof=created_at updated_at title"`
-
-	//	Order by direction.
-	OrderDirection string `query:"orderDirection" validate:"oneof=asc desc"`
+	//	Comma separated list of fields to sort the records by, each optionally followed by "asc" or "desc".
+	//	Sortable fields: "created_at", "updated_at" and "title".
+	//
+	//	Example: "title desc, created_at"
+	OrderBy string `qstring:"order_by"`
 
 	//	Title of the record.
-	Title string `query:"name"`
+	Title string `qstring:"title"`
+
+	//	AIP-160 filter expression on the "title", "created_at", "updated_at" and "user_id" fields.
+	//
+	//	Example: `created_at >= "2024-01-01T00:00:00Z" AND title:report`
+	Filter string `qstring:"filter"`
+
+	//	Include the soft-deleted records.
+	//	Default: false
+	ShowDeleted bool `qstring:"show_deleted"`
 }
 
 // List handler lists the records.
@@ -87,12 +98,13 @@ func (h *ListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Call the service method that performs the required operation.
-	records, err := h.service.List(r.Context(), &service.ListOptions{
-		Title:          options.Title,
-		Skip:           options.Skip,
-		Limit:          options.Limit,
-		OrderBy:        options.OrderBy,
-		OrderDirection: options.OrderDirection,
+	records, token, err := h.service.List(r.Context(), &service.ListOptions{
+		Title:       options.Title,
+		Filter:      options.Filter,
+		PageSize:    options.PageSize,
+		PageToken:   options.PageToken,
+		OrderBy:     options.OrderBy,
+		ShowDeleted: options.ShowDeleted,
 	})
 	if err != nil {
 		write(w, http.StatusBadRequest, &Response{
@@ -103,7 +115,8 @@ func (h *ListHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	write(w, http.StatusOK, &Response{
-		Message: "The records were retrieved successfully.",
-		Data:    records,
+		Message:       "The records were retrieved successfully.",
+		Data:          records,
+		NextPageToken: token,
 	})
 }
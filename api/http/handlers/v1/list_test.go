@@ -1,7 +1,6 @@
 package v1
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/service"
 	"go.uber.org/mock/gomock"
 )
 
@@ -58,11 +58,14 @@ func TestListHandler_ServeHTTP(t *testing.T) {
 				{
 					Title: "Record 1",
 				},
-			}, nil),
+			}, "", nil),
 			validation: func(r *Response) error {
 				if r == nil {
 					return fmt.Errorf("expected a response, got nil")
 				}
+				if r.NextPageToken != "" {
+					return fmt.Errorf("expected no next page token, got %q", r.NextPageToken)
+				}
 				records := r.Data.([]interface{})
 				if len(records) < 1 {
 					return fmt.Errorf("expected at least 1 record, got %d", len(records))
@@ -75,17 +78,20 @@ func TestListHandler_ServeHTTP(t *testing.T) {
 			name: "list only 1 record",
 			args: args{
 				w: httptest.NewRecorder(),
-				r: httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(`{"limit":1}`)),
+				r: httptest.NewRequest(http.MethodGet, "/?page_size=1", nil),
 			},
 			expectation: config.service.EXPECT().List(gomock.Any(), gomock.Any()).Return([]*model.Record{
 				{
 					Title: "Record 1",
 				},
-			}, nil),
+			}, "next", nil),
 			validation: func(r *Response) error {
 				if r == nil {
 					return fmt.Errorf("expected a response, got nil")
 				}
+				if r.NextPageToken != "next" {
+					return fmt.Errorf("expected the next page token to be 'next', got %q", r.NextPageToken)
+				}
 				records := r.Data.([]interface{})
 				if len(records) != 1 {
 					return fmt.Errorf("expected only 1 record, got %d", len(records))
@@ -98,7 +104,7 @@ func TestListHandler_ServeHTTP(t *testing.T) {
 			name: "return all records while requesting only 1 record",
 			args: args{
 				w: httptest.NewRecorder(),
-				r: httptest.NewRequest(http.MethodGet, "/", bytes.NewBufferString(`{"limit":1}`)),
+				r: httptest.NewRequest(http.MethodGet, "/?page_size=1", nil),
 			},
 			expectation: config.service.EXPECT().List(gomock.Any(), gomock.Any()).Return([]*model.Record{
 				{
@@ -107,7 +113,7 @@ func TestListHandler_ServeHTTP(t *testing.T) {
 				{
 					Title: "Record 2",
 				},
-			}, nil),
+			}, "", nil),
 			validation: func(r *Response) error {
 				if r == nil {
 					return fmt.Errorf("expected a response, got nil")
@@ -121,6 +127,26 @@ func TestListHandler_ServeHTTP(t *testing.T) {
 			want:    http.StatusOK,
 			wantErr: true,
 		},
+		{
+			name: "bind every query parameter",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: httptest.NewRequest(http.MethodGet, "/?title=Record+1&filter=title%3Arecord+AND+created_at+%3E+2024-01-01T00%3A00%3A00Z&order_by=title+desc&page_size=5&page_token=abc&show_deleted=true", nil),
+			},
+			expectation: config.service.EXPECT().List(gomock.Any(), &service.ListOptions{
+				Title:       "Record 1",
+				Filter:      "title:record AND created_at > 2024-01-01T00:00:00Z",
+				OrderBy:     "title desc",
+				PageSize:    5,
+				PageToken:   "abc",
+				ShowDeleted: true,
+			}).Return([]*model.Record{
+				{
+					Title: "Record 1",
+				},
+			}, "", nil),
+			want: http.StatusOK,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
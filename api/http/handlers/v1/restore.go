@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/dyninc/qstring"
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/service"
+)
+
+// RestoreOptions represents the options for restoring a record to one of its revisions.
+type RestoreOptions struct {
+
+	//	Revision to restore the record to.
+	Revision int64 `qstring:"revision"`
+}
+
+// Restore handler restores the fields of a record to those of one of its revisions.
+type RestoreHandler struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	service service.Service
+
+	// log is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	log *slog.Logger
+}
+
+type RestoreHandlerConfig struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	Service service.Service
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// NewRestoreHandler creates a new instance of `RestoreHandler`.
+func NewRestoreHandler(config *RestoreHandlerConfig) Handler {
+	handler := RestoreHandler{
+		service: config.Service,
+		log:     config.Logger,
+	}
+
+	// Set the default logger if not provided.
+	if handler.log == nil {
+		handler.log = slog.Default()
+	}
+	handler.log = handler.log.With("handler", "restore")
+
+	return &handler
+}
+
+// ServeHTTP handles the incoming HTTP request.
+func (h *RestoreHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.log.DebugContext(r.Context(), "handling request")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid ID.",
+		})
+		return
+	}
+
+	// Decode the request options.
+	var options RestoreOptions
+	if err := qstring.Unmarshal(r.URL.Query(), &options); err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid request options.",
+			Err:     err,
+		})
+		return
+	}
+
+	// Only restore the version of the record the client has read, if it sent its entity tag.
+	version, err := ifMatch(r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrPreconditionFailed) {
+			status = http.StatusPreconditionFailed
+		}
+		write(w, status, &Response{
+			Message: "Invalid If-Match header.",
+			Err:     err,
+		})
+		return
+	}
+
+	record, err := h.service.Restore(r.Context(), id, &service.RestoreOptions{
+		Revision: options.Revision,
+		Version:  version,
+	})
+	if errors.Is(err, service.ErrVersionMismatch) {
+		write(w, http.StatusPreconditionFailed, &Response{
+			Message: "The record has been modified since it was read. Get it again and retry.",
+			Err:     err,
+		})
+		return
+	}
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Failed to restore the record.",
+			Err:     err,
+		})
+		return
+	}
+
+	setETag(w, record)
+	write(w, http.StatusOK, &Response{
+		Message: "The record was restored successfully.",
+		Data:    record,
+	})
+}
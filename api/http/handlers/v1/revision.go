@@ -0,0 +1,91 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/service"
+)
+
+// GetRevision handler retrieves a revision of a record.
+type GetRevisionHandler struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	service service.Service
+
+	// log is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	log *slog.Logger
+}
+
+type GetRevisionHandlerConfig struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	Service service.Service
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// NewGetRevisionHandler creates a new instance of `GetRevisionHandler`.
+func NewGetRevisionHandler(config *GetRevisionHandlerConfig) Handler {
+	handler := GetRevisionHandler{
+		service: config.Service,
+		log:     config.Logger,
+	}
+
+	// Set the default logger if not provided.
+	if handler.log == nil {
+		handler.log = slog.Default()
+	}
+	handler.log = handler.log.With("handler", "get_revision")
+
+	return &handler
+}
+
+// ServeHTTP handles the incoming HTTP request.
+func (h *GetRevisionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.log.DebugContext(r.Context(), "handling request")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid ID.",
+		})
+		return
+	}
+
+	number, err := strconv.ParseInt(r.PathValue("revision"), 10, 64)
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid revision.",
+			Err:     err,
+		})
+		return
+	}
+
+	revision, err := h.service.GetRevision(r.Context(), id, number)
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Failed to get the revision of the record.",
+			Err:     err,
+		})
+		return
+	}
+
+	write(w, http.StatusOK, &Response{
+		Message: "The revision was retrieved successfully.",
+		Data:    revision,
+	})
+}
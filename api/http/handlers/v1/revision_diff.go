@@ -0,0 +1,112 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/dyninc/qstring"
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/service"
+)
+
+// DiffRevisionsOptions represents the options for comparing two revisions of a record.
+type DiffRevisionsOptions struct {
+
+	//	Revision to compare the revision with.
+	//	Default: the previous revision.
+	Against int64 `qstring:"against"`
+}
+
+// DiffRevisions handler compares a revision of a record with another one, and returns the fields which changed.
+type DiffRevisionsHandler struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	service service.Service
+
+	// log is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	log *slog.Logger
+}
+
+type DiffRevisionsHandlerConfig struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	Service service.Service
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// NewDiffRevisionsHandler creates a new instance of `DiffRevisionsHandler`.
+func NewDiffRevisionsHandler(config *DiffRevisionsHandlerConfig) Handler {
+	handler := DiffRevisionsHandler{
+		service: config.Service,
+		log:     config.Logger,
+	}
+
+	// Set the default logger if not provided.
+	if handler.log == nil {
+		handler.log = slog.Default()
+	}
+	handler.log = handler.log.With("handler", "diff_revisions")
+
+	return &handler
+}
+
+// ServeHTTP handles the incoming HTTP request.
+func (h *DiffRevisionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.log.DebugContext(r.Context(), "handling request")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid ID.",
+		})
+		return
+	}
+
+	number, err := strconv.ParseInt(r.PathValue("revision"), 10, 64)
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid revision.",
+			Err:     err,
+		})
+		return
+	}
+
+	// Decode the request options.
+	var options DiffRevisionsOptions
+	if err := qstring.Unmarshal(r.URL.Query(), &options); err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid request options.",
+			Err:     err,
+		})
+		return
+	}
+
+	diff, err := h.service.DiffRevisions(r.Context(), id, number, &service.DiffRevisionsOptions{
+		Against: options.Against,
+	})
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Failed to compare the revisions of the record.",
+			Err:     err,
+		})
+		return
+	}
+
+	write(w, http.StatusOK, &Response{
+		Message: "The revisions were compared successfully.",
+		Data:    diff,
+	})
+}
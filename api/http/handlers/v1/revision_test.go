@@ -0,0 +1,219 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/service"
+	"go.uber.org/mock/gomock"
+)
+
+func TestListRevisionsHandler_ServeHTTP(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Create the handler.
+	handler := NewListRevisionsHandler(&ListRevisionsHandlerConfig{
+		Service: config.service,
+		Logger:  config.log,
+	})
+
+	t.Run("list revisions w/ invalid id", func(t *testing.T) {
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.SetPathValue("id", "invalid")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ListRevisionsHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("list revisions by page", func(t *testing.T) {
+
+		id := uuid.New()
+		config.service.EXPECT().ListRevisions(gomock.Any(), id, &service.ListRevisionsOptions{
+			PageSize:  2,
+			PageToken: "token",
+		}).Return([]*model.Revision{{RecordID: id, Revision: 2}, {RecordID: id, Revision: 1}}, "", nil).Times(1)
+
+		r := httptest.NewRequest(http.MethodGet, "/?page_size=2&page_token=token", nil)
+		r.SetPathValue("id", id.String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("ListRevisionsHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusOK)
+		}
+	})
+}
+
+func TestGetRevisionHandler_ServeHTTP(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Create the handler.
+	handler := NewGetRevisionHandler(&GetRevisionHandlerConfig{
+		Service: config.service,
+		Logger:  config.log,
+	})
+
+	t.Run("get revision w/ invalid revision", func(t *testing.T) {
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.SetPathValue("id", uuid.NewString())
+		r.SetPathValue("revision", "latest")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("GetRevisionHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("get revision", func(t *testing.T) {
+
+		id := uuid.New()
+		config.service.EXPECT().GetRevision(gomock.Any(), id, int64(3)).Return(&model.Revision{RecordID: id, Revision: 3}, nil).Times(1)
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.SetPathValue("id", id.String())
+		r.SetPathValue("revision", "3")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("GetRevisionHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusOK)
+		}
+	})
+}
+
+func TestDiffRevisionsHandler_ServeHTTP(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Create the handler.
+	handler := NewDiffRevisionsHandler(&DiffRevisionsHandlerConfig{
+		Service: config.service,
+		Logger:  config.log,
+	})
+
+	t.Run("diff revisions w/ invalid against", func(t *testing.T) {
+
+		// Make sure the service layer is not expecting a call.
+		config.service.EXPECT().DiffRevisions(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		r := httptest.NewRequest(http.MethodGet, "/?against=first", nil)
+		r.SetPathValue("id", uuid.NewString())
+		r.SetPathValue("revision", "3")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("DiffRevisionsHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("diff revisions", func(t *testing.T) {
+
+		id := uuid.New()
+		config.service.EXPECT().DiffRevisions(gomock.Any(), id, int64(3), &service.DiffRevisionsOptions{
+			Against: 1,
+		}).Return(&model.Diff{RecordID: id, Revision: 3, Against: 1}, nil).Times(1)
+
+		r := httptest.NewRequest(http.MethodGet, "/?against=1", nil)
+		r.SetPathValue("id", id.String())
+		r.SetPathValue("revision", "3")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("DiffRevisionsHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("diff revisions of another user", func(t *testing.T) {
+
+		id := uuid.New()
+		config.service.EXPECT().DiffRevisions(gomock.Any(), id, int64(2), &service.DiffRevisionsOptions{}).Return(nil, ErrRecordNotFound).Times(1)
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.SetPathValue("id", id.String())
+		r.SetPathValue("revision", "2")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("DiffRevisionsHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestRestoreHandler_ServeHTTP(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Create the handler.
+	handler := NewRestoreHandler(&RestoreHandlerConfig{
+		Service: config.service,
+		Logger:  config.log,
+	})
+
+	t.Run("restore record w/ invalid revision", func(t *testing.T) {
+
+		r := httptest.NewRequest(http.MethodPost, "/?revision=first", nil)
+		r.SetPathValue("id", uuid.NewString())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("RestoreHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("restore record w/ If-Match", func(t *testing.T) {
+
+		id := uuid.New()
+		config.service.EXPECT().Restore(gomock.Any(), id, &service.RestoreOptions{
+			Revision: 1,
+			Version:  3,
+		}).Return(&model.Record{Base: model.Base{ID: id}, Version: 4, ETag: model.ETag(4)}, nil).Times(1)
+
+		r := httptest.NewRequest(http.MethodPost, "/?revision=1", nil)
+		r.SetPathValue("id", id.String())
+		r.Header.Set("If-Match", `"3"`)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("RestoreHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusOK)
+		}
+		if etag := w.Header().Get("ETag"); etag != `"4"` {
+			t.Errorf("RestoreHandler.ServeHTTP() ETag = %q, want %q", etag, `"4"`)
+		}
+	})
+
+	t.Run("restore record w/ stale If-Match", func(t *testing.T) {
+
+		id := uuid.New()
+		config.service.EXPECT().Restore(gomock.Any(), id, gomock.Any()).Return(nil, service.ErrVersionMismatch).Times(1)
+
+		r := httptest.NewRequest(http.MethodPost, "/?revision=1", nil)
+		r.SetPathValue("id", id.String())
+		r.Header.Set("If-Match", `"3"`)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusPreconditionFailed {
+			t.Errorf("RestoreHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusPreconditionFailed)
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/dyninc/qstring"
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/service"
+)
+
+// ListRevisionsOptions represents the options for listing the revisions of a record.
+type ListRevisionsOptions struct {
+
+	//	Maximum number of revisions to return.
+	//	Default: 50. Page sizes larger than 100 are coerced to 100.
+	PageSize int `qstring:"page_size"`
+
+	//	Token of the page to return: the `next_page_token` of the previous page.
+	PageToken string `qstring:"page_token"`
+}
+
+// ListRevisions handler lists the revisions of a record, most recent first.
+type ListRevisionsHandler struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	service service.Service
+
+	// log is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	log *slog.Logger
+}
+
+type ListRevisionsHandlerConfig struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	Service service.Service
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// NewListRevisionsHandler creates a new instance of `ListRevisionsHandler`.
+func NewListRevisionsHandler(config *ListRevisionsHandlerConfig) Handler {
+	handler := ListRevisionsHandler{
+		service: config.Service,
+		log:     config.Logger,
+	}
+
+	// Set the default logger if not provided.
+	if handler.log == nil {
+		handler.log = slog.Default()
+	}
+	handler.log = handler.log.With("handler", "list_revisions")
+
+	return &handler
+}
+
+// ServeHTTP handles the incoming HTTP request.
+func (h *ListRevisionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.log.DebugContext(r.Context(), "handling request")
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid ID.",
+		})
+		return
+	}
+
+	// Decode the request options.
+	var options ListRevisionsOptions
+	if err := qstring.Unmarshal(r.URL.Query(), &options); err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid request options.",
+			Err:     err,
+		})
+		return
+	}
+
+	revisions, token, err := h.service.ListRevisions(r.Context(), id, &service.ListRevisionsOptions{
+		PageSize:  options.PageSize,
+		PageToken: options.PageToken,
+	})
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Failed to list the revisions of the record.",
+			Err:     err,
+		})
+		return
+	}
+
+	write(w, http.StatusOK, &Response{
+		Message:       "The revisions were retrieved successfully.",
+		Data:          revisions,
+		NextPageToken: token,
+	})
+}
@@ -0,0 +1,107 @@
+package v1
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/dyninc/qstring"
+	"github.com/mrinalwahal/service/service"
+)
+
+// SearchOptions represents the options for searching records.
+type SearchOptions struct {
+
+	//	Text to search for in the titles of the records.
+	//	The records matching all of its words are returned, most relevant first.
+	Query string `qstring:"q"`
+
+	//	Maximum number of records to return.
+	//	Default: 50. Page sizes larger than 100 are coerced to 100.
+	PageSize int `qstring:"page_size"`
+}
+
+// Search handler searches the records.
+type SearchHandler struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	service service.Service
+
+	// log is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	log *slog.Logger
+}
+
+type SearchHandlerConfig struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	Service service.Service
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// NewSearchHandler creates a new instance of `SearchHandler`.
+func NewSearchHandler(config *SearchHandlerConfig) Handler {
+	handler := SearchHandler{
+		service: config.Service,
+		log:     config.Logger,
+	}
+
+	// Set the default logger if not provided.
+	if handler.log == nil {
+		handler.log = slog.Default()
+	}
+	handler.log = handler.log.With("handler", "search")
+
+	return &handler
+}
+
+// ServeHTTP handles the incoming HTTP request.
+func (h *SearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.log.DebugContext(r.Context(), "handling request")
+
+	// Decode the request options.
+	var options SearchOptions
+	if err := qstring.Unmarshal(r.URL.Query(), &options); err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid request options.",
+			Err:     err,
+		})
+		return
+	}
+
+	// Call the service method that performs the required operation.
+	results, err := h.service.Search(r.Context(), &service.SearchOptions{
+		Query:    options.Query,
+		PageSize: options.PageSize,
+	})
+	if errors.Is(err, service.ErrSearchUnavailable) {
+		write(w, http.StatusNotImplemented, &Response{
+			Message: "Search is not available.",
+			Err:     err,
+		})
+		return
+	}
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Failed to search the records.",
+			Err:     err,
+		})
+		return
+	}
+
+	write(w, http.StatusOK, &Response{
+		Message: "The records were searched successfully.",
+		Data:    results,
+	})
+}
@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/service"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSearchHandler_ServeHTTP(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Create the handler.
+	handler := NewSearchHandler(&SearchHandlerConfig{
+		Service: config.service,
+		Logger:  config.log,
+	})
+
+	t.Run("search records", func(t *testing.T) {
+
+		config.service.EXPECT().Search(gomock.Any(), &service.SearchOptions{
+			Query:    "quarterly report",
+			PageSize: 5,
+		}).Return([]*model.SearchResult{
+			{
+				Record: model.Record{
+					Title: "Quarterly report",
+				},
+				Rank:    0.5,
+				Snippet: "<mark>Quarterly</mark> <mark>report</mark>",
+			},
+		}, nil).Times(1)
+
+		r := httptest.NewRequest(http.MethodGet, "/v1:search?q=quarterly+report&page_size=5", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("SearchHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusOK)
+		}
+
+		var body struct {
+			Data []model.SearchResult `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to decode the response: %v", err)
+		}
+		if len(body.Data) != 1 || body.Data[0].Title != "Quarterly report" || body.Data[0].Snippet != "<mark>Quarterly</mark> <mark>report</mark>" {
+			t.Errorf("SearchHandler.ServeHTTP() = %+v", body.Data)
+		}
+	})
+
+	t.Run("search w/o query", func(t *testing.T) {
+
+		config.service.EXPECT().Search(gomock.Any(), gomock.Any()).Return(nil, service.ErrInvalidQuery).Times(1)
+
+		r := httptest.NewRequest(http.MethodGet, "/v1:search", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("SearchHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("search w/o search support", func(t *testing.T) {
+
+		config.service.EXPECT().Search(gomock.Any(), gomock.Any()).Return(nil, service.ErrSearchUnavailable).Times(1)
+
+		r := httptest.NewRequest(http.MethodGet, "/v1:search?q=report", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusNotImplemented {
+			t.Errorf("SearchHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusNotImplemented)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/service"
+)
+
+// Undelete handler restores a soft-deleted record.
+type UndeleteHandler struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	service service.Service
+
+	// log is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	log *slog.Logger
+}
+
+type UndeleteHandlerConfig struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	Service service.Service
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// NewUndeleteHandler creates a new instance of `UndeleteHandler`.
+func NewUndeleteHandler(config *UndeleteHandlerConfig) Handler {
+	handler := UndeleteHandler{
+		service: config.Service,
+		log:     config.Logger,
+	}
+
+	// Set the default logger if not provided.
+	if handler.log == nil {
+		handler.log = slog.Default()
+	}
+	handler.log = handler.log.With("handler", "undelete")
+
+	return &handler
+}
+
+// ServeHTTP handles the incoming HTTP request.
+func (h *UndeleteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.log.DebugContext(r.Context(), "handling request")
+
+	// Decode the request options.
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid ID.",
+			Err:     err,
+		})
+		return
+	}
+
+	record, err := h.service.Undelete(r.Context(), id)
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Failed to undelete the record.",
+			Err:     err,
+		})
+		return
+	}
+
+	setETag(w, record)
+	write(w, http.StatusOK, &Response{
+		Message: "The record was undeleted successfully.",
+		Data:    record,
+	})
+}
@@ -0,0 +1,71 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"go.uber.org/mock/gomock"
+)
+
+func TestUndeleteHandler_ServeHTTP(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Create the handler.
+	handler := NewUndeleteHandler(&UndeleteHandlerConfig{
+		Service: config.service,
+		Logger:  config.log,
+	})
+
+	t.Run("undelete record w/ invalid id", func(t *testing.T) {
+
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.SetPathValue("id", "invalid")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("UndeleteHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("undelete record which is not deleted", func(t *testing.T) {
+
+		id := uuid.New()
+		config.service.EXPECT().Undelete(gomock.Any(), id).Return(nil, errors.New("no rows affected")).Times(1)
+
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.SetPathValue("id", id.String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("UndeleteHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("undelete record", func(t *testing.T) {
+
+		id := uuid.New()
+		config.service.EXPECT().Undelete(gomock.Any(), id).Return(&model.Record{
+			Base: model.Base{
+				ID: id,
+			},
+			Title: "Record 1",
+		}, nil).Times(1)
+
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.SetPathValue("id", id.String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("UndeleteHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusOK)
+		}
+	})
+}
@@ -1,14 +1,20 @@
 package v1
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/mrinalwahal/service/service"
 )
 
 // UpdateOptions represents the options for updating a record.
+//
+// The body is a JSON merge patch: only the fields it contains are updated, unless the `update_mask` query
+// parameter lists the fields to update.
 type UpdateOptions struct {
 
 	//	Title of the record.
@@ -72,7 +78,7 @@ func (h *UpdateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	options, err := decode[UpdateOptions](r)
+	options, fields, err := decodePatch[UpdateOptions](r)
 	if err != nil {
 		write(w, http.StatusBadRequest, &Response{
 			Message: "Invalid request options.",
@@ -81,9 +87,45 @@ func (h *UpdateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Update the fields of the mask, or else the fields of the patch.
+	mask := updateMask(r)
+	if mask == nil {
+		mask = fields
+	}
+	if len(mask) == 0 {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid request options.",
+			Err:     fmt.Errorf("%w: no fields to update", ErrInvalidRequestOptions),
+		})
+		return
+	}
+
+	// Only update the version of the record the client has read, if it sent its entity tag.
+	version, err := ifMatch(r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrPreconditionFailed) {
+			status = http.StatusPreconditionFailed
+		}
+		write(w, status, &Response{
+			Message: "Invalid If-Match header.",
+			Err:     err,
+		})
+		return
+	}
+
 	record, err := h.service.Update(r.Context(), id, &service.UpdateOptions{
-		Title: options.Title,
+		Title:      options.Title,
+		UpdateMask: mask,
+		Version:    version,
 	})
+	if errors.Is(err, service.ErrVersionMismatch) {
+		write(w, http.StatusPreconditionFailed, &Response{
+			Message: "The record has been modified since it was read. Get it again and retry.",
+			Err:     err,
+		})
+		return
+	}
 	if err != nil {
 		write(w, http.StatusBadRequest, &Response{
 			Message: "Failed to update the record.",
@@ -92,9 +134,21 @@ func (h *UpdateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setETag(w, record)
 	write(w, http.StatusOK, &Response{
 		Message: "The record was updated successfully.",
 		Data:    record,
 	})
 	return
 }
+
+// updateMask returns the paths of the `update_mask` query parameter, a comma separated list of fields.
+//
+// It returns nil if the parameter is missing or empty.
+func updateMask(r *http.Request) []string {
+	mask := r.URL.Query().Get("update_mask")
+	if strings.TrimSpace(mask) == "" {
+		return nil
+	}
+	return strings.Split(mask, ",")
+}
@@ -64,7 +64,8 @@ func TestUpdateHandler_ServeHTTP(t *testing.T) {
 				}(),
 			},
 			expectation: environment.service.EXPECT().Update(gomock.Any(), recordID, &service.UpdateOptions{
-				Title: "Updated Title",
+				Title:      "Updated Title",
+				UpdateMask: []string{"title"},
 			}).Return(&model.Record{
 				Title: "Updated Title",
 			}, nil),
@@ -82,7 +83,8 @@ func TestUpdateHandler_ServeHTTP(t *testing.T) {
 				}(),
 			},
 			expectation: environment.service.EXPECT().Update(gomock.Any(), recordID, &service.UpdateOptions{
-				Title: "Updated Title",
+				Title:      "Updated Title",
+				UpdateMask: []string{"title"},
 			}).Return(&model.Record{
 				Title: "Wrong Title",
 			}, nil),
@@ -127,4 +129,123 @@ func TestUpdateHandler_ServeHTTP(t *testing.T) {
 			}
 		})
 	}
+	t.Run("update record w/ If-Match", func(t *testing.T) {
+
+		environment.service.EXPECT().Update(gomock.Any(), recordID, &service.UpdateOptions{
+			Title:      "Updated Title",
+			UpdateMask: []string{"title"},
+			Version:    3,
+		}).Return(&model.Record{
+			Title:   "Updated Title",
+			Version: 4,
+			ETag:    `"4"`,
+		}, nil).Times(1)
+
+		r := httptest.NewRequest(http.MethodPatch, "/", bytes.NewBufferString(`{"title": "Updated Title"}`))
+		r.SetPathValue("id", recordID.String())
+		r.Header.Set("If-Match", `"3"`)
+		w := httptest.NewRecorder()
+		NewUpdateHandler(&UpdateHandlerConfig{Service: environment.service}).ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("UpdateHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusOK)
+		}
+		if got := w.Header().Get("ETag"); got != `"4"` {
+			t.Errorf("UpdateHandler.ServeHTTP() ETag = %v, want %v", got, `"4"`)
+		}
+	})
+
+	t.Run("update record w/ merge patch", func(t *testing.T) {
+
+		// The fields of the patch make the update mask, including the immutable ones, which the service rejects.
+		environment.service.EXPECT().Update(gomock.Any(), recordID, &service.UpdateOptions{
+			Title:      "Updated Title",
+			UpdateMask: []string{"title", "user_id"},
+		}).Return(nil, fmt.Errorf("invalid update_mask: field \"user_id\" is immutable")).Times(1)
+
+		r := httptest.NewRequest(http.MethodPatch, "/", bytes.NewBufferString(`{"title": "Updated Title", "user_id": null}`))
+		r.SetPathValue("id", recordID.String())
+		r.Header.Set("Content-Type", "application/merge-patch+json")
+		w := httptest.NewRecorder()
+		NewUpdateHandler(&UpdateHandlerConfig{Service: environment.service}).ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("UpdateHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("update record w/ update_mask", func(t *testing.T) {
+
+		// The mask of the query takes precedence over the fields of the patch.
+		environment.service.EXPECT().Update(gomock.Any(), recordID, &service.UpdateOptions{
+			Title:      "Updated Title",
+			UpdateMask: []string{"title"},
+		}).Return(&model.Record{
+			Title: "Updated Title",
+		}, nil).Times(1)
+
+		r := httptest.NewRequest(http.MethodPatch, "/?update_mask=title", bytes.NewBufferString(`{"title": "Updated Title", "user_id": null}`))
+		r.SetPathValue("id", recordID.String())
+		w := httptest.NewRecorder()
+		NewUpdateHandler(&UpdateHandlerConfig{Service: environment.service}).ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("UpdateHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("update record w/o fields", func(t *testing.T) {
+
+		// Make sure the service layer is not expecting a call.
+		environment.service.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		for _, body := range []string{`{}`, `null`, `[]`, `{"title": 1}`} {
+			r := httptest.NewRequest(http.MethodPatch, "/", bytes.NewBufferString(body))
+			r.SetPathValue("id", recordID.String())
+			w := httptest.NewRecorder()
+			NewUpdateHandler(&UpdateHandlerConfig{Service: environment.service}).ServeHTTP(w, r)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("UpdateHandler.ServeHTTP() w/ %s = %v, want %v", body, w.Code, http.StatusBadRequest)
+			}
+		}
+	})
+
+	t.Run("update record w/ stale If-Match", func(t *testing.T) {
+
+		environment.service.EXPECT().Update(gomock.Any(), recordID, gomock.Any()).Return(nil, service.ErrVersionMismatch).Times(1)
+
+		r := httptest.NewRequest(http.MethodPatch, "/", bytes.NewBufferString(`{"title": "Updated Title"}`))
+		r.SetPathValue("id", recordID.String())
+		r.Header.Set("If-Match", `"3"`)
+		w := httptest.NewRecorder()
+		NewUpdateHandler(&UpdateHandlerConfig{Service: environment.service}).ServeHTTP(w, r)
+
+		if w.Code != http.StatusPreconditionFailed {
+			t.Errorf("UpdateHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusPreconditionFailed)
+		}
+	})
+
+	t.Run("update record w/ invalid If-Match", func(t *testing.T) {
+
+		// Make sure the service layer is not expecting a call.
+		environment.service.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		for header, want := range map[string]int{
+			`W/"3"`:     http.StatusPreconditionFailed,
+			`3`:         http.StatusPreconditionFailed,
+			`"3", "4"`:  http.StatusBadRequest,
+			`"invalid"`: http.StatusPreconditionFailed,
+		} {
+			r := httptest.NewRequest(http.MethodPatch, "/", bytes.NewBufferString(`{"title": "Updated Title"}`))
+			r.SetPathValue("id", recordID.String())
+			r.Header.Set("If-Match", header)
+			w := httptest.NewRecorder()
+			NewUpdateHandler(&UpdateHandlerConfig{Service: environment.service}).ServeHTTP(w, r)
+
+			if w.Code != want {
+				t.Errorf("UpdateHandler.ServeHTTP() w/ If-Match %s = %v, want %v", header, w.Code, want)
+			}
+		}
+	})
 }
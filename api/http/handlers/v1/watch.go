@@ -0,0 +1,186 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"github.com/mrinalwahal/service/service"
+)
+
+// DefaultHeartbeat is the default interval of the heartbeats of the watch streams.
+const DefaultHeartbeat = 15 * time.Second
+
+// EventReset is the type of the event sent to the watchers which resume from an event the stream cannot replay
+// from. They must get the records again, e.g. with `GET /v1`, to catch up.
+const EventReset = "reset"
+
+// Watch handler streams the events of the records the caller can see, as Server-Sent Events.
+//
+// Link: https://html.spec.whatwg.org/multipage/server-sent-events.html
+type WatchHandler struct {
+
+	// Hub of the events of the records.
+	//
+	// This field is mandatory.
+	hub *service.Hub
+
+	// heartbeat is the interval of the comments which keep the idle streams open.
+	heartbeat time.Duration
+
+	// log is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	log *slog.Logger
+}
+
+type WatchHandlerConfig struct {
+
+	// Hub of the events of the records.
+	//
+	// This field is mandatory.
+	Hub *service.Hub
+
+	// Heartbeat is the interval of the comments sent on the idle streams, so the proxies do not close them.
+	// Default: `DefaultHeartbeat`
+	//
+	// This field is optional.
+	Heartbeat time.Duration
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// NewWatchHandler creates a new instance of `WatchHandler`.
+func NewWatchHandler(config *WatchHandlerConfig) Handler {
+	handler := WatchHandler{
+		hub:       config.Hub,
+		heartbeat: config.Heartbeat,
+		log:       config.Logger,
+	}
+
+	if handler.heartbeat <= 0 {
+		handler.heartbeat = DefaultHeartbeat
+	}
+
+	// Set the default logger if not provided.
+	if handler.log == nil {
+		handler.log = slog.Default()
+	}
+	handler.log = handler.log.With("handler", "watch")
+
+	return &handler
+}
+
+// ServeHTTP handles the incoming HTTP request.
+//
+// The stream resumes after the event of the `Last-Event-ID` header, or of the `last_event_id` query parameter.
+// It ends when the client disconnects, when it falls behind, or when the server shuts down.
+func (h *WatchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.log.DebugContext(r.Context(), "handling request")
+
+	// Only stream the events of the records of the caller, so there is no stream without one.
+	claims, exists := r.Context().Value(middleware.XJWTClaims).(middleware.JWTClaims)
+	if !exists {
+		write(w, http.StatusUnauthorized, &Response{
+			Message: "Failed to identify the caller of the request.",
+			Err:     ErrInvalidJWTClaims,
+		})
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+
+	subscription, replay, err := h.hub.Subscribe(lastEventID)
+	if errors.Is(err, service.ErrHubClosed) {
+		write(w, http.StatusServiceUnavailable, &Response{
+			Message: "The server is shutting down.",
+			Err:     err,
+		})
+		return
+	}
+	defer subscription.Close()
+
+	// The stream outlives the write timeout of the server.
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		h.log.WarnContext(r.Context(), "failed to clear the write deadline", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Skip the events of the records of other users.
+	send := func(event *model.Event) error {
+		if event.Data.UserID != claims.XUserID {
+			return nil
+		}
+		return writeEvent(w, event)
+	}
+
+	if errors.Is(err, service.ErrEventNotFound) {
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: {}\n\n", EventReset); err != nil {
+			return
+		}
+	}
+	for _, event := range replay {
+		if err := send(event); err != nil {
+			return
+		}
+	}
+	if err := controller.Flush(); err != nil {
+		h.log.WarnContext(r.Context(), "failed to flush the stream", "error", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			if err := send(event); err != nil {
+				return
+			}
+
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent writes the event as a Server-Sent Event, with its ID, its type, and itself as JSON data.
+func writeEvent(w io.Writer, event *model.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
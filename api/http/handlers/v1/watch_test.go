@@ -0,0 +1,185 @@
+package v1
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"github.com/mrinalwahal/service/service"
+)
+
+// stream reads the lines of a Server-Sent Events stream.
+type stream struct {
+	t     *testing.T
+	lines chan string
+}
+
+// watch opens a stream on the server, with the headers.
+func watch(t *testing.T, server *httptest.Server, header http.Header) (*http.Response, *stream) {
+	t.Helper()
+
+	r, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	for key, values := range header {
+		r.Header[key] = values
+	}
+	response, err := server.Client().Do(r)
+	if err != nil {
+		t.Fatalf("failed to open the stream: %v", err)
+	}
+	t.Cleanup(func() { response.Body.Close() })
+
+	s := stream{t: t, lines: make(chan string)}
+	go func() {
+		defer close(s.lines)
+		scanner := bufio.NewScanner(response.Body)
+		for scanner.Scan() {
+			s.lines <- scanner.Text()
+		}
+	}()
+	return response, &s
+}
+
+// next returns the next line of the stream which is not empty, or an empty line if the stream ended.
+func (s *stream) next() string {
+	s.t.Helper()
+
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok || line != "" {
+				return line
+			}
+		case <-time.After(time.Second):
+			s.t.Fatalf("expected a line, got none")
+		}
+	}
+}
+
+func TestWatchHandler_ServeHTTP(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	user := uuid.New()
+	event := func(owner uuid.UUID) *model.Event {
+		return &model.Event{
+			ID:   uuid.New(),
+			Type: model.EventRecordCreated,
+			Data: model.Record{UserID: owner},
+		}
+	}
+
+	// Serve the handler to the user.
+	serve := func(hub *service.Hub, heartbeat time.Duration) *httptest.Server {
+		handler := NewWatchHandler(&WatchHandlerConfig{
+			Hub:       hub,
+			Heartbeat: heartbeat,
+			Logger:    config.log,
+		})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), middleware.XJWTClaims, middleware.JWTClaims{
+				XUserID: user,
+			})
+			handler.ServeHTTP(w, r.WithContext(ctx))
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	t.Run("refuse to stream w/o jwt claims", func(t *testing.T) {
+
+		handler := NewWatchHandler(&WatchHandlerConfig{
+			Hub:    service.NewHub(nil),
+			Logger: config.log,
+		})
+		r := httptest.NewRequest(http.MethodGet, "/v1:watch", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("expected status code %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+	})
+
+	t.Run("stream the events of the user", func(t *testing.T) {
+
+		hub := service.NewHub(nil)
+		response, stream := watch(t, serve(hub, time.Minute), nil)
+		if response.StatusCode != http.StatusOK || response.Header.Get("Content-Type") != "text/event-stream" {
+			t.Fatalf("WatchHandler.ServeHTTP() = %v, %q", response.StatusCode, response.Header.Get("Content-Type"))
+		}
+
+		// The events of the records of other users are skipped.
+		hub.Publish(context.Background(), event(uuid.New()))
+		published := event(user)
+		hub.Publish(context.Background(), published)
+
+		if got, want := stream.next(), "id: "+published.ID.String(); got != want {
+			t.Errorf("WatchHandler.ServeHTTP() line = %q, want %q", got, want)
+		}
+		if got, want := stream.next(), "event: "+model.EventRecordCreated; got != want {
+			t.Errorf("WatchHandler.ServeHTTP() line = %q, want %q", got, want)
+		}
+		if got := stream.next(); !strings.HasPrefix(got, "data: {") {
+			t.Errorf("WatchHandler.ServeHTTP() line = %q, want the event as JSON", got)
+		}
+
+		// The stream ends when the hub closes.
+		hub.Close()
+		if got := stream.next(); got != "" {
+			t.Errorf("WatchHandler.ServeHTTP() line = %q, want the end of the stream", got)
+		}
+	})
+
+	t.Run("resume after the last event seen", func(t *testing.T) {
+
+		hub := service.NewHub(nil)
+		seen, missed := event(user), event(user)
+		hub.Publish(context.Background(), seen)
+		hub.Publish(context.Background(), missed)
+
+		_, stream := watch(t, serve(hub, time.Minute), http.Header{
+			"Last-Event-ID": {seen.ID.String()},
+		})
+		if got, want := stream.next(), "id: "+missed.ID.String(); got != want {
+			t.Errorf("WatchHandler.ServeHTTP() line = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("reset the stream of an unknown event", func(t *testing.T) {
+
+		hub := service.NewHub(nil)
+		_, stream := watch(t, serve(hub, time.Minute), http.Header{
+			"Last-Event-ID": {uuid.NewString()},
+		})
+		if got, want := stream.next(), "event: "+EventReset; got != want {
+			t.Errorf("WatchHandler.ServeHTTP() line = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("send heartbeats on idle streams", func(t *testing.T) {
+
+		hub := service.NewHub(nil)
+		_, stream := watch(t, serve(hub, 10*time.Millisecond), nil)
+		if got, want := stream.next(), ": heartbeat"; got != want {
+			t.Errorf("WatchHandler.ServeHTTP() line = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("refuse to stream once the hub is closed", func(t *testing.T) {
+
+		hub := service.NewHub(nil)
+		hub.Close()
+		response, _ := watch(t, serve(hub, time.Minute), nil)
+		if response.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("WatchHandler.ServeHTTP() = %v, want %v", response.StatusCode, http.StatusServiceUnavailable)
+		}
+	})
+}
@@ -0,0 +1,144 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"github.com/mrinalwahal/service/service"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateWebhookHandler_ServeHTTP(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Create the handler.
+	handler := NewCreateWebhookHandler(&CreateWebhookHandlerConfig{
+		Service: config.service,
+		Logger:  config.log,
+	})
+
+	body := `{"url": "https://example.com/hooks", "events": ["record.deleted"]}`
+
+	t.Run("create webhook w/o jwt claims", func(t *testing.T) {
+
+		r := httptest.NewRequest(http.MethodPost, "/v1/webhooks", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("CreateWebhookHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("create webhook", func(t *testing.T) {
+
+		user := uuid.New()
+		config.service.EXPECT().CreateWebhook(gomock.Any(), &service.CreateWebhookOptions{
+			UserID: user,
+			URL:    "https://example.com/hooks",
+			Events: []string{model.EventRecordDeleted},
+		}).Return(&model.Webhook{ID: uuid.New(), UserID: user, Secret: "whsec_secret"}, nil).Times(1)
+
+		r := httptest.NewRequest(http.MethodPost, "/v1/webhooks", strings.NewReader(body))
+		r = r.WithContext(context.WithValue(r.Context(), middleware.XJWTClaims, middleware.JWTClaims{
+			XUserID: user,
+		}))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusCreated {
+			t.Errorf("CreateWebhookHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusCreated)
+		}
+		if !strings.Contains(w.Body.String(), "whsec_secret") {
+			t.Errorf("expected the response to hold the secret of the webhook")
+		}
+	})
+}
+
+func TestDeleteWebhookHandler_ServeHTTP(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Create the handler.
+	handler := NewDeleteWebhookHandler(&DeleteWebhookHandlerConfig{
+		Service: config.service,
+		Logger:  config.log,
+	})
+
+	t.Run("delete webhook w/ invalid id", func(t *testing.T) {
+
+		r := httptest.NewRequest(http.MethodDelete, "/", nil)
+		r.SetPathValue("id", "invalid")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("DeleteWebhookHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("delete webhook", func(t *testing.T) {
+
+		id := uuid.New()
+		config.service.EXPECT().DeleteWebhook(gomock.Any(), id).Return(nil).Times(1)
+
+		r := httptest.NewRequest(http.MethodDelete, "/", nil)
+		r.SetPathValue("id", id.String())
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("DeleteWebhookHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusOK)
+		}
+	})
+}
+
+func TestListDeliveriesHandler_ServeHTTP(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Create the handler.
+	handler := NewListDeliveriesHandler(&ListDeliveriesHandlerConfig{
+		Service: config.service,
+		Logger:  config.log,
+	})
+
+	t.Run("list deliveries w/ invalid webhook id", func(t *testing.T) {
+
+		r := httptest.NewRequest(http.MethodGet, "/?webhook_id=invalid", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("ListDeliveriesHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("list deliveries by webhook and status", func(t *testing.T) {
+
+		id := uuid.New()
+		config.service.EXPECT().ListDeliveries(gomock.Any(), &service.ListDeliveriesOptions{
+			WebhookID: id,
+			Status:    model.DeliveryFailed,
+			PageSize:  10,
+		}).Return([]*model.Delivery{{WebhookID: id, Status: model.DeliveryFailed}}, "", nil).Times(1)
+
+		r := httptest.NewRequest(http.MethodGet, "/?webhook_id="+id.String()+"&status=failed&page_size=10", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("ListDeliveriesHandler.ServeHTTP() = %v, want %v", w.Code, http.StatusOK)
+		}
+	})
+}
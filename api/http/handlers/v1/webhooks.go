@@ -0,0 +1,98 @@
+package v1
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/dyninc/qstring"
+	"github.com/mrinalwahal/service/service"
+)
+
+// ListWebhooksOptions represents the options for listing the webhooks.
+type ListWebhooksOptions struct {
+
+	//	Maximum number of webhooks to return.
+	//	Default: 50. Page sizes larger than 100 are coerced to 100.
+	PageSize int `qstring:"page_size"`
+
+	//	Token of the page to return: the `next_page_token` of the previous page.
+	PageToken string `qstring:"page_token"`
+}
+
+// ListWebhooks handler lists the webhooks of the caller, oldest first, without their secrets.
+type ListWebhooksHandler struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	service service.Service
+
+	// log is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	log *slog.Logger
+}
+
+type ListWebhooksHandlerConfig struct {
+
+	// Service layer.
+	//
+	// This field is mandatory.
+	Service service.Service
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// NewListWebhooksHandler creates a new instance of `ListWebhooksHandler`.
+func NewListWebhooksHandler(config *ListWebhooksHandlerConfig) Handler {
+	handler := ListWebhooksHandler{
+		service: config.Service,
+		log:     config.Logger,
+	}
+
+	// Set the default logger if not provided.
+	if handler.log == nil {
+		handler.log = slog.Default()
+	}
+	handler.log = handler.log.With("handler", "list_webhooks")
+
+	return &handler
+}
+
+// ServeHTTP handles the incoming HTTP request.
+func (h *ListWebhooksHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.log.DebugContext(r.Context(), "handling request")
+
+	// Decode the request options.
+	var options ListWebhooksOptions
+	if err := qstring.Unmarshal(r.URL.Query(), &options); err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Invalid request options.",
+			Err:     err,
+		})
+		return
+	}
+
+	webhooks, token, err := h.service.ListWebhooks(r.Context(), &service.ListWebhooksOptions{
+		PageSize:  options.PageSize,
+		PageToken: options.PageToken,
+	})
+	if err != nil {
+		write(w, http.StatusBadRequest, &Response{
+			Message: "Failed to list the webhooks.",
+			Err:     err,
+		})
+		return
+	}
+
+	write(w, http.StatusOK, &Response{
+		Message:       "The webhooks were retrieved successfully.",
+		Data:          webhooks,
+		NextPageToken: token,
+	})
+}
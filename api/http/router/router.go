@@ -1,10 +1,15 @@
 package router
 
 import (
+	"encoding/json"
 	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
 	v1 "github.com/mrinalwahal/service/api/http/handlers/v1"
+	"github.com/mrinalwahal/service/pkg/health"
+	"github.com/mrinalwahal/service/pkg/writer"
 	"github.com/mrinalwahal/service/service"
 )
 
@@ -21,13 +26,48 @@ type HTTPRouter struct {
 	//
 	// This field is optional.
 	log *slog.Logger
+
+	// ready reports whether the service is ready to serve traffic.
+	//
+	// This field is optional.
+	ready func() bool
+
+	// health holds the checks of the dependencies reported by `/readyz`.
+	//
+	// This field is optional.
+	health *health.Registry
+
+	// hub streams the events of the records to the watchers.
+	//
+	// This field is optional.
+	hub *service.Hub
+
+	// heartbeat is the interval of the heartbeats of the watch streams.
+	heartbeat time.Duration
+
+	// maxBatchSize is the maximum number of items of the batches.
+	maxBatchSize int
+
+	// build is the build information reported by the probes.
+	build health.Build
+
+	// mounts are the prefixes the router has been mounted on with `Mount`.
+	mounts []string
 }
 
 // HandleFunc registers the handler function for the given pattern.
 // func (r *HTTPRouter) HandleFunc(pattern string, handlerFunc func(w http.ResponseWriter, req *http.Request)) {}
 
 // ServeHTTP handles the incoming HTTP request.
-// func (r *HTTPRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {}
+//
+// It records the pattern of the matched route on the response writer, so the middlewares can label their
+// metrics with it instead of the raw path.
+func (r *HTTPRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if _, pattern := r.ServeMux.Handler(req); pattern != "" {
+		writer.SetPattern(w, pattern)
+	}
+	r.ServeMux.ServeHTTP(w, req)
+}
 
 type HTTPRouterConfig struct {
 
@@ -41,15 +81,53 @@ type HTTPRouterConfig struct {
 	//
 	// This field is optional.
 	Logger *slog.Logger
+
+	// Ready reports whether the service has started and is not shutting down.
+	// When it returns false, `/readyz` responds with `503 Service Unavailable`.
+	// Default: always ready
+	//
+	// This field is optional.
+	Ready func() bool
+
+	// Health holds the checks of the dependencies of the service, e.g. the database.
+	// When a critical check fails, `/readyz` responds with `503 Service Unavailable`.
+	// Default: no checks
+	//
+	// This field is optional.
+	Health *health.Registry
+
+	// Hub streams the events of the records to the watchers of `GET /v1:watch`.
+	// Default: none, and `GET /v1:watch` is not served.
+	//
+	// This field is optional.
+	Hub *service.Hub
+
+	// Heartbeat is the interval of the heartbeats of the watch streams.
+	// Default: `v1.DefaultHeartbeat`
+	//
+	// This field is optional.
+	Heartbeat time.Duration
+
+	// MaxBatchSize is the maximum number of items of the batches, which limits the size of their request bodies.
+	// Default: `service.DefaultMaxBatchSize`
+	//
+	// This field is optional.
+	MaxBatchSize int
 }
 
 // NewHTTPRouter creates a new instance of `HTTPRouter`.
 func NewHTTPRouter(config *HTTPRouterConfig) *HTTPRouter {
 
 	router := HTTPRouter{
-		ServeMux: http.NewServeMux(),
-		service:  config.Service,
-		log:      config.Logger,
+		ServeMux:     http.NewServeMux(),
+		service:      config.Service,
+		log:          config.Logger,
+		ready:        config.Ready,
+		health:       config.Health,
+		hub:          config.Hub,
+		heartbeat:    config.Heartbeat,
+		maxBatchSize: config.MaxBatchSize,
+		build:        health.ReadBuild(),
 	}
 
 	// Set the default logger if not provided.
@@ -57,13 +135,16 @@ func NewHTTPRouter(config *HTTPRouterConfig) *HTTPRouter {
 		router.log = slog.Default()
 	}
 
-	// router.log = router.log.With("layer", "http")
+	router.log = router.log.With("layer", "http")
 
-	// Register the default routes.
-	router.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+	// Set the empty registry if not provided.
+	if router.health == nil {
+		router.health = health.NewRegistry()
+	}
+
+	// Register the probes.
+	router.HandleFunc("GET /livez", router.livez)
+	router.HandleFunc("GET /readyz", router.readyz)
 
 	// Register the v1 routes.
 	router.RegisterV1Routes()
@@ -71,6 +152,85 @@ func NewHTTPRouter(config *HTTPRouterConfig) *HTTPRouter {
 	return &router
 }
 
+// probes are the paths of the probes, relative to the router.
+var probes = []string{"/livez", "/readyz"}
+
+// Mount serves the router on the supplied mux under the prefix, and remembers the prefix for `IsProbe`.
+//
+// It must be called before the mux starts serving requests.
+//
+// Example: `router.Mount(mux, "/records")` serves `/records/v1`, `/records/livez` and `/records/readyz`.
+func (r *HTTPRouter) Mount(mux *http.ServeMux, prefix string) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		mux.Handle("/", r)
+	} else {
+		mux.Handle(prefix+"/", http.StripPrefix(prefix, r))
+	}
+	r.mounts = append(r.mounts, prefix)
+}
+
+// IsProbe reports whether the request is for one of the probes, under any of the prefixes the router is mounted on.
+//
+// It is meant for middlewares which run before the router, like authentication, to let the probes through.
+func (r *HTTPRouter) IsProbe(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	for _, prefix := range r.mounts {
+		for _, probe := range probes {
+			if req.URL.Path == prefix+probe {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// livez reports whether the process is alive.
+//
+// It does not check any dependency: restarting the process would not fix them.
+func (r *HTTPRouter) livez(w http.ResponseWriter, req *http.Request) {
+	r.probe(w, req, health.Report{
+		Status: health.StatusUp,
+	})
+}
+
+// readyz reports whether the service can serve traffic, which requires it to have started and every critical
+// dependency to be healthy.
+func (r *HTTPRouter) readyz(w http.ResponseWriter, req *http.Request) {
+	report := r.health.Run(req.Context())
+	if r.ready != nil && !r.ready() {
+		report.Status = health.StatusDown
+	}
+	r.probe(w, req, report)
+}
+
+// probe writes the report of a probe.
+//
+// The report is written as plain text, unless the `verbose` query parameter is set, in which case
+// the whole report is written as JSON along with the build information.
+func (r *HTTPRouter) probe(w http.ResponseWriter, req *http.Request, report health.Report) {
+	status, body := http.StatusOK, "OK"
+	if report.Status == health.StatusDown {
+		status, body = http.StatusServiceUnavailable, "Service Unavailable"
+	}
+
+	if !req.URL.Query().Has("verbose") {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+		return
+	}
+
+	report.Build = &r.build
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		r.log.ErrorContext(req.Context(), "failed to write the probe report", "error", err)
+	}
+}
+
 // RegisterV1Routes registers /v1 routes.
 func (r *HTTPRouter) RegisterV1Routes() {
 
@@ -84,6 +244,64 @@ func (r *HTTPRouter) RegisterV1Routes() {
 		Logger:  r.log,
 	}))
 
+	r.Handle("GET /v1:search", v1.NewSearchHandler(&v1.SearchHandlerConfig{
+		Service: r.service,
+		Logger:  r.log,
+	}))
+
+	if r.hub != nil {
+		r.Handle("GET /v1:watch", v1.NewWatchHandler(&v1.WatchHandlerConfig{
+			Hub:       r.hub,
+			Heartbeat: r.heartbeat,
+			Logger:    r.log,
+		}))
+	}
+
+	r.Handle("POST /v1:batchCreate", v1.NewBatchCreateHandler(&v1.BatchCreateHandlerConfig{
+		Service:      r.service,
+		Logger:       r.log,
+		MaxBatchSize: r.maxBatchSize,
+	}))
+
+	r.Handle("GET /v1:batchGet", v1.NewBatchGetHandler(&v1.BatchGetHandlerConfig{
+		Service: r.service,
+		Logger:  r.log,
+	}))
+
+	r.Handle("POST /v1:batchUpdate", v1.NewBatchUpdateHandler(&v1.BatchUpdateHandlerConfig{
+		Service:      r.service,
+		Logger:       r.log,
+		MaxBatchSize: r.maxBatchSize,
+	}))
+
+	r.Handle("POST /v1:batchDelete", v1.NewBatchDeleteHandler(&v1.BatchDeleteHandlerConfig{
+		Service:      r.service,
+		Logger:       r.log,
+		MaxBatchSize: r.maxBatchSize,
+	}))
+
+	r.Handle("POST /v1/webhooks", v1.NewCreateWebhookHandler(&v1.CreateWebhookHandlerConfig{
+		Service: r.service,
+		Logger:  r.log,
+	}))
+
+	r.Handle("GET /v1/webhooks", v1.NewListWebhooksHandler(&v1.ListWebhooksHandlerConfig{
+		Service: r.service,
+		Logger:  r.log,
+	}))
+
+	r.Handle("DELETE /v1/webhooks/{id}", v1.NewDeleteWebhookHandler(&v1.DeleteWebhookHandlerConfig{
+		Service: r.service,
+		Logger:  r.log,
+	}))
+
+	// The deliveries are filtered by webhook with a query parameter, because `GET /v1/webhooks/{id}/deliveries`
+	// would conflict with `GET /v1/{id}/revisions/{revision}`.
+	r.Handle("GET /v1/webhooks/deliveries", v1.NewListDeliveriesHandler(&v1.ListDeliveriesHandlerConfig{
+		Service: r.service,
+		Logger:  r.log,
+	}))
+
 	r.Handle("GET /v1/{id}", v1.NewGetHandler(&v1.GetHandlerConfig{
 		Service: r.service,
 		Logger:  r.log,
@@ -98,4 +316,82 @@ func (r *HTTPRouter) RegisterV1Routes() {
 		Service: r.service,
 		Logger:  r.log,
 	}))
+
+	r.Handle("GET /v1/{id}/revisions", v1.NewListRevisionsHandler(&v1.ListRevisionsHandlerConfig{
+		Service: r.service,
+		Logger:  r.log,
+	}))
+
+	r.Handle("GET /v1/{id}/revisions/{revision}", &customMethods{
+		pattern:  "GET /v1/{id}/revisions/{revision}",
+		wildcard: "revision",
+		handlers: map[string]http.Handler{
+			"": v1.NewGetRevisionHandler(&v1.GetRevisionHandlerConfig{
+				Service: r.service,
+				Logger:  r.log,
+			}),
+			"diff": v1.NewDiffRevisionsHandler(&v1.DiffRevisionsHandlerConfig{
+				Service: r.service,
+				Logger:  r.log,
+			}),
+		},
+	})
+
+	r.Handle("POST /v1/{id}", &customMethods{
+		pattern: "POST /v1/{id}",
+		handlers: map[string]http.Handler{
+			"undelete": v1.NewUndeleteHandler(&v1.UndeleteHandlerConfig{
+				Service: r.service,
+				Logger:  r.log,
+			}),
+			"restore": v1.NewRestoreHandler(&v1.RestoreHandlerConfig{
+				Service: r.service,
+				Logger:  r.log,
+			}),
+		},
+	})
+}
+
+// customMethods serves the custom methods of a resource, e.g. `POST /v1/{id}:undelete`, by their verb.
+//
+// `http.ServeMux` wildcards must span whole path segments, so `{id}:undelete` cannot be registered as a pattern.
+// Instead, the custom methods are registered together as `{id}`, and the verb is split from the ID here.
+//
+// Link: https://google.aip.dev/136
+type customMethods struct {
+
+	// pattern is the pattern the custom methods are registered with.
+	//
+	// Example: "POST /v1/{id}"
+	pattern string
+
+	// wildcard is the wildcard of the pattern which the verb is split from.
+	// Default: "id"
+	wildcard string
+
+	// handlers are the handlers of the custom methods, by verb. The handler of the empty verb, if any, serves the
+	// requests without verb, e.g. the standard method of the pattern.
+	handlers map[string]http.Handler
+}
+
+func (c *customMethods) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	wildcard := c.wildcard
+	if wildcard == "" {
+		wildcard = "id"
+	}
+
+	value, verb, found := strings.Cut(req.PathValue(wildcard), ":")
+	handler, ok := c.handlers[verb]
+	if (found && verb == "") || !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	// Label the metrics of every custom method separately.
+	if found {
+		writer.SetPattern(w, c.pattern+":"+verb)
+	}
+
+	req.SetPathValue(wildcard, value)
+	handler.ServeHTTP(w, req)
 }
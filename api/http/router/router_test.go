@@ -14,7 +14,9 @@ import (
 	v1 "github.com/mrinalwahal/service/api/http/handlers/v1"
 	"github.com/mrinalwahal/service/db"
 	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/health"
 	"github.com/mrinalwahal/service/pkg/middleware"
+	"github.com/mrinalwahal/service/pkg/writer"
 	"github.com/mrinalwahal/service/service"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -40,7 +42,7 @@ func configure(t *testing.T) *testconfig {
 	}
 
 	// Migrate the schema.
-	if err := conn.AutoMigrate(&model.Record{}); err != nil {
+	if err := conn.AutoMigrate(&model.Record{}, &model.Revision{}, &model.Event{}, &model.Webhook{}, &model.Delivery{}); err != nil {
 		t.Fatalf("failed to migrate the schema: %v", err)
 	}
 
@@ -79,6 +81,194 @@ func Test_Router(t *testing.T) {
 	// Configure the test environment.
 	config := configure(t)
 
+	t.Run("request to livez", func(t *testing.T) {
+
+		// Prepare the r and response recorder.
+		r := httptest.NewRequest(http.MethodGet, "/livez", nil)
+		w := httptest.NewRecorder()
+
+		// Prepare the router.
+		// Liveness must not depend on readiness.
+		router := NewHTTPRouter(&HTTPRouterConfig{
+			Service: config.service,
+			Logger:  config.log,
+			Ready: func() bool {
+				return false
+			},
+		})
+
+		// Serve the request.
+		router.ServeHTTP(w, r)
+
+		// Check the response status code.
+		if w.Code != http.StatusOK {
+			t.Logf("got response body = %v", w.Body.String())
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("request to readyz while not ready", func(t *testing.T) {
+
+		// Prepare the r and response recorder.
+		r := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+		w := httptest.NewRecorder()
+
+		// Prepare the router.
+		router := NewHTTPRouter(&HTTPRouterConfig{
+			Service: config.service,
+			Logger:  config.log,
+			Ready: func() bool {
+				return false
+			},
+		})
+
+		// Serve the request.
+		router.ServeHTTP(w, r)
+
+		// Check the response status code.
+		if w.Code != http.StatusServiceUnavailable {
+			t.Logf("got response body = %v", w.Body.String())
+			t.Fatalf("expected status code %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+	})
+
+	t.Run("request to readyz w/ failing checks", func(t *testing.T) {
+
+		checks := health.NewRegistry()
+		checks.Register(
+			health.Check{
+				Name:     "database",
+				Critical: true,
+				Func: func(ctx context.Context) (string, error) {
+					return "", nil
+				},
+			},
+			health.Check{
+				Name: "cache",
+				Func: func(ctx context.Context) (string, error) {
+					return "", fmt.Errorf("connection refused")
+				},
+			},
+		)
+
+		// Prepare the router.
+		router := NewHTTPRouter(&HTTPRouterConfig{
+			Service: config.service,
+			Logger:  config.log,
+			Health:  checks,
+		})
+
+		// A failing non-critical check degrades the service, but it stays ready.
+		r := httptest.NewRequest(http.MethodGet, "/readyz?verbose", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Logf("got response body = %v", w.Body.String())
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		var report health.Report
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatalf("failed to decode the response body: %v", err)
+		}
+		if report.Status != health.StatusDegraded {
+			t.Errorf("expected status %q, got %q", health.StatusDegraded, report.Status)
+		}
+		if len(report.Checks) != 2 {
+			t.Errorf("expected 2 checks, got %d", len(report.Checks))
+		}
+		if report.Build == nil {
+			t.Errorf("expected the build information to be reported")
+		}
+
+		// A failing critical check takes it down.
+		checks.Register(health.Check{
+			Name:     "migrations",
+			Critical: true,
+			Func: func(ctx context.Context) (string, error) {
+				return "", fmt.Errorf("schema has not been checked yet")
+			},
+		})
+
+		r = httptest.NewRequest(http.MethodGet, "/readyz", nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Logf("got response body = %v", w.Body.String())
+			t.Fatalf("expected status code %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+	})
+
+	t.Run("record the pattern of the matched route", func(t *testing.T) {
+
+		// Prepare the router.
+		router := NewHTTPRouter(&HTTPRouterConfig{
+			Service: config.service,
+			Logger:  config.log,
+		})
+		mux := http.NewServeMux()
+		router.Mount(mux, "/records")
+
+		r := httptest.NewRequest(http.MethodGet, "/records/v1/"+uuid.NewString(), nil)
+		w := writer.NewWriter(httptest.NewRecorder())
+		mux.ServeHTTP(w, r)
+
+		if want := "GET /v1/{id}"; w.Pattern() != want {
+			t.Errorf("expected pattern %q, got %q", want, w.Pattern())
+		}
+
+		r = httptest.NewRequest(http.MethodPost, "/records/v1/"+uuid.NewString()+":undelete", nil)
+		w = writer.NewWriter(httptest.NewRecorder())
+		mux.ServeHTTP(w, r)
+
+		if want := "POST /v1/{id}:undelete"; w.Pattern() != want {
+			t.Errorf("expected pattern %q, got %q", want, w.Pattern())
+		}
+
+		r = httptest.NewRequest(http.MethodGet, "/records/v1/"+uuid.NewString()+"/revisions/2:diff", nil)
+		w = writer.NewWriter(httptest.NewRecorder())
+		mux.ServeHTTP(w, r)
+
+		if want := "GET /v1/{id}/revisions/{revision}:diff"; w.Pattern() != want {
+			t.Errorf("expected pattern %q, got %q", want, w.Pattern())
+		}
+	})
+
+	t.Run("probes are exempt from auth wherever mounted", func(t *testing.T) {
+
+		// Prepare the router.
+		router := NewHTTPRouter(&HTTPRouterConfig{
+			Service: config.service,
+			Logger:  config.log,
+		})
+
+		// Mount it twice, behind the JWT middleware.
+		mux := http.NewServeMux()
+		router.Mount(mux, "/records")
+		router.Mount(mux, "/api/records/")
+		handler := middleware.JWT(&middleware.JWTConfig{
+			Key:  "secret",
+			Skip: router.IsProbe,
+		})(mux)
+
+		for path, want := range map[string]int{
+			"/records/livez":      http.StatusOK,
+			"/records/readyz":     http.StatusOK,
+			"/api/records/readyz": http.StatusOK,
+			"/records/v1":         http.StatusUnauthorized,
+			"/records/v1/readyz":  http.StatusUnauthorized,
+		} {
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != want {
+				t.Errorf("expected status code %d for %s, got %d", want, path, w.Code)
+			}
+		}
+	})
+
 	t.Run("request to create record w/ valid body", func(t *testing.T) {
 
 		// Prepare a body with invalid JSON.
@@ -291,9 +481,366 @@ func Test_Router(t *testing.T) {
 		}
 
 		// Try to fetch the deleted record and ensure it doesn't exist.
-		_, err = config.service.Get(context.Background(), record.ID)
+		_, err = config.service.Get(context.Background(), record.ID, nil)
 		if err == nil {
 			t.Fatal("expected to get an error, got nil")
 		}
 	})
+
+	t.Run("request to undelete and force delete record", func(t *testing.T) {
+
+		claims := middleware.JWTClaims{
+			XUserID: uuid.New(),
+		}
+		ctx := context.WithValue(context.Background(), middleware.XJWTClaims, claims)
+
+		// Create and soft-delete a record.
+		record, err := config.service.Create(ctx, &service.CreateOptions{
+			Title:  "test",
+			UserID: claims.XUserID,
+		})
+		if err != nil {
+			t.Fatalf("failed to create a record: %v", err)
+		}
+		if err := config.service.Delete(ctx, record.ID, nil); err != nil {
+			t.Fatalf("failed to delete the record: %v", err)
+		}
+
+		// Prepare the router.
+		router := NewHTTPRouter(&HTTPRouterConfig{
+			Service: config.service,
+			Logger:  config.log,
+		})
+		serve := func(method, path string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(method, path, nil).WithContext(ctx)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			return w
+		}
+
+		// The deleted record is only visible with `show_deleted`.
+		if w := serve(http.MethodGet, fmt.Sprintf("/v1/%s", record.ID)); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		if w := serve(http.MethodGet, fmt.Sprintf("/v1/%s?show_deleted=true", record.ID)); w.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+
+		// Unknown custom methods are not found.
+		if w := serve(http.MethodPost, fmt.Sprintf("/v1/%s:archive", record.ID)); w.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, w.Code)
+		}
+
+		// Undelete the record.
+		w := serve(http.MethodPost, fmt.Sprintf("/v1/%s:undelete", record.ID))
+		if w.Code != http.StatusOK {
+			t.Logf("got response body = %v", w.Body.String())
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		if _, err := config.service.Get(ctx, record.ID, nil); err != nil {
+			t.Fatalf("failed to get the undeleted record: %v", err)
+		}
+
+		// Force delete the record, which cannot be undeleted afterwards.
+		if w := serve(http.MethodDelete, fmt.Sprintf("/v1/%s?force=true", record.ID)); w.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		if _, err := config.service.Get(ctx, record.ID, &service.GetOptions{ShowDeleted: true}); err == nil {
+			t.Fatal("expected to get an error, got nil")
+		}
+		if w := serve(http.MethodPost, fmt.Sprintf("/v1/%s:undelete", record.ID)); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("request to list, get, diff and restore the revisions of a record", func(t *testing.T) {
+
+		claims := middleware.JWTClaims{
+			XUserID: uuid.New(),
+		}
+		ctx := context.WithValue(context.Background(), middleware.XJWTClaims, claims)
+
+		// Create and update a record.
+		record, err := config.service.Create(ctx, &service.CreateOptions{
+			Title:  "first",
+			UserID: claims.XUserID,
+		})
+		if err != nil {
+			t.Fatalf("failed to create a record: %v", err)
+		}
+		if _, err := config.service.Update(ctx, record.ID, &service.UpdateOptions{Title: "second"}); err != nil {
+			t.Fatalf("failed to update the record: %v", err)
+		}
+
+		// Prepare the router.
+		router := NewHTTPRouter(&HTTPRouterConfig{
+			Service: config.service,
+			Logger:  config.log,
+		})
+		serve := func(ctx context.Context, method, path string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(method, path, nil).WithContext(ctx)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			return w
+		}
+
+		w := serve(ctx, http.MethodGet, fmt.Sprintf("/v1/%s/revisions", record.ID))
+		if w.Code != http.StatusOK {
+			t.Logf("got response body = %v", w.Body.String())
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		var response struct {
+			Data []model.Revision `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to unmarshal the response body: %v", err)
+		}
+		if len(response.Data) != 2 || response.Data[0].Snapshot.Title != "second" {
+			t.Fatalf("expected the 2 revisions of the record, most recent first, got %+v", response.Data)
+		}
+
+		if w := serve(ctx, http.MethodGet, fmt.Sprintf("/v1/%s/revisions/1", record.ID)); w.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+
+		// Compare the second revision with the previous one.
+		w = serve(ctx, http.MethodGet, fmt.Sprintf("/v1/%s/revisions/2:diff", record.ID))
+		if w.Code != http.StatusOK {
+			t.Logf("got response body = %v", w.Body.String())
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		var diff struct {
+			Data model.Diff `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &diff); err != nil {
+			t.Fatalf("failed to unmarshal the response body: %v", err)
+		}
+		if diff.Data.Against != 1 || len(diff.Data.Changes) != 1 || diff.Data.Changes[0].Field != "title" ||
+			string(diff.Data.Changes[0].From) != `"first"` || string(diff.Data.Changes[0].To) != `"second"` {
+			t.Errorf("expected the change of the title from the first revision, got %+v", diff.Data)
+		}
+		if w := serve(ctx, http.MethodGet, fmt.Sprintf("/v1/%s/revisions/2:compare", record.ID)); w.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, w.Code)
+		}
+
+		// Other users can neither see nor restore the revisions.
+		other := context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{XUserID: uuid.New()})
+		if w := serve(other, http.MethodGet, fmt.Sprintf("/v1/%s/revisions", record.ID)); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		if w := serve(other, http.MethodGet, fmt.Sprintf("/v1/%s/revisions/1", record.ID)); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		if w := serve(other, http.MethodGet, fmt.Sprintf("/v1/%s/revisions/2:diff?against=1", record.ID)); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		if w := serve(other, http.MethodPost, fmt.Sprintf("/v1/%s:restore?revision=1", record.ID)); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+
+		// Restore the first revision.
+		w = serve(ctx, http.MethodPost, fmt.Sprintf("/v1/%s:restore?revision=1", record.ID))
+		if w.Code != http.StatusOK {
+			t.Logf("got response body = %v", w.Body.String())
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		restored, err := config.service.Get(ctx, record.ID, nil)
+		if err != nil {
+			t.Fatalf("failed to get the restored record: %v", err)
+		}
+		if restored.Title != "first" || restored.Version != 3 {
+			t.Errorf("expected the title of the first revision at version 3, got %q at version %d", restored.Title, restored.Version)
+		}
+	})
+
+	t.Run("request to update and delete record w/ If-Match", func(t *testing.T) {
+
+		claims := middleware.JWTClaims{
+			XUserID: uuid.New(),
+		}
+		ctx := context.WithValue(context.Background(), middleware.XJWTClaims, claims)
+
+		// Create a record.
+		record, err := config.service.Create(ctx, &service.CreateOptions{
+			Title:  "test",
+			UserID: claims.XUserID,
+		})
+		if err != nil {
+			t.Fatalf("failed to create a record: %v", err)
+		}
+
+		// Prepare the router.
+		router := NewHTTPRouter(&HTTPRouterConfig{
+			Service: config.service,
+			Logger:  config.log,
+		})
+		serve := func(method, path, etag string, body []byte) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(method, path, bytes.NewBuffer(body)).WithContext(ctx)
+			if etag != "" {
+				r.Header.Set("If-Match", etag)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			return w
+		}
+		path := fmt.Sprintf("/v1/%s", record.ID)
+
+		// Read the entity tag of the record.
+		w := serve(http.MethodGet, path, "", nil)
+		etag := w.Header().Get("ETag")
+		if w.Code != http.StatusOK || etag != record.ETag {
+			t.Fatalf("expected status code %d and ETag %s, got %d and %s", http.StatusOK, record.ETag, w.Code, etag)
+		}
+
+		// The first update based on the entity tag wins, the second one is rejected.
+		if w := serve(http.MethodPatch, path, etag, []byte(`{"title": "first"}`)); w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+			t.Fatalf("expected status code %d and a new ETag, got %d and %s", http.StatusOK, w.Code, w.Header().Get("ETag"))
+		}
+		if w := serve(http.MethodPatch, path, etag, []byte(`{"title": "second"}`)); w.Code != http.StatusPreconditionFailed {
+			t.Fatalf("expected status code %d, got %d", http.StatusPreconditionFailed, w.Code)
+		}
+		if w := serve(http.MethodDelete, path, etag, nil); w.Code != http.StatusPreconditionFailed {
+			t.Fatalf("expected status code %d, got %d", http.StatusPreconditionFailed, w.Code)
+		}
+
+		got, err := config.service.Get(ctx, record.ID, nil)
+		if err != nil {
+			t.Fatalf("failed to get the record: %v", err)
+		}
+		if got.Title != "first" {
+			t.Errorf("expected title to be 'first', got %s", got.Title)
+		}
+
+		// Deleting the current version succeeds.
+		if w := serve(http.MethodDelete, path, got.ETag, nil); w.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("request to update record w/ update mask", func(t *testing.T) {
+
+		claims := middleware.JWTClaims{
+			XUserID: uuid.New(),
+		}
+		ctx := context.WithValue(context.Background(), middleware.XJWTClaims, claims)
+
+		// Create a record.
+		record, err := config.service.Create(ctx, &service.CreateOptions{
+			Title:  "test",
+			UserID: claims.XUserID,
+		})
+		if err != nil {
+			t.Fatalf("failed to create a record: %v", err)
+		}
+
+		// Prepare the router.
+		router := NewHTTPRouter(&HTTPRouterConfig{
+			Service: config.service,
+			Logger:  config.log,
+		})
+		serve := func(path string, body string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(http.MethodPatch, path, bytes.NewBufferString(body)).WithContext(ctx)
+			r.Header.Set("Content-Type", "application/merge-patch+json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			return w
+		}
+		path := fmt.Sprintf("/v1/%s", record.ID)
+
+		// The immutable and unknown fields are rejected, whether they are in the patch or in the mask.
+		for _, request := range []struct{ path, body string }{
+			{path, fmt.Sprintf(`{"title": "updated", "user_id": %q}`, uuid.New())},
+			{path, `{"id": null}`},
+			{path, `{"name": "updated"}`},
+			{path + "?update_mask=created_at", `{"title": "updated"}`},
+		} {
+			if w := serve(request.path, request.body); w.Code != http.StatusBadRequest {
+				t.Errorf("expected status code %d for %s %s, got %d", http.StatusBadRequest, request.path, request.body, w.Code)
+			}
+		}
+
+		// The fields outside the mask are ignored.
+		if w := serve(path+"?update_mask=title", fmt.Sprintf(`{"title": "updated", "user_id": %q}`, uuid.New())); w.Code != http.StatusOK {
+			t.Logf("got response body = %v", w.Body.String())
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+
+		got, err := config.service.Get(ctx, record.ID, nil)
+		if err != nil {
+			t.Fatalf("failed to get the record: %v", err)
+		}
+		if got.Title != "updated" || got.UserID != claims.XUserID || got.Version != 2 {
+			t.Errorf("expected only the title to be updated, got %q of %s at version %d", got.Title, got.UserID, got.Version)
+		}
+	})
+
+	t.Run("request to batch create, get, update and delete records", func(t *testing.T) {
+
+		claims := middleware.JWTClaims{
+			XUserID: uuid.New(),
+		}
+		ctx := context.WithValue(context.Background(), middleware.XJWTClaims, claims)
+
+		// Prepare the router.
+		router := NewHTTPRouter(&HTTPRouterConfig{
+			Service: config.service,
+			Logger:  config.log,
+		})
+		serve := func(method, path, body string) *httptest.ResponseRecorder {
+			r := httptest.NewRequest(method, path, bytes.NewBufferString(body)).WithContext(ctx)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			return w
+		}
+		records := func(t *testing.T, w *httptest.ResponseRecorder) []*model.Record {
+			var response struct {
+				Data []*model.Record `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("failed to unmarshal the response body: %v", err)
+			}
+			return response.Data
+		}
+
+		// Create the records.
+		w := serve(http.MethodPost, "/v1:batchCreate", `{"requests": [{"title": "first"}, {"title": "second"}]}`)
+		if w.Code != http.StatusCreated {
+			t.Logf("got response body = %v", w.Body.String())
+			t.Fatalf("expected status code %d, got %d", http.StatusCreated, w.Code)
+		}
+		created := records(t, w)
+		if len(created) != 2 || created[0].Title != "first" || created[1].UserID != claims.XUserID {
+			t.Fatalf("expected the records to be created, got %+v", created)
+		}
+
+		// A failed update leaves every record unchanged.
+		body := fmt.Sprintf(`{"requests": [{"id": %q, "title": "updated"}, {"id": %q, "etag": "\"2\"", "title": "stale"}]}`, created[0].ID, created[1].ID)
+		if w := serve(http.MethodPost, "/v1:batchUpdate", body); w.Code != http.StatusPreconditionFailed {
+			t.Fatalf("expected status code %d, got %d", http.StatusPreconditionFailed, w.Code)
+		}
+		body = fmt.Sprintf(`{"requests": [{"id": %q, "title": "updated"}, {"id": %q, "etag": %q, "title": "updated"}]}`, created[0].ID, created[1].ID, created[1].ETag)
+		if w := serve(http.MethodPost, "/v1:batchUpdate", body); w.Code != http.StatusOK {
+			t.Logf("got response body = %v", w.Body.String())
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+
+		// Get the records, in the order of their IDs.
+		w = serve(http.MethodGet, fmt.Sprintf("/v1:batchGet?ids=%s,%s", created[1].ID, created[0].ID), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		got := records(t, w)
+		if len(got) != 2 || got[0].ID != created[1].ID || got[0].Title != "updated" || got[1].Version != 2 {
+			t.Errorf("expected the updated records in order, got %+v", got)
+		}
+
+		// Delete the records.
+		body = fmt.Sprintf(`{"ids": [%q, %q]}`, created[0].ID, created[1].ID)
+		if w := serve(http.MethodPost, "/v1:batchDelete", body); w.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Code)
+		}
+		if w := serve(http.MethodGet, fmt.Sprintf("/v1:batchGet?ids=%s", created[0].ID), ""); w.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
 }
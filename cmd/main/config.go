@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/mrinalwahal/service/config"
+)
+
+// configure inspects the configuration.
+func configure(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return fmt.Errorf("config: missing action, expected print")
+	}
+
+	cfg, err := config.Load(&config.LoadOptions{
+		Args: args[1:],
+	})
+	if err != nil {
+		return err
+	}
+	return cfg.Print(os.Stdout)
+}
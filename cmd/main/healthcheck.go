@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/mrinalwahal/service/config"
+)
+
+// healthcheck probes the readiness endpoint of the server running at the configured address.
+//
+// It is meant to be used as the health check of the container, which has no `curl` or `wget`.
+func healthcheck(args []string) error {
+	cfg, err := config.Load(&config.LoadOptions{
+		Args: args,
+	})
+	if err != nil {
+		return err
+	}
+
+	host, port, err := net.SplitHostPort(cfg.Server.Address)
+	if err != nil {
+		return fmt.Errorf("healthcheck: %w", err)
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	url := fmt.Sprintf("http://%s%s/readyz", net.JoinHostPort(host, port), basePath)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("healthcheck: %w", err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("healthcheck: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("healthcheck: unhealthy: %s", response.Status)
+	}
+	return nil
+}
@@ -1,155 +1,78 @@
 package main
 
 import (
+	"errors"
 	"fmt"
-	"log"
-	"log/slog"
-	"net/http"
+	"io/fs"
 	"os"
-	"strconv"
-	"time"
+	"strings"
 
 	"github.com/joho/godotenv"
-	"github.com/mrinalwahal/service/api/http/router"
-	"github.com/mrinalwahal/service/db"
-	"github.com/mrinalwahal/service/pkg/middleware"
-	"github.com/mrinalwahal/service/service"
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
-
-	slogGorm "github.com/orandin/slog-gorm"
+	"github.com/mrinalwahal/service/config"
+	"github.com/spf13/pflag"
 )
 
-func main() {
+const usage = `Usage: records <command> [arguments] [flags]
 
-	err := godotenv.Load(".env.example")
-	if err != nil {
-		log.Println("Error loading .env.development file")
-	}
+Commands:
+  serve                          Run the HTTP server. This is the default command.
+  migrate up|down|status|redo    Manage the database migrations.
+  config print                   Print the configuration with the secrets redacted.
+  healthcheck                    Probe the health of a running server. Exits with 1 if it is unhealthy.
 
-	//	Setup the logger.
-	level := slog.LevelInfo
-	addSource := false
-	DEBUG, err := strconv.ParseBool(os.Getenv("DEBUG"))
-	if err != nil {
-		panic(err)
-	}
-	if DEBUG {
-		level = slog.LevelDebug
-		addSource = true
-	}
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		AddSource: addSource,
-		Level:     level,
-	}))
-	logger = logger.
-		With("service", "record").
-		With("environment", os.Getenv("ENV"))
+Flags:
+`
 
-	//	Setup the gorm logger.
-	handler := logger.With("layer", "database").Handler()
-	gormLogger := slogGorm.New(
-		slogGorm.WithHandler(handler),                        // since v1.3.0
-		slogGorm.WithTraceAll(),                              // trace all messages
-		slogGorm.SetLogLevel(slogGorm.DefaultLogType, level), // set log level (default: slog.LevelInfo)
-	)
+func main() {
 
-	// Open a database connection.
-	conn, err := gorm.Open(postgres.Open("host=127.0.0.1 user=postgres password=postgres dbname=postgres port=5432 sslmode=disable TimeZone=Asia/Kolkata"), &gorm.Config{
-		Logger: gormLogger,
-	})
-	if err != nil {
-		panic(err)
+	// Load the environment variables from the `.env` file, if there is one.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "failed to load the .env file: %v\n", err)
+		os.Exit(1)
 	}
 
-	sqlDB, err := conn.DB()
-	if err != nil {
-		panic(err)
+	if err := run(os.Args[1:]); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
+}
 
-	// Configure connection pooling.
-	//
-	// Link: https://gorm.io/docs/generic_interface.html#Connection-Pool
-	sqlDB.SetConnMaxLifetime(time.Hour)
-	sqlDB.SetConnMaxIdleTime(time.Minute * 5)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetMaxIdleConns(10)
-
-	// Connect the database layer.
-	db := db.NewSQLDB(&db.SQLDBConfig{
-		DB: conn,
-	})
-
-	// GORM provides Prometheus plugin to collect DBStats or user-defined metrics
-	// https://gorm.io/docs/prometheus.html
-	// https://github.com/go-gorm/prometheus
-	//
-	// db.Use(prometheus.New(prometheus.Config{
-	// 	DBName:          "db1",                       // use `DBName` as metrics label
-	// 	RefreshInterval: 15,                          // Refresh metrics interval (default 15 seconds)
-	// 	PushAddr:        "prometheus pusher address", // push metrics if `PushAddr` configured
-	// 	StartServer:     true,                        // start http server to expose metrics
-	// 	HTTPServerPort:  8080,                        // configure http server port, default port 8080 (if you have configured multiple instances, only the first `HTTPServerPort` will be used to start server)
-	// 	MetricsCollector: []prometheus.MetricsCollector{
-	// 		&prometheus.MySQL{
-	// 			VariableNames: []string{"Threads_running"},
-	// 		},
-	// 	}, // user defined metrics
-	// }))
-
-	// Get the service layer.
-	service := service.NewService(&service.Config{
-		DB:     db,
-		Logger: logger,
-	})
-
-	//	Initialize the router.
-	router := router.NewHTTPRouter(&router.HTTPRouterConfig{
-		Service: service,
-		Logger:  logger,
-	})
-
-	// Prepare the middleware chain.
-	// The order of the middlewares is important.
-	// Recommended order: Request ID -> RateLimit -> CORS -> Logging -> Recover -> Auth -> Cache -> Compression
-	middlewareLogger := logger.With("protocol", "HTTP/1.0")
-	chain := middleware.Chain(
-		middleware.RequestID,
-		middleware.TraceID,
-		middleware.CorrelationID,
-		// TODO: middleware.RateLimit,
-		middleware.CORS(nil),
-		middleware.Recover(&middleware.RecoverConfig{
-			Logger: middlewareLogger,
-		}),
-		middleware.Logging(&middleware.LoggingConfig{
-			Logger: middlewareLogger,
-		}),
-		middleware.JWT(&middleware.JWTConfig{
-			Key: os.Getenv("JWT_SECRET"),
-			ExceptionalRoutes: []string{
-				"/login",
-				"/healthz",
-			},
-		}),
-	)
+// run runs the command selected by the supplied arguments.
+func run(args []string) error {
 
-	// Prepare the base router.
-	baseRouter := http.NewServeMux()
-	baseRouter.Handle("/records/", http.StripPrefix("/records", router))
+	// Without a command, or with only flags, serve.
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
+			printUsage()
+			return pflag.ErrHelp
+		}
+		return serve(args)
+	}
 
-	//	Configure and start the server.
-	server := http.Server{
-		Addr:     ":8080",
-		Handler:  chain(baseRouter),
-		ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	command, args := args[0], args[1:]
+	switch command {
+	case "serve":
+		return serve(args)
+	case "migrate":
+		return migrate(args)
+	case "config":
+		return configure(args)
+	case "healthcheck":
+		return healthcheck(args)
+	case "help":
+		printUsage()
+		return nil
 	}
 
-	fmt.Println("Server is running on port 8080")
-	server.ListenAndServe()
+	printUsage()
+	return fmt.Errorf("unknown command %q", command)
+}
 
-	// Close the database connection.
-	if err := sqlDB.Close(); err != nil {
-		panic(err)
-	}
+// printUsage prints the usage of the binary to the standard error.
+func printUsage() {
+	fmt.Fprint(os.Stderr, usage)
+	fmt.Fprint(os.Stderr, config.NewFlagSet("records").FlagUsages())
 }
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/mrinalwahal/service/config"
+	"github.com/mrinalwahal/service/db"
+)
+
+// migrate manages the database migrations embedded in the binary.
+func migrate(args []string) error {
+	if len(args) == 0 || args[0] == "" || args[0][0] == '-' {
+		return fmt.Errorf("migrate: missing action, expected one of up, down, status or redo")
+	}
+	action, args := args[0], args[1:]
+
+	// Load the configuration.
+	cfg, err := config.Load(&config.LoadOptions{
+		Args: args,
+	})
+	if err != nil {
+		return err
+	}
+
+	logger, flush := newLogger(cfg)
+	defer flush()
+
+	conn, err := openDB(cfg, logger)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	ctx := context.Background()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	// The SQLite engines have no versioned migrations.
+	if cfg.Database.Engine != db.EnginePostgres {
+		switch action {
+		case "up":
+			if err := db.AutoMigrate(ctx, conn); err != nil {
+				return fmt.Errorf("migrate: %w", err)
+			}
+			fmt.Println("The schema was created from the models.")
+			return nil
+		case "status", "down", "redo":
+			return fmt.Errorf("migrate: %s: the %s engine has no versioned migrations, its schema is created from the models", action, cfg.Database.Engine)
+		}
+		return fmt.Errorf("migrate: unknown action %q", action)
+	}
+
+	migrator, err := db.NewMigrator(&db.MigratorConfig{
+		DB:   conn,
+		Lock: true,
+	})
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+
+	switch action {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("applied %s\n", migration.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("There are no pending migrations.")
+		}
+		return nil
+
+	case "down":
+		migration, err := migrator.Down(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+		fmt.Printf("rolled back %s\n", migration.Name)
+		return nil
+
+	case "redo":
+		migration, err := migrator.Redo(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+		fmt.Printf("redid %s\n", migration.Name)
+		return nil
+
+	case "status":
+		migrations, err := migrator.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("migrate: %w", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "APPLIED AT\tMIGRATION")
+		for _, migration := range migrations {
+			at := "pending"
+			if migration.Applied {
+				at = migration.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%s\t%s\n", at, migration.Name)
+		}
+		return w.Flush()
+	}
+
+	return fmt.Errorf("migrate: unknown action %q", action)
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/mrinalwahal/service/api/http/router"
+	"github.com/mrinalwahal/service/config"
+	"github.com/mrinalwahal/service/db"
+	"github.com/mrinalwahal/service/pkg/health"
+	"github.com/mrinalwahal/service/pkg/lifecycle"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"github.com/mrinalwahal/service/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// basePath is the path the HTTP router is mounted on.
+const basePath = "/records"
+
+// serve runs the HTTP server until it receives a shutdown signal.
+func serve(args []string) error {
+
+	// Load the configuration.
+	cfg, err := config.Load(&config.LoadOptions{
+		Args: args,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The records which have not been pushed yet are flushed once the server has stopped.
+	logger, flushLogs := newLogger(cfg)
+	defer flushLogs()
+
+	// Install the tracer provider before anything creates a tracer.
+	tracerProvider, err := newTracerProvider(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+
+	// Propagate the IDs of the incoming requests on the outbound requests of the default client.
+	http.DefaultClient.Transport = middleware.NewTransport(http.DefaultTransport)
+
+	// Open a database connection.
+	//
+	// The connection is verified by the "database" component on startup.
+	conn, err := openDB(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return err
+	}
+
+	// Open the connections to the read replicas, if any.
+	replicas, err := openReplicas(cfg, logger)
+	if err != nil {
+		return err
+	}
+	replicaDBs := make([]*sql.DB, len(replicas))
+	for i, replica := range replicas {
+		if replicaDBs[i], err = replica.DB(); err != nil {
+			return err
+		}
+	}
+
+	// Prepare the metrics registry, with the runtime metrics of the process and the stats of the connection pools.
+	metrics := prometheus.NewRegistry()
+	metrics.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(sqlDB, cfg.Database.Engine),
+	)
+	for i, replicaDB := range replicaDBs {
+		metrics.MustRegister(collectors.NewDBStatsCollector(replicaDB, fmt.Sprintf("%s-replica-%d", cfg.Database.Engine, i)))
+	}
+
+	// Connect the database layer.
+	database := db.WithTracing(&db.TracingConfig{
+		DB: db.NewSQLDB(&db.SQLDBConfig{
+			DB:               conn,
+			PageTokenKey:     []byte(cfg.Database.PageTokenKey),
+			InsertBatchSize:  cfg.Database.InsertBatchSize,
+			RowLevelSecurity: cfg.Database.RowLevelSecurity,
+			Replicas:         replicas,
+			LoadBalancing:    cfg.Database.Replicas.LoadBalancing,
+			ReadYourWrites:   cfg.Database.Replicas.ReadYourWrites,
+			Logger:           logger,
+			Registerer:       metrics,
+
+			AllowPrivateWebhooks: cfg.Webhooks.AllowPrivate,
+		}),
+	})
+
+	// Prepare the hub of the events of the records, to which the dispatcher of the outbox publishes them.
+	// With Postgres, the events are broadcast to the hubs of every replica.
+	var broadcaster service.Broadcaster
+	if cfg.Database.Engine == db.EnginePostgres {
+		broadcaster = db.NewNotifier(&db.NotifierConfig{
+			DB: conn,
+		})
+	}
+	hub := service.NewHub(&service.HubConfig{
+		Broadcaster: broadcaster,
+		History:     cfg.Server.WatchHistory,
+		Logger:      logger,
+	})
+
+	// Get the service layer.
+	svc := service.WithMetrics(&service.MetricsConfig{
+		Service: service.WithTracing(&service.TracingConfig{
+			Service: service.NewService(&service.Config{
+				DB:           database,
+				Logger:       logger,
+				MaxBatchSize: cfg.Database.MaxBatchSize,
+			}),
+		}),
+		Registerer: metrics,
+	})
+
+	// Prepare the lifecycle manager.
+	manager := lifecycle.NewManager(&lifecycle.ManagerConfig{
+		Logger:          logger,
+		ShutdownTimeout: cfg.Server.ShutdownTimeout,
+		ShutdownDelay:   cfg.Server.ShutdownDelay,
+	})
+
+	// migrations is the state of the schema, set by the "migrations" component on startup.
+	var migrations atomic.Pointer[string]
+
+	// Register the checks of the dependencies reported by the readiness probe.
+	checks := health.NewRegistry()
+	checks.Register(
+		health.Check{
+			Name:     "database",
+			Critical: true,
+			Func: func(ctx context.Context) (string, error) {
+				return "", sqlDB.PingContext(ctx)
+			},
+		},
+		health.Check{
+			Name:     "migrations",
+			Critical: true,
+			Func: func(ctx context.Context) (string, error) {
+				state := migrations.Load()
+				if state == nil {
+					return "", errors.New("schema has not been checked yet")
+				}
+				return *state, nil
+			},
+		},
+	)
+
+	// A replica which is down only degrades the service: the writes, and the reads of the primary, keep working.
+	for i, replicaDB := range replicaDBs {
+		checks.Register(health.Check{
+			Name: fmt.Sprintf("database-replica-%d", i),
+			Func: func(ctx context.Context) (string, error) {
+				return "", replicaDB.PingContext(ctx)
+			},
+		})
+	}
+
+	// The service has no cache client yet, so only check that the cache is reachable.
+	if cfg.Cache.Engine != "" {
+		checks.Register(health.Check{
+			Name: "cache",
+			Func: health.Dial("tcp", net.JoinHostPort(cfg.Cache.Host, strconv.Itoa(cfg.Cache.Port))),
+		})
+	}
+
+	//	Initialize the router.
+	router := router.NewHTTPRouter(&router.HTTPRouterConfig{
+		Service:      svc,
+		Logger:       logger,
+		Ready:        manager.Ready,
+		Health:       checks,
+		Hub:          hub,
+		Heartbeat:    cfg.Server.WatchHeartbeat,
+		MaxBatchSize: cfg.Database.MaxBatchSize,
+	})
+
+	// Prepare the base router.
+	baseRouter := http.NewServeMux()
+	router.Mount(baseRouter, basePath)
+
+	// Serve the metrics on the admin server, or on the main one if there is no admin server.
+	metricsHandler := promhttp.HandlerFor(metrics, promhttp.HandlerOpts{
+		ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	})
+	adminRouter := baseRouter
+	if cfg.Server.AdminAddress != "" {
+		adminRouter = http.NewServeMux()
+	}
+	adminRouter.Handle("GET /metrics", metricsHandler)
+
+	// Prepare the middleware chain.
+	// The order of the middlewares is important.
+	// Recommended order: Request ID -> RateLimit -> CORS -> Logging -> Recover -> Auth -> Cache -> Compression
+	middlewareLogger := logger.With("protocol", "HTTP/1.0")
+	chain := middleware.Chain(
+		middleware.Metrics(&middleware.MetricsConfig{
+			Registerer: metrics,
+		}),
+		middleware.RequestID(nil),
+		middleware.TraceID(nil),
+		middleware.CorrelationID(nil),
+		// TODO: middleware.RateLimit,
+		middleware.CORS(nil),
+		middleware.Recover(&middleware.RecoverConfig{
+			Logger: middlewareLogger,
+		}),
+		middleware.Logging(&middleware.LoggingConfig{
+			Logger: middlewareLogger,
+		}),
+		middleware.JWT(&middleware.JWTConfig{
+			Algorithm: cfg.Authentication.Key.Algorithm,
+			Key:       cfg.Authentication.Key.Key,
+			ExceptionalRoutes: []string{
+				"/login",
+				"/metrics",
+			},
+			Skip: router.IsProbe,
+		}),
+	)
+
+	//	Configure and start the server.
+	server := http.Server{
+		Addr:         cfg.Server.Address,
+		Handler:      chain(baseRouter),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+		ErrorLog:     slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+
+	// End the watch streams as soon as the server shuts down, since they would never finish draining otherwise.
+	server.RegisterOnShutdown(hub.Close)
+
+	// Register the components in the order they have to be started.
+	// They are stopped in the reverse order.
+	manager.Append(
+		lifecycle.Hook{
+			Name: "tracing",
+			OnStop: func(ctx context.Context) error {
+
+				// Flush the spans which have not been exported yet.
+				return tracerProvider.Shutdown(ctx)
+			},
+		},
+		lifecycle.Hook{
+			Name: "database",
+			OnStart: func(ctx context.Context) error {
+				return sqlDB.PingContext(ctx)
+			},
+			OnStop: func(ctx context.Context) error {
+				errs := []error{sqlDB.Close()}
+				for _, replicaDB := range replicaDBs {
+					errs = append(errs, replicaDB.Close())
+				}
+				return errors.Join(errs...)
+			},
+		},
+	)
+
+	manager.Append(lifecycle.Hook{
+		Name: "migrations",
+		OnStart: func(ctx context.Context) error {
+
+			// The SQLite engines have no migrations, so their schema is created from the models.
+			if cfg.Database.Engine != db.EnginePostgres {
+				if err := db.AutoMigrate(ctx, conn); err != nil {
+					return err
+				}
+				state := "auto-migrated"
+				migrations.Store(&state)
+				return nil
+			}
+
+			migrator, err := db.NewMigrator(&db.MigratorConfig{
+				DB:   conn,
+				Lock: true,
+			})
+			if err != nil {
+				return err
+			}
+			if cfg.Database.MigrateOnStart {
+				applied, err := migrator.Up(ctx)
+				if err != nil {
+					return err
+				}
+				for _, migration := range applied {
+					logger.Info("applied migration", "version", migration.Version, "name", migration.Name)
+				}
+			}
+
+			// Refuse to start against a schema from a newer release.
+			state, err := migrator.State(ctx)
+			if err != nil {
+				return err
+			}
+			if state.Pending > 0 {
+				logger.Warn("database has pending migrations", "version", state.Version, "pending", state.Pending)
+			}
+
+			// Without the identity of the callers, the row level security would hide every record.
+			enforced, err := db.RowLevelSecurity(ctx, conn)
+			if err != nil {
+				return err
+			}
+			if enforced && !cfg.Database.RowLevelSecurity {
+				return errors.New("the database enforces the row level security of the records: set database.row_level_security")
+			}
+			if !enforced && cfg.Database.RowLevelSecurity {
+				logger.Warn("database does not enforce the row level security of the records yet")
+			}
+			description := state.String()
+			migrations.Store(&description)
+			return nil
+		},
+	})
+
+	// Purge the records which have been soft-deleted for longer than the retention period.
+	if cfg.Database.Retention > 0 {
+		purger := service.NewPurger(&service.PurgerConfig{
+			Service:   svc,
+			Retention: cfg.Database.Retention,
+			Interval:  cfg.Database.PurgeInterval,
+			Logger:    logger,
+		})
+		manager.Append(lifecycle.Hook{
+			Name: "purge",
+			Run:  purger.Run,
+		})
+	}
+
+	// Publish the events of the records written to the outbox, schedule their deliveries to the webhooks, and
+	// stream them to the watchers, last, so they only see the events the other publishers accepted.
+	var publishers db.Publishers
+	if cfg.Database.Outbox.Publisher != "none" {
+		publishers = append(publishers, db.NewLogPublisher(logger.With("publisher", cfg.Database.Outbox.Publisher)))
+	}
+	if cfg.Webhooks.Enabled {
+		publishers = append(publishers, db.NewWebhookPublisher(conn))
+	}
+	publishers = append(publishers, hub)
+	dispatcher := db.NewDispatcher(&db.DispatcherConfig{
+		DB:          conn,
+		Publisher:   publishers,
+		Interval:    cfg.Database.Outbox.Interval,
+		BatchSize:   cfg.Database.Outbox.BatchSize,
+		MinBackoff:  cfg.Database.Outbox.MinBackoff,
+		MaxBackoff:  cfg.Database.Outbox.MaxBackoff,
+		MaxAttempts: cfg.Database.Outbox.MaxAttempts,
+		Logger:      logger,
+
+		RowLevelSecurity: cfg.Database.RowLevelSecurity,
+	})
+	manager.Append(lifecycle.Hook{
+		Name: "dispatch",
+		Run:  dispatcher.Run,
+	})
+
+	// Send the deliveries of the events to the webhooks.
+	if cfg.Webhooks.Enabled {
+		deliverer := db.NewDeliverer(&db.DelivererConfig{
+			DB:          conn,
+			Interval:    cfg.Webhooks.Interval,
+			BatchSize:   cfg.Webhooks.BatchSize,
+			Timeout:     cfg.Webhooks.Timeout,
+			MinBackoff:  cfg.Webhooks.MinBackoff,
+			MaxBackoff:  cfg.Webhooks.MaxBackoff,
+			MaxAttempts: cfg.Webhooks.MaxAttempts,
+			MaxFailures: cfg.Webhooks.MaxFailures,
+			Logger:      logger,
+
+			RowLevelSecurity: cfg.Database.RowLevelSecurity,
+
+			AllowPrivate: cfg.Webhooks.AllowPrivate,
+		})
+		manager.Append(lifecycle.Hook{
+			Name: "deliver",
+			Run:  deliverer.Run,
+		})
+	}
+
+	// Receive the events broadcast by every replica.
+	manager.Append(lifecycle.Hook{
+		Name: "hub",
+		Run:  hub.Run,
+	})
+
+	manager.Append(lifecycle.HTTPServer("http", &server))
+
+	if cfg.Server.AdminAddress != "" {
+		manager.Append(lifecycle.HTTPServer("admin", &http.Server{
+			Addr:        cfg.Server.AdminAddress,
+			Handler:     adminRouter,
+			ReadTimeout: cfg.Server.ReadTimeout,
+			IdleTimeout: cfg.Server.IdleTimeout,
+			ErrorLog:    slog.NewLogLogger(logger.Handler(), slog.LevelError),
+		}))
+	}
+
+	return manager.Run(context.Background())
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/mrinalwahal/service/config"
+	"github.com/mrinalwahal/service/db"
+	"github.com/mrinalwahal/service/pkg/loki"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	slogGorm "github.com/orandin/slog-gorm"
+)
+
+// newLogger returns the logger configured for the service and installs it as the default logger, along with the
+// function which flushes its pending records on shutdown.
+//
+// The records are written as JSON to stdout, or pushed to Loki with the "loki" engine. Every record logged with a
+// context is stamped with the request metadata of the context, e.g. its request ID.
+func newLogger(cfg *config.Config) (*slog.Logger, func() error) {
+	var output io.Writer = os.Stdout
+	flush := func() error { return nil }
+	if cfg.Logs.Engine == "loki" {
+		writer := loki.NewWriter(&loki.Config{
+			Address: cfg.Logs.Address,
+			Labels: map[string]string{
+				"service":     "record",
+				"environment": cfg.Environment.Environment,
+			},
+		})
+		output, flush = writer, writer.Close
+	}
+
+	logger := slog.New(middleware.NewContextHandler(slog.NewJSONHandler(output, &slog.HandlerOptions{
+		AddSource: cfg.Environment.Debug,
+		Level:     cfg.LogLevel(),
+	}))).
+		With("service", "record").
+		With("environment", cfg.Environment.Environment)
+	slog.SetDefault(logger)
+	return logger, flush
+}
+
+// newTracerProvider returns the tracer provider configured for the service and installs it globally,
+// along with the W3C trace context and baggage propagators.
+//
+// The spans are exported over OTLP/HTTP to the meter endpoint when the "otlp" exporter is configured.
+// Otherwise they are still created, so the trace IDs are propagated, but they are not exported.
+func newTracerProvider(ctx context.Context, cfg *config.Config) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName("record"),
+		semconv.DeploymentEnvironment(cfg.Environment.Environment),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+	}
+	if cfg.Meter.Exporter == "otlp" {
+		exporter, err := otlptracehttp.New(ctx, exporterOptions(cfg.Meter.Endpoint)...)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+	return provider, nil
+}
+
+// exporterOptions returns the options of the OTLP/HTTP exporter of the spans to the endpoint.
+//
+// The endpoint is either a `host:port`, or a URL whose scheme picks the transport. The spans are only sent in
+// plain text to the endpoints without a scheme or with the `http` scheme, never to the `https` ones.
+func exporterOptions(endpoint string) []otlptracehttp.Option {
+	scheme, _, found := strings.Cut(endpoint, "://")
+	if !found {
+		return []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint), otlptracehttp.WithInsecure()}
+	}
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(endpoint)}
+	if strings.EqualFold(scheme, "http") {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	return options
+}
+
+// openDB opens a connection to the configured database.
+//
+// The connection is not verified.
+func openDB(cfg *config.Config, logger *slog.Logger) (*gorm.DB, error) {
+	return db.Open(&db.OpenConfig{
+		Engine:          cfg.Database.Engine,
+		DSN:             cfg.Database.DSN,
+		MaxOpenConns:    cfg.Database.Pool.MaxOpenConns,
+		MaxIdleConns:    cfg.Database.Pool.MaxIdleConns,
+		ConnMaxLifetime: cfg.Database.Pool.ConnMaxLifetime,
+		ConnMaxIdleTime: cfg.Database.Pool.ConnMaxIdleTime,
+		Logger:          newGormLogger(cfg, logger),
+	})
+}
+
+// openReplicas opens a connection to every configured read replica of the database, with the connection pool
+// settings of the primary.
+//
+// The connections are not verified.
+func openReplicas(cfg *config.Config, logger *slog.Logger) ([]*gorm.DB, error) {
+	var replicas []*gorm.DB
+	for i, dsn := range cfg.Database.Replicas.DSNs {
+		conn, err := db.Open(&db.OpenConfig{
+			Engine:          cfg.Database.Engine,
+			DSN:             dsn,
+			MaxOpenConns:    cfg.Database.Pool.MaxOpenConns,
+			MaxIdleConns:    cfg.Database.Pool.MaxIdleConns,
+			ConnMaxLifetime: cfg.Database.Pool.ConnMaxLifetime,
+			ConnMaxIdleTime: cfg.Database.Pool.ConnMaxIdleTime,
+			Logger:          newGormLogger(cfg, logger.With("replica", i)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		replicas = append(replicas, conn)
+	}
+	return replicas, nil
+}
+
+// newGormLogger returns the GORM logger which writes to the `log/slog` logger.
+func newGormLogger(cfg *config.Config, logger *slog.Logger) gormlogger.Interface {
+	handler := logger.With("layer", "database").Handler()
+	return slogGorm.New(
+		slogGorm.WithHandler(handler),                                 // since v1.3.0
+		slogGorm.WithTraceAll(),                                       // trace all messages
+		slogGorm.SetLogLevel(slogGorm.DefaultLogType, cfg.LogLevel()), // set log level (default: slog.LevelInfo)
+	)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mrinalwahal/service/config"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Test_exporterOptions tests the transport of the spans exported to the endpoints.
+func Test_exporterOptions(t *testing.T) {
+
+	// export exports a span to the endpoint with the options of the endpoint, and returns whether the collector
+	// received it.
+	export := func(t *testing.T, server *httptest.Server, endpoint string) bool {
+		var received atomic.Int32
+		server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received.Add(1)
+			w.WriteHeader(http.StatusOK)
+		})
+
+		options := append(exporterOptions(endpoint),
+			otlptracehttp.WithRetry(otlptracehttp.RetryConfig{Enabled: false}),
+		)
+		if server.TLS != nil {
+			options = append(options, otlptracehttp.WithTLSClientConfig(&tls.Config{
+				RootCAs: server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+			}))
+		}
+		exporter, err := otlptracehttp.New(context.Background(), options...)
+		if err != nil {
+			t.Fatalf("failed to create the exporter: %v", err)
+		}
+
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+		_, span := provider.Tracer("test").Start(context.Background(), "span")
+		span.End()
+		if err := provider.Shutdown(context.Background()); err != nil {
+			t.Logf("failed to shut the provider down: %v", err)
+		}
+		return received.Load() > 0
+	}
+
+	t.Run("send spans in plain text to an http endpoint", func(t *testing.T) {
+		server := httptest.NewServer(nil)
+		defer server.Close()
+
+		if !export(t, server, server.URL) {
+			t.Fatal("the collector did not receive the spans")
+		}
+	})
+
+	t.Run("send spans in plain text to an endpoint without scheme", func(t *testing.T) {
+		server := httptest.NewServer(nil)
+		defer server.Close()
+
+		if !export(t, server, strings.TrimPrefix(server.URL, "http://")) {
+			t.Fatal("the collector did not receive the spans")
+		}
+	})
+
+	t.Run("send spans over tls to an https endpoint", func(t *testing.T) {
+		server := httptest.NewTLSServer(nil)
+		defer server.Close()
+
+		// The collector only receives the spans sent over TLS.
+		if !export(t, server, server.URL) {
+			t.Fatal("the collector did not receive the spans over tls")
+		}
+	})
+}
+
+// Test_newLogger tests the engines of the logs.
+func Test_newLogger(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	t.Run("push the records to loki", func(t *testing.T) {
+
+		var body atomic.Value
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/loki/api/v1/push" {
+				payload, _ := io.ReadAll(r.Body)
+				body.Store(string(payload))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		logger, flush := newLogger(&config.Config{
+			Environment: config.Environment{Environment: "qa"},
+			Logs:        config.Logs{Engine: "loki", Address: strings.TrimPrefix(server.URL, "http://"), Level: "info"},
+		})
+		logger.Info("pushed to loki")
+		if err := flush(); err != nil {
+			t.Fatalf("failed to flush the logs: %v", err)
+		}
+
+		pushed, _ := body.Load().(string)
+		if !strings.Contains(pushed, "pushed to loki") || !strings.Contains(pushed, `"environment":"qa"`) {
+			t.Errorf("expected the record to be pushed with the labels of the service, got %q", pushed)
+		}
+	})
+}
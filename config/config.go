@@ -1,58 +1,500 @@
-package main
+package config
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"time"
+)
 
-	"github.com/spf13/viper"
+// Supported environments.
+const (
+	EnvironmentDev     = "dev"
+	EnvironmentStaging = "staging"
+	EnvironmentProd    = "prod"
 )
 
-// Base configuration.
-type config struct {
-	Environment    *environment    `mapstructure:"environment"`
-	Database       *database       `mapstructure:"database"`
-	Authentication *authentication `mapstructure:"authentication"`
+// Config is the base configuration of the service.
+type Config struct {
+	Environment    Environment    `mapstructure:"environment"`
+	Server         Server         `mapstructure:"server"`
+	Database       Database       `mapstructure:"database"`
+	Authentication Authentication `mapstructure:"authentication"`
+	Cache          Cache          `mapstructure:"cache"`
+	Logs           Logs           `mapstructure:"logs"`
+	Meter          Meter          `mapstructure:"meter"`
+	Webhooks       Webhooks       `mapstructure:"webhooks"`
 }
 
 // Environment configuration.
-type environment struct {
+type Environment struct {
+
+	// Environment is the name of the environment the service is running in.
+	// It also selects the `config.<environment>.toml` overrides file.
+	//
+	// Example: "dev", "staging" or "prod"
 	Environment string `mapstructure:"environment"`
-	Debug       bool   `mapstructure:"debug"`
+
+	// Debug enables debug logs and source locations in log lines.
+	Debug bool `mapstructure:"debug"`
+}
+
+// Server configuration.
+type Server struct {
+
+	// Address is the TCP address the HTTP server listens on.
+	//
+	// Example: ":8080"
+	Address string `mapstructure:"address"`
+
+	// AdminAddress is the TCP address the admin HTTP server, which serves `/metrics`, listens on.
+	// When it is empty, `/metrics` is served by the main HTTP server instead.
+	//
+	// Example: ":2112"
+	AdminAddress string `mapstructure:"admin_address"`
+
+	// ReadTimeout is the maximum duration for reading the entire request.
+	ReadTimeout time.Duration `mapstructure:"read_timeout"`
+
+	// WriteTimeout is the maximum duration before timing out writes of the response.
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+
+	// IdleTimeout is the maximum amount of time to wait for the next request on keep-alive connections.
+	IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+
+	// ShutdownTimeout is the maximum duration to drain in-flight requests and close the resources in, on shutdown.
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+
+	// ShutdownDelay is the duration to keep serving requests, with a failing readiness, before draining starts.
+	ShutdownDelay time.Duration `mapstructure:"shutdown_delay"`
+
+	// WatchHeartbeat is the interval of the heartbeats of the `:watch` streams, which keep the idle streams open.
+	WatchHeartbeat time.Duration `mapstructure:"watch_heartbeat"`
+
+	// WatchHistory is the number of the most recent events replayed to the `:watch` streams which resume.
+	WatchHistory int `mapstructure:"watch_history"`
 }
 
 // Database configuration.
-type database struct {
+type Database struct {
+
+	// Engine is the database engine.
+	//
+	// Example: "postgres", "sqlite-file" or "sqlite-memory"
 	Engine string `mapstructure:"engine"`
-	DSN    string `mapstructure:"dsn"` // Data Source Name
+
+	// DSN is the Data Source Name.
+	// It is the connection string for "postgres", the file path for "sqlite-file" and it is ignored by "sqlite-memory".
+	DSN string `mapstructure:"dsn" redact:"dsn"`
+
+	// MigrateOnStart applies the pending migrations when the server starts.
+	// With "postgres" the migrations are applied under an advisory lock, so every replica can enable it.
+	MigrateOnStart bool `mapstructure:"migrate_on_start"`
+
+	// RowLevelSecurity sets the identity of the caller in every transaction, for the row level security of the
+	// records, which the migrations enable with "postgres". It is only supported by the "postgres" engine, and the
+	// server refuses to start if the database enforces the row level security without it.
+	RowLevelSecurity bool `mapstructure:"row_level_security"`
+
+	// PageTokenKey signs the page tokens of the listings.
+	// Every replica must share the same key. When it is empty, a random key is generated on startup,
+	// and the page tokens are only valid on the replica which issued them, until it restarts.
+	PageTokenKey string `mapstructure:"page_token_key" redact:"true"`
+
+	// Retention is how long the soft-deleted records are kept before they are purged permanently.
+	// When it is zero, the soft-deleted records are never purged.
+	//
+	// Example: "720h"
+	Retention time.Duration `mapstructure:"retention"`
+
+	// PurgeInterval is the time between two purges of the soft-deleted records older than the retention.
+	PurgeInterval time.Duration `mapstructure:"purge_interval"`
+
+	// MaxBatchSize is the maximum number of records of the batch operations, e.g. `:batchCreate`.
+	MaxBatchSize int `mapstructure:"max_batch_size"`
+
+	// InsertBatchSize is the maximum number of records inserted per statement by `:batchCreate`.
+	InsertBatchSize int `mapstructure:"insert_batch_size"`
+
+	Pool Pool `mapstructure:"pool"`
+
+	Replicas Replicas `mapstructure:"replicas"`
+
+	Outbox Outbox `mapstructure:"outbox"`
+}
+
+// Pool is the connection pool configuration of the database.
+//
+// Link: https://gorm.io/docs/generic_interface.html#Connection-Pool
+type Pool struct {
+	MaxOpenConns    int           `mapstructure:"max_open_conns"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time"`
+}
+
+// Replicas is the configuration of the read replicas of the database.
+type Replicas struct {
+
+	// DSNs are the connection strings of the read replicas of "postgres". The reads of the records, by id and
+	// by listing, are balanced between them, with the same connection pool settings as the primary.
+	//
+	// Example: ["host=replica-1 user=postgres password=postgres dbname=postgres port=5432 sslmode=disable"]
+	DSNs []string `mapstructure:"dsns" redact:"dsn"`
+
+	// LoadBalancing is the policy which picks the replica of every read.
+	//
+	// Example: "round-robin", "random" or "least-conns"
+	LoadBalancing string `mapstructure:"load_balancing"`
+
+	// ReadYourWrites is how long the reads of a user go to the primary after they write, so they see their own
+	// changes despite the replication lag. The writes are only tracked by the replica of the service which made
+	// them. When it is zero, the reads go to the replicas right after a write.
+	ReadYourWrites time.Duration `mapstructure:"read_your_writes"`
+}
+
+// Outbox is the configuration of the dispatcher of the events of the records, written to the outbox table.
+type Outbox struct {
+
+	// Publisher publishes the events: "log" logs them, and "none" publishes them nowhere. Either way, the
+	// dispatcher delivers the events to the webhooks, if they are enabled, and to the watch streams.
+	Publisher string `mapstructure:"publisher"`
+
+	// Interval is the time between two polls of the outbox, when it has no more events to publish.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// BatchSize is the maximum number of events claimed per poll.
+	BatchSize int `mapstructure:"batch_size"`
+
+	// MinBackoff is the delay before the first retry of an event which failed to be published.
+	// It doubles with every failed attempt, up to MaxBackoff.
+	MinBackoff time.Duration `mapstructure:"min_backoff"`
+
+	// MaxBackoff is the maximum delay between two attempts to publish an event.
+	MaxBackoff time.Duration `mapstructure:"max_backoff"`
+
+	// MaxAttempts is the number of attempts after which an event which fails to be published is abandoned.
+	MaxAttempts int `mapstructure:"max_attempts"`
+}
+
+// Webhooks is the configuration of the deliveries of the events of the records to the webhooks of their owners.
+type Webhooks struct {
+
+	// Enabled schedules the deliveries of the events dispatched from the outbox, and sends them.
+	Enabled bool `mapstructure:"enabled"`
+
+	// Timeout is the maximum duration of a delivery attempt, after which it fails.
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Interval is the time between two polls of the deliveries, when there are no more deliveries to send.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// BatchSize is the maximum number of deliveries claimed per poll, which are sent concurrently.
+	BatchSize int `mapstructure:"batch_size"`
+
+	// MinBackoff is the delay before the first retry of a delivery which failed.
+	// It doubles with every failed attempt, up to MaxBackoff.
+	MinBackoff time.Duration `mapstructure:"min_backoff"`
+
+	// MaxBackoff is the maximum delay between two attempts of a delivery.
+	MaxBackoff time.Duration `mapstructure:"max_backoff"`
+
+	// MaxAttempts is the number of attempts after which a delivery fails for good.
+	MaxAttempts int `mapstructure:"max_attempts"`
+
+	// MaxFailures is the number of consecutive failed attempts after which a webhook is disabled.
+	MaxFailures int `mapstructure:"max_failures"`
+
+	// AllowPrivate allows the webhooks to target "localhost" and the loopback, private, link-local and unspecified
+	// addresses. It should only be enabled in tests and development.
+	AllowPrivate bool `mapstructure:"allow_private"`
 }
 
 // Authentication configuration.
-type authentication struct {
+type Authentication struct {
 	Method string `mapstructure:"method"`
-	Key    struct {
-		Algorithm string `mapstructure:"algorithm"`
-		Key       string `mapstructure:"key"`
-	} `mapstructure:"key"`
+	Key    Key    `mapstructure:"key"`
+}
+
+// Key is the key used to validate the authentication tokens.
+type Key struct {
+	Algorithm string `mapstructure:"algorithm"`
+	Key       string `mapstructure:"key" redact:"true"`
+}
+
+// Cache configuration.
+type Cache struct {
+	Engine   string `mapstructure:"engine"`
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Password string `mapstructure:"password" redact:"true"`
 }
 
-var c config
+// Logs configuration.
+type Logs struct {
 
-func Get() *config {
-	return &c
+	// Engine is where the logs are written: "stdout", or "loki" to push them to Loki.
+	Engine string `mapstructure:"engine"`
+
+	// Address of Loki, a `host:port` or a URL. It is required by the "loki" engine.
+	Address string `mapstructure:"address"`
+
+	Level string `mapstructure:"level"`
+}
+
+// Meter configuration.
+type Meter struct {
+	Exporter string `mapstructure:"exporter"`
+	Endpoint string `mapstructure:"endpoint"`
 }
 
-func init() {
-	viper.SetConfigName("config")
-	viper.AddConfigPath(".")
-	viper.AutomaticEnv()
-	if err := viper.ReadInConfig(); err != nil {
-		panic(fmt.Sprintf("unable to read config file, %v", err))
+// LogLevel returns the `log/slog` level of the configured logs.
+//
+// Debug mode always lowers the level to `slog.LevelDebug`.
+func (c *Config) LogLevel() slog.Level {
+	if c.Environment.Debug {
+		return slog.LevelDebug
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Logs.Level)); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// validate validates the entire configuration and reports all the errors together.
+func (c *Config) validate() error {
+	return errors.Join(
+		c.Environment.validate(),
+		c.Server.validate(),
+		c.Database.validate(),
+		c.Authentication.validate(),
+		c.Cache.validate(),
+		c.Logs.validate(),
+		c.Meter.validate(),
+		c.Webhooks.validate(),
+	)
+}
+
+func (e *Environment) validate() error {
+	switch e.Environment {
+	case EnvironmentDev, EnvironmentStaging, EnvironmentProd:
+		return nil
+	}
+	return invalid("environment.environment", "must be one of dev, staging or prod, got %q", e.Environment)
+}
+
+func (s *Server) validate() error {
+	var errs []error
+	if s.Address == "" {
+		errs = append(errs, invalid("server.address", "is required"))
+	}
+	if s.AdminAddress != "" && s.AdminAddress == s.Address {
+		errs = append(errs, invalid("server.admin_address", "must be different from server.address"))
+	}
+	if s.ReadTimeout < 0 {
+		errs = append(errs, invalid("server.read_timeout", "must not be negative"))
+	}
+	if s.WriteTimeout < 0 {
+		errs = append(errs, invalid("server.write_timeout", "must not be negative"))
+	}
+	if s.IdleTimeout < 0 {
+		errs = append(errs, invalid("server.idle_timeout", "must not be negative"))
+	}
+	if s.ShutdownTimeout <= 0 {
+		errs = append(errs, invalid("server.shutdown_timeout", "must be positive"))
+	}
+	if s.ShutdownDelay < 0 {
+		errs = append(errs, invalid("server.shutdown_delay", "must not be negative"))
+	}
+	if s.ShutdownDelay >= s.ShutdownTimeout && s.ShutdownTimeout > 0 {
+		errs = append(errs, invalid("server.shutdown_delay", "must be shorter than shutdown_timeout"))
+	}
+	if s.WatchHeartbeat <= 0 {
+		errs = append(errs, invalid("server.watch_heartbeat", "must be positive"))
+	}
+	if s.WatchHistory <= 0 {
+		errs = append(errs, invalid("server.watch_history", "must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+func (d *Database) validate() error {
+	var errs []error
+	switch d.Engine {
+	case "postgres":
+		if d.DSN == "" {
+			errs = append(errs, invalid("database.dsn", "is required for the %s engine", d.Engine))
+		}
+	case "sqlite-file", "sqlite-memory":
+	default:
+		errs = append(errs, invalid("database.engine", "unsupported engine %q", d.Engine))
+	}
+	if d.Retention < 0 {
+		errs = append(errs, invalid("database.retention", "must not be negative"))
+	}
+	if d.Retention > 0 && d.PurgeInterval <= 0 {
+		errs = append(errs, invalid("database.purge_interval", "must be positive when database.retention is set"))
+	}
+	if d.MaxBatchSize <= 0 {
+		errs = append(errs, invalid("database.max_batch_size", "must be positive"))
+	}
+	if d.InsertBatchSize <= 0 {
+		errs = append(errs, invalid("database.insert_batch_size", "must be positive"))
+	}
+	if d.Pool.MaxOpenConns < 0 {
+		errs = append(errs, invalid("database.pool.max_open_conns", "must not be negative"))
+	}
+	if d.Pool.MaxIdleConns < 0 {
+		errs = append(errs, invalid("database.pool.max_idle_conns", "must not be negative"))
+	}
+	if d.Pool.MaxOpenConns > 0 && d.Pool.MaxIdleConns > d.Pool.MaxOpenConns {
+		errs = append(errs, invalid("database.pool.max_idle_conns", "must not exceed max_open_conns"))
+	}
+	if d.Pool.ConnMaxLifetime < 0 {
+		errs = append(errs, invalid("database.pool.conn_max_lifetime", "must not be negative"))
+	}
+	if d.Pool.ConnMaxIdleTime < 0 {
+		errs = append(errs, invalid("database.pool.conn_max_idle_time", "must not be negative"))
+	}
+	if d.RowLevelSecurity && d.Engine != "postgres" {
+		errs = append(errs, invalid("database.row_level_security", "is only supported by the postgres engine"))
+	}
+	if len(d.Replicas.DSNs) > 0 && d.Engine != "postgres" {
+		errs = append(errs, invalid("database.replicas.dsns", "are only supported by the postgres engine"))
+	}
+	for i, dsn := range d.Replicas.DSNs {
+		if dsn == "" {
+			errs = append(errs, invalid("database.replicas.dsns", "must not be empty, got an empty dsn at %d", i))
+		}
+	}
+	switch d.Replicas.LoadBalancing {
+	case "round-robin", "random", "least-conns":
+	default:
+		errs = append(errs, invalid("database.replicas.load_balancing", "unsupported policy %q", d.Replicas.LoadBalancing))
+	}
+	if d.Replicas.ReadYourWrites < 0 {
+		errs = append(errs, invalid("database.replicas.read_your_writes", "must not be negative"))
+	}
+	switch d.Outbox.Publisher {
+	case "none", "log":
+	default:
+		errs = append(errs, invalid("database.outbox.publisher", "unsupported publisher %q", d.Outbox.Publisher))
+	}
+
+	// The dispatcher also runs without a publisher when the webhooks are enabled.
+	if d.Outbox.Interval <= 0 {
+		errs = append(errs, invalid("database.outbox.interval", "must be positive"))
+	}
+	if d.Outbox.BatchSize <= 0 {
+		errs = append(errs, invalid("database.outbox.batch_size", "must be positive"))
+	}
+	if d.Outbox.MinBackoff <= 0 {
+		errs = append(errs, invalid("database.outbox.min_backoff", "must be positive"))
+	}
+	if d.Outbox.MaxBackoff < d.Outbox.MinBackoff {
+		errs = append(errs, invalid("database.outbox.max_backoff", "must not be less than min_backoff"))
+	}
+	if d.Outbox.MaxAttempts <= 0 {
+		errs = append(errs, invalid("database.outbox.max_attempts", "must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+func (a *Authentication) validate() error {
+	var errs []error
+	if a.Method != "jwt" {
+		errs = append(errs, invalid("authentication.method", "unsupported method %q", a.Method))
+	}
+	switch a.Key.Algorithm {
+	case "HS256", "HS384", "HS512":
+	default:
+		errs = append(errs, invalid("authentication.key.algorithm", "unsupported algorithm %q", a.Key.Algorithm))
+	}
+	if a.Key.Key == "" {
+		errs = append(errs, invalid("authentication.key.key", "is required"))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Cache) validate() error {
+	switch c.Engine {
+	case "":
+		return nil
+	case "redis":
+		var errs []error
+		if c.Host == "" {
+			errs = append(errs, invalid("cache.host", "is required for the %s engine", c.Engine))
+		}
+		if c.Port < 1 || c.Port > 65535 {
+			errs = append(errs, invalid("cache.port", "must be between 1 and 65535, got %d", c.Port))
+		}
+		return errors.Join(errs...)
+	}
+	return invalid("cache.engine", "unsupported engine %q", c.Engine)
+}
+
+func (l *Logs) validate() error {
+	var errs []error
+	switch l.Engine {
+	case "", "stdout":
+	case "loki":
+		if l.Address == "" {
+			errs = append(errs, invalid("logs.address", "is required for the %s engine", l.Engine))
+		}
+	default:
+		errs = append(errs, invalid("logs.engine", "unsupported engine %q", l.Engine))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(l.Level)); err != nil {
+		errs = append(errs, invalid("logs.level", "unsupported level %q", l.Level))
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Meter) validate() error {
+	switch m.Exporter {
+	case "", "none":
+		return nil
+	case "otlp":
+		if m.Endpoint == "" {
+			return invalid("meter.endpoint", "is required for the %s exporter", m.Exporter)
+		}
+		return nil
+	}
+	return invalid("meter.exporter", "unsupported exporter %q", m.Exporter)
+}
+
+func (w *Webhooks) validate() error {
+	if !w.Enabled {
+		return nil
+	}
+	var errs []error
+	if w.Timeout <= 0 {
+		errs = append(errs, invalid("webhooks.timeout", "must be positive"))
+	}
+	if w.Interval <= 0 {
+		errs = append(errs, invalid("webhooks.interval", "must be positive"))
+	}
+	if w.BatchSize <= 0 {
+		errs = append(errs, invalid("webhooks.batch_size", "must be positive"))
+	}
+	if w.MinBackoff <= 0 {
+		errs = append(errs, invalid("webhooks.min_backoff", "must be positive"))
+	}
+	if w.MaxBackoff < w.MinBackoff {
+		errs = append(errs, invalid("webhooks.max_backoff", "must not be less than min_backoff"))
+	}
+	if w.MaxAttempts <= 0 {
+		errs = append(errs, invalid("webhooks.max_attempts", "must be positive"))
 	}
-	err := viper.Unmarshal(&c)
-	if err != nil {
-		panic(fmt.Sprintf("unable to decode into struct, %v", err))
+	if w.MaxFailures <= 0 {
+		errs = append(errs, invalid("webhooks.max_failures", "must be positive"))
 	}
+	return errors.Join(errs...)
 }
 
-func main() {
-	fmt.Println(Get().Database)
+// invalid returns a validation error for the supplied configuration key.
+func invalid(key, format string, args ...any) error {
+	return fmt.Errorf("%w: %s %s", ErrInvalidConfig, key, fmt.Sprintf(format, args...))
 }
@@ -0,0 +1,8 @@
+package config
+
+import "fmt"
+
+var (
+	ErrInvalidConfig = fmt.Errorf("invalid config")
+	ErrReadConfig    = fmt.Errorf("failed to read config")
+)
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix is the prefix of the environment variables that override the configuration.
+//
+// Every configuration key can be overridden by upper-casing it, replacing the dots with underscores and adding the prefix.
+//
+// Example: `RECORDS_DATABASE_DSN` overrides `database.dsn`.
+const EnvPrefix = "RECORDS"
+
+// defaults are the values used when a key is not set in any of the configuration layers.
+var defaults = map[string]any{
+	"environment.environment":            EnvironmentDev,
+	"environment.debug":                  false,
+	"server.address":                     ":8080",
+	"server.admin_address":               ":2112",
+	"server.read_timeout":                15 * time.Second,
+	"server.write_timeout":               15 * time.Second,
+	"server.idle_timeout":                60 * time.Second,
+	"server.shutdown_timeout":            30 * time.Second,
+	"server.shutdown_delay":              0,
+	"server.watch_heartbeat":             15 * time.Second,
+	"server.watch_history":               1000,
+	"database.engine":                    "sqlite-memory",
+	"database.dsn":                       "",
+	"database.migrate_on_start":          false,
+	"database.row_level_security":        false,
+	"database.page_token_key":            "",
+	"database.retention":                 30 * 24 * time.Hour,
+	"database.purge_interval":            time.Hour,
+	"database.max_batch_size":            100,
+	"database.insert_batch_size":         100,
+	"database.pool.max_open_conns":       100,
+	"database.pool.max_idle_conns":       10,
+	"database.pool.conn_max_lifetime":    time.Hour,
+	"database.pool.conn_max_idle_time":   5 * time.Minute,
+	"database.replicas.dsns":             []string{},
+	"database.replicas.load_balancing":   "round-robin",
+	"database.replicas.read_your_writes": 5 * time.Second,
+	"database.outbox.publisher":          "log",
+	"database.outbox.interval":           time.Second,
+	"database.outbox.batch_size":         100,
+	"database.outbox.min_backoff":        time.Second,
+	"database.outbox.max_backoff":        10 * time.Minute,
+	"database.outbox.max_attempts":       10,
+	"authentication.method":              "jwt",
+	"authentication.key.algorithm":       "HS256",
+	"authentication.key.key":             "",
+	"cache.engine":                       "",
+	"cache.host":                         "",
+	"cache.port":                         6379,
+	"cache.password":                     "",
+	"logs.engine":                        "stdout",
+	"logs.address":                       "",
+	"logs.level":                         "info",
+	"meter.exporter":                     "none",
+	"meter.endpoint":                     "",
+	"webhooks.enabled":                   true,
+	"webhooks.timeout":                   10 * time.Second,
+	"webhooks.interval":                  time.Second,
+	"webhooks.batch_size":                20,
+	"webhooks.min_backoff":               10 * time.Second,
+	"webhooks.max_backoff":               time.Hour,
+	"webhooks.max_attempts":              10,
+	"webhooks.max_failures":              20,
+	"webhooks.allow_private":             false,
+}
+
+// aliases are the legacy environment variables, from `.env.example`, which are still honoured.
+var aliases = map[string][]string{
+	"environment.environment": {"ENV"},
+	"environment.debug":       {"DEBUG"},
+	"authentication.key.key":  {"JWT_SECRET"},
+}
+
+// flags maps the supported command-line flags to their configuration keys.
+var flags = map[string]string{
+	"environment":      "environment.environment",
+	"debug":            "environment.debug",
+	"address":          "server.address",
+	"database-engine":  "database.engine",
+	"database-dsn":     "database.dsn",
+	"migrate-on-start": "database.migrate_on_start",
+	"log-level":        "logs.level",
+}
+
+type LoadOptions struct {
+
+	// Paths are the directories that will be searched for the `config.toml` file.
+	// Default: `[]string{".", "./config"}`
+	//
+	// This field is optional.
+	Paths []string
+
+	// Args are the command-line arguments, without the program name, that will be parsed as flags.
+	//
+	// This field is optional.
+	Args []string
+}
+
+// Load loads and validates the configuration.
+//
+// The configuration is layered in the following order, where every layer overrides the previous one:
+//
+// 1. Defaults.
+// 2. `config.toml`.
+// 3. `config.<environment>.toml`, if it exists.
+// 4. Environment variables. See `EnvPrefix`.
+// 5. Command-line flags.
+//
+// All the validation errors are reported together.
+func Load(options *LoadOptions) (*Config, error) {
+	if options == nil {
+		options = &LoadOptions{}
+	}
+	if options.Paths == nil {
+		options.Paths = []string{".", "./config"}
+	}
+
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+
+	// Bind the command-line flags.
+	set := NewFlagSet("config")
+	if err := set.Parse(options.Args); err != nil {
+		return nil, err
+	}
+	for name, key := range flags {
+		if err := v.BindPFlag(key, set.Lookup(name)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Bind the environment variables.
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	for key, names := range aliases {
+		input := append([]string{key, EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))}, names...)
+		if err := v.BindEnv(input...); err != nil {
+			return nil, err
+		}
+	}
+
+	// Read the base configuration file.
+	dirs := options.Paths
+	if file, _ := set.GetString("config"); file != "" {
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrReadConfig, err)
+		}
+		dirs = []string{filepath.Dir(file)}
+	} else {
+		v.SetConfigName("config")
+		v.SetConfigType("toml")
+		for _, path := range options.Paths {
+			v.AddConfigPath(path)
+		}
+		if err := v.ReadInConfig(); err != nil {
+			var notFound viper.ConfigFileNotFoundError
+			if !errors.As(err, &notFound) {
+				return nil, fmt.Errorf("%w: %w", ErrReadConfig, err)
+			}
+		}
+		if used := v.ConfigFileUsed(); used != "" {
+			dirs = []string{filepath.Dir(used)}
+		}
+	}
+
+	// Merge the per-environment overrides.
+	environment := v.GetString("environment.environment")
+	for _, dir := range dirs {
+		path := filepath.Join(dir, fmt.Sprintf("config.%s.toml", environment))
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		v.SetConfigFile(path)
+		if err := v.MergeInConfig(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrReadConfig, err)
+		}
+		break
+	}
+
+	var config Config
+	if err := v.Unmarshal(&config); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReadConfig, err)
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// NewFlagSet returns the command-line flags understood by `Load`.
+func NewFlagSet(name string) *pflag.FlagSet {
+	set := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	set.String("config", "", "path to the configuration file")
+	set.String("environment", "", "environment to run in: dev, staging or prod")
+	set.Bool("debug", false, "enable debug logs")
+	set.String("address", "", "address the HTTP server listens on")
+	set.String("database-engine", "", "database engine")
+	set.String("database-dsn", "", "database data source name")
+	set.Bool("migrate-on-start", false, "apply the pending database migrations on startup")
+	set.String("log-level", "", "log level: debug, info, warn or error")
+	return set
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// redacted replaces the secrets in the printed configuration.
+const redacted = "REDACTED"
+
+// password matches the password parameter of a data source name.
+//
+// Example: "host=127.0.0.1 password=postgres" or "postgres://127.0.0.1/records?password=postgres"
+var password = regexp.MustCompile(`(password=)('[^']*'|[^\s&]+)`)
+
+// Print writes every configuration key and its value to the supplied writer, one per line, with the secrets redacted.
+//
+// Example: `server.address = ":8080"`
+func (c *Config) Print(w io.Writer) error {
+	return printFields(w, "", reflect.ValueOf(*c))
+}
+
+func printFields(w io.Writer, prefix string, value reflect.Value) error {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		key := prefix + field.Tag.Get("mapstructure")
+		item := value.Field(i)
+
+		if item.Kind() == reflect.Struct {
+			if err := printFields(w, key+".", item); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var formatted string
+		switch v := item.Interface().(type) {
+		case time.Duration:
+			formatted = fmt.Sprintf("%q", v.String())
+		case string:
+			formatted = fmt.Sprintf("%q", redact(field.Tag.Get("redact"), v))
+		case []string:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = redact(field.Tag.Get("redact"), item)
+			}
+			formatted = fmt.Sprintf("%q", items)
+		default:
+			formatted = fmt.Sprint(v)
+		}
+
+		if _, err := fmt.Fprintf(w, "%s = %s\n", key, formatted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redact redacts the secret of a value, according to the `redact` tag of its field.
+func redact(tag, value string) string {
+	switch tag {
+	case "true":
+		if value != "" {
+			return redacted
+		}
+	case "dsn":
+		return redactDSN(value)
+	}
+	return value
+}
+
+// redactDSN redacts the password of a URL or key/value data source name.
+func redactDSN(dsn string) string {
+	if strings.Contains(dsn, "://") {
+		if u, err := url.Parse(dsn); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				u.User = url.UserPassword(u.User.Username(), redacted)
+				dsn = u.String()
+			}
+		}
+	}
+	return password.ReplaceAllString(dsn, "${1}"+redacted)
+}
@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultInsertBatchSize is the default number of records inserted per statement by `BatchCreate`.
+const DefaultInsertBatchSize = 100
+
+// insertBatchSize returns the number of records inserted per statement by `BatchCreate`.
+func (db *sqldb) insertBatchSize() int {
+	if db.batchSize <= 0 {
+		return DefaultInsertBatchSize
+	}
+	return db.batchSize
+}
+
+// BatchCreate operation creates the records in a single transaction.
+//
+// The records, their first revisions and their events, are inserted with as few statements as possible, up to `InsertBatchSize`
+// records per statement. Either all the records are created, or none of them is.
+func (db *sqldb) BatchCreate(ctx context.Context, options *BatchCreateOptions) ([]*model.Record, error) {
+	if options == nil {
+		return nil, ErrInvalidOptions
+	}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	//
+	// This method has no Row Level Security (RLS) checks in Go. On Postgres, the policy of the records only lets
+	// the callers create their own records.
+	//
+
+	// Prepare the payload we have to send to the database transaction.
+	payload := make([]*model.Record, len(options.Requests))
+	for i, request := range options.Requests {
+		payload[i] = &model.Record{
+			Title:   request.Title,
+			UserID:  request.UserID,
+			Version: 1,
+		}
+	}
+
+	// Execute the transaction.
+	err := db.RunInTx(ctx, func(ctx context.Context) error {
+		if err := db.session(ctx).CreateInBatches(payload, db.insertBatchSize()).Error; err != nil {
+			return err
+		}
+		revisions := make([]*model.Revision, len(payload))
+		events := make([]*model.Event, len(payload))
+		for i, record := range payload {
+			revisions[i] = newRevision(ctx, record, model.RevisionCreate)
+			events[i] = newEvent(ctx, record, model.EventRecordCreated)
+		}
+		if err := db.session(ctx).CreateInBatches(revisions, db.insertBatchSize()).Error; err != nil {
+			return err
+		}
+		return db.session(ctx).CreateInBatches(events, db.insertBatchSize()).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// BatchGet operation fetches the records in the order of their IDs.
+//
+// If any of the records is not found, it returns a `BatchError` with `gorm.ErrRecordNotFound` for every missing
+// record, and no records.
+func (db *sqldb) BatchGet(ctx context.Context, options *BatchGetOptions) ([]*model.Record, error) {
+	txn := db.session(ctx)
+	if options == nil {
+		return nil, ErrInvalidOptions
+	}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	if options.ShowDeleted {
+		txn = txn.Unscoped()
+	}
+
+	// If the request context contains JWT claims, apply Row Level Security (RLS) checks.
+	claims, exists := ctx.Value(middleware.XJWTClaims).(middleware.JWTClaims)
+	if exists {
+
+		// 1. Only the user who created the records can get them.
+		txn = txn.Where(&model.Record{
+			UserID: claims.XUserID,
+		})
+	}
+
+	ids := make([]any, len(options.IDs))
+	for i, id := range options.IDs {
+		ids[i] = id
+	}
+
+	var records []*model.Record
+	if err := db.scoped(ctx, txn, func(txn *gorm.DB) error {
+		return txn.Where(clause.IN{Column: clause.Column{Name: "id"}, Values: ids}).Find(&records).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	found := make(map[uuid.UUID]*model.Record, len(records))
+	for _, record := range records {
+		found[record.ID] = record
+	}
+
+	var batch BatchError
+	payload := make([]*model.Record, len(options.IDs))
+	for i, id := range options.IDs {
+		record, ok := found[id]
+		if !ok {
+			batch.add(i, gorm.ErrRecordNotFound)
+			continue
+		}
+		payload[i] = record
+	}
+	if err := batch.err(); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// BatchUpdate operation updates the records in a single transaction, in order.
+//
+// Either all the records are updated, or none of them is: if an update fails, the transaction is rolled back and
+// a `BatchError` is returned with the error of the update.
+func (db *sqldb) BatchUpdate(ctx context.Context, options *BatchUpdateOptions) ([]*model.Record, error) {
+	if options == nil {
+		return nil, ErrInvalidOptions
+	}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	payload := make([]*model.Record, len(options.Requests))
+	err := db.RunInTx(ctx, func(ctx context.Context) error {
+		for i, request := range options.Requests {
+			record, err := db.Update(ctx, request.ID, &request.UpdateOptions)
+			if err != nil {
+				return itemError(i, err)
+			}
+			payload[i] = record
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// BatchDelete operation deletes the records in a single transaction.
+//
+// Either all the records are deleted, or none of them is: if a deletion fails, the transaction is rolled back and
+// a `BatchError` is returned with the error of the deletion.
+func (db *sqldb) BatchDelete(ctx context.Context, options *BatchDeleteOptions) error {
+	if options == nil {
+		return ErrInvalidOptions
+	}
+	if err := options.validate(); err != nil {
+		return err
+	}
+
+	return db.RunInTx(ctx, func(ctx context.Context) error {
+		for i, id := range options.IDs {
+			if err := db.Delete(ctx, id, &DeleteOptions{Force: options.Force}); err != nil {
+				return itemError(i, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,226 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"gorm.io/gorm"
+)
+
+func Test_Database_Batch(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Initialize the database, with two records per insert statement.
+	db := &sqldb{
+		conn:      config.conn,
+		batchSize: 2,
+	}
+
+	userID := uuid.New()
+	ctx := context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+		XUserID: userID,
+	})
+
+	// count returns the number of records of the user.
+	count := func(t *testing.T) int64 {
+		var count int64
+		if err := config.conn.Model(&model.Record{}).Where(&model.Record{UserID: userID}).Count(&count).Error; err != nil {
+			t.Fatalf("failed to count the records: %v", err)
+		}
+		return count
+	}
+
+	var records []*model.Record
+
+	t.Run("batch create records", func(t *testing.T) {
+
+		var err error
+		records, err = db.BatchCreate(ctx, &BatchCreateOptions{
+			Requests: []*CreateOptions{
+				{Title: "First", UserID: userID},
+				{Title: "Second", UserID: userID},
+				{Title: "Third", UserID: userID},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to create the records: %v", err)
+		}
+		if len(records) != 3 || count(t) != 3 {
+			t.Fatalf("expected 3 records to be created, got %d", len(records))
+		}
+		for i, title := range []string{"First", "Second", "Third"} {
+			if records[i].Title != title || records[i].ID == uuid.Nil || records[i].ETag != `"1"` {
+				t.Errorf("expected record %d to be created as %q at version 1, got %+v", i, title, records[i])
+			}
+		}
+	})
+
+	t.Run("batch create w/ invalid records", func(t *testing.T) {
+
+		_, err := db.BatchCreate(ctx, &BatchCreateOptions{
+			Requests: []*CreateOptions{
+				{Title: "Valid", UserID: userID},
+				{Title: "", UserID: userID},
+				nil,
+			},
+		})
+		var batch *BatchError
+		if !errors.As(err, &batch) || len(batch.Items) != 2 {
+			t.Fatalf("expected a batch error with 2 items, got %v", err)
+		}
+		if batch.Items[0].Index != 1 || !errors.Is(batch.Items[0].Err, ErrInvalidTitle) {
+			t.Errorf("expected item 1 to have an invalid title, got %v", batch.Items[0])
+		}
+		if batch.Items[1].Index != 2 || !errors.Is(batch.Items[1].Err, ErrInvalidOptions) {
+			t.Errorf("expected item 2 to have invalid options, got %v", batch.Items[1])
+		}
+		if count(t) != 3 {
+			t.Errorf("expected no record to be created, got %d records", count(t))
+		}
+	})
+
+	t.Run("batch create w/o records", func(t *testing.T) {
+
+		if _, err := db.BatchCreate(ctx, &BatchCreateOptions{}); err != ErrInvalidOptions {
+			t.Errorf("db.BatchCreate() error = %v, want %v", err, ErrInvalidOptions)
+		}
+	})
+
+	t.Run("batch get records in order", func(t *testing.T) {
+
+		got, err := db.BatchGet(ctx, &BatchGetOptions{
+			IDs: []uuid.UUID{records[2].ID, records[0].ID, records[2].ID},
+		})
+		if err != nil {
+			t.Fatalf("failed to get the records: %v", err)
+		}
+		if len(got) != 3 || got[0].ID != records[2].ID || got[1].ID != records[0].ID || got[2].ID != records[2].ID {
+			t.Errorf("expected the records in the order of the IDs, got %v", got)
+		}
+	})
+
+	t.Run("batch get w/ missing records", func(t *testing.T) {
+
+		got, err := db.BatchGet(ctx, &BatchGetOptions{
+			IDs: []uuid.UUID{records[0].ID, uuid.New()},
+		})
+		var batch *BatchError
+		if !errors.As(err, &batch) || len(batch.Items) != 1 || batch.Items[0].Index != 1 {
+			t.Fatalf("expected a batch error for item 1, got %v", err)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) || got != nil {
+			t.Errorf("expected no records and %v, got %v and %v", gorm.ErrRecordNotFound, got, err)
+		}
+	})
+
+	t.Run("batch get records of another user", func(t *testing.T) {
+
+		ctx := context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+			XUserID: uuid.New(),
+		})
+		if _, err := db.BatchGet(ctx, &BatchGetOptions{IDs: []uuid.UUID{records[0].ID}}); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("db.BatchGet() error = %v, want %v", err, gorm.ErrRecordNotFound)
+		}
+	})
+
+	t.Run("batch update records", func(t *testing.T) {
+
+		got, err := db.BatchUpdate(ctx, &BatchUpdateOptions{
+			Requests: []*BatchUpdateRequest{
+				{ID: records[0].ID, UpdateOptions: UpdateOptions{Title: "First Updated"}},
+				{ID: records[1].ID, UpdateOptions: UpdateOptions{Title: "Second Updated", Version: 1}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("failed to update the records: %v", err)
+		}
+		if got[0].Title != "First Updated" || got[1].Title != "Second Updated" || got[1].Version != 2 {
+			t.Errorf("expected the records to be updated, got %+v and %+v", got[0], got[1])
+		}
+	})
+
+	t.Run("batch update is all-or-nothing", func(t *testing.T) {
+
+		// The second update is based on a stale version, so the first one must be rolled back.
+		_, err := db.BatchUpdate(ctx, &BatchUpdateOptions{
+			Requests: []*BatchUpdateRequest{
+				{ID: records[0].ID, UpdateOptions: UpdateOptions{Title: "Rolled Back"}},
+				{ID: records[1].ID, UpdateOptions: UpdateOptions{Title: "Stale", Version: 1}},
+			},
+		})
+		var batch *BatchError
+		if !errors.As(err, &batch) || len(batch.Items) != 1 || batch.Items[0].Index != 1 || !errors.Is(err, ErrVersionMismatch) {
+			t.Fatalf("expected a version mismatch of item 1, got %v", err)
+		}
+
+		got, err := db.Get(ctx, records[0].ID, nil)
+		if err != nil {
+			t.Fatalf("failed to get the record: %v", err)
+		}
+		if got.Title != "First Updated" || got.Version != 2 {
+			t.Errorf("expected the first update to be rolled back, got %q at version %d", got.Title, got.Version)
+		}
+	})
+
+	t.Run("batch update w/ invalid requests", func(t *testing.T) {
+
+		_, err := db.BatchUpdate(ctx, &BatchUpdateOptions{
+			Requests: []*BatchUpdateRequest{
+				{ID: records[0].ID, UpdateOptions: UpdateOptions{Title: "Valid"}},
+				{ID: records[0].ID, UpdateOptions: UpdateOptions{Title: "Duplicate"}},
+				{ID: records[1].ID, UpdateOptions: UpdateOptions{UpdateMask: []string{"user_id"}}},
+				{UpdateOptions: UpdateOptions{Title: "Missing ID"}},
+			},
+		})
+		var batch *BatchError
+		if !errors.As(err, &batch) || len(batch.Items) != 3 {
+			t.Fatalf("expected a batch error with 3 items, got %v", err)
+		}
+		if !errors.Is(batch.Items[0].Err, ErrDuplicateRecordID) || !errors.Is(batch.Items[1].Err, ErrInvalidFilters) || !errors.Is(batch.Items[2].Err, ErrInvalidRecordID) {
+			t.Errorf("expected the errors of items 1, 2 and 3, got %v", err)
+		}
+	})
+
+	t.Run("batch delete is all-or-nothing", func(t *testing.T) {
+
+		err := db.BatchDelete(ctx, &BatchDeleteOptions{
+			IDs: []uuid.UUID{records[0].ID, uuid.New()},
+		})
+		var batch *BatchError
+		if !errors.As(err, &batch) || len(batch.Items) != 1 || batch.Items[0].Index != 1 {
+			t.Fatalf("expected a batch error for item 1, got %v", err)
+		}
+		if _, err := db.Get(ctx, records[0].ID, nil); err != nil {
+			t.Errorf("expected the first deletion to be rolled back, got %v", err)
+		}
+	})
+
+	t.Run("batch delete records", func(t *testing.T) {
+
+		if err := db.BatchDelete(ctx, &BatchDeleteOptions{
+			IDs: []uuid.UUID{records[0].ID, records[1].ID},
+		}); err != nil {
+			t.Fatalf("failed to delete the records: %v", err)
+		}
+		if _, err := db.BatchGet(ctx, &BatchGetOptions{IDs: []uuid.UUID{records[0].ID, records[1].ID}}); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("expected the records to be deleted, got %v", err)
+		}
+
+		// The soft-deleted records are still found w/ `ShowDeleted`, until they are deleted permanently.
+		if _, err := db.BatchGet(ctx, &BatchGetOptions{IDs: []uuid.UUID{records[0].ID}, ShowDeleted: true}); err != nil {
+			t.Errorf("expected the soft-deleted record to be found, got %v", err)
+		}
+		if err := db.BatchDelete(ctx, &BatchDeleteOptions{IDs: []uuid.UUID{records[0].ID, records[1].ID}, Force: true}); err != nil {
+			t.Fatalf("failed to delete the records permanently: %v", err)
+		}
+		if count(t) != 1 {
+			t.Errorf("expected 1 record to be left, got %d", count(t))
+		}
+	})
+}
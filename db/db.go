@@ -11,8 +11,24 @@ import (
 // DB interface declares the signature of the database layer.
 type DB interface {
 	Create(context.Context, *CreateOptions) (*model.Record, error)
-	List(context.Context, *ListOptions) ([]*model.Record, error)
-	Get(context.Context, uuid.UUID) (*model.Record, error)
+	List(context.Context, *ListOptions) ([]*model.Record, string, error)
+	Search(context.Context, *SearchOptions) ([]*model.SearchResult, error)
+	Get(context.Context, uuid.UUID, *GetOptions) (*model.Record, error)
 	Update(context.Context, uuid.UUID, *UpdateOptions) (*model.Record, error)
-	Delete(context.Context, uuid.UUID) error
+	Delete(context.Context, uuid.UUID, *DeleteOptions) error
+	Undelete(context.Context, uuid.UUID) (*model.Record, error)
+	Purge(context.Context, *PurgeOptions) (int64, error)
+	BatchCreate(context.Context, *BatchCreateOptions) ([]*model.Record, error)
+	BatchGet(context.Context, *BatchGetOptions) ([]*model.Record, error)
+	BatchUpdate(context.Context, *BatchUpdateOptions) ([]*model.Record, error)
+	BatchDelete(context.Context, *BatchDeleteOptions) error
+	ListRevisions(context.Context, uuid.UUID, *ListRevisionsOptions) ([]*model.Revision, string, error)
+	GetRevision(context.Context, uuid.UUID, int64) (*model.Revision, error)
+	DiffRevisions(context.Context, uuid.UUID, int64, *DiffRevisionsOptions) (*model.Diff, error)
+	Restore(context.Context, uuid.UUID, *RestoreOptions) (*model.Record, error)
+	CreateWebhook(context.Context, *CreateWebhookOptions) (*model.Webhook, error)
+	ListWebhooks(context.Context, *ListWebhooksOptions) ([]*model.Webhook, string, error)
+	DeleteWebhook(context.Context, uuid.UUID) error
+	ListDeliveries(context.Context, *ListDeliveriesOptions) ([]*model.Delivery, string, error)
+	RunInTx(context.Context, func(context.Context) error) error
 }
@@ -0,0 +1,498 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Defaults of the deliverer of the webhooks.
+const (
+	DefaultDeliverInterval  = time.Second
+	DefaultDeliverBatchSize = 20
+	DefaultWebhookTimeout   = 10 * time.Second
+	DefaultMaxAttempts      = 10
+	DefaultMaxFailures      = 20
+)
+
+// errWebhookDisabled is the error of the deliveries which are abandoned because their webhook has been disabled.
+var errWebhookDisabled = errors.New("webhook disabled")
+
+// errPrivateAddress is the error of the attempts whose webhook resolves to a private address.
+var errPrivateAddress = errors.New("webhook resolves to a private address")
+
+// dialPublic refuses the connections to the loopback, private, link-local and unspecified addresses.
+//
+// It is the `Control` of the dialer of the default client, so it checks the addresses the hosts of the webhooks
+// resolve to when the deliveries are sent, which may differ from those they resolved to when they were created.
+func dialPublic(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil || privateAddr(addrPort.Addr()) {
+		return errPrivateAddress
+	}
+	return nil
+}
+
+type DelivererConfig struct {
+
+	// Database connection.
+	// The connection should already be open.
+	//
+	// This field is mandatory.
+	DB *gorm.DB
+
+	// Client sends the deliveries.
+	// Default: a client which does not follow redirects, nor connect to private addresses unless `AllowPrivate`
+	// is set.
+	//
+	// This field is optional.
+	Client *http.Client
+
+	// AllowPrivate allows the default client to connect to the loopback, private, link-local and unspecified
+	// addresses. It should only be set in tests and development.
+	// Default: `false`
+	//
+	// This field is optional.
+	AllowPrivate bool
+
+	// Interval is the time between two polls of the deliveries, when there are no more deliveries to send.
+	// Default: `DefaultDeliverInterval`
+	//
+	// This field is optional.
+	Interval time.Duration
+
+	// BatchSize is the maximum number of deliveries claimed per poll, which are sent concurrently.
+	// Default: `DefaultDeliverBatchSize`
+	//
+	// This field is optional.
+	BatchSize int
+
+	// Timeout is the maximum duration of an attempt, after which it fails.
+	// Default: `DefaultWebhookTimeout`
+	//
+	// This field is optional.
+	Timeout time.Duration
+
+	// MinBackoff is the delay before the first retry of a delivery which failed.
+	// It doubles with every failed attempt, up to `MaxBackoff`.
+	// Default: `DefaultMinBackoff`
+	//
+	// This field is optional.
+	MinBackoff time.Duration
+
+	// MaxBackoff is the maximum delay between two attempts of a delivery.
+	// Default: `DefaultMaxBackoff`
+	//
+	// This field is optional.
+	MaxBackoff time.Duration
+
+	// MaxAttempts is the number of attempts after which a delivery fails for good.
+	// Default: `DefaultMaxAttempts`
+	//
+	// This field is optional.
+	MaxAttempts int
+
+	// MaxFailures is the number of consecutive failed attempts, of any delivery, after which a webhook is disabled.
+	// Default: `DefaultMaxFailures`
+	//
+	// This field is optional.
+	MaxFailures int
+
+	// RowLevelSecurity runs the transactions as `BypassRole`, so they see the deliveries of every user, when the
+	// database enforces the row level security.
+	// Default: `false`
+	//
+	// This field is optional.
+	RowLevelSecurity bool
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// Deliverer sends the deliveries of the events to the webhooks, signed with their secrets, and records their
+// outcome.
+//
+// It is a background job: `Run` polls the pending deliveries until its context is cancelled. Like the dispatchers,
+// several deliverers can share the deliveries: every poll claims them with `FOR UPDATE SKIP LOCKED` on Postgres,
+// and postpones their next attempt while they are sent, so a delivery is only sent by one deliverer at a time.
+type Deliverer struct {
+	conn        *gorm.DB
+	client      *http.Client
+	interval    time.Duration
+	batchSize   int
+	timeout     time.Duration
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	maxAttempts int
+	maxFailures int
+	rls         bool
+	log         *slog.Logger
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// NewDeliverer creates a new instance of `Deliverer`.
+func NewDeliverer(config *DelivererConfig) *Deliverer {
+	if config == nil || config.DB == nil {
+		panic("db: nil deliverer config")
+	}
+
+	deliverer := Deliverer{
+		conn:        config.DB,
+		client:      config.Client,
+		interval:    config.Interval,
+		batchSize:   config.BatchSize,
+		timeout:     config.Timeout,
+		minBackoff:  config.MinBackoff,
+		maxBackoff:  config.MaxBackoff,
+		maxAttempts: config.MaxAttempts,
+		maxFailures: config.MaxFailures,
+		rls:         config.RowLevelSecurity,
+		log:         config.Logger,
+		now:         config.DB.NowFunc,
+	}
+
+	if deliverer.client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if !config.AllowPrivate {
+			dialer := &net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+				Control:   dialPublic,
+			}
+			transport.DialContext = dialer.DialContext
+
+			// A proxy would connect to the webhooks on behalf of the client, past its checks.
+			transport.Proxy = nil
+		}
+		deliverer.client = &http.Client{
+			Transport: transport,
+
+			// A redirect is a failed attempt: the deliveries are only sent to the URLs of the webhooks.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	if deliverer.interval <= 0 {
+		deliverer.interval = DefaultDeliverInterval
+	}
+	if deliverer.batchSize <= 0 {
+		deliverer.batchSize = DefaultDeliverBatchSize
+	}
+	if deliverer.timeout <= 0 {
+		deliverer.timeout = DefaultWebhookTimeout
+	}
+	if deliverer.minBackoff <= 0 {
+		deliverer.minBackoff = DefaultMinBackoff
+	}
+	if deliverer.maxBackoff < deliverer.minBackoff {
+		deliverer.maxBackoff = max(DefaultMaxBackoff, deliverer.minBackoff)
+	}
+	if deliverer.maxAttempts <= 0 {
+		deliverer.maxAttempts = DefaultMaxAttempts
+	}
+	if deliverer.maxFailures <= 0 {
+		deliverer.maxFailures = DefaultMaxFailures
+	}
+
+	if deliverer.log == nil {
+		deliverer.log = slog.Default()
+	}
+	deliverer.log = deliverer.log.With("job", "deliver")
+
+	return &deliverer
+}
+
+// Run sends the pending deliveries until the context is cancelled.
+//
+// It polls the deliveries again right away as long as the polls claim full batches, and waits for the interval
+// otherwise. A failed poll is logged and retried at the next interval, so it never stops the service.
+func (d *Deliverer) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+
+		claimed, err := d.Deliver(ctx)
+		if err != nil && ctx.Err() == nil {
+			d.log.ErrorContext(ctx, "failed to send the deliveries", "error", err)
+		}
+		if err != nil || claimed < d.batchSize {
+			timer.Reset(d.interval)
+		} else {
+			timer.Reset(0)
+		}
+	}
+}
+
+// Deliver claims a batch of the pending deliveries, sends them concurrently, and records their outcome. It returns
+// the number of deliveries claimed.
+//
+// A delivery whose outcome could not be recorded, e.g. because the context was cancelled, is sent again once its
+// claim expires.
+func (d *Deliverer) Deliver(ctx context.Context) (int, error) {
+	deliveries, webhooks, err := d.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery *model.Delivery) {
+			defer wg.Done()
+
+			if err := d.attempt(ctx, delivery, webhooks[delivery.WebhookID]); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}(delivery)
+	}
+	wg.Wait()
+
+	return len(deliveries), errors.Join(errs...)
+}
+
+// claim claims a batch of the pending deliveries whose attempt is due, and fetches their webhooks.
+//
+// The next attempt of the claimed deliveries is postponed until their attempt is over, plus the minimum backoff,
+// so the other deliverers skip them even once they are no longer locked.
+func (d *Deliverer) claim(ctx context.Context) ([]*model.Delivery, map[uuid.UUID]*model.Webhook, error) {
+	var (
+		deliveries []*model.Delivery
+		webhooks   = make(map[uuid.UUID]*model.Webhook)
+	)
+	err := d.transaction(ctx, func(tx *gorm.DB) error {
+		now := d.now()
+
+		// GORM stores the timestamps in the local time zone, and SQLite compares them as text,
+		// so the current time must be in the same time zone to compare correctly.
+		result := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+			Where(&model.Delivery{Status: model.DeliveryPending}).
+			Where(clause.Lte{Column: clause.Column{Name: "next_attempt_at"}, Value: now.Local()}).
+			Order(clause.OrderBy{Columns: []clause.OrderByColumn{
+				{Column: clause.Column{Name: "next_attempt_at"}},
+				{Column: clause.Column{Name: "id"}},
+			}}).
+			Limit(d.batchSize).
+			Find(&deliveries)
+		if result.Error != nil || len(deliveries) == 0 {
+			return result.Error
+		}
+
+		ids := make([]any, len(deliveries))
+		webhookIDs := make([]any, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+			webhookIDs[i] = delivery.WebhookID
+		}
+		result = tx.Model(&model.Delivery{}).
+			Where(clause.IN{Column: clause.Column{Name: "id"}, Values: ids}).
+			UpdateColumn("next_attempt_at", now.Add(d.timeout+d.minBackoff))
+		if result.Error != nil {
+			return result.Error
+		}
+
+		var found []*model.Webhook
+		if err := tx.Where(clause.IN{Column: clause.Column{Name: "id"}, Values: webhookIDs}).Find(&found).Error; err != nil {
+			return err
+		}
+		for _, webhook := range found {
+			webhooks[webhook.ID] = webhook
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return deliveries, webhooks, nil
+}
+
+// attempt sends the delivery to its webhook, and records the outcome.
+//
+// The deliveries of the webhooks which have been deleted or disabled are abandoned without being sent.
+func (d *Deliverer) attempt(ctx context.Context, delivery *model.Delivery, webhook *model.Webhook) error {
+	if webhook == nil || webhook.DisabledAt != nil {
+		return d.abandon(ctx, delivery)
+	}
+
+	status, err := d.send(ctx, delivery, webhook)
+	if ctx.Err() != nil {
+
+		// The attempt was interrupted, e.g. by a shutdown, rather than failed: leave it to the next claim.
+		return nil
+	}
+	return d.record(ctx, delivery, webhook, status, err)
+}
+
+// send posts the event of the delivery to the URL of its webhook, signed with its secret, and returns the status
+// code of the response, if any. Any status code but 2xx fails the attempt.
+func (d *Deliverer) send(ctx context.Context, delivery *model.Delivery, webhook *model.Webhook) (int, error) {
+	body, err := json.Marshal(delivery.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := d.now()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(string(middleware.XWebhookID), delivery.ID.String())
+	request.Header.Set(string(middleware.XWebhookEvent), delivery.Event.Type)
+	request.Header.Set(string(middleware.XWebhookTimestamp), strconv.FormatInt(timestamp.Unix(), 10))
+	request.Header.Set(string(middleware.XWebhookSignature), middleware.SignWebhook(webhook.Secret, timestamp, body))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	// Drain the response, so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("unexpected status %s", response.Status)
+	}
+	return response.StatusCode, nil
+}
+
+// record records the outcome of an attempt of the delivery.
+//
+// A failed delivery is retried after the backoff, unless it has been attempted `maxAttempts` times. Every failed
+// attempt counts as a failure of the webhook, which is disabled after `maxFailures` consecutive ones, along with its
+// pending deliveries.
+func (d *Deliverer) record(ctx context.Context, delivery *model.Delivery, webhook *model.Webhook, status int, failure error) error {
+	now := d.now()
+	attempts := delivery.Attempts + 1
+
+	return d.transaction(ctx, func(tx *gorm.DB) error {
+		columns := map[string]any{
+			"attempts":        attempts,
+			"response_status": status,
+		}
+		if failure == nil {
+			columns["status"] = model.DeliverySucceeded
+			columns["delivered_at"] = now
+			columns["last_error"] = ""
+		} else {
+			columns["last_error"] = failure.Error()
+			if attempts >= d.maxAttempts {
+				columns["status"] = model.DeliveryFailed
+			} else {
+				columns["next_attempt_at"] = now.Add(d.backoff(attempts))
+			}
+		}
+		if err := tx.Model(delivery).UpdateColumns(columns).Error; err != nil {
+			return err
+		}
+
+		// The webhook is shared by the concurrent attempts of its deliveries, so it is not updated in place.
+		if failure == nil {
+			return tx.Model(&model.Webhook{ID: webhook.ID}).UpdateColumn("failures", 0).Error
+		}
+
+		d.log.WarnContext(ctx, "failed to send a delivery",
+			"delivery_id", delivery.ID,
+			"webhook_id", webhook.ID,
+			"event_id", delivery.EventID,
+			"attempts", attempts,
+			"status", status,
+			"error", failure,
+		)
+		if err := tx.Model(&model.Webhook{ID: webhook.ID}).UpdateColumn("failures", gorm.Expr("? + 1", clause.Column{Name: "failures"})).Error; err != nil {
+			return err
+		}
+
+		// Disable the webhook, unless it is still below the maximum or already disabled.
+		result := tx.Model(&model.Webhook{}).
+			Where(&model.Webhook{ID: webhook.ID}).
+			Where(clause.Gte{Column: clause.Column{Name: "failures"}, Value: d.maxFailures}).
+			Where(clause.Eq{Column: clause.Column{Name: "disabled_at"}, Value: nil}).
+			UpdateColumn("disabled_at", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		d.log.WarnContext(ctx, "disabled a webhook after too many failures",
+			"webhook_id", webhook.ID,
+			"failures", d.maxFailures,
+		)
+
+		// Fail this delivery for good, and abandon the other pending deliveries of the webhook.
+		if err := tx.Model(delivery).UpdateColumn("status", model.DeliveryFailed).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Delivery{}).
+			Where(&model.Delivery{WebhookID: webhook.ID, Status: model.DeliveryPending}).
+			UpdateColumns(map[string]any{
+				"status":     model.DeliveryFailed,
+				"last_error": errWebhookDisabled.Error(),
+			}).Error
+	})
+}
+
+// abandon fails the delivery for good, without sending it, because its webhook is disabled.
+func (d *Deliverer) abandon(ctx context.Context, delivery *model.Delivery) error {
+	return d.transaction(ctx, func(tx *gorm.DB) error {
+		return tx.Model(delivery).UpdateColumns(map[string]any{
+			"status":     model.DeliveryFailed,
+			"last_error": errWebhookDisabled.Error(),
+		}).Error
+	})
+}
+
+// transaction runs the function in a transaction, as `BypassRole` when the database enforces the row level security.
+func (d *Deliverer) transaction(ctx context.Context, fn func(*gorm.DB) error) error {
+	return d.conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := bypass(ctx, tx, d.rls); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+// backoff returns the delay before the next attempt of a delivery, after the number of failed attempts.
+func (d *Deliverer) backoff(attempts int) time.Duration {
+	delay := d.minBackoff
+	for i := 1; i < attempts && delay < d.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.maxBackoff)
+}
@@ -0,0 +1,311 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+)
+
+func Test_NewDeliverer(t *testing.T) {
+
+	t.Run("nil config", func(t *testing.T) {
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("NewDeliverer() did not panic")
+			}
+		}()
+
+		NewDeliverer(nil)
+	})
+
+	t.Run("default options", func(t *testing.T) {
+
+		config := configure(t)
+		deliverer := NewDeliverer(&DelivererConfig{
+			DB: config.conn,
+		})
+		if deliverer.interval != DefaultDeliverInterval || deliverer.batchSize != DefaultDeliverBatchSize || deliverer.timeout != DefaultWebhookTimeout {
+			t.Errorf("NewDeliverer() interval = %v, batch size = %d, timeout = %v", deliverer.interval, deliverer.batchSize, deliverer.timeout)
+		}
+		if deliverer.maxAttempts != DefaultMaxAttempts || deliverer.maxFailures != DefaultMaxFailures {
+			t.Errorf("NewDeliverer() max attempts = %d, max failures = %d", deliverer.maxAttempts, deliverer.maxFailures)
+		}
+		if deliverer.client == nil || deliverer.client.CheckRedirect == nil {
+			t.Errorf("NewDeliverer() should default to a client which does not follow redirects")
+		}
+	})
+}
+
+// receiver is a webhook receiver which verifies the signatures of the deliveries.
+type receiver struct {
+	*httptest.Server
+
+	// secret of the webhook.
+	secret string
+
+	// status is the status code of the responses.
+	status atomic.Int64
+
+	mu       sync.Mutex
+	received []string
+	errs     []error
+}
+
+func newReceiver(t *testing.T, secret string) *receiver {
+	r := &receiver{
+		secret: secret,
+	}
+	r.status.Store(http.StatusNoContent)
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if err := middleware.VerifyWebhook(r.secret, req.Header, body, time.Hour); err != nil {
+			r.errs = append(r.errs, err)
+		}
+		r.received = append(r.received, req.Header.Get(string(middleware.XWebhookEvent)))
+		w.WriteHeader(int(r.status.Load()))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// events returns the types of the events received, and the errors of their signatures.
+func (r *receiver) events() ([]string, []error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.received...), append([]error(nil), r.errs...)
+}
+
+func Test_Database_Deliveries(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// The attempts are recorded concurrently, which a shared in-memory SQLite database only allows one at a time.
+	sqlDB, err := config.conn.DB()
+	if err != nil {
+		t.Fatalf("failed to get the database connection: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	// Initialize the database.
+	// The receiver listens on the loopback address.
+	db := &sqldb{
+		conn:                 config.conn,
+		allowPrivateWebhooks: true,
+	}
+
+	owner := uuid.New()
+	ctx := context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+		XUserID: owner,
+	})
+
+	const secret = "a-secret-of-sixteen"
+	server := newReceiver(t, secret)
+	webhook, err := db.CreateWebhook(ctx, &CreateWebhookOptions{UserID: owner, URL: server.URL, Secret: secret})
+	if err != nil {
+		t.Fatalf("failed to create the webhook: %v", err)
+	}
+	if _, err := db.CreateWebhook(ctx, &CreateWebhookOptions{UserID: owner, URL: server.URL, Secret: secret, Events: []string{model.EventRecordDeleted}}); err != nil {
+		t.Fatalf("failed to create the webhook: %v", err)
+	}
+
+	dispatcher := NewDispatcher(&DispatcherConfig{
+		DB:        config.conn,
+		Publisher: NewWebhookPublisher(config.conn),
+	})
+	deliverer := NewDeliverer(&DelivererConfig{
+		DB:          config.conn,
+		BatchSize:   1,
+		MinBackoff:  time.Minute,
+		MaxAttempts: 2,
+		MaxFailures: 3,
+
+		AllowPrivate: true,
+	})
+
+	// deliveries returns the deliveries of the webhook, most recent first.
+	deliveries := func(t *testing.T) []*model.Delivery {
+		payload, _, err := db.ListDeliveries(ctx, &ListDeliveriesOptions{WebhookID: webhook.ID})
+		if err != nil {
+			t.Fatalf("failed to list the deliveries: %v", err)
+		}
+		return payload
+	}
+
+	// dispatch dispatches the events of the outbox to the webhooks.
+	dispatch := func(t *testing.T) {
+		if _, err := dispatcher.Dispatch(context.Background()); err != nil {
+			t.Fatalf("Dispatcher.Dispatch() error = %v", err)
+		}
+	}
+
+	// deliver sends a batch of deliveries, and returns the number of deliveries claimed.
+	deliver := func(t *testing.T) int {
+		claimed, err := deliverer.Deliver(context.Background())
+		if err != nil {
+			t.Fatalf("Deliverer.Deliver() error = %v", err)
+		}
+		return claimed
+	}
+
+	var record *model.Record
+	t.Run("fan out the events to the subscribed webhooks", func(t *testing.T) {
+
+		record, err = db.Create(ctx, &CreateOptions{Title: "Test Record", UserID: owner})
+		if err != nil {
+			t.Fatalf("failed to create record: %v", err)
+		}
+		dispatch(t)
+
+		// Only the webhook subscribed to every event gets the creation.
+		var count int64
+		config.conn.Model(&model.Delivery{}).Count(&count)
+		if count != 1 {
+			t.Fatalf("expected 1 delivery, got %d", count)
+		}
+		if got := deliveries(t); len(got) != 1 || got[0].Status != model.DeliveryPending || got[0].Event.RecordID != record.ID {
+			t.Fatalf("expected a pending delivery of the creation of the record")
+		}
+	})
+
+	t.Run("send the signed deliveries", func(t *testing.T) {
+
+		if claimed := deliver(t); claimed != 1 {
+			t.Fatalf("Deliverer.Deliver() claimed %d deliveries, want 1", claimed)
+		}
+		events, errs := server.events()
+		if len(events) != 1 || events[0] != model.EventRecordCreated || len(errs) != 0 {
+			t.Fatalf("expected a signed delivery of the creation, got %v, errors = %v", events, errs)
+		}
+
+		got := deliveries(t)
+		if got[0].Status != model.DeliverySucceeded || got[0].Attempts != 1 || got[0].ResponseStatus != http.StatusNoContent || got[0].DeliveredAt == nil {
+			t.Errorf("expected the delivery to succeed, got %s after %d attempts", got[0].Status, got[0].Attempts)
+		}
+
+		// A delivered event is not sent again.
+		if claimed := deliver(t); claimed != 0 {
+			t.Errorf("Deliverer.Deliver() claimed %d deliveries, want 0", claimed)
+		}
+	})
+
+	t.Run("retry the failed deliveries", func(t *testing.T) {
+
+		server.status.Store(http.StatusInternalServerError)
+		if _, err := db.Update(ctx, record.ID, &UpdateOptions{Title: "Updated Record"}); err != nil {
+			t.Fatalf("failed to update record: %v", err)
+		}
+		dispatch(t)
+		deliver(t)
+
+		got := deliveries(t)
+		if got[0].Status != model.DeliveryPending || got[0].Attempts != 1 || got[0].ResponseStatus != http.StatusInternalServerError || got[0].LastError == "" {
+			t.Fatalf("expected the delivery to be retried, got %s after %d attempts", got[0].Status, got[0].Attempts)
+		}
+		if !got[0].NextAttemptAt.After(time.Now()) {
+			t.Errorf("expected the retry to be delayed, got %v", got[0].NextAttemptAt)
+		}
+
+		// The retry is not due yet.
+		if claimed := deliver(t); claimed != 0 {
+			t.Errorf("Deliverer.Deliver() claimed %d deliveries, want 0", claimed)
+		}
+
+		// The delivery fails for good after the maximum number of attempts.
+		deliverer.now = func() time.Time { return time.Now().Add(time.Hour) }
+		t.Cleanup(func() { deliverer.now = time.Now })
+		if claimed := deliver(t); claimed != 1 {
+			t.Fatalf("Deliverer.Deliver() claimed %d deliveries, want 1", claimed)
+		}
+		if got := deliveries(t); got[0].Status != model.DeliveryFailed || got[0].Attempts != 2 {
+			t.Errorf("expected the delivery to fail, got %s after %d attempts", got[0].Status, got[0].Attempts)
+		}
+	})
+
+	t.Run("disable the webhooks after too many failures", func(t *testing.T) {
+
+		if _, err := db.BatchCreate(ctx, &BatchCreateOptions{Requests: []*CreateOptions{
+			{Title: "January", UserID: owner},
+			{Title: "February", UserID: owner},
+		}}); err != nil {
+			t.Fatalf("failed to create records: %v", err)
+		}
+		dispatch(t)
+
+		// The third consecutive failure disables the webhook, and abandons its other pending delivery.
+		deliver(t)
+		var found model.Webhook
+		if err := config.conn.First(&found, "id = ?", webhook.ID).Error; err != nil {
+			t.Fatalf("failed to get the webhook: %v", err)
+		}
+		if found.DisabledAt == nil || found.Failures != 3 {
+			t.Fatalf("expected the webhook to be disabled after 3 failures, got %d failures", found.Failures)
+		}
+		got := deliveries(t)
+		if got[0].Status != model.DeliveryFailed || got[0].Attempts != 0 || got[0].LastError != errWebhookDisabled.Error() {
+			t.Errorf("expected the pending delivery to be abandoned, got %s after %d attempts", got[0].Status, got[0].Attempts)
+		}
+		if got[1].Status != model.DeliveryFailed || got[1].Attempts != 1 {
+			t.Errorf("expected the delivery to fail, got %s after %d attempts", got[1].Status, got[1].Attempts)
+		}
+
+		// The disabled webhooks get no more deliveries.
+		if err := db.Delete(ctx, record.ID, &DeleteOptions{Force: true}); err != nil {
+			t.Fatalf("failed to delete record: %v", err)
+		}
+		dispatch(t)
+		if got := deliveries(t); len(got) != 4 {
+			t.Errorf("expected no new delivery to the disabled webhook, got %d deliveries", len(got))
+		}
+	})
+}
+
+func Test_Deliverer_PrivateAddresses(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	t.Run("refuse to connect to private addresses", func(t *testing.T) {
+
+		deliverer := NewDeliverer(&DelivererConfig{DB: config.conn})
+		response, err := deliverer.client.Post(server.URL, "application/json", nil)
+		if err == nil {
+			response.Body.Close()
+		}
+		if !errors.Is(err, errPrivateAddress) {
+			t.Errorf("expected the connection to the loopback address to be refused, got %v", err)
+		}
+	})
+
+	t.Run("connect to private addresses when allowed", func(t *testing.T) {
+
+		deliverer := NewDeliverer(&DelivererConfig{DB: config.conn, AllowPrivate: true})
+		response, err := deliverer.client.Post(server.URL, "application/json", nil)
+		if err != nil {
+			t.Fatalf("failed to connect to the loopback address: %v", err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusNoContent {
+			t.Errorf("expected status %d, got %d", http.StatusNoContent, response.StatusCode)
+		}
+	})
+}
@@ -0,0 +1,251 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mrinalwahal/service/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Defaults of the dispatcher of the outbox.
+const (
+	DefaultDispatchInterval  = time.Second
+	DefaultDispatchBatchSize = 100
+	DefaultMinBackoff        = time.Second
+	DefaultMaxBackoff        = 10 * time.Minute
+)
+
+type DispatcherConfig struct {
+
+	// Database connection.
+	// The connection should already be open.
+	//
+	// This field is mandatory.
+	DB *gorm.DB
+
+	// Publisher publishes the events of the outbox.
+	//
+	// This field is mandatory.
+	Publisher Publisher
+
+	// Interval is the time between two polls of the outbox, when it has no more events to publish.
+	// Default: `DefaultDispatchInterval`
+	//
+	// This field is optional.
+	Interval time.Duration
+
+	// BatchSize is the maximum number of events claimed per poll.
+	// Default: `DefaultDispatchBatchSize`
+	//
+	// This field is optional.
+	BatchSize int
+
+	// MinBackoff is the delay before the first retry of an event which failed to be published.
+	// It doubles with every failed attempt, up to `MaxBackoff`.
+	// Default: `DefaultMinBackoff`
+	//
+	// This field is optional.
+	MinBackoff time.Duration
+
+	// MaxBackoff is the maximum delay between two attempts to publish an event.
+	// Default: `DefaultMaxBackoff`
+	//
+	// This field is optional.
+	MaxBackoff time.Duration
+
+	// MaxAttempts is the number of attempts after which an event which fails to be published is abandoned.
+	// Default: `DefaultMaxAttempts`
+	//
+	// This field is optional.
+	MaxAttempts int
+
+	// RowLevelSecurity runs the transactions as `BypassRole`, so they see the events of every user, when the
+	// database enforces the row level security.
+	// Default: `false`
+	//
+	// This field is optional.
+	RowLevelSecurity bool
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// Dispatcher publishes the events of the outbox, and marks them delivered, or failed after `MaxAttempts` attempts.
+//
+// It is a background job: `Run` polls the outbox until its context is cancelled. Several dispatchers can share the
+// outbox, e.g. one per replica of the service: on Postgres, every poll claims its events with `FOR UPDATE SKIP
+// LOCKED`, so an event is only claimed by one dispatcher at a time. The events are published in the order they
+// were written, but a failed event is retried later, after the next ones.
+type Dispatcher struct {
+	conn        *gorm.DB
+	publisher   Publisher
+	interval    time.Duration
+	batchSize   int
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	maxAttempts int
+	rls         bool
+	log         *slog.Logger
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// NewDispatcher creates a new instance of `Dispatcher`.
+func NewDispatcher(config *DispatcherConfig) *Dispatcher {
+	if config == nil || config.DB == nil || config.Publisher == nil {
+		panic("db: nil dispatcher config")
+	}
+
+	dispatcher := Dispatcher{
+		conn:        config.DB,
+		publisher:   config.Publisher,
+		interval:    config.Interval,
+		batchSize:   config.BatchSize,
+		minBackoff:  config.MinBackoff,
+		maxBackoff:  config.MaxBackoff,
+		maxAttempts: config.MaxAttempts,
+		rls:         config.RowLevelSecurity,
+		log:         config.Logger,
+		now:         config.DB.NowFunc,
+	}
+
+	if dispatcher.interval <= 0 {
+		dispatcher.interval = DefaultDispatchInterval
+	}
+	if dispatcher.batchSize <= 0 {
+		dispatcher.batchSize = DefaultDispatchBatchSize
+	}
+	if dispatcher.minBackoff <= 0 {
+		dispatcher.minBackoff = DefaultMinBackoff
+	}
+	if dispatcher.maxBackoff < dispatcher.minBackoff {
+		dispatcher.maxBackoff = max(DefaultMaxBackoff, dispatcher.minBackoff)
+	}
+	if dispatcher.maxAttempts <= 0 {
+		dispatcher.maxAttempts = DefaultMaxAttempts
+	}
+
+	if dispatcher.log == nil {
+		dispatcher.log = slog.Default()
+	}
+	dispatcher.log = dispatcher.log.With("job", "dispatch")
+
+	return &dispatcher
+}
+
+// Run publishes the pending events of the outbox until the context is cancelled.
+//
+// It polls the outbox again right away as long as the polls claim full batches, and waits for the interval
+// otherwise. A failed poll is logged and retried at the next interval, so it never stops the service.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+
+		claimed, err := d.Dispatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			d.log.ErrorContext(ctx, "failed to dispatch the events", "error", err)
+		}
+		if err != nil || claimed < d.batchSize {
+			timer.Reset(d.interval)
+		} else {
+			timer.Reset(0)
+		}
+	}
+}
+
+// Dispatch claims a batch of the pending events, publishes them, and marks them delivered, or schedules their
+// next attempt. It returns the number of events claimed.
+//
+// The events which have been attempted `maxAttempts` times are marked failed instead, and are never claimed again.
+// The events stay locked until they are marked, so they are never published by two dispatchers at once.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	var claimed int
+	err := d.conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := bypass(ctx, tx, d.rls); err != nil {
+			return err
+		}
+		now := d.now()
+
+		// GORM stores the timestamps in the local time zone, and SQLite compares them as text,
+		// so the current time must be in the same time zone to compare correctly.
+		var events []*model.Event
+		result := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+			Where(clause.Eq{Column: clause.Column{Name: "delivered_at"}, Value: nil}).
+			Where(clause.Eq{Column: clause.Column{Name: "failed_at"}, Value: nil}).
+			Where(clause.Lte{Column: clause.Column{Name: "next_attempt_at"}, Value: now.Local()}).
+			Order(clause.OrderBy{Columns: []clause.OrderByColumn{
+				{Column: clause.Column{Name: "created_at"}},
+				{Column: clause.Column{Name: "id"}},
+			}}).
+			Limit(d.batchSize).
+			Find(&events)
+		if result.Error != nil {
+			return result.Error
+		}
+		claimed = len(events)
+
+		// The publishers which write to the database can do it in the transaction, like `RunInTx`.
+		ctx := context.WithValue(ctx, txKey{}, tx)
+		for _, event := range events {
+			columns := map[string]any{
+				"attempts": event.Attempts + 1,
+			}
+			if err := d.publisher.Publish(ctx, event); err != nil {
+				columns["last_error"] = err.Error()
+				if event.Attempts+1 >= d.maxAttempts {
+					d.log.ErrorContext(ctx, "abandoned an event which failed to be published",
+						"event_id", event.ID,
+						"event_type", event.Type,
+						"attempts", event.Attempts+1,
+						"error", err,
+					)
+					columns["failed_at"] = now
+				} else {
+					retry := now.Add(d.backoff(event.Attempts + 1))
+					d.log.WarnContext(ctx, "failed to publish an event",
+						"event_id", event.ID,
+						"event_type", event.Type,
+						"attempts", event.Attempts+1,
+						"retry_at", retry,
+						"error", err,
+					)
+					columns["next_attempt_at"] = retry
+				}
+			} else {
+				columns["delivered_at"] = now
+				columns["last_error"] = ""
+			}
+			if err := tx.Model(event).UpdateColumns(columns).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return claimed, nil
+}
+
+// backoff returns the delay before the next attempt to publish an event, after the number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.minBackoff
+	for i := 1; i < attempts && delay < d.maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.maxBackoff)
+}
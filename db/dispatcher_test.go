@@ -0,0 +1,252 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+)
+
+func Test_NewDispatcher(t *testing.T) {
+
+	t.Run("nil config", func(t *testing.T) {
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("NewDispatcher() did not panic")
+			}
+		}()
+
+		NewDispatcher(nil)
+	})
+
+	t.Run("default options", func(t *testing.T) {
+
+		config := configure(t)
+		dispatcher := NewDispatcher(&DispatcherConfig{
+			DB:        config.conn,
+			Publisher: NewMemoryPublisher(),
+		})
+		if dispatcher.interval != DefaultDispatchInterval || dispatcher.batchSize != DefaultDispatchBatchSize {
+			t.Errorf("NewDispatcher() interval = %v, batch size = %d", dispatcher.interval, dispatcher.batchSize)
+		}
+		if dispatcher.minBackoff != DefaultMinBackoff || dispatcher.maxBackoff != DefaultMaxBackoff {
+			t.Errorf("NewDispatcher() backoff = [%v, %v]", dispatcher.minBackoff, dispatcher.maxBackoff)
+		}
+		if dispatcher.maxAttempts != DefaultMaxAttempts {
+			t.Errorf("NewDispatcher() max attempts = %d", dispatcher.maxAttempts)
+		}
+	})
+}
+
+func Test_Dispatcher_backoff(t *testing.T) {
+
+	dispatcher := &Dispatcher{
+		minBackoff: time.Second,
+		maxBackoff: 10 * time.Second,
+	}
+	tests := map[int]time.Duration{
+		1:  time.Second,
+		2:  2 * time.Second,
+		4:  8 * time.Second,
+		5:  10 * time.Second,
+		64: 10 * time.Second,
+	}
+	for attempts, want := range tests {
+		if got := dispatcher.backoff(attempts); got != want {
+			t.Errorf("Dispatcher.backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func Test_Database_Outbox(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Initialize the database.
+	db := &sqldb{
+		conn: config.conn,
+	}
+
+	owner := uuid.New()
+	ctx := context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+		XUserID: owner,
+	})
+	ctx = context.WithValue(ctx, middleware.XRequestID, "test-request")
+
+	// pending returns the events of the outbox which have not been delivered yet.
+	pending := func(t *testing.T) []*model.Event {
+		var events []*model.Event
+		if err := config.conn.Where("delivered_at IS NULL").Order("created_at, id").Find(&events).Error; err != nil {
+			t.Fatalf("failed to get the events: %v", err)
+		}
+		return events
+	}
+
+	publisher := NewMemoryPublisher()
+	dispatcher := NewDispatcher(&DispatcherConfig{
+		DB:         config.conn,
+		Publisher:  publisher,
+		BatchSize:  2,
+		MinBackoff: time.Minute,
+	})
+
+	t.Run("write the events of the changes in their transaction", func(t *testing.T) {
+
+		record, err := db.Create(ctx, &CreateOptions{Title: "Test Record", UserID: owner})
+		if err != nil {
+			t.Fatalf("failed to create record: %v", err)
+		}
+		if _, err := db.Update(ctx, record.ID, &UpdateOptions{Title: "Updated Record"}); err != nil {
+			t.Fatalf("failed to update record: %v", err)
+		}
+		if err := db.Delete(ctx, record.ID, &DeleteOptions{Force: true}); err != nil {
+			t.Fatalf("failed to delete record: %v", err)
+		}
+
+		// A rolled back change writes no event.
+		errRollback := errors.New("rollback")
+		if err := db.RunInTx(ctx, func(ctx context.Context) error {
+			if _, err := db.Create(ctx, &CreateOptions{Title: "Rolled Back", UserID: owner}); err != nil {
+				return err
+			}
+			return errRollback
+		}); err != errRollback {
+			t.Fatalf("db.RunInTx() error = %v, want %v", err, errRollback)
+		}
+
+		events := pending(t)
+		types := []string{model.EventRecordCreated, model.EventRecordUpdated, model.EventRecordDeleted}
+		if len(events) != len(types) {
+			t.Fatalf("expected %d events, got %d", len(types), len(events))
+		}
+		for i, event := range events {
+			if event.Type != types[i] || event.RecordID != record.ID {
+				t.Errorf("events[%d] = %s of %v, want %s of %v", i, event.Type, event.RecordID, types[i], record.ID)
+			}
+			if event.ActorID == nil || *event.ActorID != owner || event.RequestID != "test-request" {
+				t.Errorf("events[%d] was made by %v in %q", i, event.ActorID, event.RequestID)
+			}
+		}
+		if events[2].Data.Title != "Updated Record" {
+			t.Errorf("expected the event of the forced deletion to hold the deleted record, got %q", events[2].Data.Title)
+		}
+	})
+
+	t.Run("retry the events which failed to be published later", func(t *testing.T) {
+
+		publisher.Fail(errors.New("broker unavailable"))
+		claimed, err := dispatcher.Dispatch(context.Background())
+		if err != nil {
+			t.Fatalf("Dispatcher.Dispatch() error = %v", err)
+		}
+		if claimed != 2 {
+			t.Errorf("Dispatcher.Dispatch() claimed %d events, want 2", claimed)
+		}
+
+		events := pending(t)
+		if events[0].Attempts != 1 || events[0].LastError != "broker unavailable" || !events[0].NextAttemptAt.After(time.Now()) {
+			t.Errorf("expected the failed event to be scheduled for a retry, got %d attempts at %v", events[0].Attempts, events[0].NextAttemptAt)
+		}
+
+		// Only the event which was not claimed yet is due.
+		publisher.Fail(nil)
+		claimed, err = dispatcher.Dispatch(context.Background())
+		if err != nil {
+			t.Fatalf("Dispatcher.Dispatch() error = %v", err)
+		}
+		if claimed != 1 || len(publisher.Events()) != 1 || publisher.Events()[0].Type != model.EventRecordDeleted {
+			t.Errorf("expected only the due event to be published, claimed %d", claimed)
+		}
+	})
+
+	t.Run("publish the events once their retry is due", func(t *testing.T) {
+
+		dispatcher.now = func() time.Time { return time.Now().Add(time.Hour) }
+		t.Cleanup(func() { dispatcher.now = time.Now })
+
+		if _, err := dispatcher.Dispatch(context.Background()); err != nil {
+			t.Fatalf("Dispatcher.Dispatch() error = %v", err)
+		}
+		if events := pending(t); len(events) != 0 {
+			t.Errorf("expected every event to be delivered, %d pending", len(events))
+		}
+		if got := len(publisher.Events()); got != 3 {
+			t.Errorf("expected 3 events to be published, got %d", got)
+		}
+	})
+
+	t.Run("run until the context is cancelled", func(t *testing.T) {
+
+		if _, err := db.BatchCreate(ctx, &BatchCreateOptions{Requests: []*CreateOptions{
+			{Title: "January", UserID: owner},
+			{Title: "February", UserID: owner},
+			{Title: "March", UserID: owner},
+		}}); err != nil {
+			t.Fatalf("failed to create records: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- dispatcher.Run(ctx)
+		}()
+
+		// The events are published in successive batches, without waiting for the interval.
+		deadline := time.Now().Add(time.Second)
+		for len(publisher.Events()) < 6 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Dispatcher.Run() error = %v", err)
+		}
+		if got := len(publisher.Events()); got != 6 {
+			t.Errorf("expected 6 events to be published, got %d", got)
+		}
+	})
+
+	t.Run("abandon the events which failed to be published too many times", func(t *testing.T) {
+
+		record, err := db.Create(ctx, &CreateOptions{Title: "Rejected Record", UserID: owner})
+		if err != nil {
+			t.Fatalf("failed to create record: %v", err)
+		}
+
+		rejecting := NewMemoryPublisher()
+		rejecting.Fail(errors.New("event rejected"))
+		dispatcher := NewDispatcher(&DispatcherConfig{
+			DB:          config.conn,
+			Publisher:   rejecting,
+			MinBackoff:  time.Minute,
+			MaxAttempts: 2,
+		})
+
+		// The event is retried until its last attempt.
+		for attempt := 1; attempt <= 2; attempt++ {
+			dispatcher.now = func() time.Time { return time.Now().Add(time.Duration(attempt) * time.Hour) }
+			if claimed, err := dispatcher.Dispatch(context.Background()); err != nil || claimed != 1 {
+				t.Fatalf("Dispatcher.Dispatch() claimed %d events, error = %v", claimed, err)
+			}
+		}
+
+		var event model.Event
+		if err := config.conn.Where(&model.Event{RecordID: record.ID}).First(&event).Error; err != nil {
+			t.Fatalf("failed to get the event: %v", err)
+		}
+		if event.Attempts != 2 || event.FailedAt == nil || event.DeliveredAt != nil || event.LastError != "event rejected" {
+			t.Errorf("expected the event to be failed after 2 attempts, got %d attempts, failed at %v", event.Attempts, event.FailedAt)
+		}
+
+		// The failed event is never claimed again.
+		dispatcher.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+		if claimed, err := dispatcher.Dispatch(context.Background()); err != nil || claimed != 0 {
+			t.Errorf("Dispatcher.Dispatch() claimed %d events, error = %v, want none", claimed, err)
+		}
+	})
+}
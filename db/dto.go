@@ -1,7 +1,14 @@
 package db
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"gorm.io/gorm/clause"
 )
 
 // CreateOptions holds the options for creating a new record.
@@ -29,19 +36,94 @@ type ListOptions struct {
 
 	//	Title of the record.
 	Title string
-	//	Skip for pagination.
-	Skip int
-	//	Limit for pagination.
-	Limit int
-	//	Order by field.
+
+	//	Filter is an AIP-160 filter expression on the "title", "created_at", "updated_at" and "user_id" fields.
+	//
+	//	Example: `created_at >= "2024-01-01T00:00:00Z" AND title:report`
+	Filter string
+
+	//	PageSize is the maximum number of records to return.
+	//	Default: `DefaultPageSize`. Page sizes larger than `MaxPageSize` are coerced to it.
+	PageSize int
+
+	//	PageToken is the `next_page_token` of the previous page.
+	//	It must be used with the same options as the previous page.
+	PageToken string
+
+	//	OrderBy is an AIP-132 `order_by` expression: a comma separated list of fields, each optionally
+	//	followed by "asc" or "desc". The sortable fields are "created_at", "updated_at" and "title".
+	//	The records are always sorted by ID last, so their order is stable.
+	//	Default: "created_at"
+	//
+	//	Example: "title desc, created_at"
 	OrderBy string
-	//	Order by direction.
-	OrderDirection string
+
+	//	ShowDeleted includes the soft-deleted records in the listing.
+	//	Default: false
+	ShowDeleted bool
 }
 
 func (o *ListOptions) validate() error {
-	if o.Skip < 0 ||
-		o.Limit < 0 || o.Limit > 100 {
+	if o.PageSize < 0 {
+		return ErrInvalidFilters
+	}
+	if _, err := o.orders(); err != nil {
+		return err
+	}
+	if _, err := o.condition(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// pageSize returns the number of records to return in the page.
+func (o *ListOptions) pageSize() int {
+	return pageSize(o.PageSize)
+}
+
+// condition returns the condition of the filter, or nil when there is no filter.
+func (o *ListOptions) condition() (clause.Expression, error) {
+	return compileFilter(o.Filter, filterables)
+}
+
+// orders returns the fields to sort the records on.
+func (o *ListOptions) orders() ([]order, error) {
+	return parseOrderBy(o.OrderBy, sortables)
+}
+
+// GetOptions holds the options for getting a record.
+type GetOptions struct {
+
+	//	ShowDeleted returns the record even if it has been soft-deleted.
+	//	Default: false
+	ShowDeleted bool
+}
+
+// SearchOptions holds the options for searching records.
+type SearchOptions struct {
+
+	//	Query is the text to search for in the titles of the records.
+	//	The records matching all of its words are returned, most relevant first.
+	//
+	//	Example: "quarterly report"
+	Query string
+
+	//	PageSize is the maximum number of records to return.
+	//	Default: `DefaultPageSize`. Page sizes larger than `MaxPageSize` are coerced to it.
+	PageSize int
+}
+
+// MaxQueryLength is the maximum length of a search query, in bytes.
+const MaxQueryLength = 256
+
+func (o *SearchOptions) validate() error {
+	if strings.TrimSpace(o.Query) == "" {
+		return &FieldError{Field: "q", Reason: "is required"}
+	}
+	if len(o.Query) > MaxQueryLength {
+		return &FieldError{Field: "q", Reason: fmt.Sprintf("is longer than %d bytes", MaxQueryLength)}
+	}
+	if o.PageSize < 0 {
 		return ErrInvalidFilters
 	}
 	return nil
@@ -52,11 +134,346 @@ type UpdateOptions struct {
 
 	//	Title of the record.
 	Title string
+
+	//	UpdateMask is the AIP-134 field mask of the fields to update. The other fields of the options are ignored.
+	//	The immutable fields, "id", "user_id" and "created_at", cannot be updated.
+	//	Default: every updatable field, like `[]string{"*"}`.
+	//
+	//	Example: `[]string{"title"}`
+	UpdateMask []string
+
+	//	Version is the version of the record the update is based on.
+	//	If it is set and the record is at another version, the record is not updated and `ErrVersionMismatch`
+	//	is returned, so concurrent updates cannot overwrite each other.
+	//	Default: 0, which updates any version.
+	Version int64
 }
 
 func (o *UpdateOptions) validate() error {
-	if o.Title == "" {
-		return ErrInvalidTitle
+	if o.Version < 0 {
+		return ErrInvalidOptions
+	}
+	if _, err := o.columns(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// columns returns the values of the fields of the update mask, by column.
+func (o *UpdateOptions) columns() (map[string]any, error) {
+	fields, err := parseUpdateMask(o.UpdateMask, updatables)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]any, len(fields))
+	for _, field := range fields {
+		value, err := updatables[field].value(o)
+		if err != nil {
+			return nil, err
+		}
+		columns[updatables[field].column] = value
+	}
+	return columns, nil
+}
+
+// DeleteOptions holds the options for deleting a record.
+type DeleteOptions struct {
+
+	//	Force permanently deletes the record, instead of soft-deleting it.
+	//	It also purges a record which has already been soft-deleted.
+	//	Default: false
+	Force bool
+
+	//	Version is the version of the record the deletion is based on.
+	//	If it is set and the record is at another version, the record is not deleted and `ErrVersionMismatch`
+	//	is returned.
+	//	Default: 0, which deletes any version.
+	Version int64
+}
+
+// PurgeOptions holds the options for purging the soft-deleted records.
+type PurgeOptions struct {
+
+	//	DeletedBefore selects the records soft-deleted before this time.
+	//
+	//	This field is mandatory.
+	DeletedBefore time.Time
+}
+
+func (o *PurgeOptions) validate() error {
+	if o.DeletedBefore.IsZero() {
+		return ErrInvalidFilters
+	}
+	return nil
+}
+
+// BatchCreateOptions holds the options for creating records in a batch.
+type BatchCreateOptions struct {
+
+	//	Requests are the options of the records to create, in order.
+	Requests []*CreateOptions
+}
+
+func (o *BatchCreateOptions) validate() error {
+	if len(o.Requests) == 0 {
+		return ErrInvalidOptions
+	}
+	var batch BatchError
+	for i, request := range o.Requests {
+		if request == nil {
+			batch.add(i, ErrInvalidOptions)
+		} else if err := request.validate(); err != nil {
+			batch.add(i, err)
+		}
+	}
+	return batch.err()
+}
+
+// BatchGetOptions holds the options for getting records in a batch.
+type BatchGetOptions struct {
+
+	//	IDs of the records to get, in order. An ID may be repeated.
+	IDs []uuid.UUID
+
+	//	ShowDeleted also returns the soft-deleted records.
+	//	Default: false
+	ShowDeleted bool
+}
+
+func (o *BatchGetOptions) validate() error {
+	if len(o.IDs) == 0 {
+		return ErrInvalidOptions
+	}
+	var batch BatchError
+	for i, id := range o.IDs {
+		if id == uuid.Nil {
+			batch.add(i, ErrInvalidRecordID)
+		}
+	}
+	return batch.err()
+}
+
+// BatchUpdateRequest holds the options for updating a record in a batch.
+type BatchUpdateRequest struct {
+
+	//	ID of the record to update.
+	ID uuid.UUID
+
+	UpdateOptions
+}
+
+// BatchUpdateOptions holds the options for updating records in a batch.
+type BatchUpdateOptions struct {
+
+	//	Requests are the updates of the records, in order. A record may only be updated once per batch.
+	Requests []*BatchUpdateRequest
+}
+
+func (o *BatchUpdateOptions) validate() error {
+	if len(o.Requests) == 0 {
+		return ErrInvalidOptions
+	}
+	var (
+		batch BatchError
+		seen  = make(map[uuid.UUID]bool, len(o.Requests))
+	)
+	for i, request := range o.Requests {
+		switch {
+		case request == nil:
+			batch.add(i, ErrInvalidOptions)
+		case request.ID == uuid.Nil:
+			batch.add(i, ErrInvalidRecordID)
+		case seen[request.ID]:
+			batch.add(i, ErrDuplicateRecordID)
+		default:
+			seen[request.ID] = true
+			if err := request.validate(); err != nil {
+				batch.add(i, err)
+			}
+		}
+	}
+	return batch.err()
+}
+
+// BatchDeleteOptions holds the options for deleting records in a batch.
+type BatchDeleteOptions struct {
+
+	//	IDs of the records to delete. A record may only be deleted once per batch.
+	IDs []uuid.UUID
+
+	//	Force permanently deletes the records, instead of soft-deleting them.
+	Force bool
+}
+
+func (o *BatchDeleteOptions) validate() error {
+	if len(o.IDs) == 0 {
+		return ErrInvalidOptions
+	}
+	var (
+		batch BatchError
+		seen  = make(map[uuid.UUID]bool, len(o.IDs))
+	)
+	for i, id := range o.IDs {
+		switch {
+		case id == uuid.Nil:
+			batch.add(i, ErrInvalidRecordID)
+		case seen[id]:
+			batch.add(i, ErrDuplicateRecordID)
+		default:
+			seen[id] = true
+		}
+	}
+	return batch.err()
+}
+
+// ListRevisionsOptions holds the options for listing the revisions of a record.
+type ListRevisionsOptions struct {
+
+	//	PageSize is the maximum number of revisions to return.
+	//	Default: `DefaultPageSize`. Page sizes larger than `MaxPageSize` are coerced to it.
+	PageSize int
+
+	//	PageToken is the `next_page_token` of the previous page.
+	PageToken string
+}
+
+func (o *ListRevisionsOptions) validate() error {
+	if o.PageSize < 0 {
+		return ErrInvalidFilters
+	}
+	return nil
+}
+
+// DiffRevisionsOptions holds the options for comparing two revisions of a record.
+type DiffRevisionsOptions struct {
+
+	//	Against is the revision to compare the revision with.
+	//	Default: 0, which compares it with the previous revision, or with nothing for the first revision.
+	Against int64
+}
+
+func (o *DiffRevisionsOptions) validate() error {
+	if o.Against < 0 {
+		return ErrInvalidRevision
+	}
+	return nil
+}
+
+// RestoreOptions holds the options for restoring a record to one of its revisions.
+type RestoreOptions struct {
+
+	//	Revision to restore the record to.
+	Revision int64
+
+	//	Version is the version of the record the restoration is based on.
+	//	If it is set and the record is at another version, `ErrVersionMismatch` is returned.
+	//	Default: 0, which restores any version.
+	Version int64
+}
+
+func (o *RestoreOptions) validate() error {
+	if o.Revision < 1 {
+		return ErrInvalidRevision
+	}
+	if o.Version < 0 {
+		return ErrInvalidOptions
+	}
+	return nil
+}
+
+// MinSecretLength is the minimum length of the secrets of the webhooks.
+const MinSecretLength = 16
+
+// CreateWebhookOptions holds the options for creating a webhook.
+type CreateWebhookOptions struct {
+
+	//	ID of the user who owns the webhook.
+	UserID uuid.UUID
+
+	//	URL the events are posted to. It must be an absolute `http` or `https` URL, whose host is neither
+	//	"localhost" nor a loopback, private, link-local or unspecified address.
+	URL string
+
+	//	Events are the types of the events delivered to the webhook.
+	//	Default: every event.
+	Events []string
+
+	//	Secret is the key of the signatures of the deliveries, of at least `MinSecretLength` characters.
+	//	Default: a random secret.
+	Secret string
+}
+
+// validate the options. The URLs of private hosts are only valid when `allowPrivate` is set.
+func (o *CreateWebhookOptions) validate(allowPrivate bool) error {
+	if o.UserID == uuid.Nil {
+		return ErrInvalidUserID
+	}
+	target, err := url.Parse(o.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return ErrInvalidURL
+	}
+	if !allowPrivate && privateHost(target.Hostname()) {
+		return ErrPrivateURL
+	}
+	for _, eventType := range o.Events {
+		switch eventType {
+		case model.EventRecordCreated, model.EventRecordUpdated, model.EventRecordDeleted:
+		default:
+			return ErrInvalidEventType
+		}
+	}
+	if o.Secret != "" && len(o.Secret) < MinSecretLength {
+		return ErrInvalidSecret
+	}
+	return nil
+}
+
+// ListWebhooksOptions holds the options for listing the webhooks.
+type ListWebhooksOptions struct {
+
+	//	PageSize is the maximum number of webhooks to return.
+	//	Default: `DefaultPageSize`. Page sizes larger than `MaxPageSize` are coerced to it.
+	PageSize int
+
+	//	PageToken is the `next_page_token` of the previous page.
+	PageToken string
+}
+
+func (o *ListWebhooksOptions) validate() error {
+	if o.PageSize < 0 {
+		return ErrInvalidFilters
+	}
+	return nil
+}
+
+// ListDeliveriesOptions holds the options for listing the deliveries of the webhooks.
+type ListDeliveriesOptions struct {
+
+	//	WebhookID restricts the deliveries to those of a webhook.
+	//	Default: the deliveries of every webhook.
+	WebhookID uuid.UUID
+
+	//	Status restricts the deliveries to those with the status, e.g. `model.DeliveryFailed`.
+	//	Default: every status.
+	Status string
+
+	//	PageSize is the maximum number of deliveries to return.
+	//	Default: `DefaultPageSize`. Page sizes larger than `MaxPageSize` are coerced to it.
+	PageSize int
+
+	//	PageToken is the `next_page_token` of the previous page.
+	PageToken string
+}
+
+func (o *ListDeliveriesOptions) validate() error {
+	switch o.Status {
+	case "", model.DeliveryPending, model.DeliverySucceeded, model.DeliveryFailed:
+	default:
+		return &FieldError{Field: "status", Reason: fmt.Sprintf("unknown status %q", o.Status)}
+	}
+	if o.PageSize < 0 {
+		return ErrInvalidFilters
 	}
 	return nil
 }
@@ -1,6 +1,9 @@
 package db
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 var (
 	ErrInvalidOptions  = fmt.Errorf("invalid options")
@@ -9,4 +12,108 @@ var (
 	ErrInvalidTitle    = fmt.Errorf("invalid title")
 	ErrInvalidFilters  = fmt.Errorf("invalid filters")
 	ErrNoRowsAffected  = fmt.Errorf("no rows affected")
+	ErrVersionMismatch = fmt.Errorf("version mismatch")
+
+	ErrDuplicateRecordID = fmt.Errorf("duplicate record id")
+	ErrInvalidRevision   = fmt.Errorf("invalid revision")
+
+	ErrInvalidWebhookID = fmt.Errorf("invalid webhook id")
+	ErrInvalidURL       = fmt.Errorf("invalid url")
+	ErrPrivateURL       = fmt.Errorf("url of a private address")
+	ErrInvalidEventType = fmt.Errorf("invalid event type")
+	ErrInvalidSecret    = fmt.Errorf("invalid secret")
+
+	ErrInvalidPageToken  = fmt.Errorf("invalid page token")
+	ErrSearchUnavailable = fmt.Errorf("full-text search is unavailable")
+
+	ErrUnsupportedEngine = fmt.Errorf("unsupported engine")
+	ErrNoMigrations      = fmt.Errorf("no migrations to roll back")
+	ErrSchemaTooNew      = fmt.Errorf("database schema is newer than the binary")
 )
+
+// FieldError is the error of a single field of the options.
+//
+// It wraps `ErrInvalidFilters`, so `errors.Is(err, ErrInvalidFilters)` holds.
+type FieldError struct {
+
+	// Field is the name of the invalid field, as exposed by the API.
+	//
+	// Example: "order_by"
+	Field string
+
+	// Reason describes why the field is invalid.
+	//
+	// Example: `unknown field "name"`
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Reason)
+}
+
+func (e *FieldError) Unwrap() error {
+	return ErrInvalidFilters
+}
+
+// ItemError is the error of a single item of a batch.
+type ItemError struct {
+
+	// Index of the item in the batch.
+	Index int
+
+	// Err is the error of the item.
+	Err error
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError is the error of a batch whose items failed.
+//
+// The batches are all-or-nothing: when any item fails, none of them is applied. It holds the error of every item
+// which failed validation, or else of the item whose operation failed, and it wraps them, so
+// `errors.Is(err, ErrVersionMismatch)` holds when an item was at another version.
+type BatchError struct {
+
+	// Items are the errors of the failed items, by increasing index.
+	Items []*ItemError
+}
+
+func (e *BatchError) Error() string {
+	items := make([]string, len(e.Items))
+	for i, item := range e.Items {
+		items[i] = item.Error()
+	}
+	return fmt.Sprintf("batch failed: %s", strings.Join(items, "; "))
+}
+
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Items))
+	for i, item := range e.Items {
+		errs[i] = item
+	}
+	return errs
+}
+
+// add records the error of the item at the supplied index.
+func (e *BatchError) add(index int, err error) {
+	e.Items = append(e.Items, &ItemError{Index: index, Err: err})
+}
+
+// err returns the batch error, or nil if no item failed.
+func (e *BatchError) err() error {
+	if len(e.Items) == 0 {
+		return nil
+	}
+	return e
+}
+
+// itemError returns the batch error of the item at the supplied index.
+func itemError(index int, err error) error {
+	return &BatchError{Items: []*ItemError{{Index: index, Err: err}}}
+}
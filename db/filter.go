@@ -0,0 +1,165 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/pkg/filter"
+	"gorm.io/gorm/clause"
+)
+
+// kind is the type of a filterable field, which decides how its values are parsed and compared.
+type kind int
+
+const (
+	kindString kind = iota
+	kindTimestamp
+	kindUUID
+)
+
+// filterable is a field of the records which can be filtered on.
+type filterable struct {
+
+	// column is the column of the field.
+	column string
+
+	// kind is the type of the field.
+	kind kind
+}
+
+// filterables are the fields the records can be filtered on, by name.
+//
+// It is the allowlist of the filters of the records.
+var filterables = map[string]filterable{
+	"title":      {column: "title", kind: kindString},
+	"created_at": {column: "created_at", kind: kindTimestamp},
+	"updated_at": {column: "updated_at", kind: kindTimestamp},
+	"user_id":    {column: "user_id", kind: kindUUID},
+}
+
+// operators are the SQL operators of the filter comparators, besides `:`.
+var operators = map[filter.Operator]string{
+	filter.Equals:        "=",
+	filter.NotEquals:     "<>",
+	filter.LessThan:      "<",
+	filter.LessEquals:    "<=",
+	filter.GreaterThan:   ">",
+	filter.GreaterEquals: ">=",
+}
+
+// compileFilter parses an AIP-160 filter and translates it into a parameterized condition.
+//
+// The fields must be keys of the supplied allowlist. Their values are parsed according to their type:
+//
+//   - Strings support every comparator. `:` matches the values which contain the substring, ignoring the case.
+//   - Timestamps are RFC 3339 literals, e.g. `2024-01-01T00:00:00Z`, and support every comparator but `:`.
+//   - UUIDs support `=`, `!=` and `:`, which is the same as `=`.
+//
+// It returns a nil condition for an empty filter.
+//
+// Link: https://google.aip.dev/160
+func compileFilter(expression string, allowed map[string]filterable) (clause.Expression, error) {
+	expr, err := filter.Parse(expression)
+	if err != nil {
+		return nil, &FieldError{Field: "filter", Reason: err.Error()}
+	}
+	if expr == nil {
+		return nil, nil
+	}
+
+	var vars []any
+	sql, err := translate(expr, allowed, &vars)
+	if err != nil {
+		return nil, &FieldError{Field: "filter", Reason: err.Error()}
+	}
+	return clause.Expr{SQL: sql, Vars: vars}, nil
+}
+
+// translate translates the expression into SQL, appending its arguments to the supplied variables.
+//
+// The columns are appended as variables too, so they are quoted by the dialect.
+func translate(expr filter.Expr, allowed map[string]filterable, vars *[]any) (string, error) {
+	switch expr := expr.(type) {
+	case *filter.And:
+		return translateAll(expr.Exprs, " AND ", allowed, vars)
+	case *filter.Or:
+		return translateAll(expr.Exprs, " OR ", allowed, vars)
+	case *filter.Not:
+		sql, err := translate(expr.Expr, allowed, vars)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + sql + ")", nil
+	case *filter.Restriction:
+		return translateRestriction(expr, allowed, vars)
+	}
+	return "", fmt.Errorf("unsupported expression %s", expr)
+}
+
+func translateAll(exprs []filter.Expr, separator string, allowed map[string]filterable, vars *[]any) (string, error) {
+	parts := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		sql, err := translate(expr, allowed, vars)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, "("+sql+")")
+	}
+	return strings.Join(parts, separator), nil
+}
+
+func translateRestriction(r *filter.Restriction, allowed map[string]filterable, vars *[]any) (string, error) {
+	field, ok := allowed[r.Field]
+	if !ok {
+		return "", fmt.Errorf("unknown field %q", r.Field)
+	}
+	column := clause.Column{Name: field.column}
+
+	switch field.kind {
+	case kindString:
+		if r.Operator == filter.Has {
+			*vars = append(*vars, column, "%"+escapeLike(strings.ToLower(r.Value))+"%")
+			return `LOWER(?) LIKE ? ESCAPE '\'`, nil
+		}
+		*vars = append(*vars, column, r.Value)
+
+	case kindTimestamp:
+		if r.Operator == filter.Has {
+			return "", fmt.Errorf("operator %q is not supported by the timestamp field %q", r.Operator, r.Field)
+		}
+		value, err := time.Parse(time.RFC3339Nano, r.Value)
+		if err != nil {
+			return "", fmt.Errorf("invalid timestamp %q of field %q: expected an RFC 3339 timestamp, e.g. 2024-01-01T00:00:00Z", r.Value, r.Field)
+		}
+
+		// GORM stores the timestamps in the local time zone, and SQLite compares them as text,
+		// so the literal must be in the same time zone to compare correctly.
+		*vars = append(*vars, column, value.Local())
+
+	case kindUUID:
+		value, err := uuid.Parse(r.Value)
+		if err != nil {
+			return "", fmt.Errorf("invalid UUID %q of field %q", r.Value, r.Field)
+		}
+		operator := r.Operator
+		if operator == filter.Has {
+			operator = filter.Equals
+		}
+		if operator != filter.Equals && operator != filter.NotEquals {
+			return "", fmt.Errorf("operator %q is not supported by the UUID field %q", r.Operator, r.Field)
+		}
+		*vars = append(*vars, column, value)
+		return "? " + operators[operator] + " ?", nil
+	}
+	return "? " + operators[r.Operator] + " ?", nil
+}
+
+// likeEscaper escapes the wildcards of the LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike escapes the value so it matches itself in a LIKE pattern with `ESCAPE '\'`.
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+)
+
+func Test_Database_List_Filter(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Initialize the database.
+	db := &sqldb{
+		conn: config.conn,
+	}
+
+	ctx := context.Background()
+
+	// Seed the database with records of two users, created on different days.
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	users := []uuid.UUID{uuid.New(), uuid.New()}
+	seeds := []struct {
+		title   string
+		user    uuid.UUID
+		created time.Time
+	}{
+		{"Quarterly report", users[0], now.AddDate(0, 0, -10)},
+		{"Weekly report", users[0], now.AddDate(0, 0, -3)},
+		{"Invoice 100%", users[1], now.AddDate(0, 0, -1)},
+		{"invoice_draft", users[1], now},
+	}
+	for _, seed := range seeds {
+		record := model.Record{
+			Base: model.Base{
+				CreatedAt: seed.created.Local(),
+			},
+			Title:  seed.title,
+			UserID: seed.user,
+		}
+		if err := config.conn.Create(&record).Error; err != nil {
+			t.Fatalf("failed to seed the database: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		filter  string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "title equals",
+			filter: `title = "Weekly report"`,
+			want:   []string{"Weekly report"},
+		},
+		{
+			name:   "title contains, ignoring the case",
+			filter: `title:REPORT`,
+			want:   []string{"Quarterly report", "Weekly report"},
+		},
+		{
+			name:   "title contains a wildcard",
+			filter: `title:"%"`,
+			want:   []string{"Invoice 100%"},
+		},
+		{
+			name:   "title contains an underscore",
+			filter: `title:e_d`,
+			want:   []string{"invoice_draft"},
+		},
+		{
+			name:   "created in the last week with title containing report",
+			filter: fmt.Sprintf(`created_at >= %s AND title:report`, now.AddDate(0, 0, -7).Format(time.RFC3339)),
+			want:   []string{"Weekly report"},
+		},
+		{
+			name:   "created before a timestamp in another time zone",
+			filter: `created_at < "2024-05-29T13:00:00+02:00"`,
+			want:   []string{"Quarterly report"},
+		},
+		{
+			name:   "updated after a timestamp",
+			filter: `updated_at > 2000-01-01T00:00:00Z`,
+			want:   []string{"Invoice 100%", "Quarterly report", "Weekly report", "invoice_draft"},
+		},
+		{
+			name:   "user equals",
+			filter: fmt.Sprintf(`user_id = %s`, users[1]),
+			want:   []string{"Invoice 100%", "invoice_draft"},
+		},
+		{
+			name:   "user has",
+			filter: fmt.Sprintf(`user_id:%s`, users[0]),
+			want:   []string{"Quarterly report", "Weekly report"},
+		},
+		{
+			name:   "user not equals",
+			filter: fmt.Sprintf(`user_id != %s`, users[1]),
+			want:   []string{"Quarterly report", "Weekly report"},
+		},
+		{
+			name:   "negation",
+			filter: `NOT title:invoice`,
+			want:   []string{"Quarterly report", "Weekly report"},
+		},
+		{
+			name:   "disjunction",
+			filter: fmt.Sprintf(`title:invoice OR created_at < %s`, now.AddDate(0, 0, -5).Format(time.RFC3339)),
+			want:   []string{"Invoice 100%", "Quarterly report", "invoice_draft"},
+		},
+		{
+			name:    "unknown field",
+			filter:  `name = x`,
+			wantErr: true,
+		},
+		{
+			name:    "has on a timestamp",
+			filter:  `created_at:2024`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid timestamp",
+			filter:  `created_at > yesterday`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid UUID",
+			filter:  `user_id = nope`,
+			wantErr: true,
+		},
+		{
+			name:    "ordering on a UUID",
+			filter:  fmt.Sprintf(`user_id > %s`, users[0]),
+			wantErr: true,
+		},
+		{
+			name:    "syntax error",
+			filter:  `title =`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Only match the seeded records, whatever the other tests left in the database.
+			filter := fmt.Sprintf("(%s) AND (user_id = %s OR user_id = %s)", tt.filter, users[0], users[1])
+
+			records, _, err := db.List(ctx, &ListOptions{
+				Filter:   filter,
+				PageSize: MaxPageSize,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("db.List() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var fieldErr *FieldError
+				if !errors.As(err, &fieldErr) || fieldErr.Field != "filter" {
+					t.Errorf("db.List() error = %v, want a field error of filter", err)
+				}
+				return
+			}
+
+			got := make([]string, 0, len(records))
+			for _, record := range records {
+				got = append(got, record.Title)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("db.List() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("page token of a different filter", func(t *testing.T) {
+
+		_, token, err := db.List(ctx, &ListOptions{
+			Filter:   fmt.Sprintf(`user_id = %s`, users[0]),
+			PageSize: 1,
+		})
+		if err != nil {
+			t.Fatalf("failed to list records: %v", err)
+		}
+
+		_, _, err = db.List(ctx, &ListOptions{
+			Filter:    fmt.Sprintf(`user_id = %s`, users[1]),
+			PageSize:  1,
+			PageToken: token,
+		})
+		if err != ErrInvalidPageToken {
+			t.Errorf("db.List() error = %v, want %v", err, ErrInvalidPageToken)
+		}
+	})
+}
@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+	"gorm.io/gorm"
+)
+
+// migrations are the goose-format migrations in `./migrations`, embedded in the binary.
+//
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Migration is a versioned migration of the schema.
+type Migration struct {
+
+	// Version of the migration.
+	//
+	// Example: 20240409234208
+	Version int64
+
+	// Name of the migration file.
+	//
+	// Example: "20240409234208_init.sql"
+	Name string
+
+	// Applied reports whether the migration has been applied to the database.
+	Applied bool
+
+	// AppliedAt is the time the migration was applied at.
+	// It is zero for pending migrations and for the results of `Down`.
+	AppliedAt time.Time
+}
+
+type MigratorConfig struct {
+
+	// Database connection.
+	// The connection should already be open.
+	//
+	// This field is mandatory.
+	DB *gorm.DB
+
+	// Lock makes the migrator hold a Postgres session-level advisory lock while it applies or rolls back migrations.
+	// It lets several replicas migrate the same database on startup without racing each other.
+	// Default: false
+	//
+	// This field is optional.
+	Lock bool
+}
+
+// MigrationState is the version of the database schema compared to the migrations embedded in the binary.
+type MigrationState struct {
+
+	// Version is the most recent migration applied to the database.
+	// It is 0 when no migration has been applied yet.
+	Version int64
+
+	// Latest is the most recent migration embedded in the binary.
+	Latest int64
+
+	// Pending is the number of embedded migrations which have not been applied yet.
+	Pending int
+}
+
+// String returns the state in a human-readable form.
+//
+// Example: "version 20240409234208, 1 pending"
+func (s MigrationState) String() string {
+	switch {
+	case s.Version > s.Latest:
+		return fmt.Sprintf("version %d, newer than %d", s.Version, s.Latest)
+	case s.Pending > 0:
+		return fmt.Sprintf("version %d, %d pending", s.Version, s.Pending)
+	default:
+		return fmt.Sprintf("version %d, up to date", s.Version)
+	}
+}
+
+// Migrator applies and rolls back the embedded migrations.
+//
+// Only the Postgres engine has versioned migrations. The schema of the SQLite engines is created with `AutoMigrate`.
+type Migrator struct {
+	provider *goose.Provider
+}
+
+// NewMigrator creates a new instance of `Migrator`.
+func NewMigrator(config *MigratorConfig) (*Migrator, error) {
+	if config == nil || config.DB == nil {
+		return nil, ErrInvalidOptions
+	}
+	if config.DB.Name() != "postgres" {
+		return nil, ErrUnsupportedEngine
+	}
+
+	sqlDB, err := config.DB.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	fsys, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	options := []goose.ProviderOption{
+		goose.WithDisableGlobalRegistry(true),
+	}
+	if config.Lock {
+		locker, err := lock.NewPostgresSessionLocker()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, goose.WithSessionLocker(locker))
+	}
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, sqlDB, fsys, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		provider: provider,
+	}, nil
+}
+
+// Up applies all the pending migrations and returns them.
+//
+// It fails with `ErrSchemaTooNew` if the database has migrations the binary does not know about.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if _, err := m.State(ctx); err != nil {
+		return nil, err
+	}
+	results, err := m.provider.Up(ctx)
+	applied := make([]Migration, 0, len(results))
+	for _, result := range results {
+		if result.Error == nil {
+			applied = append(applied, newMigration(result.Source, true, time.Now()))
+		}
+	}
+	return applied, err
+}
+
+// Down rolls back the most recently applied migration and returns it.
+func (m *Migrator) Down(ctx context.Context) (Migration, error) {
+	result, err := m.provider.Down(ctx)
+	if err != nil {
+		if errors.Is(err, goose.ErrNoNextVersion) {
+			return Migration{}, ErrNoMigrations
+		}
+		return Migration{}, err
+	}
+	return newMigration(result.Source, false, time.Time{}), nil
+}
+
+// Redo rolls back the most recently applied migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) (Migration, error) {
+	if _, err := m.Down(ctx); err != nil {
+		return Migration{}, err
+	}
+	result, err := m.provider.UpByOne(ctx)
+	if err != nil {
+		return Migration{}, err
+	}
+	return newMigration(result.Source, true, time.Now()), nil
+}
+
+// Status returns every known migration along with whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.provider.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Migration, 0, len(statuses))
+	for _, status := range statuses {
+		list = append(list, newMigration(status.Source, status.State == goose.StateApplied, status.AppliedAt))
+	}
+	return list, nil
+}
+
+// State compares the database schema with the embedded migrations.
+//
+// It fails with `ErrSchemaTooNew` if the database has migrations the binary does not know about,
+// which happens when a newer release has already migrated the database.
+func (m *Migrator) State(ctx context.Context) (MigrationState, error) {
+	statuses, err := m.provider.Status(ctx)
+	if err != nil {
+		return MigrationState{}, err
+	}
+	version, err := m.provider.GetDBVersion(ctx)
+	if err != nil {
+		return MigrationState{}, err
+	}
+
+	state := MigrationState{
+		Version: version,
+	}
+	for _, status := range statuses {
+		state.Latest = max(state.Latest, status.Source.Version)
+		if status.State == goose.StatePending {
+			state.Pending++
+		}
+	}
+	if state.Version > state.Latest {
+		return state, fmt.Errorf("%w: database is at version %d, the binary knows up to %d", ErrSchemaTooNew, state.Version, state.Latest)
+	}
+	return state, nil
+}
+
+func newMigration(source *goose.Source, applied bool, at time.Time) Migration {
+	return Migration{
+		Version:   source.Version,
+		Name:      filepath.Base(source.Path),
+		Applied:   applied,
+		AppliedAt: at,
+	}
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func Test_NewMigrator(t *testing.T) {
+
+	t.Run("create migrator with nil config", func(t *testing.T) {
+
+		if _, err := NewMigrator(nil); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("NewMigrator() error = %v, want %v", err, ErrInvalidOptions)
+		}
+	})
+
+	t.Run("create migrator for sqlite", func(t *testing.T) {
+
+		conn, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+		if err != nil {
+			t.Fatalf("failed to open the database connection: %v", err)
+		}
+
+		if _, err := NewMigrator(&MigratorConfig{DB: conn}); !errors.Is(err, ErrUnsupportedEngine) {
+			t.Errorf("NewMigrator() error = %v, want %v", err, ErrUnsupportedEngine)
+		}
+	})
+
+	t.Run("migrations are embedded", func(t *testing.T) {
+
+		files, err := fs.Glob(migrations, "migrations/*.sql")
+		if err != nil {
+			t.Fatalf("failed to list the embedded migrations: %v", err)
+		}
+		if len(files) < 1 {
+			t.Fatalf("expected at least 1 embedded migration, got %d", len(files))
+		}
+	})
+}
+
+func Test_MigrationState_String(t *testing.T) {
+	tests := []struct {
+		name  string
+		state MigrationState
+		want  string
+	}{
+		{
+			name:  "up to date",
+			state: MigrationState{Version: 2, Latest: 2},
+			want:  "version 2, up to date",
+		},
+		{
+			name:  "pending",
+			state: MigrationState{Version: 1, Latest: 3, Pending: 2},
+			want:  "version 1, 2 pending",
+		},
+		{
+			name:  "newer than the binary",
+			state: MigrationState{Version: 4, Latest: 3},
+			want:  "version 4, newer than 3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.state.String(); got != tt.want {
+				t.Errorf("MigrationState.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Migrator_RowLevelSecurity(t *testing.T) {
+
+	// The cluster must not have been bootstrapped, so the migrations find no role of the jobs.
+	dsn := postgresDSN(t, postgresBareDSNEnv)
+	superuser := openPostgres(t, dsn)
+	suffix := uuid.NewString()[:8]
+
+	// database creates a database, dropped after the test, and returns a connection of the user to it.
+	database := func(name, user, password string) *gorm.DB {
+		if err := superuser.Exec(fmt.Sprintf(`CREATE DATABASE %q OWNER %q`, name, user)).Error; err != nil {
+			t.Fatalf("failed to create the database: %v", err)
+		}
+		t.Cleanup(func() {
+			superuser.Exec(fmt.Sprintf(`DROP DATABASE IF EXISTS %q WITH (FORCE)`, name))
+		})
+		u, err := url.Parse(dsn)
+		if err != nil {
+			t.Fatalf("%s must be a URL: %v", postgresBareDSNEnv, err)
+		}
+		if user != "" && password != "" {
+			u.User = url.UserPassword(user, password)
+		}
+		u.Path = "/" + name
+		return openPostgres(t, u.String())
+	}
+
+	// The user of the service, who is not a superuser, and the superuser.
+	service := "records_service_" + suffix
+	if err := superuser.Exec(fmt.Sprintf(`CREATE ROLE %q LOGIN PASSWORD '%s'`, service, service)).Error; err != nil {
+		t.Fatalf("failed to create the user of the service: %v", err)
+	}
+	t.Cleanup(func() {
+		superuser.Exec(fmt.Sprintf(`DROP ROLE IF EXISTS %q`, service))
+	})
+	var owner string
+	if err := superuser.Raw(`SELECT CURRENT_USER`).Scan(&owner).Error; err != nil {
+		t.Fatalf("failed to get the superuser: %v", err)
+	}
+	migrated := database("records_"+suffix, service, service)
+	privileged := database("records_superuser_"+suffix, owner, "")
+
+	t.Run("migrate as a user who cannot create the role of the jobs", func(t *testing.T) {
+
+		migratePostgres(t, migrated)
+
+		if enforced, err := RowLevelSecurity(context.Background(), migrated); err != nil || enforced {
+			t.Errorf("expected the migrations to skip the row level security, got %v, error = %v", enforced, err)
+		}
+	})
+
+	t.Run("migrate as a superuser", func(t *testing.T) {
+
+		migratePostgres(t, privileged)
+
+		if enforced, err := RowLevelSecurity(context.Background(), privileged); err != nil || !enforced {
+			t.Errorf("expected the migrations to enable the row level security, got %v, error = %v", enforced, err)
+		}
+	})
+
+	t.Run("enable the row level security once the role of the jobs is granted", func(t *testing.T) {
+
+		// As `scripts/rls.sql` does, once the migrations have created the role of the jobs.
+		if err := superuser.Exec(fmt.Sprintf(`GRANT %q TO %q`, BypassRole, service)).Error; err != nil {
+			t.Fatalf("failed to grant the role of the jobs: %v", err)
+		}
+		if err := migrated.Exec(`SELECT "public"."records_enable_rls"()`).Error; err != nil {
+			t.Fatalf("failed to enable the row level security: %v", err)
+		}
+		if enforced, err := RowLevelSecurity(context.Background(), migrated); err != nil || !enforced {
+			t.Errorf("expected the row level security to be enabled, got %v, error = %v", enforced, err)
+		}
+	})
+}
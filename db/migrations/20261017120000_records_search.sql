@@ -0,0 +1,11 @@
+-- +goose Up
+-- modify "records" table
+ALTER TABLE "public"."records" ADD COLUMN "search" tsvector GENERATED ALWAYS AS (to_tsvector('english', "title")) STORED;
+-- create index "idx_records_search" to table: "records"
+CREATE INDEX "idx_records_search" ON "public"."records" USING GIN ("search");
+
+-- +goose Down
+-- reverse: create index "idx_records_search" to table: "records"
+DROP INDEX "public"."idx_records_search";
+-- reverse: modify "records" table
+ALTER TABLE "public"."records" DROP COLUMN "search";
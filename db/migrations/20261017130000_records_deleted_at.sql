@@ -0,0 +1,7 @@
+-- +goose Up
+-- create index "idx_records_deleted_at" to table: "records"
+CREATE INDEX "idx_records_deleted_at" ON "public"."records" ("deleted_at") WHERE ("deleted_at" IS NOT NULL);
+
+-- +goose Down
+-- reverse: create index "idx_records_deleted_at" to table: "records"
+DROP INDEX "public"."idx_records_deleted_at";
@@ -0,0 +1,7 @@
+-- +goose Up
+-- modify "records" table
+ALTER TABLE "public"."records" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
+
+-- +goose Down
+-- reverse: modify "records" table
+ALTER TABLE "public"."records" DROP COLUMN "version";
@@ -0,0 +1,16 @@
+-- +goose Up
+-- create "revisions" table
+CREATE TABLE "public"."revisions" (
+  "record_id" uuid NOT NULL,
+  "revision" bigint NOT NULL,
+  "action" text NOT NULL,
+  "snapshot" jsonb NOT NULL,
+  "actor_id" uuid NULL,
+  "request_id" text NULL,
+  "created_at" timestamptz NULL,
+  PRIMARY KEY ("record_id", "revision")
+);
+
+-- +goose Down
+-- reverse: create "revisions" table
+DROP TABLE "public"."revisions";
@@ -0,0 +1,24 @@
+-- +goose Up
+-- create "outbox" table
+CREATE TABLE "public"."outbox" (
+  "id" uuid NOT NULL,
+  "type" text NOT NULL,
+  "record_id" uuid NOT NULL,
+  "data" jsonb NOT NULL,
+  "actor_id" uuid NULL,
+  "request_id" text NULL,
+  "created_at" timestamptz NULL,
+  "attempts" bigint NOT NULL DEFAULT 0,
+  "next_attempt_at" timestamptz NULL,
+  "delivered_at" timestamptz NULL,
+  "last_error" text NULL,
+  PRIMARY KEY ("id")
+);
+-- create index "idx_outbox_pending" to table: "outbox"
+CREATE INDEX "idx_outbox_pending" ON "public"."outbox" ("next_attempt_at") WHERE (delivered_at IS NULL);
+
+-- +goose Down
+-- reverse: create index "idx_outbox_pending" to table: "outbox"
+DROP INDEX "public"."idx_outbox_pending";
+-- reverse: create "outbox" table
+DROP TABLE "public"."outbox";
@@ -0,0 +1,48 @@
+-- +goose Up
+-- create "webhooks" table
+CREATE TABLE "public"."webhooks" (
+  "id" uuid NOT NULL,
+  "user_id" uuid NOT NULL,
+  "url" text NOT NULL,
+  "events" jsonb NOT NULL,
+  "secret" text NOT NULL,
+  "failures" bigint NOT NULL DEFAULT 0,
+  "disabled_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- create index "idx_webhooks_user_id" to table: "webhooks"
+CREATE INDEX "idx_webhooks_user_id" ON "public"."webhooks" ("user_id");
+-- create "deliveries" table
+CREATE TABLE "public"."deliveries" (
+  "id" uuid NOT NULL,
+  "webhook_id" uuid NOT NULL,
+  "event_id" uuid NOT NULL,
+  "event" jsonb NOT NULL,
+  "status" text NOT NULL,
+  "attempts" bigint NOT NULL DEFAULT 0,
+  "response_status" bigint NULL,
+  "last_error" text NULL,
+  "next_attempt_at" timestamptz NULL,
+  "delivered_at" timestamptz NULL,
+  "created_at" timestamptz NULL,
+  "updated_at" timestamptz NULL,
+  PRIMARY KEY ("id")
+);
+-- create index "idx_deliveries_event" to table: "deliveries"
+CREATE UNIQUE INDEX "idx_deliveries_event" ON "public"."deliveries" ("webhook_id", "event_id");
+-- create index "idx_deliveries_pending" to table: "deliveries"
+CREATE INDEX "idx_deliveries_pending" ON "public"."deliveries" ("next_attempt_at") WHERE ((status)::text = 'pending'::text);
+
+-- +goose Down
+-- reverse: create index "idx_deliveries_pending" to table: "deliveries"
+DROP INDEX "public"."idx_deliveries_pending";
+-- reverse: create index "idx_deliveries_event" to table: "deliveries"
+DROP INDEX "public"."idx_deliveries_event";
+-- reverse: create "deliveries" table
+DROP TABLE "public"."deliveries";
+-- reverse: create index "idx_webhooks_user_id" to table: "webhooks"
+DROP INDEX "public"."idx_webhooks_user_id";
+-- reverse: create "webhooks" table
+DROP TABLE "public"."webhooks";
@@ -0,0 +1,88 @@
+-- +goose Up
+-- create function "records_enable_rls", which enables the row level security of the records and their snapshots
+-- it is idempotent, so "db/scripts/rls.sql" runs it again once it has created the role of the jobs
+-- the data migrations of these tables must run as "records_jobs" once it has run
+-- +goose StatementBegin
+CREATE FUNCTION "public"."records_enable_rls"() RETURNS void LANGUAGE plpgsql AS $$
+BEGIN
+  -- let the role of the jobs, which bypasses the row level security, use the tables
+  GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA "public" TO "records_jobs";
+  ALTER DEFAULT PRIVILEGES IN SCHEMA "public" GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO "records_jobs";
+  -- a transaction only sees and changes the records of the user in "app.user_id", and none without it
+  ALTER TABLE "public"."records" ENABLE ROW LEVEL SECURITY;
+  ALTER TABLE "public"."records" FORCE ROW LEVEL SECURITY;
+  DROP POLICY IF EXISTS "records_owner" ON "public"."records";
+  CREATE POLICY "records_owner" ON "public"."records"
+    USING ("user_id" = NULLIF(current_setting('app.user_id', true), '')::uuid)
+    WITH CHECK ("user_id" = NULLIF(current_setting('app.user_id', true), '')::uuid);
+  -- the revisions, the events and the deliveries hold snapshots of the records, restricted to their owner
+  -- they are kept by the owner of their snapshot, even once the record itself is gone
+  ALTER TABLE "public"."revisions" ENABLE ROW LEVEL SECURITY;
+  ALTER TABLE "public"."revisions" FORCE ROW LEVEL SECURITY;
+  DROP POLICY IF EXISTS "revisions_owner" ON "public"."revisions";
+  CREATE POLICY "revisions_owner" ON "public"."revisions"
+    USING (("snapshot" ->> 'user_id')::uuid = NULLIF(current_setting('app.user_id', true), '')::uuid)
+    WITH CHECK (("snapshot" ->> 'user_id')::uuid = NULLIF(current_setting('app.user_id', true), '')::uuid);
+  ALTER TABLE "public"."outbox" ENABLE ROW LEVEL SECURITY;
+  ALTER TABLE "public"."outbox" FORCE ROW LEVEL SECURITY;
+  DROP POLICY IF EXISTS "outbox_owner" ON "public"."outbox";
+  CREATE POLICY "outbox_owner" ON "public"."outbox"
+    USING (("data" ->> 'user_id')::uuid = NULLIF(current_setting('app.user_id', true), '')::uuid)
+    WITH CHECK (("data" ->> 'user_id')::uuid = NULLIF(current_setting('app.user_id', true), '')::uuid);
+  ALTER TABLE "public"."deliveries" ENABLE ROW LEVEL SECURITY;
+  ALTER TABLE "public"."deliveries" FORCE ROW LEVEL SECURITY;
+  DROP POLICY IF EXISTS "deliveries_owner" ON "public"."deliveries";
+  CREATE POLICY "deliveries_owner" ON "public"."deliveries"
+    USING (("event" -> 'data' ->> 'user_id')::uuid = NULLIF(current_setting('app.user_id', true), '')::uuid)
+    WITH CHECK (("event" -> 'data' ->> 'user_id')::uuid = NULLIF(current_setting('app.user_id', true), '')::uuid);
+END
+$$;
+-- +goose StatementEnd
+-- enable the row level security, if the user which applies the migrations can use the role of the jobs
+-- only superusers can create roles with BYPASSRLS, so the other users skip it until "db/scripts/rls.sql" is run
+-- +goose StatementBegin
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT FROM "pg_catalog"."pg_roles" WHERE "rolname" = 'records_jobs') THEN
+    IF NOT (SELECT "rolsuper" FROM "pg_catalog"."pg_roles" WHERE "rolname" = CURRENT_USER) THEN
+      RAISE NOTICE 'role "records_jobs" does not exist, so the row level security is not enabled: run "db/scripts/rls.sql" as a superuser to enable it';
+      RETURN;
+    END IF;
+    CREATE ROLE "records_jobs" NOLOGIN BYPASSRLS;
+  END IF;
+  IF NOT pg_has_role(CURRENT_USER, 'records_jobs', 'MEMBER') THEN
+    RAISE NOTICE 'role "records_jobs" is not granted to "%", so the row level security is not enabled: run "db/scripts/rls.sql" as a superuser to enable it', CURRENT_USER;
+    RETURN;
+  END IF;
+  PERFORM "public"."records_enable_rls"();
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- reverse: enable the row level security
+-- the role of the jobs is left to the administrator, who may drop it with "DROP ROLE"
+DROP POLICY IF EXISTS "deliveries_owner" ON "public"."deliveries";
+ALTER TABLE "public"."deliveries" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "public"."deliveries" DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS "outbox_owner" ON "public"."outbox";
+ALTER TABLE "public"."outbox" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "public"."outbox" DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS "revisions_owner" ON "public"."revisions";
+ALTER TABLE "public"."revisions" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "public"."revisions" DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS "records_owner" ON "public"."records";
+ALTER TABLE "public"."records" NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE "public"."records" DISABLE ROW LEVEL SECURITY;
+-- +goose StatementBegin
+DO $$
+BEGIN
+  IF EXISTS (SELECT FROM "pg_catalog"."pg_roles" WHERE "rolname" = 'records_jobs') THEN
+    ALTER DEFAULT PRIVILEGES IN SCHEMA "public" REVOKE SELECT, INSERT, UPDATE, DELETE ON TABLES FROM "records_jobs";
+    REVOKE ALL ON ALL TABLES IN SCHEMA "public" FROM "records_jobs";
+  END IF;
+END
+$$;
+-- +goose StatementEnd
+-- reverse: create function "records_enable_rls"
+DROP FUNCTION "public"."records_enable_rls"();
@@ -0,0 +1,15 @@
+-- +goose Up
+-- modify "outbox" table
+ALTER TABLE "public"."outbox" ADD COLUMN "failed_at" timestamptz NULL;
+-- drop index "idx_outbox_pending" from table: "outbox"
+DROP INDEX "public"."idx_outbox_pending";
+-- create index "idx_outbox_pending" to table: "outbox"
+CREATE INDEX "idx_outbox_pending" ON "public"."outbox" ("next_attempt_at") WHERE ((delivered_at IS NULL) AND (failed_at IS NULL));
+
+-- +goose Down
+-- reverse: create index "idx_outbox_pending" to table: "outbox"
+DROP INDEX "public"."idx_outbox_pending";
+-- reverse: drop index "idx_outbox_pending" from table: "outbox"
+CREATE INDEX "idx_outbox_pending" ON "public"."outbox" ("next_attempt_at") WHERE (delivered_at IS NULL);
+-- reverse: modify "outbox" table
+ALTER TABLE "public"."outbox" DROP COLUMN "failed_at";
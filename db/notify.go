@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/mrinalwahal/service/model"
+	"gorm.io/gorm"
+)
+
+// DefaultNotifyChannel is the default Postgres channel of the events of the records.
+const DefaultNotifyChannel = "records_events"
+
+// maxNotifyPayload is the maximum size of the payload of a Postgres notification, in bytes.
+const maxNotifyPayload = 7999
+
+var (
+	ErrNotifyUnsupported   = fmt.Errorf("notifications are only supported by postgres")
+	ErrNotifyPayloadTooBig = fmt.Errorf("notification payload too big")
+)
+
+type NotifierConfig struct {
+
+	// Database connection, to a Postgres database.
+	// The connection should already be open.
+	//
+	// This field is mandatory.
+	DB *gorm.DB
+
+	// Channel is the Postgres channel the events are sent on.
+	// Every instance of the service must use the same channel.
+	// Default: `DefaultNotifyChannel`
+	//
+	// This field is optional.
+	Channel string
+}
+
+// Notifier broadcasts the events of the records to every instance of the service with Postgres `NOTIFY`, and
+// receives them with `LISTEN`.
+//
+// It implements `service.Broadcaster`.
+type Notifier struct {
+	conn    *gorm.DB
+	channel string
+}
+
+// NewNotifier creates a new instance of `Notifier`.
+func NewNotifier(config *NotifierConfig) *Notifier {
+	if config == nil || config.DB == nil {
+		panic("db: nil notifier config")
+	}
+
+	notifier := Notifier{
+		conn:    config.DB,
+		channel: config.Channel,
+	}
+
+	if notifier.channel == "" {
+		notifier.channel = DefaultNotifyChannel
+	}
+
+	return &notifier
+}
+
+// Broadcast sends the event on the channel, to every listener, including the ones of this instance.
+//
+// The notification is sent in the transaction of the context, if any, e.g. the one of the dispatcher which marks
+// the event published, so it is only delivered once that transaction commits. Otherwise, it is delivered right
+// away. Postgres limits the payload of the notifications to 8000 bytes, so larger events are rejected with
+// `ErrNotifyPayloadTooBig`.
+func (n *Notifier) Broadcast(ctx context.Context, event *model.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxNotifyPayload {
+		return ErrNotifyPayloadTooBig
+	}
+
+	// Run in a savepoint of the transaction of the context, if any, so a failure does not abort it.
+	return session(ctx, n.conn).Transaction(func(tx *gorm.DB) error {
+		return tx.Exec("SELECT pg_notify(?, ?)", n.channel, string(payload)).Error
+	})
+}
+
+// Listen calls the function with every event sent on the channel, until the context is cancelled or the connection
+// fails.
+//
+// It holds a connection of the pool for as long as it listens.
+func (n *Notifier) Listen(ctx context.Context, fn func(*model.Event)) error {
+	if n.conn.Dialector.Name() != "postgres" {
+		return ErrNotifyUnsupported
+	}
+
+	sqlDB, err := n.conn.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		stdConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return ErrNotifyUnsupported
+		}
+		listener := stdConn.Conn()
+
+		if _, err := listener.Exec(ctx, "LISTEN "+pgx.Identifier{n.channel}.Sanitize()); err != nil {
+			return err
+		}
+
+		// Stop listening before the connection goes back to the pool, unless it has been closed.
+		defer listener.Exec(context.Background(), "UNLISTEN *")
+
+		for {
+			notification, err := listener.WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+
+			// Skip the notifications which are not events, e.g. sent on the channel by hand.
+			var event model.Event
+			if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+				continue
+			}
+			fn(&event)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+)
+
+func Test_Notifier(t *testing.T) {
+
+	t.Run("nil config", func(t *testing.T) {
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("NewNotifier() did not panic")
+			}
+		}()
+
+		NewNotifier(nil)
+	})
+
+	config := configure(t)
+	notifier := NewNotifier(&NotifierConfig{
+		DB: config.conn,
+	})
+	if notifier.channel != DefaultNotifyChannel {
+		t.Errorf("NewNotifier() channel = %q, want %q", notifier.channel, DefaultNotifyChannel)
+	}
+
+	t.Run("listen on sqlite", func(t *testing.T) {
+
+		err := notifier.Listen(context.Background(), func(*model.Event) {})
+		if err != ErrNotifyUnsupported {
+			t.Errorf("Notifier.Listen() error = %v, want %v", err, ErrNotifyUnsupported)
+		}
+	})
+
+	t.Run("broadcast an event too big", func(t *testing.T) {
+
+		err := notifier.Broadcast(context.Background(), &model.Event{
+			ID:   uuid.New(),
+			Type: model.EventRecordUpdated,
+			Data: model.Record{
+				Title: strings.Repeat("a", maxNotifyPayload),
+			},
+		})
+		if err != ErrNotifyPayloadTooBig {
+			t.Errorf("Notifier.Broadcast() error = %v, want %v", err, ErrNotifyPayloadTooBig)
+		}
+	})
+}
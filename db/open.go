@@ -0,0 +1,213 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mrinalwahal/service/model"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Supported database engines.
+const (
+
+	// EnginePostgres connects to a PostgreSQL server.
+	EnginePostgres = "postgres"
+
+	// EngineSQLiteFile opens a SQLite database stored in a file.
+	// The data source name is the path of the file.
+	EngineSQLiteFile = "sqlite-file"
+
+	// EngineSQLiteMemory opens a new in-memory SQLite database.
+	// The data is lost when the connection is closed.
+	EngineSQLiteMemory = "sqlite-memory"
+)
+
+// DefaultSQLiteFile is the path of the database file used by `EngineSQLiteFile` when no data source name is supplied.
+const DefaultSQLiteFile = "records.db"
+
+// models are the models whose schema is managed by this layer.
+var models = []any{
+	&model.Record{},
+	&model.Revision{},
+	&model.Event{},
+	&model.Webhook{},
+	&model.Delivery{},
+}
+
+type OpenConfig struct {
+
+	// Engine is the database engine to connect to.
+	// Default: `EngineSQLiteMemory`
+	//
+	// This field is optional.
+	Engine string
+
+	// DSN is the data source name of the database.
+	// It is mandatory for `EnginePostgres` and ignored by `EngineSQLiteMemory`.
+	DSN string
+
+	// Connection pool settings.
+	// They are overridden for `EngineSQLiteMemory`, which always uses a single connection.
+	//
+	// Link: https://gorm.io/docs/generic_interface.html#Connection-Pool
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Logger is the GORM logger.
+	// Default: GORM's default logger
+	//
+	// This field is optional.
+	Logger logger.Interface
+
+	// TracerProvider creates the tracer which records every SQL statement as a span.
+	// Default: `otel.GetTracerProvider()`
+	//
+	// This field is optional.
+	TracerProvider trace.TracerProvider
+}
+
+// Open opens a connection to the configured database engine and configures its connection pool.
+//
+// The connection is not verified. Ping it before use.
+func Open(config *OpenConfig) (*gorm.DB, error) {
+	if config == nil {
+		return nil, ErrInvalidOptions
+	}
+
+	engine := config.Engine
+	if engine == "" {
+		engine = EngineSQLiteMemory
+	}
+
+	maxOpenConns, maxIdleConns := config.MaxOpenConns, config.MaxIdleConns
+	connMaxLifetime, connMaxIdleTime := config.ConnMaxLifetime, config.ConnMaxIdleTime
+
+	var dialector gorm.Dialector
+	system := semconv.DBSystemSqlite
+	switch engine {
+	case EnginePostgres:
+		system = semconv.DBSystemPostgreSQL
+		if config.DSN == "" {
+			return nil, fmt.Errorf("%w: missing dsn for the %s engine", ErrInvalidOptions, engine)
+		}
+		dialector = postgres.Open(config.DSN)
+
+	case EngineSQLiteFile:
+		dsn := config.DSN
+		if dsn == "" {
+			dsn = DefaultSQLiteFile
+		}
+		dialector = sqlite.Open(sqliteDSN(dsn, map[string]string{
+
+			// Let readers proceed while a write is in progress.
+			"_journal_mode": "WAL",
+
+			// Wait for the lock instead of failing with "database is locked".
+			"_busy_timeout": "5000",
+
+			// Take the write lock when the transaction begins, which avoids deadlocks on upgrade.
+			"_txlock": "immediate",
+
+			"_foreign_keys": "1",
+		}))
+
+	case EngineSQLiteMemory:
+		dialector = sqlite.Open(sqliteDSN("file::memory:", map[string]string{
+			"_foreign_keys": "1",
+		}))
+
+		// Every connection to ":memory:" opens a different database, and the database is dropped along with
+		// its last connection. So keep exactly one connection open forever.
+		maxOpenConns, maxIdleConns = 1, 1
+		connMaxLifetime, connMaxIdleTime = 0, 0
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported engine %q", ErrInvalidOptions, engine)
+	}
+
+	conn, err := gorm.Open(dialector, &gorm.Config{
+		Logger:               config.Logger,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	provider := config.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	if err := conn.Use(&tracingPlugin{
+		tracer: provider.Tracer(instrumentation),
+		system: system,
+	}); err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := conn.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(maxOpenConns)
+	sqlDB.SetMaxIdleConns(maxIdleConns)
+	sqlDB.SetConnMaxLifetime(connMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(connMaxIdleTime)
+
+	return conn, nil
+}
+
+// AutoMigrate creates or updates the schema of the models to match their definition.
+//
+// It is meant for the SQLite engines. The schema of `EnginePostgres` is managed with the migrations in `./migrations`.
+// On SQLite, it also creates the full-text search index of the records, when the driver is built with the
+// `sqlite_fts5` tag.
+//
+// The schema is changed in a single transaction. With `EngineSQLiteFile` the transaction takes the write lock of the
+// database file as soon as it begins, so processes sharing the file migrate it one after the other.
+func AutoMigrate(ctx context.Context, conn *gorm.DB) error {
+	return conn.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.AutoMigrate(models...); err != nil {
+			return err
+		}
+		if tx.Dialector.Name() == "sqlite" {
+			return migrateSQLiteSearch(tx)
+		}
+		return nil
+	})
+}
+
+// sqliteDSN appends the supplied parameters to a SQLite data source name, unless it already sets them.
+func sqliteDSN(dsn string, params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var extra []string
+	for _, key := range keys {
+		if !strings.Contains(dsn, key+"=") {
+			extra = append(extra, key+"="+params[key])
+		}
+	}
+	if len(extra) == 0 {
+		return dsn
+	}
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + strings.Join(extra, "&")
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func Test_Open(t *testing.T) {
+
+	t.Run("open w/ nil config", func(t *testing.T) {
+
+		if _, err := Open(nil); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("Open() error = %v, want %v", err, ErrInvalidOptions)
+		}
+	})
+
+	t.Run("open w/ unsupported engine", func(t *testing.T) {
+
+		if _, err := Open(&OpenConfig{Engine: "oracle"}); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("Open() error = %v, want %v", err, ErrInvalidOptions)
+		}
+	})
+
+	t.Run("open postgres w/o dsn", func(t *testing.T) {
+
+		if _, err := Open(&OpenConfig{Engine: EnginePostgres}); !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("Open() error = %v, want %v", err, ErrInvalidOptions)
+		}
+	})
+
+	for _, engine := range []string{EngineSQLiteMemory, EngineSQLiteFile} {
+		t.Run("open and migrate "+engine, func(t *testing.T) {
+
+			ctx := context.Background()
+
+			conn, err := Open(&OpenConfig{
+				Engine:       engine,
+				DSN:          filepath.Join(t.TempDir(), "records.db"),
+				MaxOpenConns: 10,
+				MaxIdleConns: 5,
+			})
+			if err != nil {
+				t.Fatalf("failed to open the database connection: %v", err)
+			}
+			t.Cleanup(func() {
+				sqlDB, err := conn.DB()
+				if err != nil {
+					t.Fatalf("failed to get the database connection: %v", err)
+				}
+				if err := sqlDB.Close(); err != nil {
+					t.Fatalf("failed to close the database connection: %v", err)
+				}
+			})
+
+			if err := AutoMigrate(ctx, conn); err != nil {
+				t.Fatalf("failed to migrate the schema: %v", err)
+			}
+
+			// The schema must be usable by the database layer.
+			db := NewSQLDB(&SQLDBConfig{
+				DB: conn,
+			})
+			record, err := db.Create(ctx, &CreateOptions{
+				Title:  "Test Record",
+				UserID: uuid.New(),
+			})
+			if err != nil {
+				t.Fatalf("failed to create record: %v", err)
+			}
+			if _, err := db.Get(ctx, record.ID, nil); err != nil {
+				t.Fatalf("failed to get record: %v", err)
+			}
+		})
+	}
+}
+
+func Test_AutoMigrate(t *testing.T) {
+
+	t.Run("migrate the same file concurrently", func(t *testing.T) {
+
+		ctx := context.Background()
+		dsn := filepath.Join(t.TempDir(), "records.db")
+
+		// Every goroutine plays a different replica sharing the same database file.
+		errs := make(chan error, 4)
+		for i := 0; i < cap(errs); i++ {
+			go func() {
+				conn, err := Open(&OpenConfig{
+					Engine: EngineSQLiteFile,
+					DSN:    dsn,
+				})
+				if err != nil {
+					errs <- err
+					return
+				}
+				sqlDB, err := conn.DB()
+				if err != nil {
+					errs <- err
+					return
+				}
+				defer sqlDB.Close()
+				errs <- AutoMigrate(ctx, conn)
+			}()
+		}
+		for i := 0; i < cap(errs); i++ {
+			if err := <-errs; err != nil {
+				t.Errorf("AutoMigrate() error = %v", err)
+			}
+		}
+	})
+}
+
+func Test_sqliteDSN(t *testing.T) {
+	tests := []struct {
+		name   string
+		dsn    string
+		params map[string]string
+		want   string
+	}{
+		{
+			name:   "path w/o query",
+			dsn:    "records.db",
+			params: map[string]string{"_busy_timeout": "5000", "_foreign_keys": "1"},
+			want:   "records.db?_busy_timeout=5000&_foreign_keys=1",
+		},
+		{
+			name:   "path w/ query",
+			dsn:    "records.db?mode=ro",
+			params: map[string]string{"_foreign_keys": "1"},
+			want:   "records.db?mode=ro&_foreign_keys=1",
+		},
+		{
+			name:   "parameter already set",
+			dsn:    "records.db?_busy_timeout=100",
+			params: map[string]string{"_busy_timeout": "5000"},
+			want:   "records.db?_busy_timeout=100",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sqliteDSN(tt.dsn, tt.params); got != tt.want {
+				t.Errorf("sqliteDSN() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
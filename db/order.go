@@ -0,0 +1,53 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultOrders are the orders of the records when `order_by` is empty.
+var defaultOrders = []order{{field: "created_at"}}
+
+// parseOrderBy parses an AIP-132 `order_by` expression into the orders it describes.
+//
+// The expression is a comma separated list of fields, each optionally followed by "asc" or "desc".
+// The fields must be keys of the supplied allowlist and appear at most once.
+// Example: "title desc, created_at"
+//
+// Link: https://google.aip.dev/132#ordering
+func parseOrderBy(expression string, allowed map[string]sortable) ([]order, error) {
+	if strings.TrimSpace(expression) == "" {
+		return defaultOrders, nil
+	}
+
+	var (
+		orders []order
+		seen   = make(map[string]bool)
+	)
+	for _, item := range strings.Split(expression, ",") {
+		words := strings.Fields(item)
+		if len(words) == 0 {
+			return nil, &FieldError{Field: "order_by", Reason: "empty field"}
+		}
+
+		o := order{field: words[0]}
+		if _, ok := allowed[o.field]; !ok {
+			return nil, &FieldError{Field: "order_by", Reason: fmt.Sprintf("unknown field %q", o.field)}
+		}
+		if seen[o.field] {
+			return nil, &FieldError{Field: "order_by", Reason: fmt.Sprintf("duplicate field %q", o.field)}
+		}
+		seen[o.field] = true
+
+		switch {
+		case len(words) == 1:
+		case len(words) == 2 && words[1] == "asc":
+		case len(words) == 2 && words[1] == "desc":
+			o.desc = true
+		default:
+			return nil, &FieldError{Field: "order_by", Reason: fmt.Sprintf("invalid direction %q of field %q", strings.Join(words[1:], " "), o.field)}
+		}
+		orders = append(orders, o)
+	}
+	return orders, nil
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_parseOrderBy(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       []order
+		wantErr    bool
+	}{
+		{
+			name:       "empty expression",
+			expression: "  ",
+			want:       defaultOrders,
+		},
+		{
+			name:       "single field",
+			expression: "title",
+			want:       []order{{field: "title"}},
+		},
+		{
+			name:       "multiple fields with directions",
+			expression: "title desc, created_at,updated_at  asc",
+			want:       []order{{field: "title", desc: true}, {field: "created_at"}, {field: "updated_at"}},
+		},
+		{
+			name:       "unknown field",
+			expression: "name",
+			wantErr:    true,
+		},
+		{
+			name:       "field not in the allowlist",
+			expression: "user_id",
+			wantErr:    true,
+		},
+		{
+			name:       "duplicate field",
+			expression: "title, title desc",
+			wantErr:    true,
+		},
+		{
+			name:       "empty field",
+			expression: "title,",
+			wantErr:    true,
+		},
+		{
+			name:       "invalid direction",
+			expression: "title descending",
+			wantErr:    true,
+		},
+		{
+			name:       "uppercase direction",
+			expression: "title DESC",
+			wantErr:    true,
+		},
+		{
+			name:       "injection",
+			expression: "title; DROP TABLE records",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseOrderBy(tt.expression, sortables)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseOrderBy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var fieldErr *FieldError
+				if !errors.As(err, &fieldErr) || fieldErr.Field != "order_by" {
+					t.Errorf("parseOrderBy() error = %v, want a field error of order_by", err)
+				}
+				if !errors.Is(err, ErrInvalidFilters) {
+					t.Errorf("parseOrderBy() error = %v, want it to wrap %v", err, ErrInvalidFilters)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseOrderBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+)
+
+// eventTypes are the types of the events of the changes, by the action of their revision.
+var eventTypes = map[string]string{
+	model.RevisionCreate:   model.EventRecordCreated,
+	model.RevisionUpdate:   model.EventRecordUpdated,
+	model.RevisionRestore:  model.EventRecordUpdated,
+	model.RevisionUndelete: model.EventRecordUpdated,
+	model.RevisionDelete:   model.EventRecordDeleted,
+}
+
+// newEvent returns the event of a change of the record, made by the actor and the request of the context.
+func newEvent(ctx context.Context, record *model.Record, eventType string) *model.Event {
+	event := model.Event{
+		ID:       uuid.New(),
+		Type:     eventType,
+		RecordID: record.ID,
+		Data:     *record,
+	}
+	if claims, exists := ctx.Value(middleware.XJWTClaims).(middleware.JWTClaims); exists {
+		event.ActorID = &claims.XUserID
+	}
+	event.RequestID, _ = ctx.Value(middleware.XRequestID).(string)
+	return &event
+}
+
+// emit writes the event of a change of the record to the outbox.
+//
+// It must be called with the context of the transaction of the change, so the event is only published if the
+// change is committed.
+func (db *sqldb) emit(ctx context.Context, record *model.Record, eventType string) error {
+	return db.session(ctx).Create(newEvent(ctx, record, eventType)).Error
+}
+
+// changed records a change of the record, in the transaction of the context: its revision, and its event.
+func (db *sqldb) changed(ctx context.Context, record *model.Record, action string) error {
+	if err := db.revise(ctx, record, action); err != nil {
+		return err
+	}
+	return db.emit(ctx, record, eventTypes[action])
+}
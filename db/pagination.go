@@ -0,0 +1,245 @@
+package db
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"gorm.io/gorm/clause"
+)
+
+// Page sizes of `List`.
+const (
+
+	// DefaultPageSize is the number of records returned when the page size is not set.
+	DefaultPageSize = 50
+
+	// MaxPageSize is the maximum number of records returned in a page.
+	// Larger page sizes are coerced to it.
+	MaxPageSize = 100
+)
+
+// pageSize returns the number of records to return for the requested page size.
+func pageSize(size int) int {
+	switch {
+	case size == 0:
+		return DefaultPageSize
+	case size > MaxPageSize:
+		return MaxPageSize
+	}
+	return size
+}
+
+// defaultPageTokenKey signs the page tokens when no key is configured.
+//
+// It is random, so the tokens are only valid within this process.
+var defaultPageTokenKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("db: failed to generate the page token key: %v", err))
+	}
+	return key
+}()
+
+// sortable is a field of the records which can be sorted on, and therefore stored in a page token.
+type sortable struct {
+
+	// column is the column of the field.
+	column string
+
+	// value returns the value of the field of a record, formatted for a page token.
+	value func(*model.Record) string
+
+	// parse parses the value of a page token back into a query argument.
+	parse func(string) (any, error)
+}
+
+// parseTime parses the timestamps stored in the page tokens.
+//
+// The offset of the timestamp is kept as is, because SQLite compares the timestamps as text.
+func parseTime(value string) (any, error) {
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// sortables are the fields the records can be sorted on, by name.
+//
+// It is the allowlist of the `order_by` expressions of the records.
+var sortables = map[string]sortable{
+	"created_at": {
+		column: "created_at",
+		value:  func(r *model.Record) string { return r.CreatedAt.Format(time.RFC3339Nano) },
+		parse:  parseTime,
+	},
+	"updated_at": {
+		column: "updated_at",
+		value:  func(r *model.Record) string { return r.UpdatedAt.Format(time.RFC3339Nano) },
+		parse:  parseTime,
+	},
+	"title": {
+		column: "title",
+		value:  func(r *model.Record) string { return r.Title },
+		parse:  func(value string) (any, error) { return value, nil },
+	},
+}
+
+// order is a field the records are sorted on.
+type order struct {
+	field string
+	desc  bool
+}
+
+// String returns the order in its `order_by` form.
+//
+// Example: "title desc"
+func (o order) String() string {
+	if o.desc {
+		return o.field + " desc"
+	}
+	return o.field
+}
+
+// cursor is the position of a page in a listing, encoded in its page token.
+//
+// It holds the sort key and the ID of the last record of the previous page, along with the query the page
+// belongs to, so the token can not be reused with a different query.
+type cursor struct {
+
+	// Query is the description of the options the listing was made with, besides the pagination.
+	Query string `json:"q"`
+
+	// Values are the values of the sort fields of the last record, in the order of the sort fields.
+	Values []string `json:"v"`
+
+	// ID of the last record.
+	ID uuid.UUID `json:"id"`
+}
+
+// encodePageToken signs the cursor and encodes it into an opaque page token.
+func encodePageToken(key []byte, c cursor) (string, error) {
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(append(payload, mac.Sum(nil)...)), nil
+}
+
+// decodePageToken verifies the signature of the page token and decodes its cursor.
+func decodePageToken(key []byte, token string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) <= sha256.Size {
+		return c, ErrInvalidPageToken
+	}
+	payload, signature := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return c, ErrInvalidPageToken
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&c); err != nil {
+		return c, ErrInvalidPageToken
+	}
+	return c, nil
+}
+
+// describe returns the description of a query, which ties the page tokens to it.
+func describe(orders []order, filters ...string) string {
+	parts := make([]string, 0, len(orders))
+	for _, o := range orders {
+		parts = append(parts, o.String())
+	}
+	return strings.Join(append([]string{strings.Join(parts, ",")}, filters...), ";")
+}
+
+// newCursor returns the cursor positioned after the supplied record.
+func newCursor(query string, orders []order, record *model.Record) cursor {
+	values := make([]string, 0, len(orders))
+	for _, o := range orders {
+		values = append(values, sortables[o.field].value(record))
+	}
+	return cursor{
+		Query:  query,
+		Values: values,
+		ID:     record.ID,
+	}
+}
+
+// after returns the condition which selects the records after the cursor, for the supplied orders and the ID
+// tiebreaker sorted in the direction of the last order.
+//
+// For the orders `a, b desc`, it is `(a > ?) OR (a = ? AND b < ?) OR (a = ? AND b = ? AND id < ?)`.
+func after(orders []order, c cursor) (clause.Expression, error) {
+	if len(c.Values) != len(orders) {
+		return nil, ErrInvalidPageToken
+	}
+
+	type key struct {
+		column string
+		desc   bool
+		value  any
+	}
+	keys := make([]key, 0, len(orders)+1)
+	for i, o := range orders {
+		value, err := sortables[o.field].parse(c.Values[i])
+		if err != nil {
+			return nil, ErrInvalidPageToken
+		}
+		keys = append(keys, key{sortables[o.field].column, o.desc, value})
+	}
+	keys = append(keys, key{"id", tiebreaker(orders), c.ID})
+
+	// The columns are passed as variables, so they are quoted by the dialect.
+	var (
+		alternatives []string
+		vars         []any
+	)
+	for i := range keys {
+		var conditions []string
+		for _, previous := range keys[:i] {
+			conditions = append(conditions, "? = ?")
+			vars = append(vars, clause.Column{Name: previous.column}, previous.value)
+		}
+		if keys[i].desc {
+			conditions = append(conditions, "? < ?")
+		} else {
+			conditions = append(conditions, "? > ?")
+		}
+		vars = append(vars, clause.Column{Name: keys[i].column}, keys[i].value)
+		alternatives = append(alternatives, "("+strings.Join(conditions, " AND ")+")")
+	}
+	return clause.Expr{SQL: "(" + strings.Join(alternatives, " OR ") + ")", Vars: vars}, nil
+}
+
+// tiebreaker reports whether the ID tiebreaker is sorted in descending order, which follows the last order.
+func tiebreaker(orders []order) bool {
+	return len(orders) > 0 && orders[len(orders)-1].desc
+}
+
+// columns returns the columns the records are sorted by, including the ID tiebreaker.
+func columns(orders []order) []clause.OrderByColumn {
+	list := make([]clause.OrderByColumn, 0, len(orders)+1)
+	for _, o := range orders {
+		list = append(list, clause.OrderByColumn{
+			Column: clause.Column{Name: sortables[o.field].column},
+			Desc:   o.desc,
+		})
+	}
+	return append(list, clause.OrderByColumn{
+		Column: clause.Column{Name: "id"},
+		Desc:   tiebreaker(orders),
+	})
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Publisher publishes the events of the outbox, e.g. to a message broker.
+//
+// The events are delivered at least once: the dispatcher may publish an event again if it stops before marking it
+// delivered, so the consumers should ignore the events whose ID they have already seen.
+//
+// When it is called by a `Dispatcher`, the context carries the transaction which marks the event published, so the
+// operations of the database layer called with it are committed along with the event.
+type Publisher interface {
+	Publish(context.Context, *model.Event) error
+}
+
+// MemoryPublisher is a `Publisher` which keeps the published events in memory.
+//
+// It is meant for tests.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []*model.Event
+	err    error
+}
+
+// NewMemoryPublisher creates a new instance of `MemoryPublisher`.
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+// Publish keeps the event, unless the publisher has been set to fail.
+func (p *MemoryPublisher) Publish(ctx context.Context, event *model.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events returns the events published so far, in order.
+func (p *MemoryPublisher) Events() []*model.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]*model.Event(nil), p.events...)
+}
+
+// Fail makes the next publications fail with the error, until it is called again with nil.
+func (p *MemoryPublisher) Fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.err = err
+}
+
+// LogPublisher is a `Publisher` which logs the events, for the deployments without a message broker.
+type LogPublisher struct {
+	log *slog.Logger
+}
+
+// NewLogPublisher creates a new instance of `LogPublisher`.
+//
+// The events are logged at the info level with the logger, or with `slog.Default()` if it is nil.
+func NewLogPublisher(logger *slog.Logger) *LogPublisher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &LogPublisher{
+		log: logger,
+	}
+}
+
+// Publish logs the event.
+func (p *LogPublisher) Publish(ctx context.Context, event *model.Event) error {
+	p.log.InfoContext(ctx, "published an event",
+		"event_id", event.ID,
+		"event_type", event.Type,
+		"record_id", event.RecordID,
+		"request_id", event.RequestID,
+	)
+	return nil
+}
+
+// Publishers is a `Publisher` which publishes the events with every publisher, in order.
+//
+// It stops at the first publisher which fails, in which case the event is published again by all of them when it
+// is retried.
+type Publishers []Publisher
+
+// Publish publishes the event with every publisher.
+func (p Publishers) Publish(ctx context.Context, event *model.Event) error {
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WebhookPublisher is a `Publisher` which schedules the deliveries of the events to the enabled webhooks of the
+// owners of their records, which subscribe to them. The deliveries are sent by a `Deliverer`.
+//
+// When it is called by a `Dispatcher`, the deliveries are written in the transaction which marks the event
+// published, so they are scheduled if, and only if, the event is. An event is delivered at most once per webhook,
+// even if it is published again.
+type WebhookPublisher struct {
+	conn *gorm.DB
+}
+
+// NewWebhookPublisher creates a new instance of `WebhookPublisher` on the database connection.
+func NewWebhookPublisher(conn *gorm.DB) *WebhookPublisher {
+	if conn == nil {
+		panic("db: nil webhook publisher connection")
+	}
+	return &WebhookPublisher{
+		conn: conn,
+	}
+}
+
+// Publish schedules the deliveries of the event.
+func (p *WebhookPublisher) Publish(ctx context.Context, event *model.Event) error {
+
+	// Run in a savepoint of the transaction of the context, if any, so a failure does not abort it.
+	return session(ctx, p.conn).Transaction(func(tx *gorm.DB) error {
+		var webhooks []*model.Webhook
+		result := tx.Where(&model.Webhook{UserID: event.Data.UserID}).
+			Where(clause.Eq{Column: clause.Column{Name: "disabled_at"}, Value: nil}).
+			Find(&webhooks)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		var deliveries []*model.Delivery
+		for _, webhook := range webhooks {
+			if !webhook.Subscribes(event.Type) {
+				continue
+			}
+			deliveries = append(deliveries, &model.Delivery{
+				ID:        uuid.Must(uuid.NewV7()),
+				WebhookID: webhook.ID,
+				EventID:   event.ID,
+				Event:     *event,
+				Status:    model.DeliveryPending,
+			})
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+	})
+}
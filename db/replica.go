@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+// Load balancing policies of the reads routed to the replicas.
+const (
+
+	// BalanceRoundRobin sends the reads to every replica in turn.
+	BalanceRoundRobin = "round-robin"
+
+	// BalanceRandom sends every read to a random replica.
+	BalanceRandom = "random"
+
+	// BalanceLeastConns sends every read to the replica with the fewest connections in use.
+	BalanceLeastConns = "least-conns"
+)
+
+// Reasons of the routing of the reads, in the logs and the metrics.
+const (
+	routeBalanced      = "balanced"
+	routeTransaction   = "transaction"
+	routeReadYourWrite = "read_your_writes"
+	routeForced        = "forced"
+)
+
+// primaryKey is the key of the routing to the primary set by `WithPrimary` in the contexts.
+type primaryKey struct{}
+
+// WithPrimary returns a copy of the context in which the reads of the database layer go to the primary, rather
+// than to its replicas, e.g. to read a change right after it was made by another caller.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// replicas routes the reads of the database layer between its primary and its read replicas.
+type replicas struct {
+	conns  []*gorm.DB
+	policy string
+
+	//	Index of the next replica of the round-robin.
+	next atomic.Uint64
+
+	//	Duration for which the reads of a user go to the primary after they write.
+	window time.Duration
+
+	//	Time of the last write of every user who wrote within the window.
+	mu     sync.Mutex
+	writes map[uuid.UUID]time.Time
+
+	//	Time of the next sweep of the writes which are out of the window.
+	sweepAt time.Time
+
+	log   *slog.Logger
+	reads *prometheus.CounterVec
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// newReplicas creates the router of the reads to the replicas, and registers its metrics.
+func newReplicas(config *SQLDBConfig) *replicas {
+	r := replicas{
+		conns:  config.Replicas,
+		policy: config.LoadBalancing,
+		window: config.ReadYourWrites,
+		writes: make(map[uuid.UUID]time.Time),
+		log:    config.Logger,
+		reads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "db_reads_total",
+			Help: "Total number of reads routed by the database layer, by target and reason.",
+		}, []string{"target", "reason"}),
+		now: time.Now,
+	}
+
+	if r.policy == "" {
+		r.policy = BalanceRoundRobin
+	}
+	if r.log == nil {
+		r.log = slog.Default()
+	}
+	r.log = r.log.With("layer", "database")
+
+	registerer := config.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+	registerer.MustRegister(r.reads)
+
+	return &r
+}
+
+// route returns the connection the read of the operation must run on, and records its target and the reason.
+//
+// The reads go to the primary in a transaction, in a context of `WithPrimary`, and within the read-your-writes
+// window of their user. Otherwise they are balanced between the replicas.
+func (r *replicas) route(ctx context.Context, primary *gorm.DB, operation string) *gorm.DB {
+	conn, target, reason := primary, "primary", routeBalanced
+	switch {
+	case InTx(ctx):
+		reason = routeTransaction
+	case ctx.Value(primaryKey{}) != nil:
+		reason = routeForced
+	case r.wroteRecently(ctx):
+		reason = routeReadYourWrite
+	default:
+		i := r.pick()
+		conn, target = r.conns[i], "replica-"+strconv.Itoa(i)
+	}
+
+	r.reads.WithLabelValues(target, reason).Inc()
+	r.log.DebugContext(ctx, "routed a read",
+		"operation", operation,
+		"target", target,
+		"reason", reason,
+	)
+	return session(ctx, conn)
+}
+
+// pick returns the index of the replica of the next read, according to the load balancing policy.
+func (r *replicas) pick() int {
+	switch r.policy {
+	case BalanceRandom:
+		return rand.IntN(len(r.conns))
+
+	case BalanceLeastConns:
+		best, fewest := 0, -1
+		for i, conn := range r.conns {
+			sqlDB, err := conn.DB()
+			if err != nil {
+				continue
+			}
+			if inUse := sqlDB.Stats().InUse; fewest < 0 || inUse < fewest {
+				best, fewest = i, inUse
+			}
+		}
+		return best
+	}
+	return int((r.next.Add(1) - 1) % uint64(len(r.conns)))
+}
+
+// wrote records that the user of the context wrote, so their reads go to the primary for the read-your-writes window.
+func (r *replicas) wrote(ctx context.Context) {
+	claims, exists := ctx.Value(middleware.XJWTClaims).(middleware.JWTClaims)
+	if r.window <= 0 || !exists {
+		return
+	}
+
+	now := r.now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.writes[claims.XUserID] = now
+
+	// Forget the users whose window is over, at most once per window.
+	if now.After(r.sweepAt) {
+		for user, at := range r.writes {
+			if now.Sub(at) >= r.window {
+				delete(r.writes, user)
+			}
+		}
+		r.sweepAt = now.Add(r.window)
+	}
+}
+
+// wroteRecently reports whether the user of the context wrote within the read-your-writes window.
+func (r *replicas) wroteRecently(ctx context.Context) bool {
+	claims, exists := ctx.Value(middleware.XJWTClaims).(middleware.JWTClaims)
+	if r.window <= 0 || !exists {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	at, ok := r.writes[claims.XUserID]
+	return ok && r.now().Sub(at) < r.window
+}
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// replica opens an in-memory SQLite database, separate from the primary, to stand for a read replica.
+func replica(t *testing.T, name string) *gorm.DB {
+	conn, err := gorm.Open(sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", name)), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open the replica: %v", err)
+	}
+	if err := conn.AutoMigrate(&model.Record{}); err != nil {
+		t.Fatalf("failed to migrate the replica: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := conn.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return conn
+}
+
+func Test_Database_Replicas(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+	replicas := []*gorm.DB{replica(t, "replica0"), replica(t, "replica1")}
+
+	// Initialize the database, with a read-your-writes window of a minute.
+	registry := prometheus.NewRegistry()
+	db := NewSQLDB(&SQLDBConfig{
+		DB:             config.conn,
+		Replicas:       replicas,
+		ReadYourWrites: time.Minute,
+		Registerer:     registry,
+	}).(*sqldb)
+
+	owner, other := uuid.New(), uuid.New()
+	claims := func(user uuid.UUID) context.Context {
+		return context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+			XUserID: user,
+		})
+	}
+
+	// reads returns the number of reads routed to the target for the reason.
+	reads := func(target, reason string) float64 {
+		return testutil.ToFloat64(db.replicas.reads.WithLabelValues(target, reason))
+	}
+
+	// The records only reach the replicas through the replication, which the tests do by hand.
+	record, err := db.Create(context.Background(), &CreateOptions{Title: "Test Record", UserID: owner})
+	if err != nil {
+		t.Fatalf("failed to create record: %v", err)
+	}
+
+	t.Run("balance the reads between the replicas", func(t *testing.T) {
+
+		if err := replicas[1].Create(&model.Record{Base: model.Base{ID: record.ID}, Title: "Replicated", UserID: owner, Version: 1}).Error; err != nil {
+			t.Fatalf("failed to replicate the record: %v", err)
+		}
+
+		// The replicas are picked in turn.
+		if _, err := db.Get(context.Background(), record.ID, nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("db.Get() error = %v, want %v from the first replica", err, gorm.ErrRecordNotFound)
+		}
+		records, _, err := db.List(context.Background(), nil)
+		if err != nil || len(records) != 1 || records[0].Title != "Replicated" {
+			t.Errorf("expected the list of the second replica, got %d records, error = %v", len(records), err)
+		}
+		if reads("replica-0", routeBalanced) != 1 || reads("replica-1", routeBalanced) != 1 {
+			t.Errorf("expected a read on every replica")
+		}
+	})
+
+	t.Run("pin the reads in transactions to the primary", func(t *testing.T) {
+
+		err := db.RunInTx(context.Background(), func(ctx context.Context) error {
+			_, err := db.Get(ctx, record.ID, nil)
+			return err
+		})
+		if err != nil {
+			t.Errorf("db.Get() error = %v, want the record of the primary", err)
+		}
+		if _, err := db.Get(WithPrimary(context.Background()), record.ID, nil); err != nil {
+			t.Errorf("db.Get() error = %v, want the record of the primary", err)
+		}
+		if reads("primary", routeTransaction) != 1 || reads("primary", routeForced) != 1 {
+			t.Errorf("expected the reads to be routed to the primary")
+		}
+	})
+
+	t.Run("read your writes", func(t *testing.T) {
+
+		created, err := db.Create(claims(owner), &CreateOptions{Title: "Fresh Record", UserID: owner})
+		if err != nil {
+			t.Fatalf("failed to create record: %v", err)
+		}
+
+		// The writer reads from the primary, while the other users still read from the replicas.
+		if _, err := db.Get(claims(owner), created.ID, nil); err != nil {
+			t.Errorf("db.Get() error = %v, want the record of the primary", err)
+		}
+		if reads("primary", routeReadYourWrite) != 1 {
+			t.Errorf("expected the read of the writer to be routed to the primary")
+		}
+		if _, _, err := db.List(claims(other), nil); err != nil {
+			t.Fatalf("db.List() error = %v", err)
+		}
+		if reads("primary", routeReadYourWrite) != 1 {
+			t.Errorf("expected the read of another user to be routed to a replica")
+		}
+
+		// Once the window is over, the writer reads from the replicas again.
+		db.replicas.now = func() time.Time { return time.Now().Add(time.Minute) }
+		if _, err := db.Get(claims(owner), created.ID, nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("db.Get() error = %v, want %v from a replica", err, gorm.ErrRecordNotFound)
+		}
+	})
+
+	t.Run("balance the reads to the least busy replica", func(t *testing.T) {
+
+		db.replicas.policy = BalanceLeastConns
+
+		// Hold a connection of the first replica.
+		sqlDB, err := replicas[0].DB()
+		if err != nil {
+			t.Fatalf("failed to get the replica connection: %v", err)
+		}
+		conn, err := sqlDB.Conn(context.Background())
+		if err != nil {
+			t.Fatalf("failed to get a replica connection: %v", err)
+		}
+		defer conn.Close()
+
+		for i := 0; i < 3; i++ {
+			if got := db.replicas.pick(); got != 1 {
+				t.Errorf("replicas.pick() = %d, want 1", got)
+			}
+		}
+	})
+}
@@ -0,0 +1,287 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// newRevision returns the revision of the record after the change, made by the actor and the request of the context.
+func newRevision(ctx context.Context, record *model.Record, action string) *model.Revision {
+	revision := model.Revision{
+		RecordID: record.ID,
+		Revision: record.Version,
+		Action:   action,
+		Snapshot: *record,
+	}
+	if claims, exists := ctx.Value(middleware.XJWTClaims).(middleware.JWTClaims); exists {
+		revision.ActorID = &claims.XUserID
+	}
+	revision.RequestID, _ = ctx.Value(middleware.XRequestID).(string)
+	return &revision
+}
+
+// revise writes the revision of the record after the change.
+//
+// It must be called with the context of the transaction of the change, so both are committed together.
+func (db *sqldb) revise(ctx context.Context, record *model.Record, action string) error {
+	return db.session(ctx).Create(newRevision(ctx, record, action)).Error
+}
+
+// ListRevisions operation fetches a page of the revisions of a record, most recent first, along with the token of
+// the next page.
+//
+// The revisions of the soft-deleted records are returned too. Only the users who can get the record can list them.
+func (db *sqldb) ListRevisions(ctx context.Context, id uuid.UUID, options *ListRevisionsOptions) ([]*model.Revision, string, error) {
+	if id == uuid.Nil {
+		return nil, "", ErrInvalidRecordID
+	}
+	if options == nil {
+		options = &ListRevisionsOptions{}
+	}
+	if err := options.validate(); err != nil {
+		return nil, "", err
+	}
+
+	// The revisions are subject to the Row Level Security (RLS) checks of their record.
+	if _, err := db.Get(ctx, id, &GetOptions{ShowDeleted: true}); err != nil {
+		return nil, "", err
+	}
+
+	query := db.session(ctx).Where(&model.Revision{RecordID: id})
+
+	// The page token holds the last revision of the previous page.
+	description := fmt.Sprintf("revisions;record_id=%s", id)
+	if options.PageToken != "" {
+		cursor, err := decodePageToken(db.pageTokenKey(), options.PageToken)
+		if err != nil {
+			return nil, "", err
+		}
+		if cursor.Query != description || cursor.ID != id || len(cursor.Values) != 1 {
+			return nil, "", ErrInvalidPageToken
+		}
+		last, err := strconv.ParseInt(cursor.Values[0], 10, 64)
+		if err != nil {
+			return nil, "", ErrInvalidPageToken
+		}
+		query = query.Where(clause.Lt{Column: clause.Column{Name: "revision"}, Value: last})
+	}
+
+	// Fetch one more revision than the page size, to know whether there is a next page.
+	var payload []*model.Revision
+	size := pageSize(options.PageSize)
+	query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: "revision"}, Desc: true})
+	if err := db.scoped(ctx, query, func(query *gorm.DB) error {
+		return query.Limit(size + 1).Find(&payload).Error
+	}); err != nil {
+		return nil, "", err
+	}
+	if len(payload) <= size {
+		return payload, "", nil
+	}
+
+	payload = payload[:size]
+	token, err := encodePageToken(db.pageTokenKey(), cursor{
+		Query:  description,
+		Values: []string{strconv.FormatInt(payload[size-1].Revision, 10)},
+		ID:     id,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, token, nil
+}
+
+// GetRevision operation fetches a revision of a record.
+//
+// Only the users who can get the record can get its revisions.
+func (db *sqldb) GetRevision(ctx context.Context, id uuid.UUID, revision int64) (*model.Revision, error) {
+	if id == uuid.Nil {
+		return nil, ErrInvalidRecordID
+	}
+	if revision < 1 {
+		return nil, ErrInvalidRevision
+	}
+
+	// The revisions are subject to the Row Level Security (RLS) checks of their record.
+	if _, err := db.Get(ctx, id, &GetOptions{ShowDeleted: true}); err != nil {
+		return nil, err
+	}
+
+	var payload model.Revision
+	query := db.session(ctx).Where(&model.Revision{RecordID: id, Revision: revision})
+	if err := db.scoped(ctx, query, func(query *gorm.DB) error {
+		return query.First(&payload).Error
+	}); err != nil {
+		return nil, err
+	}
+	return &payload, nil
+}
+
+// DiffRevisions operation compares a revision of a record with another one, and returns the changes of the fields
+// of the record from the other revision to the revision.
+//
+// Only the users who can get the record can compare its revisions.
+func (db *sqldb) DiffRevisions(ctx context.Context, id uuid.UUID, revision int64, options *DiffRevisionsOptions) (*model.Diff, error) {
+	if id == uuid.Nil {
+		return nil, ErrInvalidRecordID
+	}
+	if revision < 1 {
+		return nil, ErrInvalidRevision
+	}
+	if options == nil {
+		options = &DiffRevisionsOptions{}
+	}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	against := options.Against
+	if against == 0 {
+		against = revision - 1
+	}
+
+	// The revisions are subject to the Row Level Security (RLS) checks of their record.
+	if _, err := db.Get(ctx, id, &GetOptions{ShowDeleted: true}); err != nil {
+		return nil, err
+	}
+
+	// The first revision is compared with nothing, unless another revision is supplied.
+	numbers := []any{revision}
+	if against > 0 {
+		numbers = append(numbers, against)
+	}
+	var payload []*model.Revision
+	query := db.session(ctx).
+		Where(&model.Revision{RecordID: id}).
+		Where(clause.IN{Column: clause.Column{Name: "revision"}, Values: numbers})
+	if err := db.scoped(ctx, query, func(query *gorm.DB) error {
+		return query.Find(&payload).Error
+	}); err != nil {
+		return nil, err
+	}
+
+	snapshots := make(map[int64]*model.Record, len(payload))
+	for _, item := range payload {
+		snapshots[item.Revision] = &item.Snapshot
+	}
+	to, found := snapshots[revision]
+	if !found {
+		return nil, gorm.ErrRecordNotFound
+	}
+	from, found := snapshots[against]
+	if against > 0 && !found {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	changes, err := diff(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Diff{
+		RecordID: id,
+		Revision: revision,
+		Against:  against,
+		Changes:  changes,
+	}, nil
+}
+
+// bookkeeping are the fields of the records which change with every revision, so they are left out of the diffs.
+var bookkeeping = map[string]bool{
+	"version":    true,
+	"etag":       true,
+	"updated_at": true,
+}
+
+// diff returns the changes of the fields of a record from a snapshot to another, sorted by field. Every field of the
+// record is changed from null when there is no snapshot to change from.
+func diff(from, to *model.Record) ([]model.Change, error) {
+	before, err := fields(from)
+	if err != nil {
+		return nil, err
+	}
+	after, err := fields(to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []model.Change{}
+	for field, value := range after {
+		previous, found := before[field]
+		if !found {
+			previous = json.RawMessage("null")
+		}
+		if bookkeeping[field] || bytes.Equal(previous, value) {
+			continue
+		}
+		changes = append(changes, model.Change{
+			Field: field,
+			From:  previous,
+			To:    value,
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes, nil
+}
+
+// fields returns the JSON values of the fields of the record, by name, or none when there is no record.
+func fields(record *model.Record) (map[string]json.RawMessage, error) {
+	if record == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// Restore operation restores the fields of a record to those of one of its revisions.
+//
+// The restoration is a change of the record like any other: it increments the version of the record, and it
+// creates a new revision. A soft-deleted record must be undeleted before it is restored.
+func (db *sqldb) Restore(ctx context.Context, id uuid.UUID, options *RestoreOptions) (*model.Record, error) {
+	if id == uuid.Nil {
+		return nil, ErrInvalidRecordID
+	}
+	if options == nil {
+		return nil, ErrInvalidOptions
+	}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	var record *model.Record
+	err := db.RunInTx(ctx, func(ctx context.Context) error {
+		revision, err := db.GetRevision(ctx, id, options.Revision)
+		if err != nil {
+			return err
+		}
+
+		// Restore every updatable field.
+		record, err = db.update(ctx, id, &UpdateOptions{
+			Title:   revision.Snapshot.Title,
+			Version: options.Version,
+		}, model.RevisionRestore)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
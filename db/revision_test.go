@@ -0,0 +1,330 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"gorm.io/gorm"
+)
+
+func Test_Database_Revisions(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Initialize the database.
+	db := &sqldb{
+		conn: config.conn,
+	}
+
+	// Act on behalf of the owner of the record, in a request.
+	owner := uuid.New()
+	ctx := context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+		XUserID: owner,
+	})
+	ctx = context.WithValue(ctx, middleware.XRequestID, "test-request")
+
+	seed, err := db.Create(ctx, &CreateOptions{
+		Title:  "First Title",
+		UserID: owner,
+	})
+	if err != nil {
+		t.Fatalf("failed to seed the database: %v", err)
+	}
+	if _, err := db.Update(ctx, seed.ID, &UpdateOptions{Title: "Second Title"}); err != nil {
+		t.Fatalf("failed to update record: %v", err)
+	}
+	if err := db.Delete(ctx, seed.ID, nil); err != nil {
+		t.Fatalf("failed to delete record: %v", err)
+	}
+	if _, err := db.Undelete(ctx, seed.ID); err != nil {
+		t.Fatalf("failed to undelete record: %v", err)
+	}
+
+	t.Run("list the revisions of every change", func(t *testing.T) {
+
+		revisions, token, err := db.ListRevisions(ctx, seed.ID, nil)
+		if err != nil {
+			t.Fatalf("db.ListRevisions() error = %v", err)
+		}
+		if token != "" {
+			t.Errorf("expected no next page, got %q", token)
+		}
+
+		// The most recent revision comes first.
+		actions := []string{model.RevisionUndelete, model.RevisionDelete, model.RevisionUpdate, model.RevisionCreate}
+		if len(revisions) != len(actions) {
+			t.Fatalf("expected %d revisions, got %d", len(actions), len(revisions))
+		}
+		for i, revision := range revisions {
+			if revision.Action != actions[i] || revision.Revision != int64(len(actions)-i) {
+				t.Errorf("revisions[%d] = %s at %d, want %s at %d", i, revision.Action, revision.Revision, actions[i], len(actions)-i)
+			}
+			if revision.Snapshot.Version != revision.Revision {
+				t.Errorf("revisions[%d] has a snapshot of version %d", i, revision.Snapshot.Version)
+			}
+			if revision.ActorID == nil || *revision.ActorID != owner || revision.RequestID != "test-request" {
+				t.Errorf("revisions[%d] was made by %v in %q", i, revision.ActorID, revision.RequestID)
+			}
+			if revision.CreatedAt.IsZero() {
+				t.Errorf("revisions[%d] has no timestamp", i)
+			}
+		}
+		if revisions[2].Snapshot.Title != "Second Title" || !revisions[1].Snapshot.DeletedAt.Valid {
+			t.Errorf("expected the snapshots to hold the record after every change")
+		}
+	})
+
+	t.Run("list the revisions by page", func(t *testing.T) {
+
+		var got []int64
+		options := &ListRevisionsOptions{PageSize: 3}
+		for {
+			revisions, token, err := db.ListRevisions(ctx, seed.ID, options)
+			if err != nil {
+				t.Fatalf("db.ListRevisions() error = %v", err)
+			}
+			for _, revision := range revisions {
+				got = append(got, revision.Revision)
+			}
+			if token == "" {
+				break
+			}
+			options.PageToken = token
+		}
+		if len(got) != 4 || got[0] != 4 || got[3] != 1 {
+			t.Errorf("expected revisions 4 to 1, got %v", got)
+		}
+	})
+
+	t.Run("list the revisions with the page token of another record", func(t *testing.T) {
+
+		other, err := db.Create(ctx, &CreateOptions{Title: "Other Record", UserID: owner})
+		if err != nil {
+			t.Fatalf("failed to create record: %v", err)
+		}
+		_, token, err := db.ListRevisions(ctx, seed.ID, &ListRevisionsOptions{PageSize: 1})
+		if err != nil {
+			t.Fatalf("db.ListRevisions() error = %v", err)
+		}
+		if _, _, err := db.ListRevisions(ctx, other.ID, &ListRevisionsOptions{PageToken: token}); err != ErrInvalidPageToken {
+			t.Errorf("db.ListRevisions() error = %v, want %v", err, ErrInvalidPageToken)
+		}
+	})
+
+	t.Run("list the revisions as a different user than the one who created the record", func(t *testing.T) {
+
+		// Add JWT claims to the context.
+		ctx := context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+			XUserID: uuid.New(),
+		})
+
+		if _, _, err := db.ListRevisions(ctx, seed.ID, nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("db.ListRevisions() error = %v, want %v", err, gorm.ErrRecordNotFound)
+		}
+		if _, err := db.GetRevision(ctx, seed.ID, 1); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("db.GetRevision() error = %v, want %v", err, gorm.ErrRecordNotFound)
+		}
+		if _, err := db.DiffRevisions(ctx, seed.ID, 2, nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("db.DiffRevisions() error = %v, want %v", err, gorm.ErrRecordNotFound)
+		}
+		if _, err := db.Restore(ctx, seed.ID, &RestoreOptions{Revision: 1}); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("db.Restore() error = %v, want %v", err, gorm.ErrRecordNotFound)
+		}
+	})
+
+	t.Run("get a revision", func(t *testing.T) {
+
+		revision, err := db.GetRevision(ctx, seed.ID, 1)
+		if err != nil {
+			t.Fatalf("db.GetRevision() error = %v", err)
+		}
+		if revision.Action != model.RevisionCreate || revision.Snapshot.Title != "First Title" {
+			t.Errorf("expected the revision of the creation, got %s of %q", revision.Action, revision.Snapshot.Title)
+		}
+	})
+
+	t.Run("get a revision which does not exist", func(t *testing.T) {
+
+		if _, err := db.GetRevision(ctx, seed.ID, 100); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("db.GetRevision() error = %v, want %v", err, gorm.ErrRecordNotFound)
+		}
+		if _, err := db.GetRevision(ctx, seed.ID, 0); err != ErrInvalidRevision {
+			t.Errorf("db.GetRevision() error = %v, want %v", err, ErrInvalidRevision)
+		}
+	})
+
+	t.Run("diff a revision with the previous one", func(t *testing.T) {
+
+		diff, err := db.DiffRevisions(ctx, seed.ID, 2, nil)
+		if err != nil {
+			t.Fatalf("db.DiffRevisions() error = %v", err)
+		}
+		if diff.RecordID != seed.ID || diff.Revision != 2 || diff.Against != 1 {
+			t.Errorf("expected the diff of revision 2 against 1, got %d against %d", diff.Revision, diff.Against)
+		}
+
+		// The version and the update time are left out.
+		if len(diff.Changes) != 1 {
+			t.Fatalf("expected 1 change, got %+v", diff.Changes)
+		}
+		if change := diff.Changes[0]; change.Field != "title" || string(change.From) != `"First Title"` || string(change.To) != `"Second Title"` {
+			t.Errorf("expected the change of the title, got %s from %s to %s", change.Field, change.From, change.To)
+		}
+	})
+
+	t.Run("diff a revision with a later one", func(t *testing.T) {
+
+		diff, err := db.DiffRevisions(ctx, seed.ID, 1, &DiffRevisionsOptions{Against: 3})
+		if err != nil {
+			t.Fatalf("db.DiffRevisions() error = %v", err)
+		}
+		if len(diff.Changes) != 2 || diff.Changes[0].Field != "deleted_at" || diff.Changes[1].Field != "title" {
+			t.Fatalf("expected the changes of the deletion and the title, got %+v", diff.Changes)
+		}
+		if string(diff.Changes[0].To) != "null" || string(diff.Changes[1].To) != `"First Title"` {
+			t.Errorf("expected the changes to the first revision, got %+v", diff.Changes)
+		}
+	})
+
+	t.Run("diff the first revision", func(t *testing.T) {
+
+		diff, err := db.DiffRevisions(ctx, seed.ID, 1, nil)
+		if err != nil {
+			t.Fatalf("db.DiffRevisions() error = %v", err)
+		}
+		if diff.Against != 0 {
+			t.Errorf("expected the diff against nothing, got %d", diff.Against)
+		}
+
+		// Every field which is set changes from null.
+		fields := []string{"created_at", "id", "title", "user_id"}
+		if len(diff.Changes) != len(fields) {
+			t.Fatalf("expected %d changes, got %+v", len(fields), diff.Changes)
+		}
+		for i, change := range diff.Changes {
+			if change.Field != fields[i] || string(change.From) != "null" {
+				t.Errorf("changes[%d] = %s from %s, want %s from null", i, change.Field, change.From, fields[i])
+			}
+		}
+	})
+
+	t.Run("diff a revision which does not exist", func(t *testing.T) {
+
+		if _, err := db.DiffRevisions(ctx, seed.ID, 100, nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("db.DiffRevisions() error = %v, want %v", err, gorm.ErrRecordNotFound)
+		}
+		if _, err := db.DiffRevisions(ctx, seed.ID, 2, &DiffRevisionsOptions{Against: 100}); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("db.DiffRevisions() error = %v, want %v", err, gorm.ErrRecordNotFound)
+		}
+		if _, err := db.DiffRevisions(ctx, seed.ID, 2, &DiffRevisionsOptions{Against: -1}); err != ErrInvalidRevision {
+			t.Errorf("db.DiffRevisions() error = %v, want %v", err, ErrInvalidRevision)
+		}
+	})
+
+	t.Run("restore a record at another version", func(t *testing.T) {
+
+		_, err := db.Restore(ctx, seed.ID, &RestoreOptions{Revision: 1, Version: 1})
+		if err != ErrVersionMismatch {
+			t.Errorf("db.Restore() error = %v, want %v", err, ErrVersionMismatch)
+		}
+	})
+
+	t.Run("restore a record", func(t *testing.T) {
+
+		record, err := db.Restore(ctx, seed.ID, &RestoreOptions{Revision: 1, Version: 4})
+		if err != nil {
+			t.Fatalf("db.Restore() error = %v", err)
+		}
+		if record.Title != "First Title" || record.Version != 5 {
+			t.Errorf("expected the title of the first revision at version 5, got %q at version %d", record.Title, record.Version)
+		}
+
+		revision, err := db.GetRevision(ctx, seed.ID, 5)
+		if err != nil {
+			t.Fatalf("db.GetRevision() error = %v", err)
+		}
+		if revision.Action != model.RevisionRestore {
+			t.Errorf("expected the restoration to be revised, got %s", revision.Action)
+		}
+	})
+
+	t.Run("roll back the revision with the change", func(t *testing.T) {
+
+		errRollback := errors.New("rollback")
+		err := db.RunInTx(ctx, func(ctx context.Context) error {
+			if _, err := db.Update(ctx, seed.ID, &UpdateOptions{Title: "Rolled Back"}); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if err != errRollback {
+			t.Fatalf("db.RunInTx() error = %v, want %v", err, errRollback)
+		}
+		if _, err := db.GetRevision(ctx, seed.ID, 6); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("expected the revision to be rolled back, got %v", err)
+		}
+	})
+
+	t.Run("revise the records created in batch", func(t *testing.T) {
+
+		records, err := db.BatchCreate(ctx, &BatchCreateOptions{Requests: []*CreateOptions{
+			{Title: "January", UserID: owner},
+			{Title: "February", UserID: owner},
+		}})
+		if err != nil {
+			t.Fatalf("failed to create records: %v", err)
+		}
+		for _, record := range records {
+			revision, err := db.GetRevision(ctx, record.ID, 1)
+			if err != nil {
+				t.Fatalf("db.GetRevision() error = %v", err)
+			}
+			if revision.Action != model.RevisionCreate || revision.Snapshot.Title != record.Title {
+				t.Errorf("expected the revision of the creation of %q, got %s of %q", record.Title, revision.Action, revision.Snapshot.Title)
+			}
+		}
+	})
+
+	t.Run("delete the revisions of a record deleted permanently", func(t *testing.T) {
+
+		record, err := db.Create(ctx, &CreateOptions{Title: "Forced Record", UserID: owner})
+		if err != nil {
+			t.Fatalf("failed to create record: %v", err)
+		}
+		if err := db.Delete(ctx, record.ID, &DeleteOptions{Force: true}); err != nil {
+			t.Fatalf("failed to delete record: %v", err)
+		}
+
+		var count int64
+		config.conn.Model(&model.Revision{}).Where(&model.Revision{RecordID: record.ID}).Count(&count)
+		if count != 0 {
+			t.Errorf("expected the revisions to be deleted, got %d", count)
+		}
+	})
+
+	t.Run("delete the revisions of the purged records", func(t *testing.T) {
+
+		record, err := db.Create(ctx, &CreateOptions{Title: "Purged Record", UserID: owner})
+		if err != nil {
+			t.Fatalf("failed to create record: %v", err)
+		}
+		if err := config.conn.Model(record).Update("deleted_at", time.Now().Add(-time.Hour)).Error; err != nil {
+			t.Fatalf("failed to delete record: %v", err)
+		}
+		if _, err := db.Purge(ctx, &PurgeOptions{DeletedBefore: time.Now()}); err != nil {
+			t.Fatalf("failed to purge records: %v", err)
+		}
+
+		var count int64
+		config.conn.Model(&model.Revision{}).Where(&model.Revision{RecordID: record.ID}).Count(&count)
+		if count != 0 {
+			t.Errorf("expected the revisions to be deleted, got %d", count)
+		}
+	})
+}
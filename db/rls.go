@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"gorm.io/gorm"
+)
+
+// Postgres row level security (RLS) of the records.
+//
+// The migrations enable RLS on the `records` table, even for its owner, with a policy which only lets a transaction
+// see and change the records of the user in `UserIDSetting`. The `revisions`, `outbox` and `deliveries` tables,
+// which hold snapshots of the records, get the same policy on the owner of their snapshots. A transaction without a
+// user sees none of them at all, unless it runs as `BypassRole`. The `webhooks` table holds no records, so it is only
+// restricted by the queries of the database layer.
+//
+// The migrations only enable RLS when `BypassRole` can be used by the user which applies them: they create it as a
+// superuser, and skip RLS with a notice otherwise, until an administrator runs `db/scripts/rls.sql`.
+const (
+
+	// UserIDSetting is the setting which holds the ID of the caller in every transaction, for the policy of the
+	// records.
+	UserIDSetting = "app.user_id"
+
+	// BypassRole is the role which bypasses the policies, for the jobs which operate on the records of every user,
+	// e.g. the purge, the dispatchers and the deliverers. The migrations create it when they are applied by a
+	// superuser. Otherwise, an administrator creates it, and grants it to the user of the service, with
+	// `db/scripts/rls.sql`.
+	BypassRole = "records_jobs"
+)
+
+// RowLevelSecurity reports whether the database enforces the row level security of the records, which the migrations
+// enable on Postgres. It reports false before the records are migrated.
+func RowLevelSecurity(ctx context.Context, conn *gorm.DB) (bool, error) {
+	var enforced bool
+	result := conn.WithContext(ctx).
+		Raw(`SELECT "relrowsecurity" FROM "pg_catalog"."pg_class" WHERE "oid" = to_regclass('public.records')`).
+		Scan(&enforced)
+	return enforced, result.Error
+}
+
+// bypassKey is the key of the bypass of the row level security set by `WithBypassRLS` in the contexts.
+type bypassKey struct{}
+
+// WithBypassRLS returns a copy of the context in which the transactions of the database layer run as `BypassRole`,
+// so the Postgres row level security does not restrict them to the records of a user.
+//
+// It is meant for the jobs, whose contexts carry no JWT claims. It has no effect on the JWT claims, nor on the
+// transactions which are already started.
+func WithBypassRLS(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// BypassesRLS reports whether the context was returned by `WithBypassRLS`.
+func BypassesRLS(ctx context.Context) bool {
+	_, ok := ctx.Value(bypassKey{}).(bool)
+	return ok
+}
+
+// identify sets the identity of the caller of the context in the transaction, for the row level security: it
+// switches to `BypassRole` in the contexts of `WithBypassRLS`, and sets `UserIDSetting` to the user of the JWT
+// claims otherwise, or to nobody without claims.
+//
+// Both only last until the end of the transaction, like `SET LOCAL`, so they never leak to the next transaction
+// of the pooled connection.
+func identify(ctx context.Context, tx *gorm.DB) error {
+	tx = tx.Session(&gorm.Session{NewDB: true})
+	if BypassesRLS(ctx) {
+		return tx.Exec("SET LOCAL ROLE " + BypassRole).Error
+	}
+
+	var user string
+	if claims, exists := ctx.Value(middleware.XJWTClaims).(middleware.JWTClaims); exists {
+		user = claims.XUserID.String()
+	}
+
+	// Unlike `SET LOCAL`, `set_config` takes the value as a parameter.
+	return tx.Exec("SELECT set_config(?, ?, true)", UserIDSetting, user).Error
+}
+
+// bypass switches the transaction to `BypassRole` when the database enforces the row level security, for the jobs
+// which work on the rows of every user outside of the database layer, e.g. the dispatchers.
+func bypass(ctx context.Context, tx *gorm.DB, rls bool) error {
+	if !rls {
+		return nil
+	}
+	return identify(WithBypassRLS(ctx), tx)
+}
+
+// scoped runs the read on the query, in a read-only transaction which carries the identity of the caller when the
+// database enforces the row level security. The reads in a transaction started by `RunInTx` already carry it.
+func (db *sqldb) scoped(ctx context.Context, query *gorm.DB, fn func(*gorm.DB) error) error {
+	if !db.rls || InTx(ctx) {
+		return fn(query)
+	}
+	return query.Transaction(func(tx *gorm.DB) error {
+		if err := identify(ctx, tx); err != nil {
+			return err
+		}
+		return fn(tx)
+	}, &sql.TxOptions{ReadOnly: true})
+}
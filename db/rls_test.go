@@ -0,0 +1,273 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"gorm.io/gorm"
+)
+
+// postgresDSNEnv is the environment variable which holds the data source name of the Postgres database of the
+// tests of the row level security, as the user of the service. The database must have been bootstrapped for the user
+// with `scripts/rls.sql`, and the user must not be a superuser, since the superusers bypass the row level security.
+const postgresDSNEnv = "RECORDS_TEST_POSTGRES_DSN"
+
+// postgresBareDSNEnv is the environment variable which holds the data source name, as a URL, of a superuser of a
+// Postgres cluster which has not been bootstrapped with `scripts/rls.sql`, for the tests of the migrations.
+const postgresBareDSNEnv = "RECORDS_TEST_POSTGRES_BARE_DSN"
+
+// requirePostgresEnv is the environment variable which makes the tests against Postgres fail, rather than be
+// skipped, without their databases, so CI cannot pass without running them.
+const requirePostgresEnv = "RECORDS_TEST_REQUIRE_POSTGRES"
+
+// postgresDSN returns the data source name of the environment variable. The test is skipped without it, unless
+// `requirePostgresEnv` is set.
+func postgresDSN(t *testing.T, env string) string {
+	dsn := os.Getenv(env)
+	if dsn == "" {
+		if require, _ := strconv.ParseBool(os.Getenv(requirePostgresEnv)); require {
+			t.Fatalf("%s is required, since %s is set", env, requirePostgresEnv)
+		}
+		t.Skipf("set %s to run the tests against Postgres", env)
+	}
+	return dsn
+}
+
+// openPostgres opens a connection to the Postgres database of the data source name.
+func openPostgres(t *testing.T, dsn string) *gorm.DB {
+	conn, err := Open(&OpenConfig{
+		Engine: EnginePostgres,
+		DSN:    dsn,
+	})
+	if err != nil {
+		t.Fatalf("failed to open the database connection: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := conn.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return conn
+}
+
+// migratePostgres applies the migrations to the database.
+func migratePostgres(t *testing.T, conn *gorm.DB) {
+	migrator, err := NewMigrator(&MigratorConfig{DB: conn, Lock: true})
+	if err != nil {
+		t.Fatalf("failed to create the migrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("failed to apply the migrations: %v", err)
+	}
+}
+
+func Test_Database_RowLevelSecurity(t *testing.T) {
+
+	conn := openPostgres(t, postgresDSN(t, postgresDSNEnv))
+	var superuser bool
+	if err := conn.Raw(`SELECT "rolsuper" FROM "pg_catalog"."pg_roles" WHERE "rolname" = CURRENT_USER`).Scan(&superuser).Error; err != nil {
+		t.Fatalf("failed to get the privileges of the user: %v", err)
+	}
+	if superuser {
+		t.Fatalf("%s must be the data source name of a user who is not a superuser, since they bypass the row level security", postgresDSNEnv)
+	}
+	migratePostgres(t, conn)
+
+	// Initialize the database.
+	db := NewSQLDB(&SQLDBConfig{
+		DB:               conn,
+		RowLevelSecurity: true,
+
+		// The webhooks of the tests are never called.
+		AllowPrivateWebhooks: true,
+	}).(*sqldb)
+	if enforced, err := RowLevelSecurity(context.Background(), conn); err != nil || !enforced {
+		t.Fatalf("expected the database to enforce the row level security, got %v, error = %v", enforced, err)
+	}
+
+	owner, other := uuid.New(), uuid.New()
+	claims := func(user uuid.UUID) context.Context {
+		return context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+			XUserID: user,
+		})
+	}
+
+	// count counts the records with the ID seen by the transaction of the context, without any check in Go.
+	count := func(t *testing.T, ctx context.Context, id uuid.UUID) int64 {
+		var n int64
+		if err := db.RunInTx(ctx, func(ctx context.Context) error {
+			return db.session(ctx).Raw(`SELECT count(*) FROM "records" WHERE "id" = ?`, id).Scan(&n).Error
+		}); err != nil {
+			t.Fatalf("failed to count the records: %v", err)
+		}
+		return n
+	}
+
+	record, err := db.Create(claims(owner), &CreateOptions{Title: "Test Record", UserID: owner})
+	if err != nil {
+		t.Fatalf("failed to create record: %v", err)
+	}
+
+	t.Run("create the records of another user", func(t *testing.T) {
+
+		if _, err := db.Create(claims(other), &CreateOptions{Title: "Forged Record", UserID: owner}); err == nil {
+			t.Errorf("expected the database to refuse a record of another user")
+		}
+		if _, err := db.Create(context.Background(), &CreateOptions{Title: "Anonymous Record", UserID: owner}); err == nil {
+			t.Errorf("expected the database to refuse a record without a caller")
+		}
+	})
+
+	t.Run("read the records of another user", func(t *testing.T) {
+
+		if n := count(t, claims(owner), record.ID); n != 1 {
+			t.Errorf("expected the owner to see the record, got %d", n)
+		}
+		if n := count(t, claims(other), record.ID); n != 0 {
+			t.Errorf("expected another user not to see the record, got %d", n)
+		}
+
+		// Without claims, the layer checks nothing in Go, but the database still hides the records.
+		if _, err := db.Get(context.Background(), record.ID, nil); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("db.Get() error = %v, want %v", err, gorm.ErrRecordNotFound)
+		}
+		records, _, err := db.List(context.Background(), nil)
+		if err != nil || len(records) != 0 {
+			t.Errorf("expected no records without a caller, got %d, error = %v", len(records), err)
+		}
+	})
+
+	t.Run("change the records of another user", func(t *testing.T) {
+
+		for _, statement := range []string{
+			`UPDATE "records" SET "title" = 'Hijacked' WHERE "id" = ?`,
+			`DELETE FROM "records" WHERE "id" = ?`,
+		} {
+			var affected int64
+			if err := db.RunInTx(claims(other), func(ctx context.Context) error {
+				result := db.session(ctx).Exec(statement, record.ID)
+				affected = result.RowsAffected
+				return result.Error
+			}); err != nil {
+				t.Fatalf("failed to run %q: %v", statement, err)
+			}
+			if affected != 0 {
+				t.Errorf("expected %q to change no record of another user, changed %d", statement, affected)
+			}
+		}
+
+		// Nor can a user hand their records over to another one.
+		err := db.RunInTx(claims(owner), func(ctx context.Context) error {
+			return db.session(ctx).Exec(`UPDATE "records" SET "user_id" = ? WHERE "id" = ?`, other, record.ID).Error
+		})
+		if err == nil {
+			t.Errorf("expected the database to refuse to give the record to another user")
+		}
+		if got, err := db.Get(claims(owner), record.ID, nil); err != nil || got.Title != record.Title {
+			t.Errorf("expected the record to be unchanged, error = %v", err)
+		}
+	})
+
+	t.Run("the identity ends with the transaction", func(t *testing.T) {
+
+		// Pin a connection, to check that the transaction leaves no identity behind on it.
+		err := conn.Connection(func(session *gorm.DB) error {
+			var n int64
+			if err := session.Transaction(func(tx *gorm.DB) error {
+				if err := identify(claims(owner), tx); err != nil {
+					return err
+				}
+				return tx.Raw(`SELECT count(*) FROM "records" WHERE "id" = ?`, record.ID).Scan(&n).Error
+			}); err != nil {
+				return err
+			}
+			if n != 1 {
+				t.Errorf("expected the owner to see the record in their transaction, got %d", n)
+			}
+			if err := session.Raw(`SELECT count(*) FROM "records" WHERE "id" = ?`, record.ID).Scan(&n).Error; err != nil {
+				return err
+			}
+			if n != 0 {
+				t.Errorf("expected the identity of the owner not to outlive their transaction")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("failed to count the records: %v", err)
+		}
+	})
+
+	t.Run("read the revisions and events of another user", func(t *testing.T) {
+
+		for _, table := range []string{"revisions", "outbox"} {
+			for user, want := range map[uuid.UUID]int64{owner: 1, other: 0} {
+				var n int64
+				if err := db.RunInTx(claims(user), func(ctx context.Context) error {
+					return db.session(ctx).Table(table).Where("record_id = ?", record.ID).Count(&n).Error
+				}); err != nil {
+					t.Fatalf("failed to count the %s: %v", table, err)
+				}
+				if n != want {
+					t.Errorf("expected %d %s of the record, got %d", want, table, n)
+				}
+			}
+		}
+		if _, _, err := db.ListRevisions(claims(other), record.ID, nil); err == nil {
+			t.Errorf("expected another user not to list the revisions of the record")
+		}
+	})
+
+	t.Run("the jobs deliver the events of every user", func(t *testing.T) {
+
+		webhook, err := db.CreateWebhook(claims(owner), &CreateWebhookOptions{UserID: owner, URL: "http://127.0.0.1:1"})
+		if err != nil {
+			t.Fatalf("failed to create the webhook: %v", err)
+		}
+
+		// Without the role of the jobs, the dispatcher sees no events.
+		confined := NewDispatcher(&DispatcherConfig{DB: conn, Publisher: NewWebhookPublisher(conn)})
+		if claimed, err := confined.Dispatch(context.Background()); err != nil || claimed != 0 {
+			t.Errorf("expected the dispatcher to claim no events without the role of the jobs, claimed %d, error = %v", claimed, err)
+		}
+
+		dispatcher := NewDispatcher(&DispatcherConfig{DB: conn, Publisher: NewWebhookPublisher(conn), RowLevelSecurity: true})
+		if claimed, err := dispatcher.Dispatch(context.Background()); err != nil || claimed < 1 {
+			t.Fatalf("expected the dispatcher to claim the events, claimed %d, error = %v", claimed, err)
+		}
+
+		options := &ListDeliveriesOptions{WebhookID: webhook.ID}
+		if deliveries, _, err := db.ListDeliveries(claims(owner), options); err != nil || len(deliveries) == 0 {
+			t.Errorf("expected the owner to see the deliveries, got %d, error = %v", len(deliveries), err)
+		}
+		var n int64
+		if err := db.RunInTx(claims(other), func(ctx context.Context) error {
+			return db.session(ctx).Table("deliveries").Where("webhook_id = ?", webhook.ID).Count(&n).Error
+		}); err != nil || n != 0 {
+			t.Errorf("expected another user not to see the deliveries, got %d, error = %v", n, err)
+		}
+	})
+
+	t.Run("the jobs bypass the row level security", func(t *testing.T) {
+
+		if _, err := db.Get(WithBypassRLS(context.Background()), record.ID, nil); err != nil {
+			t.Errorf("db.Get() error = %v, want the record", err)
+		}
+
+		if err := db.Delete(claims(owner), record.ID, nil); err != nil {
+			t.Fatalf("failed to delete record: %v", err)
+		}
+		purged, err := db.Purge(WithBypassRLS(context.Background()), &PurgeOptions{DeletedBefore: time.Now().Add(time.Minute)})
+		if err != nil || purged < 1 {
+			t.Errorf("expected the purge to delete the record, purged %d, error = %v", purged, err)
+		}
+		if n := count(t, WithBypassRLS(context.Background()), record.ID); n != 0 {
+			t.Errorf("expected the record to be purged, got %d", n)
+		}
+	})
+}
@@ -13,6 +13,10 @@ import (
 // Define the models to generate migrations for.
 var models = []any{
 	&model.Record{},
+	&model.Revision{},
+	&model.Event{},
+	&model.Webhook{},
+	&model.Delivery{},
 }
 
 func main() {
@@ -0,0 +1,27 @@
+-- Bootstrap of the Postgres row level security of the records.
+--
+-- Only superusers can create roles which bypass row level security, so the migrations only enable it when they are
+-- applied by a superuser. Otherwise, an administrator runs this script as a superuser, before or after the
+-- migrations. `service_user` is the user of the service, which applies the migrations:
+--
+--   psql "$SUPERUSER_DSN" -v ON_ERROR_STOP=1 -v service_user=records -f db/scripts/rls.sql
+--
+-- It is idempotent, so it can be run again, e.g. for another database of the cluster.
+--
+-- create the role of the jobs, which bypasses the row level security of the records
+DO $$
+BEGIN
+  IF NOT EXISTS (SELECT FROM "pg_catalog"."pg_roles" WHERE "rolname" = 'records_jobs') THEN
+    CREATE ROLE "records_jobs" NOLOGIN BYPASSRLS;
+  END IF;
+END
+$$;
+-- let the service switch to the role of the jobs, with "SET LOCAL ROLE"
+GRANT "records_jobs" TO :"service_user";
+-- enable the row level security, as the user of the service, if the migrations have skipped it
+SELECT to_regprocedure('public.records_enable_rls()') IS NOT NULL AS "migrated" \gset
+\if :migrated
+SET ROLE :"service_user";
+SELECT "public"."records_enable_rls"();
+RESET ROLE;
+\endif
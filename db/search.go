@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"gorm.io/gorm"
+)
+
+// Markers of the matched words in the snippets built by the database.
+//
+// They are private use characters, so they survive the HTML escaping of the snippets, after which they are replaced
+// by `<mark>` and `</mark>`.
+const (
+	markStart = "\uE000"
+	markEnd   = "\uE001"
+)
+
+// highlights replaces the markers of the snippets with `<mark>` and `</mark>`.
+var highlights = strings.NewReplacer(markStart, "<mark>", markEnd, "</mark>")
+
+// searchTerms splits the query into its words, dropping the punctuation and the operators of the search engines.
+//
+// Every engine is given the same words, which must all match, so they return the same records.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Search operation fetches the records whose title matches all the words of the query, most relevant first.
+//
+// With `EnginePostgres`, the records are matched against the `search` tsvector column added by the migrations.
+// With the SQLite engines, they are matched against the `records_fts` FTS5 table created by `AutoMigrate`, which
+// requires the driver to be built with the `sqlite_fts5` tag. Otherwise, it returns `ErrSearchUnavailable`.
+func (db *sqldb) Search(ctx context.Context, options *SearchOptions) ([]*model.SearchResult, error) {
+	txn := db.session(ctx)
+	if options == nil {
+		return nil, ErrInvalidOptions
+	}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	terms := searchTerms(options.Query)
+	if len(terms) == 0 {
+		return []*model.SearchResult{}, nil
+	}
+
+	var (
+		query string
+		args  []any
+	)
+	switch txn.Dialector.Name() {
+	case "postgres":
+		query = `SELECT "records".*, ts_rank("records"."search", "query") AS "rank", ts_headline('english', "records"."title", "query", ?) AS "snippet"
+FROM "records", plainto_tsquery('english', ?) AS "query"
+WHERE "records"."search" @@ "query" AND "records"."deleted_at" IS NULL`
+		args = append(args, fmt.Sprintf("StartSel=%s, StopSel=%s, HighlightAll=true", markStart, markEnd), strings.Join(terms, " "))
+
+	case "sqlite":
+		available, err := sqliteSearchAvailable(txn)
+		if err != nil {
+			return nil, err
+		}
+		if !available {
+			return nil, ErrSearchUnavailable
+		}
+
+		// Quote every word, so it is never read as an FTS5 operator.
+		query = `SELECT "records".*, -bm25("records_fts") AS "rank", highlight("records_fts", 0, ?, ?) AS "snippet"
+FROM "records_fts" JOIN "records" ON "records"."rowid" = "records_fts"."rowid"
+WHERE "records_fts" MATCH ? AND "records"."deleted_at" IS NULL`
+		args = append(args, markStart, markEnd, `"`+strings.Join(terms, `" "`)+`"`)
+
+	default:
+		return nil, ErrSearchUnavailable
+	}
+
+	// If the request context contains JWT claims, apply Row Level Security (RLS) checks.
+	claims, exists := ctx.Value(middleware.XJWTClaims).(middleware.JWTClaims)
+	if exists {
+
+		// 1. Only the user who created the record can find it.
+		query += ` AND "records"."user_id" = ?`
+		args = append(args, claims.XUserID)
+	}
+
+	query += ` ORDER BY "rank" DESC, "records"."id" LIMIT ?`
+	args = append(args, pageSize(options.PageSize))
+
+	payload := []*model.SearchResult{}
+	if err := db.scoped(ctx, txn, func(txn *gorm.DB) error {
+		return txn.Raw(query, args...).Scan(&payload).Error
+	}); err != nil {
+		return nil, err
+	}
+	for _, result := range payload {
+		result.Snippet = highlights.Replace(html.EscapeString(result.Snippet))
+		result.ETag = model.ETag(result.Version)
+	}
+	return payload, nil
+}
+
+// sqliteSearchSchema creates the FTS5 index of the titles of the records, along with the triggers which keep it in
+// sync with the records. The index stores no copy of the titles: it reads them from the records table.
+var sqliteSearchSchema = []string{
+	`CREATE VIRTUAL TABLE "records_fts" USING fts5("title", content='records', content_rowid='rowid', tokenize='porter unicode61')`,
+	`CREATE TRIGGER "records_fts_insert" AFTER INSERT ON "records" BEGIN
+	INSERT INTO "records_fts"("rowid", "title") VALUES (new."rowid", new."title");
+END`,
+	`CREATE TRIGGER "records_fts_delete" AFTER DELETE ON "records" BEGIN
+	INSERT INTO "records_fts"("records_fts", "rowid", "title") VALUES ('delete', old."rowid", old."title");
+END`,
+	`CREATE TRIGGER "records_fts_update" AFTER UPDATE OF "title" ON "records" BEGIN
+	INSERT INTO "records_fts"("records_fts", "rowid", "title") VALUES ('delete', old."rowid", old."title");
+	INSERT INTO "records_fts"("rowid", "title") VALUES (new."rowid", new."title");
+END`,
+
+	// Index the records which existed before the index.
+	`INSERT INTO "records_fts"("records_fts") VALUES ('rebuild')`,
+}
+
+// migrateSQLiteSearch creates the full-text search index of the records, unless it already exists or SQLite was
+// built without FTS5.
+func migrateSQLiteSearch(tx *gorm.DB) error {
+	var enabled bool
+	if err := tx.Raw(`SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&enabled).Error; err != nil {
+		return err
+	}
+	if !enabled {
+		return nil
+	}
+
+	exists, err := sqliteSearchAvailable(tx)
+	if err != nil || exists {
+		return err
+	}
+	for _, statement := range sqliteSearchSchema {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqliteSearchAvailable reports whether the full-text search index of the records exists.
+func sqliteSearchAvailable(tx *gorm.DB) (bool, error) {
+	var count int64
+	err := tx.Raw(`SELECT count(*) FROM "sqlite_master" WHERE "type" = 'table' AND "name" = 'records_fts'`).Scan(&count).Error
+	return count > 0, err
+}
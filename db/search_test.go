@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/pkg/middleware"
+)
+
+func Test_Database_Search(t *testing.T) {
+
+	// Open a new in-memory database, with the full-text search index of the records.
+	conn, err := Open(&OpenConfig{
+		Engine: EngineSQLiteMemory,
+	})
+	if err != nil {
+		t.Fatalf("failed to open the database connection: %v", err)
+	}
+	t.Cleanup(func() {
+		sqlDB, _ := conn.DB()
+		sqlDB.Close()
+	})
+
+	ctx := context.Background()
+
+	// Seed some records before creating the index, to make sure it indexes the existing records.
+	if err := conn.AutoMigrate(models...); err != nil {
+		t.Fatalf("failed to migrate the schema: %v", err)
+	}
+	db := &sqldb{
+		conn: conn,
+	}
+	user := uuid.New()
+	seed := func(title string) uuid.UUID {
+		record, err := db.Create(ctx, &CreateOptions{
+			Title:  title,
+			UserID: user,
+		})
+		if err != nil {
+			t.Fatalf("failed to seed the database: %v", err)
+		}
+		return record.ID
+	}
+	quarterly := seed("Quarterly <b>report</b>")
+
+	if err := AutoMigrate(ctx, conn); err != nil {
+		t.Fatalf("failed to migrate the schema: %v", err)
+	}
+	if available, _ := sqliteSearchAvailable(conn); !available {
+		t.Skip("SQLite is built without FTS5: run the tests with `-tags sqlite_fts5`")
+	}
+
+	// Migrating again must leave the index as it is.
+	if err := AutoMigrate(ctx, conn); err != nil {
+		t.Fatalf("failed to migrate the schema again: %v", err)
+	}
+
+	weekly := seed("Weekly reports of the reporting team")
+	invoice := seed("Invoice")
+	deleted := seed("Deleted report")
+	if err := db.Delete(ctx, deleted, nil); err != nil {
+		t.Fatalf("failed to delete the record: %v", err)
+	}
+	renamed := seed("Draft")
+	if _, err := db.Update(ctx, renamed, &UpdateOptions{Title: "Yearly report"}); err != nil {
+		t.Fatalf("failed to update the record: %v", err)
+	}
+
+	t.Run("search with nil options", func(t *testing.T) {
+
+		_, err := db.Search(ctx, nil)
+		if err != ErrInvalidOptions {
+			t.Errorf("db.Search() error = %v, want %v", err, ErrInvalidOptions)
+		}
+	})
+
+	t.Run("search with an empty query", func(t *testing.T) {
+
+		_, err := db.Search(ctx, &SearchOptions{Query: "  "})
+		var fieldErr *FieldError
+		if !errors.As(err, &fieldErr) || fieldErr.Field != "q" {
+			t.Errorf("db.Search() error = %v, want a field error of q", err)
+		}
+	})
+
+	t.Run("search ranks the records and highlights the matches", func(t *testing.T) {
+
+		results, err := db.Search(ctx, &SearchOptions{Query: "reports"})
+		if err != nil {
+			t.Fatalf("failed to search records: %v", err)
+		}
+
+		found := make(map[uuid.UUID]string)
+		for _, result := range results {
+			found[result.ID] = result.Snippet
+		}
+		if len(results) != 3 || found[quarterly] == "" || found[weekly] == "" || found[renamed] == "" {
+			t.Fatalf("expected the quarterly, weekly and yearly reports, got %v", found)
+		}
+		for i := 1; i < len(results); i++ {
+			if results[i].Rank > results[i-1].Rank {
+				t.Errorf("expected the results to be sorted by rank, got %v after %v", results[i].Rank, results[i-1].Rank)
+			}
+		}
+		if want := "Quarterly &lt;b&gt;<mark>report</mark>&lt;/b&gt;"; found[quarterly] != want {
+			t.Errorf("expected the snippet to be %q, got %q", want, found[quarterly])
+		}
+	})
+
+	t.Run("search matches all the words", func(t *testing.T) {
+
+		results, err := db.Search(ctx, &SearchOptions{Query: `weekly "report" OR -invoice*`})
+		if err != nil {
+			t.Fatalf("failed to search records: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("expected no records, got %d", len(results))
+		}
+
+		results, err = db.Search(ctx, &SearchOptions{Query: "INVOICE"})
+		if err != nil {
+			t.Fatalf("failed to search records: %v", err)
+		}
+		if len(results) != 1 || results[0].ID != invoice {
+			t.Errorf("expected the invoice, got %v", results)
+		}
+	})
+
+	t.Run("search w/ page size", func(t *testing.T) {
+
+		results, err := db.Search(ctx, &SearchOptions{Query: "report", PageSize: 1})
+		if err != nil {
+			t.Fatalf("failed to search records: %v", err)
+		}
+		if len(results) != 1 {
+			t.Errorf("expected 1 record, got %d", len(results))
+		}
+	})
+
+	t.Run("search as a different user than the one who created the records", func(t *testing.T) {
+
+		// Add JWT claims to the context.
+		ctx := context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+			XUserID: uuid.New(),
+		})
+
+		results, err := db.Search(ctx, &SearchOptions{Query: "report"})
+		if err != nil {
+			t.Fatalf("failed to search records: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("expected 0 records, got %d", len(results))
+		}
+	})
+}
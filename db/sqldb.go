@@ -2,11 +2,18 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mrinalwahal/service/model"
 	"github.com/mrinalwahal/service/pkg/middleware"
+	"github.com/prometheus/client_golang/prometheus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SQLDBConfig struct {
@@ -16,15 +23,93 @@ type SQLDBConfig struct {
 	//
 	// This field is mandatory.
 	DB *gorm.DB
+
+	// Isolation is the isolation level of the transactions started by `RunInTx`.
+	// It can be overridden per transaction with `WithIsolation`.
+	// Default: `sql.LevelDefault`, the default level of the database.
+	//
+	// This field is optional.
+	Isolation sql.IsolationLevel
+
+	// PageTokenKey signs the page tokens returned by `List`.
+	// Every replica of the service must share the same key, for the tokens to be valid across them.
+	// Default: a random key, which makes the tokens valid only within this process.
+	//
+	// This field is optional.
+	PageTokenKey []byte
+
+	// RowLevelSecurity sets the identity of the caller in every transaction, for the row level security of the
+	// records, which the migrations enable on Postgres. It must be set when the database enforces it, and only then.
+	// Default: `false`
+	//
+	// This field is optional.
+	RowLevelSecurity bool
+
+	// InsertBatchSize is the maximum number of records inserted per statement by `BatchCreate`.
+	// Default: `DefaultInsertBatchSize`
+	//
+	// This field is optional.
+	InsertBatchSize int
+
+	// Replicas are the connections to the read replicas of the database, which should already be open.
+	// `Get` and `List` are balanced between them, except in transactions, in the contexts of `WithPrimary`, and
+	// within the read-your-writes window of their caller, when they go to `DB`, the primary.
+	// Default: none, every operation goes to `DB`.
+	//
+	// This field is optional.
+	Replicas []*gorm.DB
+
+	// LoadBalancing is the policy which picks the replica of a read: `BalanceRoundRobin`, `BalanceRandom` or
+	// `BalanceLeastConns`.
+	// Default: `BalanceRoundRobin`
+	//
+	// This field is optional.
+	LoadBalancing string
+
+	// ReadYourWrites is how long the reads of a user go to the primary after they write, so they see their own
+	// changes despite the replication lag. The writes are only tracked by this process.
+	// Default: 0, the reads go to the replicas right after a write.
+	//
+	// This field is optional.
+	ReadYourWrites time.Duration
+
+	// AllowPrivateWebhooks allows the webhooks to target "localhost" and the loopback, private, link-local and
+	// unspecified addresses. It should only be set in tests and development.
+	// Default: `false`
+	//
+	// This field is optional.
+	AllowPrivateWebhooks bool
+
+	// Logger is the `log/slog` instance that will be used to log the routing of the reads.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+
+	// Registerer is the Prometheus registry the metrics of the routing of the reads will be registered with, when
+	// there are replicas.
+	// Default: `prometheus.DefaultRegisterer`
+	//
+	// This field is optional.
+	Registerer prometheus.Registerer
 }
 
 func NewSQLDB(config *SQLDBConfig) DB {
-	if config == nil {
+	if config == nil || config.DB == nil {
 		panic("db: nil config")
 	}
 
 	db := sqldb{
-		conn: config.DB,
+		conn:      config.DB,
+		key:       config.PageTokenKey,
+		batchSize: config.InsertBatchSize,
+		isolation: config.Isolation,
+		rls:       config.RowLevelSecurity,
+
+		allowPrivateWebhooks: config.AllowPrivateWebhooks,
+	}
+	if len(config.Replicas) > 0 {
+		db.replicas = newReplicas(config)
 	}
 
 	return &db
@@ -39,11 +124,40 @@ type sqldb struct {
 
 	//	Database Connection
 	conn *gorm.DB
+
+	//	Key which signs the page tokens.
+	key []byte
+
+	//	Maximum number of records inserted per statement.
+	batchSize int
+
+	//	Isolation level of the transactions.
+	isolation sql.IsolationLevel
+
+	//	Whether the webhooks may target private addresses.
+	allowPrivateWebhooks bool
+
+	//	Router of the reads to the replicas, if any.
+	replicas *replicas
+
+	//	Whether the database enforces the row level security of the records, so every transaction must carry the
+	//	identity of its caller.
+	rls bool
+}
+
+// nextVersion increments the version of the records in an update.
+var nextVersion = gorm.Expr("? + 1", clause.Column{Name: "version"})
+
+// pageTokenKey returns the key which signs the page tokens.
+func (db *sqldb) pageTokenKey() []byte {
+	if len(db.key) == 0 {
+		return defaultPageTokenKey
+	}
+	return db.key
 }
 
-// Create operation creates a new record in the database.
+// Create operation creates a new record in the database, along with its first revision.
 func (db *sqldb) Create(ctx context.Context, options *CreateOptions) (*model.Record, error) {
-	txn := db.conn.WithContext(ctx)
 	if options == nil {
 		return nil, ErrInvalidOptions
 	}
@@ -52,30 +166,41 @@ func (db *sqldb) Create(ctx context.Context, options *CreateOptions) (*model.Rec
 	}
 
 	//
-	// This method has no Row Level Security (RLS) checks.
+	// This method has no Row Level Security (RLS) checks in Go. On Postgres, the policy of the records only lets
+	// the callers create their own records.
 	//
 
 	// Prepare the payload we have to send to the database transaction.
 	var payload model.Record
 	payload.Title = options.Title
 	payload.UserID = options.UserID
+	payload.Version = 1
 
 	// Execute the transaction.
-	result := txn.Create(&payload)
-	if result.Error != nil {
-		return nil, result.Error
+	err := db.RunInTx(ctx, func(ctx context.Context) error {
+		if err := db.session(ctx).Create(&payload).Error; err != nil {
+			return err
+		}
+		return db.changed(ctx, &payload, model.RevisionCreate)
+	})
+	if err != nil {
+		return nil, err
 	}
 	return &payload, nil
 }
 
-// List operation fetches a list of records from the database.
-func (db *sqldb) List(ctx context.Context, options *ListOptions) ([]*model.Record, error) {
-	txn := db.conn.WithContext(ctx)
+// List operation fetches a page of records from the database, along with the token of the next page.
+//
+// The pages are keyset paginated: the page token holds the sort key and the ID of the last record of the page,
+// so the pages neither skip nor repeat records when records are inserted or deleted between requests.
+// The token of the next page is empty on the last page.
+func (db *sqldb) List(ctx context.Context, options *ListOptions) ([]*model.Record, string, error) {
+	txn := db.reader(ctx, "list")
 	if options == nil {
 		options = &ListOptions{}
 	}
 	if err := options.validate(); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	// If the request context contains JWT claims, apply Row Level Security (RLS) checks.
@@ -91,33 +216,79 @@ func (db *sqldb) List(ctx context.Context, options *ListOptions) ([]*model.Recor
 	var payload []*model.Record
 
 	query := txn
-	if options.Limit > 0 {
-		query = query.Limit(options.Limit)
-	}
-	if options.Skip > 0 {
-		query = query.Offset(options.Skip)
-	}
-	if options.OrderBy != "" {
-		query = query.Order(options.OrderBy + " " + options.OrderDirection)
+	if options.ShowDeleted {
+		query = query.Unscoped()
 	}
 	if options.Title != "" {
 		query = query.Where(&model.Record{
 			Title: options.Title,
 		})
 	}
+	condition, err := options.condition()
+	if err != nil {
+		return nil, "", err
+	}
+	if condition != nil {
+		query = query.Where(condition)
+	}
 
-	if result := query.Find(&payload); result.Error != nil {
-		return nil, result.Error
+	// The page token is only valid for the query it was issued for.
+	orders, err := options.orders()
+	if err != nil {
+		return nil, "", err
+	}
+	description := describe(orders, "title="+options.Title, "filter="+options.Filter, fmt.Sprintf("show_deleted=%t", options.ShowDeleted))
+	if options.PageToken != "" {
+		cursor, err := decodePageToken(db.pageTokenKey(), options.PageToken)
+		if err != nil {
+			return nil, "", err
+		}
+		if cursor.Query != description {
+			return nil, "", ErrInvalidPageToken
+		}
+		condition, err := after(orders, cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		query = query.Where(condition)
+	}
+	for _, column := range columns(orders) {
+		query = query.Order(column)
+	}
+
+	// Fetch one more record than the page size, to know whether there is a next page.
+	size := options.pageSize()
+	if err := db.scoped(ctx, query, func(query *gorm.DB) error {
+		return query.Limit(size + 1).Find(&payload).Error
+	}); err != nil {
+		return nil, "", err
+	}
+	if len(payload) <= size {
+		return payload, "", nil
+	}
+
+	payload = payload[:size]
+	token, err := encodePageToken(db.pageTokenKey(), newCursor(description, orders, payload[size-1]))
+	if err != nil {
+		return nil, "", err
 	}
-	return payload, nil
+	return payload, token, nil
 }
 
 // Get operation fetches a record from the database.
-func (db *sqldb) Get(ctx context.Context, ID uuid.UUID) (*model.Record, error) {
-	txn := db.conn.WithContext(ctx)
+//
+// Soft-deleted records are only returned with `ShowDeleted`.
+func (db *sqldb) Get(ctx context.Context, ID uuid.UUID, options *GetOptions) (*model.Record, error) {
+	txn := db.reader(ctx, "get")
 	if ID == uuid.Nil {
 		return nil, ErrInvalidRecordID
 	}
+	if options == nil {
+		options = &GetOptions{}
+	}
+	if options.ShowDeleted {
+		txn = txn.Unscoped()
+	}
 
 	// If the request context contains JWT claims, apply Row Level Security (RLS) checks.
 	claims, exists := ctx.Value(middleware.XJWTClaims).(middleware.JWTClaims)
@@ -131,16 +302,23 @@ func (db *sqldb) Get(ctx context.Context, ID uuid.UUID) (*model.Record, error) {
 
 	var payload model.Record
 	payload.ID = ID
-	result := txn.First(&payload)
-	if result.Error != nil {
-		return nil, result.Error
+	if err := db.scoped(ctx, txn, func(txn *gorm.DB) error {
+		return txn.First(&payload).Error
+	}); err != nil {
+		return nil, err
 	}
 	return &payload, nil
 }
 
-// Update operation updates a record in the database.
+// Update operation updates the fields of the update mask of a record in the database.
+//
+// The record is updated, fetched again and revised in a transaction, so it returns the record as updated.
 func (db *sqldb) Update(ctx context.Context, id uuid.UUID, options *UpdateOptions) (*model.Record, error) {
-	txn := db.conn.WithContext(ctx)
+	return db.update(ctx, id, options, model.RevisionUpdate)
+}
+
+// update updates the fields of the update mask of a record, and records the change as a revision of the action.
+func (db *sqldb) update(ctx context.Context, id uuid.UUID, options *UpdateOptions, action string) (*model.Record, error) {
 	if id == uuid.Nil {
 		return nil, ErrInvalidRecordID
 	}
@@ -151,36 +329,179 @@ func (db *sqldb) Update(ctx context.Context, id uuid.UUID, options *UpdateOption
 		return nil, err
 	}
 
+	// Only update the columns of the update mask, along with the version.
+	columns, err := options.columns()
+	if err != nil {
+		return nil, err
+	}
+	columns["version"] = nextVersion
+
+	var record *model.Record
+	err = db.RunInTx(ctx, func(ctx context.Context) error {
+		txn := db.session(ctx)
+
+		// If the request context contains JWT claims, apply Row Level Security (RLS) checks.
+		claims, exists := ctx.Value(middleware.XJWTClaims).(middleware.JWTClaims)
+		if exists {
+
+			// 1. Only the user who created the record can update it.
+			txn = txn.Where(&model.Record{
+				UserID: claims.XUserID,
+			})
+		}
+
+		// Only update the expected version of the record, if any.
+		// The version is compared in the same statement, so concurrent updates cannot overwrite each other.
+		if options.Version != 0 {
+			txn = txn.Where(clause.Eq{Column: clause.Column{Name: "version"}, Value: options.Version})
+		}
+
+		var payload model.Record
+		payload.ID = id
+		result := txn.Model(&payload).Updates(columns)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		var err error
+		record, err = db.Get(ctx, id, nil)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionMismatch
+		}
+		return db.changed(ctx, record, action)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Delete operation deletes a record from the database.
+//
+// The record is only soft-deleted, so it can be restored with `Undelete`, unless `Force` is set.
+// A soft deletion is revised like any other change, while a forced one deletes the revisions of the record too.
+// Either way, a `record.deleted` event is written to the outbox.
+func (db *sqldb) Delete(ctx context.Context, ID uuid.UUID, options *DeleteOptions) error {
+	if ID == uuid.Nil {
+		return ErrInvalidRecordID
+	}
+	if options == nil {
+		options = &DeleteOptions{}
+	}
+	return db.RunInTx(ctx, func(ctx context.Context) error {
+		return db.delete(ctx, ID, options)
+	})
+}
+
+// delete deletes a record, in the transaction of the context.
+func (db *sqldb) delete(ctx context.Context, ID uuid.UUID, options *DeleteOptions) error {
+	txn := db.session(ctx)
+	if options.Force {
+		txn = txn.Unscoped()
+	}
+
 	// If the request context contains JWT claims, apply Row Level Security (RLS) checks.
 	claims, exists := ctx.Value(middleware.XJWTClaims).(middleware.JWTClaims)
 	if exists {
 
-		// 1. Only the user who created the record can update it.
+		// 1. Only the user who created the record can delete it.
 		txn = txn.Where(&model.Record{
 			UserID: claims.XUserID,
 		})
 	}
 
+	// Only delete the expected version of the record, if any.
+	if options.Version != 0 {
+		txn = txn.Where(clause.Eq{Column: clause.Column{Name: "version"}, Value: options.Version})
+	}
+
+	// A forced deletion leaves nothing behind, so its event holds the record as it was before.
+	// The caller cannot delete a record they cannot get.
+	var deleted *model.Record
+	if options.Force {
+		var err error
+		if deleted, err = db.Get(ctx, ID, &GetOptions{ShowDeleted: true}); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNoRowsAffected
+			}
+			return err
+		}
+	}
+
 	var payload model.Record
-	payload.ID = id
-	if result := txn.Model(&payload).Updates(options); result.Error != nil {
-		return nil, result.Error
+	payload.ID = ID
+
+	var result *gorm.DB
+	if options.Force {
+		result = txn.Delete(&payload)
+	} else {
+
+		// Soft-delete the record, which is a change of the record like any other, so it gets a new version.
+		result = txn.Model(&payload).UpdateColumns(map[string]any{
+			"deleted_at": db.conn.NowFunc(),
+			"version":    nextVersion,
+		})
 	}
-	return db.Get(ctx, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+
+		// The record may exist, but at another version.
+		if options.Version != 0 {
+			if _, err := db.Get(ctx, ID, &GetOptions{ShowDeleted: options.Force}); err == nil {
+				return ErrVersionMismatch
+			}
+		}
+		return ErrNoRowsAffected
+	}
+
+	if options.Force {
+		if err := db.session(ctx).Where(&model.Revision{RecordID: ID}).Delete(&model.Revision{}).Error; err != nil {
+			return err
+		}
+		return db.emit(ctx, deleted, model.EventRecordDeleted)
+	}
+	record, err := db.Get(ctx, ID, &GetOptions{ShowDeleted: true})
+	if err != nil {
+		return err
+	}
+	return db.changed(ctx, record, model.RevisionDelete)
 }
 
-// Delete operation deletes a record from the database.
-func (db *sqldb) Delete(ctx context.Context, ID uuid.UUID) error {
-	txn := db.conn.WithContext(ctx)
+// Undelete operation restores a soft-deleted record, and revises it.
+func (db *sqldb) Undelete(ctx context.Context, ID uuid.UUID) (*model.Record, error) {
 	if ID == uuid.Nil {
-		return ErrInvalidRecordID
+		return nil, ErrInvalidRecordID
+	}
+
+	var record *model.Record
+	err := db.RunInTx(ctx, func(ctx context.Context) error {
+		var err error
+		record, err = db.undelete(ctx, ID)
+		if err != nil {
+			return err
+		}
+		return db.changed(ctx, record, model.RevisionUndelete)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return record, nil
+}
+
+// undelete restores a soft-deleted record, in the transaction of the context.
+func (db *sqldb) undelete(ctx context.Context, ID uuid.UUID) (*model.Record, error) {
+	txn := db.session(ctx)
 
 	// If the request context contains JWT claims, apply Row Level Security (RLS) checks.
 	claims, exists := ctx.Value(middleware.XJWTClaims).(middleware.JWTClaims)
 	if exists {
 
-		// 1. Only the user who created the record can delete it.
+		// 1. Only the user who created the record can undelete it.
 		txn = txn.Where(&model.Record{
 			UserID: claims.XUserID,
 		})
@@ -188,12 +509,71 @@ func (db *sqldb) Delete(ctx context.Context, ID uuid.UUID) error {
 
 	var payload model.Record
 	payload.ID = ID
-	result := txn.Delete(&payload)
+	result := txn.Unscoped().Model(&payload).Where(clause.Neq{Column: clause.Column{Name: "deleted_at"}, Value: nil}).Updates(map[string]any{
+		"deleted_at": nil,
+		"version":    nextVersion,
+	})
 	if result.Error != nil {
-		return result.Error
+		return nil, result.Error
 	}
 	if result.RowsAffected == 0 {
-		return ErrNoRowsAffected
+		return nil, ErrNoRowsAffected
+	}
+	return db.Get(ctx, ID, nil)
+}
+
+// Purge operation permanently deletes the records which were soft-deleted before the supplied time.
+//
+// The revisions of the purged records are deleted along with them. It returns the number of records purged.
+func (db *sqldb) Purge(ctx context.Context, options *PurgeOptions) (int64, error) {
+	if options == nil {
+		return 0, ErrInvalidOptions
+	}
+	if err := options.validate(); err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	err := db.RunInTx(ctx, func(ctx context.Context) error {
+		txn := db.session(ctx).Unscoped()
+
+		// If the request context contains JWT claims, apply Row Level Security (RLS) checks.
+		claims, exists := ctx.Value(middleware.XJWTClaims).(middleware.JWTClaims)
+		if exists {
+
+			// 1. Only the user who created the records can purge them.
+			txn = txn.Where(&model.Record{
+				UserID: claims.XUserID,
+			})
+		}
+
+		// GORM stores the timestamps in the local time zone, and SQLite compares them as text,
+		// so the cutoff must be in the same time zone to compare correctly.
+		var ids []uuid.UUID
+		if err := txn.Model(&model.Record{}).Where(clause.Lt{Column: clause.Column{Name: "deleted_at"}, Value: options.DeletedBefore.Local()}).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		// Delete the revisions of the expired records first, and then the records themselves.
+		values := make([]any, len(ids))
+		for i, id := range ids {
+			values[i] = id
+		}
+		if err := db.session(ctx).Where(clause.IN{Column: clause.Column{Name: "record_id"}, Values: values}).Delete(&model.Revision{}).Error; err != nil {
+			return err
+		}
+		result := db.session(ctx).Unscoped().Where(clause.IN{Column: clause.Column{Name: "id"}, Values: values}).Delete(&model.Record{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return 0, err
 	}
-	return nil
+	return purged, nil
 }
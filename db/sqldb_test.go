@@ -2,8 +2,11 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mrinalwahal/service/model"
@@ -29,7 +32,7 @@ func configure(t *testing.T) *testsqldbconfig {
 	}
 
 	// Migrate the schema.
-	if err := conn.AutoMigrate(&model.Record{}); err != nil {
+	if err := conn.AutoMigrate(&model.Record{}, &model.Revision{}, &model.Event{}, &model.Webhook{}, &model.Delivery{}); err != nil {
 		t.Fatalf("failed to migrate the schema: %v", err)
 	}
 
@@ -154,7 +157,7 @@ func Test_Database_List(t *testing.T) {
 
 	t.Run("list records with nil options", func(t *testing.T) {
 
-		records, err := db.List(ctx, nil)
+		records, _, err := db.List(ctx, nil)
 		if err != nil {
 			t.Fatalf("failed to list records: %v", err)
 		}
@@ -166,9 +169,8 @@ func Test_Database_List(t *testing.T) {
 
 	t.Run("list records with invalid options", func(t *testing.T) {
 
-		records, err := db.List(ctx, &ListOptions{
-			Skip:  -1,
-			Limit: -1,
+		records, _, err := db.List(ctx, &ListOptions{
+			PageSize: -1,
 		})
 		if err == nil {
 			t.Errorf("service.List() error = %v, wantErr %v", err, true)
@@ -181,7 +183,7 @@ func Test_Database_List(t *testing.T) {
 
 	t.Run("list records with valid options", func(t *testing.T) {
 
-		records, err := db.List(ctx, &ListOptions{})
+		records, _, err := db.List(ctx, &ListOptions{})
 		if err != nil {
 			t.Fatalf("failed to list records: %v", err)
 		}
@@ -198,7 +200,7 @@ func Test_Database_List(t *testing.T) {
 			XUserID: uuid.New(),
 		})
 
-		records, err := db.List(ctx, &ListOptions{})
+		records, _, err := db.List(ctx, &ListOptions{})
 		if err != nil {
 			t.Fatalf("failed to list records: %v", err)
 		}
@@ -210,7 +212,7 @@ func Test_Database_List(t *testing.T) {
 
 	t.Run("list w/ title filter", func(t *testing.T) {
 
-		records, err := db.List(ctx, &ListOptions{
+		records, _, err := db.List(ctx, &ListOptions{
 			Title: "Record 1",
 		})
 		if err != nil {
@@ -222,37 +224,108 @@ func Test_Database_List(t *testing.T) {
 		}
 	})
 
-	t.Run("list w/ skip filter", func(t *testing.T) {
+	t.Run("list w/ page size", func(t *testing.T) {
 
-		records, err := db.List(ctx, &ListOptions{
-			Skip: 2,
+		records, token, err := db.List(ctx, &ListOptions{
+			PageSize: 2,
 		})
 		if err != nil {
 			t.Fatalf("failed to list records: %v", err)
 		}
 
-		if len(records) != 3 {
-			t.Fatalf("expected 3 records, got %d", len(records))
+		if len(records) != 2 {
+			t.Fatalf("expected 2 records, got %d", len(records))
+		}
+		if token == "" {
+			t.Fatalf("expected a next page token, got none")
 		}
 	})
 
-	t.Run("list w/ limit filter", func(t *testing.T) {
+	t.Run("list w/ page size larger than the number of records", func(t *testing.T) {
 
-		records, err := db.List(ctx, &ListOptions{
-			Limit: 2,
+		records, token, err := db.List(ctx, &ListOptions{
+			PageSize: 1000,
 		})
 		if err != nil {
 			t.Fatalf("failed to list records: %v", err)
 		}
 
-		if len(records) != 2 {
-			t.Fatalf("expected 2 records, got %d", len(records))
+		if len(records) != 5 {
+			t.Fatalf("expected 5 records, got %d", len(records))
+		}
+		if token != "" {
+			t.Fatalf("expected no next page token on the last page, got %q", token)
+		}
+	})
+
+	t.Run("list w/ tampered page token", func(t *testing.T) {
+
+		_, token, err := db.List(ctx, &ListOptions{
+			PageSize: 2,
+		})
+		if err != nil {
+			t.Fatalf("failed to list records: %v", err)
+		}
+
+		tampered := []byte(token)
+		tampered[len(tampered)/2] ^= 1
+		for _, token := range []string{string(tampered), "not a token"} {
+			if _, _, err := db.List(ctx, &ListOptions{PageSize: 2, PageToken: token}); err != ErrInvalidPageToken {
+				t.Errorf("db.List() error = %v, want %v", err, ErrInvalidPageToken)
+			}
+		}
+	})
+
+	t.Run("list w/ page token of a different query", func(t *testing.T) {
+
+		_, token, err := db.List(ctx, &ListOptions{
+			PageSize: 2,
+		})
+		if err != nil {
+			t.Fatalf("failed to list records: %v", err)
+		}
+
+		_, _, err = db.List(ctx, &ListOptions{
+			PageSize:  2,
+			PageToken: token,
+			OrderBy:   "title",
+		})
+		if err != ErrInvalidPageToken {
+			t.Errorf("db.List() error = %v, want %v", err, ErrInvalidPageToken)
+		}
+	})
+
+	t.Run("list w/ page token signed by a different key", func(t *testing.T) {
+
+		_, token, err := db.List(ctx, &ListOptions{
+			PageSize: 2,
+		})
+		if err != nil {
+			t.Fatalf("failed to list records: %v", err)
+		}
+
+		other := &sqldb{
+			conn: config.conn,
+			key:  []byte("another key"),
+		}
+		if _, _, err := other.List(ctx, &ListOptions{PageSize: 2, PageToken: token}); err != ErrInvalidPageToken {
+			t.Errorf("db.List() error = %v, want %v", err, ErrInvalidPageToken)
+		}
+	})
+
+	t.Run("list w/ invalid orderBy filter", func(t *testing.T) {
+
+		_, _, err := db.List(ctx, &ListOptions{
+			OrderBy: "title; DROP TABLE records",
+		})
+		if !errors.Is(err, ErrInvalidFilters) {
+			t.Errorf("db.List() error = %v, want %v", err, ErrInvalidFilters)
 		}
 	})
 
 	t.Run("list w/ orderBy filter", func(t *testing.T) {
 
-		records, err := db.List(ctx, &ListOptions{
+		records, _, err := db.List(ctx, &ListOptions{
 			OrderBy: "title",
 		})
 		if err != nil {
@@ -265,11 +338,10 @@ func Test_Database_List(t *testing.T) {
 		}
 	})
 
-	t.Run("list w/ orderBy and orderDirection filter", func(t *testing.T) {
+	t.Run("list w/ descending orderBy filter", func(t *testing.T) {
 
-		records, err := db.List(ctx, &ListOptions{
-			OrderBy:        "title",
-			OrderDirection: "desc",
+		records, _, err := db.List(ctx, &ListOptions{
+			OrderBy: "title desc",
 		})
 		if err != nil {
 			t.Fatalf("failed to list records: %v", err)
@@ -279,6 +351,188 @@ func Test_Database_List(t *testing.T) {
 			t.Fatalf("expected first record to be 'Record 4', got '%s'", records[0].Title)
 		}
 	})
+
+	t.Run("list soft-deleted records", func(t *testing.T) {
+
+		user := uuid.New()
+		ctx := context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+			XUserID: user,
+		})
+		for _, title := range []string{"Kept", "Deleted"} {
+			record, err := db.Create(ctx, &CreateOptions{
+				Title:  title,
+				UserID: user,
+			})
+			if err != nil {
+				t.Fatalf("failed to seed the database: %v", err)
+			}
+			if title == "Deleted" {
+				if err := db.Delete(ctx, record.ID, nil); err != nil {
+					t.Fatalf("failed to delete record: %v", err)
+				}
+			}
+		}
+
+		records, _, err := db.List(ctx, &ListOptions{})
+		if err != nil {
+			t.Fatalf("failed to list records: %v", err)
+		}
+		if len(records) != 1 || records[0].Title != "Kept" {
+			t.Errorf("expected only the kept record, got %d records", len(records))
+		}
+
+		records, _, err = db.List(ctx, &ListOptions{ShowDeleted: true})
+		if err != nil {
+			t.Fatalf("failed to list records: %v", err)
+		}
+		if len(records) != 2 {
+			t.Errorf("expected 2 records, got %d", len(records))
+		}
+
+		// The page token of a listing w/o the deleted records is not valid for one with them.
+		_, token, err := db.List(ctx, &ListOptions{PageSize: 1, ShowDeleted: true})
+		if err != nil {
+			t.Fatalf("failed to list records: %v", err)
+		}
+		if _, _, err := db.List(ctx, &ListOptions{PageSize: 1, PageToken: token}); err != ErrInvalidPageToken {
+			t.Errorf("db.List() error = %v, want %v", err, ErrInvalidPageToken)
+		}
+	})
+}
+
+func Test_Database_List_Pagination(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Initialize the database.
+	db := &sqldb{
+		conn: config.conn,
+	}
+
+	// List the records of a single user, so the records of the other tests are left out.
+	user := uuid.New()
+	ctx := context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+		XUserID: user,
+	})
+
+	// Seed the database with records which share their titles, so the ID tiebreaker is exercised.
+	seeded := make(map[uuid.UUID]bool)
+	for i := 0; i < 10; i++ {
+		record, err := db.Create(ctx, &CreateOptions{
+			Title:  fmt.Sprintf("Record %d", i%3),
+			UserID: user,
+		})
+		if err != nil {
+			t.Fatalf("failed to seed the database: %v", err)
+		}
+		seeded[record.ID] = true
+	}
+
+	// less reports whether the record a is sorted strictly before the record b.
+	less := func(orders []order, a, b *model.Record) bool {
+		for _, o := range orders {
+			var compare int
+			switch o.field {
+			case "title":
+				compare = strings.Compare(a.Title, b.Title)
+			case "updated_at":
+				compare = a.UpdatedAt.Compare(b.UpdatedAt)
+			case "created_at":
+				compare = a.CreatedAt.Compare(b.CreatedAt)
+			}
+			if o.desc {
+				compare = -compare
+			}
+			if compare != 0 {
+				return compare < 0
+			}
+		}
+		compare := strings.Compare(a.ID.String(), b.ID.String())
+		if tiebreaker(orders) {
+			compare = -compare
+		}
+		return compare < 0
+	}
+
+	for _, orderBy := range []string{
+		"",
+		"created_at",
+		"created_at desc",
+		"updated_at asc",
+		"updated_at desc",
+		"title",
+		"title desc",
+		"title desc, created_at",
+		"title, updated_at desc",
+	} {
+		options := &ListOptions{
+			PageSize: 3,
+			OrderBy:  orderBy,
+		}
+		orders, err := parseOrderBy(orderBy, sortables)
+		if err != nil {
+			t.Fatalf("failed to parse the order: %v", err)
+		}
+
+		t.Run(fmt.Sprintf("page through records ordered by %q", orderBy), func(t *testing.T) {
+
+			var (
+				listed []*model.Record
+				pages  int
+			)
+			for {
+				records, token, err := db.List(ctx, options)
+				if err != nil {
+					t.Fatalf("failed to list records: %v", err)
+				}
+				listed = append(listed, records...)
+				pages++
+				if token == "" {
+					break
+				}
+				if pages > len(seeded) {
+					t.Fatalf("expected the pagination to end, got %d pages", pages)
+				}
+
+				// Insert a record between the pages, which must not shift the next pages.
+				record, err := db.Create(ctx, &CreateOptions{
+					Title:  "Record 1",
+					UserID: user,
+				})
+				if err != nil {
+					t.Fatalf("failed to create record: %v", err)
+				}
+				t.Cleanup(func() {
+					if err := db.Delete(context.Background(), record.ID, nil); err != nil {
+						t.Errorf("failed to delete record: %v", err)
+					}
+				})
+
+				options = &ListOptions{
+					PageSize:  options.PageSize,
+					PageToken: token,
+					OrderBy:   options.OrderBy,
+				}
+			}
+
+			found := make(map[uuid.UUID]bool)
+			for i, record := range listed {
+				if found[record.ID] {
+					t.Fatalf("expected record %s to be listed once, got it twice", record.ID)
+				}
+				found[record.ID] = true
+				if i > 0 && !less(orders, listed[i-1], record) {
+					t.Fatalf("expected record %d to be sorted after record %d", i, i-1)
+				}
+			}
+			for id := range seeded {
+				if !found[id] {
+					t.Fatalf("expected record %s to be listed, got it skipped", id)
+				}
+			}
+		})
+	}
 }
 
 func Test_Database_Get(t *testing.T) {
@@ -306,7 +560,7 @@ func Test_Database_Get(t *testing.T) {
 
 	t.Run("get record with nil ID", func(t *testing.T) {
 
-		_, err := db.Get(ctx, uuid.Nil)
+		_, err := db.Get(ctx, uuid.Nil, nil)
 		if err == nil {
 			t.Errorf("service.Get() error = %v, wantErr %v", err, true)
 		}
@@ -314,7 +568,7 @@ func Test_Database_Get(t *testing.T) {
 
 	t.Run("get record with valid ID", func(t *testing.T) {
 
-		record, err := db.Get(ctx, seed.ID)
+		record, err := db.Get(ctx, seed.ID, nil)
 		if err != nil {
 			t.Fatalf("failed to get record: %v", err)
 		}
@@ -331,11 +585,34 @@ func Test_Database_Get(t *testing.T) {
 			XUserID: uuid.New(),
 		})
 
-		_, err := db.Get(ctx, seed.ID)
+		_, err := db.Get(ctx, seed.ID, nil)
 		if err == nil {
 			t.Errorf("service.Get() error = %v, wantErr %v", err, true)
 		}
 	})
+
+	t.Run("get a soft-deleted record", func(t *testing.T) {
+
+		deleted, err := db.Create(ctx, &options)
+		if err != nil {
+			t.Fatalf("failed to seed the database: %v", err)
+		}
+		if err := db.Delete(ctx, deleted.ID, nil); err != nil {
+			t.Fatalf("failed to delete record: %v", err)
+		}
+
+		if _, err := db.Get(ctx, deleted.ID, nil); err == nil {
+			t.Errorf("service.Get() error = %v, wantErr %v", err, true)
+		}
+
+		record, err := db.Get(ctx, deleted.ID, &GetOptions{ShowDeleted: true})
+		if err != nil {
+			t.Fatalf("failed to get record: %v", err)
+		}
+		if !record.DeletedAt.Valid {
+			t.Errorf("expected the record to be marked deleted, got %v", record.DeletedAt)
+		}
+	})
 }
 
 func Test_Database_Update(t *testing.T) {
@@ -418,6 +695,108 @@ func Test_Database_Update(t *testing.T) {
 			t.Errorf("service.Update() error = %v, wantErr %v", err, true)
 		}
 	})
+
+	t.Run("update record w/ version", func(t *testing.T) {
+
+		record, err := db.Create(ctx, &options)
+		if err != nil {
+			t.Fatalf("failed to seed the database: %v", err)
+		}
+		if record.Version != 1 || record.ETag != `"1"` {
+			t.Fatalf("expected a new record to be at version 1, got %d (%s)", record.Version, record.ETag)
+		}
+
+		// Two clients read the same version, and both try to update it.
+		first, err := db.Update(ctx, record.ID, &UpdateOptions{
+			Title:   "First",
+			Version: record.Version,
+		})
+		if err != nil {
+			t.Fatalf("failed to update record: %v", err)
+		}
+		if first.Version != 2 || first.ETag != `"2"` {
+			t.Errorf("expected the record to be at version 2, got %d (%s)", first.Version, first.ETag)
+		}
+
+		_, err = db.Update(ctx, record.ID, &UpdateOptions{
+			Title:   "Second",
+			Version: record.Version,
+		})
+		if err != ErrVersionMismatch {
+			t.Fatalf("service.Update() error = %v, want %v", err, ErrVersionMismatch)
+		}
+
+		// The first update must not have been overwritten.
+		got, err := db.Get(ctx, record.ID, nil)
+		if err != nil {
+			t.Fatalf("failed to get record: %v", err)
+		}
+		if got.Title != "First" || got.Version != 2 {
+			t.Errorf("expected the first update to be kept, got %q at version %d", got.Title, got.Version)
+		}
+
+		// Updates w/o version always apply, and increment the version.
+		updated, err := db.Update(ctx, record.ID, &UpdateOptions{
+			Title: "Third",
+		})
+		if err != nil {
+			t.Fatalf("failed to update record: %v", err)
+		}
+		if updated.Version != 3 {
+			t.Errorf("expected the record to be at version 3, got %d", updated.Version)
+		}
+	})
+
+	t.Run("update record w/ update mask", func(t *testing.T) {
+
+		record, err := db.Create(ctx, &options)
+		if err != nil {
+			t.Fatalf("failed to seed the database: %v", err)
+		}
+
+		// The fields outside the mask are not validated, nor updated.
+		updated, err := db.Update(ctx, record.ID, &UpdateOptions{
+			UpdateMask: []string{"title"},
+			Title:      "Masked Record",
+		})
+		if err != nil {
+			t.Fatalf("failed to update record: %v", err)
+		}
+		if updated.Title != "Masked Record" || updated.UserID != record.UserID || !updated.CreatedAt.Equal(record.CreatedAt) {
+			t.Errorf("expected only the title to be updated, got %+v", updated)
+		}
+
+		for _, mask := range [][]string{{"user_id"}, {"id"}, {"created_at"}, {"version"}, {"name"}} {
+			_, err := db.Update(ctx, record.ID, &UpdateOptions{
+				UpdateMask: mask,
+				Title:      "Rejected Record",
+			})
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Field != "update_mask" {
+				t.Errorf("service.Update() error = %v, want a field error of update_mask for %v", err, mask)
+			}
+		}
+
+		// The rejected updates must not have changed the record.
+		got, err := db.Get(ctx, record.ID, nil)
+		if err != nil {
+			t.Fatalf("failed to get record: %v", err)
+		}
+		if got.Title != "Masked Record" || got.Version != 2 {
+			t.Errorf("expected the record to be left unchanged, got %q at version %d", got.Title, got.Version)
+		}
+	})
+
+	t.Run("update missing record w/ version", func(t *testing.T) {
+
+		_, err := db.Update(ctx, uuid.New(), &UpdateOptions{
+			Title:   "Updated Record",
+			Version: 1,
+		})
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("service.Update() error = %v, want %v", err, gorm.ErrRecordNotFound)
+		}
+	})
 }
 
 func Test_Database_Delete(t *testing.T) {
@@ -434,7 +813,7 @@ func Test_Database_Delete(t *testing.T) {
 
 	t.Run("delete record with nil ID", func(t *testing.T) {
 
-		err := db.Delete(ctx, uuid.Nil)
+		err := db.Delete(ctx, uuid.Nil, nil)
 		if err == nil {
 			t.Errorf("service.Delete() error = %v, wantErr %v", err, true)
 		}
@@ -450,7 +829,7 @@ func Test_Database_Delete(t *testing.T) {
 			t.Fatalf("failed to seed the database: %v", err)
 		}
 
-		if err := db.Delete(ctx, seed.ID); err != nil {
+		if err := db.Delete(ctx, seed.ID, nil); err != nil {
 			t.Fatalf("failed to delete record: %v", err)
 		}
 	})
@@ -470,9 +849,271 @@ func Test_Database_Delete(t *testing.T) {
 			XUserID: uuid.New(),
 		})
 
-		err = db.Delete(ctx, seed.ID)
+		err = db.Delete(ctx, seed.ID, nil)
 		if err == nil {
 			t.Errorf("service.Delete() error = %v, wantErr %v", err, true)
 		}
+
+		err = db.Delete(ctx, seed.ID, &DeleteOptions{Force: true})
+		if err == nil {
+			t.Errorf("service.Delete() error = %v, wantErr %v", err, true)
+		}
+	})
+
+	t.Run("delete record w/ version", func(t *testing.T) {
+
+		seed, err := db.Create(ctx, &CreateOptions{
+			Title:  "Test Record",
+			UserID: uuid.New(),
+		})
+		if err != nil {
+			t.Fatalf("failed to seed the database: %v", err)
+		}
+
+		err = db.Delete(ctx, seed.ID, &DeleteOptions{Version: seed.Version + 1})
+		if err != ErrVersionMismatch {
+			t.Fatalf("service.Delete() error = %v, want %v", err, ErrVersionMismatch)
+		}
+
+		if err := db.Delete(ctx, seed.ID, &DeleteOptions{Version: seed.Version}); err != nil {
+			t.Fatalf("failed to delete record: %v", err)
+		}
+
+		// Soft-deleting the record changes its version.
+		deleted, err := db.Get(ctx, seed.ID, &GetOptions{ShowDeleted: true})
+		if err != nil {
+			t.Fatalf("failed to get record: %v", err)
+		}
+		if deleted.Version != seed.Version+1 {
+			t.Errorf("expected the record to be at version %d, got %d", seed.Version+1, deleted.Version)
+		}
+
+		err = db.Delete(ctx, seed.ID, &DeleteOptions{Force: true, Version: seed.Version})
+		if err != ErrVersionMismatch {
+			t.Fatalf("service.Delete() error = %v, want %v", err, ErrVersionMismatch)
+		}
+		if err := db.Delete(ctx, seed.ID, &DeleteOptions{Force: true, Version: deleted.Version}); err != nil {
+			t.Fatalf("failed to force delete record: %v", err)
+		}
+	})
+
+	t.Run("force delete a soft-deleted record", func(t *testing.T) {
+
+		seed, err := db.Create(ctx, &CreateOptions{
+			Title:  "Test Record",
+			UserID: uuid.New(),
+		})
+		if err != nil {
+			t.Fatalf("failed to seed the database: %v", err)
+		}
+
+		if err := db.Delete(ctx, seed.ID, nil); err != nil {
+			t.Fatalf("failed to delete record: %v", err)
+		}
+		if err := db.Delete(ctx, seed.ID, &DeleteOptions{Force: true}); err != nil {
+			t.Fatalf("failed to force delete record: %v", err)
+		}
+
+		if _, err := db.Get(ctx, seed.ID, &GetOptions{ShowDeleted: true}); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("service.Get() error = %v, want %v", err, gorm.ErrRecordNotFound)
+		}
+	})
+
+	t.Run("force delete a missing record", func(t *testing.T) {
+
+		id := uuid.New()
+		if err := db.Delete(ctx, id, &DeleteOptions{Force: true}); err != ErrNoRowsAffected {
+			t.Errorf("service.Delete() error = %v, want %v", err, ErrNoRowsAffected)
+		}
+
+		// No event is written for a record which was not deleted.
+		var count int64
+		config.conn.Model(&model.Event{}).Where("record_id = ?", id).Count(&count)
+		if count != 0 {
+			t.Errorf("expected no event of the missing record, got %d", count)
+		}
+	})
+
+	t.Run("force delete a record which cannot be read", func(t *testing.T) {
+
+		seed, err := db.Create(ctx, &CreateOptions{
+			Title:  "Test Record",
+			UserID: uuid.New(),
+		})
+		if err != nil {
+			t.Fatalf("failed to seed the database: %v", err)
+		}
+
+		// The read of the record before its deletion fails, so nothing is deleted.
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		if err := db.Delete(cancelled, seed.ID, &DeleteOptions{Force: true}); err == nil {
+			t.Errorf("service.Delete() error = %v, wantErr %v", err, true)
+		}
+		if _, err := db.Get(ctx, seed.ID, nil); err != nil {
+			t.Errorf("expected the record to be kept, got error = %v", err)
+		}
+	})
+}
+
+func Test_Database_Undelete(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Initialize the database.
+	db := &sqldb{
+		conn: config.conn,
+	}
+
+	ctx := context.Background()
+
+	seed, err := db.Create(ctx, &CreateOptions{
+		Title:  "Test Record",
+		UserID: uuid.New(),
+	})
+	if err != nil {
+		t.Fatalf("failed to seed the database: %v", err)
+	}
+
+	t.Run("undelete record with nil ID", func(t *testing.T) {
+
+		_, err := db.Undelete(ctx, uuid.Nil)
+		if err == nil {
+			t.Errorf("service.Undelete() error = %v, wantErr %v", err, true)
+		}
+	})
+
+	t.Run("undelete a record which is not deleted", func(t *testing.T) {
+
+		_, err := db.Undelete(ctx, seed.ID)
+		if err != ErrNoRowsAffected {
+			t.Errorf("service.Undelete() error = %v, want %v", err, ErrNoRowsAffected)
+		}
+	})
+
+	if err := db.Delete(ctx, seed.ID, nil); err != nil {
+		t.Fatalf("failed to delete record: %v", err)
+	}
+
+	t.Run("undelete record as a different user than the one who created it", func(t *testing.T) {
+
+		// Add JWT claims to the context.
+		ctx := context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+			XUserID: uuid.New(),
+		})
+
+		_, err := db.Undelete(ctx, seed.ID)
+		if err == nil {
+			t.Errorf("service.Undelete() error = %v, wantErr %v", err, true)
+		}
+	})
+
+	t.Run("undelete a soft-deleted record", func(t *testing.T) {
+
+		// Add the JWT claims of the owner to the context.
+		ctx := context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+			XUserID: seed.UserID,
+		})
+
+		record, err := db.Undelete(ctx, seed.ID)
+		if err != nil {
+			t.Fatalf("failed to undelete record: %v", err)
+		}
+		if record.DeletedAt.Valid {
+			t.Errorf("expected the record to be restored, got %v", record.DeletedAt)
+		}
+		if _, err := db.Get(ctx, seed.ID, nil); err != nil {
+			t.Errorf("failed to get the restored record: %v", err)
+		}
+	})
+}
+
+func Test_Database_Purge(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Initialize the database.
+	db := &sqldb{
+		conn: config.conn,
+	}
+
+	ctx := context.Background()
+
+	// Seed records of two users, deleted at different times.
+	now := time.Now()
+	users := []uuid.UUID{uuid.New(), uuid.New()}
+	seed := func(user uuid.UUID, deleted time.Time) uuid.UUID {
+		record, err := db.Create(ctx, &CreateOptions{
+			Title:  "Test Record",
+			UserID: user,
+		})
+		if err != nil {
+			t.Fatalf("failed to seed the database: %v", err)
+		}
+		if !deleted.IsZero() {
+			if err := config.conn.Model(record).Update("deleted_at", deleted).Error; err != nil {
+				t.Fatalf("failed to delete record: %v", err)
+			}
+		}
+		return record.ID
+	}
+	expired := []uuid.UUID{
+		seed(users[0], now.Add(-48*time.Hour)),
+		seed(users[1], now.Add(-48*time.Hour)),
+	}
+	recent := seed(users[0], now.Add(-time.Hour))
+	live := seed(users[0], time.Time{})
+
+	exists := func(id uuid.UUID) bool {
+		_, err := db.Get(ctx, id, &GetOptions{ShowDeleted: true})
+		return err == nil
+	}
+
+	t.Run("purge records with nil options", func(t *testing.T) {
+
+		_, err := db.Purge(ctx, nil)
+		if err != ErrInvalidOptions {
+			t.Errorf("service.Purge() error = %v, want %v", err, ErrInvalidOptions)
+		}
+	})
+
+	t.Run("purge records w/o cutoff", func(t *testing.T) {
+
+		_, err := db.Purge(ctx, &PurgeOptions{})
+		if err == nil {
+			t.Errorf("service.Purge() error = %v, wantErr %v", err, true)
+		}
+	})
+
+	t.Run("purge the records of a user", func(t *testing.T) {
+
+		// Add JWT claims to the context.
+		ctx := context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+			XUserID: users[0],
+		})
+
+		purged, err := db.Purge(ctx, &PurgeOptions{DeletedBefore: now.Add(-24 * time.Hour)})
+		if err != nil {
+			t.Fatalf("failed to purge records: %v", err)
+		}
+		if purged != 1 || exists(expired[0]) || !exists(expired[1]) {
+			t.Errorf("expected only the expired record of the user to be purged, purged %d", purged)
+		}
+	})
+
+	t.Run("purge the records deleted before the cutoff", func(t *testing.T) {
+
+		purged, err := db.Purge(ctx, &PurgeOptions{DeletedBefore: now.Add(-24 * time.Hour)})
+		if err != nil {
+			t.Fatalf("failed to purge records: %v", err)
+		}
+		if purged != 1 || exists(expired[1]) {
+			t.Errorf("expected the expired record to be purged, purged %d", purged)
+		}
+		if !exists(recent) || !exists(live) {
+			t.Errorf("expected the recently deleted and the live records to be kept")
+		}
 	})
 }
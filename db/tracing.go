@@ -0,0 +1,294 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+// instrumentation is the name of the tracer of the database layer.
+const instrumentation = "github.com/mrinalwahal/service/db"
+
+type TracingConfig struct {
+
+	// DB is the database layer whose operations will be traced.
+	//
+	// This field is mandatory.
+	DB DB
+
+	// TracerProvider creates the tracer of the database layer.
+	// Default: `otel.GetTracerProvider()`
+	//
+	// This field is optional.
+	TracerProvider trace.TracerProvider
+}
+
+// WithTracing wraps the database layer to trace its operations.
+//
+// The SQL statements themselves are traced by the callbacks `Open` registers on the connection.
+func WithTracing(config *TracingConfig) DB {
+	if config == nil || config.DB == nil {
+		panic("db: nil tracing config")
+	}
+
+	provider := config.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return &tracing{
+		next:   config.DB,
+		tracer: provider.Tracer(instrumentation),
+	}
+}
+
+// tracing is the database layer decorator created by `WithTracing`.
+type tracing struct {
+
+	//	Traced database layer.
+	next DB
+
+	tracer trace.Tracer
+}
+
+// endSpan records the outcome of the operation on its span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (t *tracing) Create(ctx context.Context, options *CreateOptions) (*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "db.Create")
+	record, err := t.next.Create(ctx, options)
+	endSpan(span, err)
+	return record, err
+}
+
+func (t *tracing) List(ctx context.Context, options *ListOptions) ([]*model.Record, string, error) {
+	ctx, span := t.tracer.Start(ctx, "db.List")
+	records, token, err := t.next.List(ctx, options)
+	endSpan(span, err)
+	return records, token, err
+}
+
+func (t *tracing) Search(ctx context.Context, options *SearchOptions) ([]*model.SearchResult, error) {
+	ctx, span := t.tracer.Start(ctx, "db.Search")
+	results, err := t.next.Search(ctx, options)
+	endSpan(span, err)
+	return results, err
+}
+
+func (t *tracing) Get(ctx context.Context, id uuid.UUID, options *GetOptions) (*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "db.Get", trace.WithAttributes(attribute.String("record.id", id.String())))
+	record, err := t.next.Get(ctx, id, options)
+	endSpan(span, err)
+	return record, err
+}
+
+func (t *tracing) Update(ctx context.Context, id uuid.UUID, options *UpdateOptions) (*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "db.Update", trace.WithAttributes(attribute.String("record.id", id.String())))
+	record, err := t.next.Update(ctx, id, options)
+	endSpan(span, err)
+	return record, err
+}
+
+func (t *tracing) Delete(ctx context.Context, id uuid.UUID, options *DeleteOptions) error {
+	ctx, span := t.tracer.Start(ctx, "db.Delete", trace.WithAttributes(attribute.String("record.id", id.String())))
+	err := t.next.Delete(ctx, id, options)
+	endSpan(span, err)
+	return err
+}
+
+func (t *tracing) Undelete(ctx context.Context, id uuid.UUID) (*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "db.Undelete", trace.WithAttributes(attribute.String("record.id", id.String())))
+	record, err := t.next.Undelete(ctx, id)
+	endSpan(span, err)
+	return record, err
+}
+
+func (t *tracing) Purge(ctx context.Context, options *PurgeOptions) (int64, error) {
+	ctx, span := t.tracer.Start(ctx, "db.Purge")
+	purged, err := t.next.Purge(ctx, options)
+	endSpan(span, err)
+	return purged, err
+}
+
+func (t *tracing) BatchCreate(ctx context.Context, options *BatchCreateOptions) ([]*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "db.BatchCreate")
+	records, err := t.next.BatchCreate(ctx, options)
+	endSpan(span, err)
+	return records, err
+}
+
+func (t *tracing) BatchGet(ctx context.Context, options *BatchGetOptions) ([]*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "db.BatchGet")
+	records, err := t.next.BatchGet(ctx, options)
+	endSpan(span, err)
+	return records, err
+}
+
+func (t *tracing) BatchUpdate(ctx context.Context, options *BatchUpdateOptions) ([]*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "db.BatchUpdate")
+	records, err := t.next.BatchUpdate(ctx, options)
+	endSpan(span, err)
+	return records, err
+}
+
+func (t *tracing) BatchDelete(ctx context.Context, options *BatchDeleteOptions) error {
+	ctx, span := t.tracer.Start(ctx, "db.BatchDelete")
+	err := t.next.BatchDelete(ctx, options)
+	endSpan(span, err)
+	return err
+}
+
+func (t *tracing) ListRevisions(ctx context.Context, id uuid.UUID, options *ListRevisionsOptions) ([]*model.Revision, string, error) {
+	ctx, span := t.tracer.Start(ctx, "db.ListRevisions")
+	revisions, token, err := t.next.ListRevisions(ctx, id, options)
+	endSpan(span, err)
+	return revisions, token, err
+}
+
+func (t *tracing) GetRevision(ctx context.Context, id uuid.UUID, revision int64) (*model.Revision, error) {
+	ctx, span := t.tracer.Start(ctx, "db.GetRevision")
+	payload, err := t.next.GetRevision(ctx, id, revision)
+	endSpan(span, err)
+	return payload, err
+}
+
+func (t *tracing) DiffRevisions(ctx context.Context, id uuid.UUID, revision int64, options *DiffRevisionsOptions) (*model.Diff, error) {
+	ctx, span := t.tracer.Start(ctx, "db.DiffRevisions")
+	payload, err := t.next.DiffRevisions(ctx, id, revision, options)
+	endSpan(span, err)
+	return payload, err
+}
+
+func (t *tracing) Restore(ctx context.Context, id uuid.UUID, options *RestoreOptions) (*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "db.Restore")
+	record, err := t.next.Restore(ctx, id, options)
+	endSpan(span, err)
+	return record, err
+}
+
+func (t *tracing) CreateWebhook(ctx context.Context, options *CreateWebhookOptions) (*model.Webhook, error) {
+	ctx, span := t.tracer.Start(ctx, "db.CreateWebhook")
+	webhook, err := t.next.CreateWebhook(ctx, options)
+	endSpan(span, err)
+	return webhook, err
+}
+
+func (t *tracing) ListWebhooks(ctx context.Context, options *ListWebhooksOptions) ([]*model.Webhook, string, error) {
+	ctx, span := t.tracer.Start(ctx, "db.ListWebhooks")
+	webhooks, token, err := t.next.ListWebhooks(ctx, options)
+	endSpan(span, err)
+	return webhooks, token, err
+}
+
+func (t *tracing) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	ctx, span := t.tracer.Start(ctx, "db.DeleteWebhook")
+	err := t.next.DeleteWebhook(ctx, id)
+	endSpan(span, err)
+	return err
+}
+
+func (t *tracing) ListDeliveries(ctx context.Context, options *ListDeliveriesOptions) ([]*model.Delivery, string, error) {
+	ctx, span := t.tracer.Start(ctx, "db.ListDeliveries")
+	deliveries, token, err := t.next.ListDeliveries(ctx, options)
+	endSpan(span, err)
+	return deliveries, token, err
+}
+
+func (t *tracing) RunInTx(ctx context.Context, fn func(context.Context) error) error {
+	ctx, span := t.tracer.Start(ctx, "db.RunInTx", trace.WithAttributes(attribute.Bool("db.nested", InTx(ctx))))
+	err := t.next.RunInTx(ctx, fn)
+	endSpan(span, err)
+	return err
+}
+
+// tracingPlugin is a GORM plugin which records every SQL statement as a client span.
+//
+// The statements are recorded with their placeholders, never with their arguments, so the spans hold no user data.
+type tracingPlugin struct {
+	tracer trace.Tracer
+	system attribute.KeyValue
+}
+
+func (p *tracingPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize registers the callbacks around every kind of statement.
+func (p *tracingPlugin) Initialize(conn *gorm.DB) error {
+	type registerer interface {
+		Register(string, func(*gorm.DB)) error
+	}
+	callbacks := []struct {
+		operation     string
+		before, after registerer
+	}{
+		{"create", conn.Callback().Create().Before("gorm:create"), conn.Callback().Create().After("gorm:create")},
+		{"query", conn.Callback().Query().Before("gorm:query"), conn.Callback().Query().After("gorm:query")},
+		{"update", conn.Callback().Update().Before("gorm:update"), conn.Callback().Update().After("gorm:update")},
+		{"delete", conn.Callback().Delete().Before("gorm:delete"), conn.Callback().Delete().After("gorm:delete")},
+		{"row", conn.Callback().Row().Before("gorm:row"), conn.Callback().Row().After("gorm:row")},
+		{"raw", conn.Callback().Raw().Before("gorm:raw"), conn.Callback().Raw().After("gorm:raw")},
+	}
+	for _, callback := range callbacks {
+		if err := callback.before.Register("tracing:before_"+callback.operation, p.before(callback.operation)); err != nil {
+			return err
+		}
+		if err := callback.after.Register("tracing:after_"+callback.operation, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parent is the key of the context of the statement before its span was started.
+const parent = "tracing:parent"
+
+// before starts the span of the statement and stores it in the context of the statement.
+func (p *tracingPlugin) before(operation string) func(*gorm.DB) {
+	return func(tx *gorm.DB) {
+		ctx := tx.Statement.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		tx.InstanceSet(parent, ctx)
+		tx.Statement.Context, _ = p.tracer.Start(ctx, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(p.system),
+		)
+	}
+}
+
+// after ends the span started by `before`.
+func (p *tracingPlugin) after(tx *gorm.DB) {
+	ctx, ok := tx.InstanceGet(parent)
+	if !ok {
+		return
+	}
+	span := trace.SpanFromContext(tx.Statement.Context)
+
+	// Restore the context of the statement, so the next statements of the session are not children of this span.
+	tx.Statement.Context = ctx.(context.Context)
+
+	span.SetAttributes(
+		semconv.DBStatement(tx.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", tx.Statement.RowsAffected),
+	)
+	if tx.Statement.Table != "" {
+		span.SetAttributes(semconv.DBSQLTable(tx.Statement.Table))
+	}
+	endSpan(span, tx.Error)
+}
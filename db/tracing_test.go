@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+func Test_WithTracing(t *testing.T) {
+
+	ctx := context.Background()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	conn, err := Open(&OpenConfig{
+		Engine:         EngineSQLiteMemory,
+		TracerProvider: provider,
+	})
+	if err != nil {
+		t.Fatalf("failed to open the database connection: %v", err)
+	}
+	t.Cleanup(func() {
+		sqlDB, err := conn.DB()
+		if err != nil {
+			t.Fatalf("failed to get the database connection: %v", err)
+		}
+		if err := sqlDB.Close(); err != nil {
+			t.Fatalf("failed to close the database connection: %v", err)
+		}
+	})
+	if err := AutoMigrate(ctx, conn); err != nil {
+		t.Fatalf("failed to migrate the schema: %v", err)
+	}
+	exporter.Reset()
+
+	db := WithTracing(&TracingConfig{
+		DB:             NewSQLDB(&SQLDBConfig{DB: conn}),
+		TracerProvider: provider,
+	})
+	if _, err := db.Create(ctx, &CreateOptions{
+		Title:  "Test Record",
+		UserID: uuid.New(),
+	}); err != nil {
+		t.Fatalf("failed to create record: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+
+	// The statement spans, of the record, of its revision and of its event, end first.
+	statement, operation := spans[0], spans[3]
+	if operation.Name != "db.Create" {
+		t.Errorf("expected the operation span to be named 'db.Create', got %q", operation.Name)
+	}
+	if statement.Name != "gorm.create" {
+		t.Errorf("expected the statement span to be named 'gorm.create', got %q", statement.Name)
+	}
+	if statement.Parent.SpanID() != operation.SpanContext.SpanID() {
+		t.Errorf("expected the statement span to be a child of the operation span")
+	}
+
+	var sql string
+	for _, attribute := range statement.Attributes {
+		if attribute.Key == semconv.DBStatementKey {
+			sql = attribute.Value.AsString()
+		}
+	}
+	if !strings.HasPrefix(sql, "INSERT INTO") || strings.Contains(sql, "Test Record") {
+		t.Errorf("expected the statement to be recorded w/o its arguments, got %q", sql)
+	}
+}
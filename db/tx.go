@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+// txKey is the key of the transaction started by `RunInTx` in the contexts.
+type txKey struct{}
+
+// isolationKey is the key of the isolation level set by `WithIsolation` in the contexts.
+type isolationKey struct{}
+
+// WithIsolation returns a copy of the context in which `RunInTx` starts its transactions with the isolation level,
+// instead of the `Isolation` of the database layer.
+//
+// It has no effect on the transactions nested in another one, which share the isolation level of the outermost.
+func WithIsolation(ctx context.Context, level sql.IsolationLevel) context.Context {
+	return context.WithValue(ctx, isolationKey{}, level)
+}
+
+// InTx reports whether the context carries a transaction started by `RunInTx`.
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return ok
+}
+
+// session returns the connection the operations must run on with the context: the transaction started by
+// `RunInTx`, if the context carries one, or else the connection of the database layer.
+func (db *sqldb) session(ctx context.Context) *gorm.DB {
+	return session(ctx, db.conn)
+}
+
+// session returns the transaction carried by the context, if any, or else the connection.
+func session(ctx context.Context, conn *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return conn.WithContext(ctx)
+}
+
+// reader returns the connection the read of the operation must run on with the context: the connection of a
+// replica, if the read can be routed to one, or else the session of the context.
+func (db *sqldb) reader(ctx context.Context, operation string) *gorm.DB {
+	if db.replicas == nil {
+		return db.session(ctx)
+	}
+	return db.replicas.route(ctx, db.conn, operation)
+}
+
+// RunInTx runs the function in a transaction, which is carried by the context passed to the function: every
+// operation of the database layer called with that context runs in the transaction.
+//
+// The transaction is committed if the function returns nil, and rolled back if it returns an error or panics.
+// When the context already carries a transaction, the function runs in a savepoint of it instead, so only its own
+// operations are rolled back if it fails.
+//
+// The transactions are started with the isolation level of `WithIsolation`, or else `Isolation`. On Postgres, they
+// carry the identity of the caller, for the row level security of the records.
+func (db *sqldb) RunInTx(ctx context.Context, fn func(context.Context) error) error {
+	if fn == nil {
+		return ErrInvalidOptions
+	}
+
+	isolation := db.isolation
+	if level, ok := ctx.Value(isolationKey{}).(sql.IsolationLevel); ok {
+		isolation = level
+	}
+
+	// The options are ignored by GORM for the nested transactions, which are savepoints.
+	nested := InTx(ctx)
+	err := db.session(ctx).Transaction(func(tx *gorm.DB) error {
+
+		// The savepoints share the identity of the outermost transaction.
+		if db.rls && !nested {
+			if err := identify(ctx, tx); err != nil {
+				return err
+			}
+		}
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	}, &sql.TxOptions{Isolation: isolation})
+
+	// Every change is made in a transaction, so route the reads of the writer to the primary once it is committed.
+	if err == nil && !nested && db.replicas != nil {
+		db.replicas.wrote(ctx)
+	}
+	return err
+}
@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"gorm.io/gorm"
+)
+
+func Test_Database_RunInTx(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Initialize the database.
+	db := &sqldb{
+		conn:      config.conn,
+		isolation: sql.LevelSerializable,
+	}
+
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+
+	// exists reports whether the record is in the database, outside of any transaction.
+	exists := func(t *testing.T, id uuid.UUID) bool {
+		_, err := db.Get(ctx, id, nil)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("failed to get the record: %v", err)
+		}
+		return err == nil
+	}
+
+	// create creates a record with the context.
+	create := func(ctx context.Context) (*model.Record, error) {
+		return db.Create(ctx, &CreateOptions{
+			Title:  "Test Record",
+			UserID: uuid.New(),
+		})
+	}
+
+	t.Run("run in tx w/o function", func(t *testing.T) {
+
+		if err := db.RunInTx(ctx, nil); err != ErrInvalidOptions {
+			t.Errorf("db.RunInTx() error = %v, want %v", err, ErrInvalidOptions)
+		}
+	})
+
+	t.Run("commit the operations", func(t *testing.T) {
+
+		var record *model.Record
+		err := db.RunInTx(ctx, func(ctx context.Context) error {
+			if !InTx(ctx) {
+				t.Errorf("expected the context to carry the transaction")
+			}
+
+			var err error
+			if record, err = create(ctx); err != nil {
+				return err
+			}
+
+			// The operations of the transaction see its changes.
+			_, err = db.Update(ctx, record.ID, &UpdateOptions{Title: "Updated Record"})
+			return err
+		})
+		if err != nil {
+			t.Fatalf("db.RunInTx() error = %v", err)
+		}
+
+		got, err := db.Get(ctx, record.ID, nil)
+		if err != nil {
+			t.Fatalf("failed to get the record: %v", err)
+		}
+		if got.Title != "Updated Record" || got.Version != 2 {
+			t.Errorf("expected the record to be updated, got %q at version %d", got.Title, got.Version)
+		}
+	})
+
+	t.Run("roll back the operations on error", func(t *testing.T) {
+
+		var record *model.Record
+		err := db.RunInTx(ctx, func(ctx context.Context) error {
+			var err error
+			if record, err = create(ctx); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if err != errRollback {
+			t.Fatalf("db.RunInTx() error = %v, want %v", err, errRollback)
+		}
+		if exists(t, record.ID) {
+			t.Errorf("expected the record to be rolled back")
+		}
+	})
+
+	t.Run("roll back the operations on panic", func(t *testing.T) {
+
+		var record *model.Record
+		func() {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Errorf("expected db.RunInTx() to panic")
+				}
+			}()
+			db.RunInTx(ctx, func(ctx context.Context) error {
+				var err error
+				if record, err = create(ctx); err != nil {
+					return err
+				}
+				panic("boom")
+			})
+		}()
+		if record == nil || exists(t, record.ID) {
+			t.Errorf("expected the record to be rolled back")
+		}
+	})
+
+	t.Run("roll back a nested transaction to its savepoint", func(t *testing.T) {
+
+		var outer, inner *model.Record
+		err := db.RunInTx(ctx, func(ctx context.Context) error {
+			var err error
+			if outer, err = create(ctx); err != nil {
+				return err
+			}
+
+			// The failure of the nested transaction is handled, so the outer transaction commits.
+			err = db.RunInTx(ctx, func(ctx context.Context) error {
+				var err error
+				if inner, err = create(ctx); err != nil {
+					return err
+				}
+				return errRollback
+			})
+			if err != errRollback {
+				t.Errorf("nested db.RunInTx() error = %v, want %v", err, errRollback)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("db.RunInTx() error = %v", err)
+		}
+		if !exists(t, outer.ID) {
+			t.Errorf("expected the record of the outer transaction to be committed")
+		}
+		if exists(t, inner.ID) {
+			t.Errorf("expected the record of the nested transaction to be rolled back")
+		}
+	})
+
+	t.Run("roll back a nested transaction w/ the outer one", func(t *testing.T) {
+
+		var inner *model.Record
+		err := db.RunInTx(WithIsolation(ctx, sql.LevelReadCommitted), func(ctx context.Context) error {
+			if err := db.RunInTx(ctx, func(ctx context.Context) error {
+				var err error
+				inner, err = create(ctx)
+				return err
+			}); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if err != errRollback {
+			t.Fatalf("db.RunInTx() error = %v, want %v", err, errRollback)
+		}
+		if exists(t, inner.ID) {
+			t.Errorf("expected the record of the nested transaction to be rolled back")
+		}
+	})
+
+	t.Run("roll back a batch run in a transaction", func(t *testing.T) {
+
+		var records []*model.Record
+		err := db.RunInTx(ctx, func(ctx context.Context) error {
+			var err error
+			records, err = db.BatchCreate(ctx, &BatchCreateOptions{
+				Requests: []*CreateOptions{{Title: "Test Record", UserID: uuid.New()}},
+			})
+			if err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if err != errRollback {
+			t.Fatalf("db.RunInTx() error = %v, want %v", err, errRollback)
+		}
+		if exists(t, records[0].ID) {
+			t.Errorf("expected the batch to be rolled back")
+		}
+	})
+}
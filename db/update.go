@@ -0,0 +1,91 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// updatable is a field of the records which can be updated.
+type updatable struct {
+
+	// column is the column of the field.
+	column string
+
+	// value validates the value of the field in the options, and returns it.
+	value func(*UpdateOptions) (any, error)
+}
+
+// updatables are the fields of the records which can be updated, by name.
+//
+// It is the allowlist of the update masks of the records.
+var updatables = map[string]updatable{
+	"title": {
+		column: "title",
+		value: func(o *UpdateOptions) (any, error) {
+			if o.Title == "" {
+				return nil, ErrInvalidTitle
+			}
+			return o.Title, nil
+		},
+	},
+}
+
+// immutables are the fields of the records which are set when they are created, and can never be updated.
+var immutables = map[string]bool{
+	"id":         true,
+	"user_id":    true,
+	"created_at": true,
+}
+
+// outputOnly are the fields of the records which are only ever set by the service.
+var outputOnly = map[string]bool{
+	"updated_at": true,
+	"deleted_at": true,
+	"version":    true,
+	"etag":       true,
+}
+
+// parseUpdateMask resolves the paths of an AIP-134 update mask into the fields they update.
+//
+// An empty mask, or `*`, updates every field of the allowlist. The immutable and output only fields are rejected,
+// along with the unknown and repeated ones.
+//
+// Link: https://google.aip.dev/134#field-masks
+func parseUpdateMask(mask []string, allowed map[string]updatable) ([]string, error) {
+	if len(mask) == 0 || (len(mask) == 1 && strings.TrimSpace(mask[0]) == "*") {
+		fields := make([]string, 0, len(allowed))
+		for field := range allowed {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		return fields, nil
+	}
+
+	var (
+		fields []string
+		seen   = make(map[string]bool)
+	)
+	for _, path := range mask {
+		field := strings.TrimSpace(path)
+		switch {
+		case field == "":
+			return nil, &FieldError{Field: "update_mask", Reason: "empty field"}
+		case field == "*":
+			return nil, &FieldError{Field: "update_mask", Reason: "* must be the only field"}
+		case immutables[field]:
+			return nil, &FieldError{Field: "update_mask", Reason: fmt.Sprintf("field %q is immutable", field)}
+		case outputOnly[field]:
+			return nil, &FieldError{Field: "update_mask", Reason: fmt.Sprintf("field %q is output only", field)}
+		}
+		if _, ok := allowed[field]; !ok {
+			return nil, &FieldError{Field: "update_mask", Reason: fmt.Sprintf("unknown field %q", field)}
+		}
+		if seen[field] {
+			return nil, &FieldError{Field: "update_mask", Reason: fmt.Sprintf("duplicate field %q", field)}
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_parseUpdateMask(t *testing.T) {
+	tests := []struct {
+		name    string
+		mask    []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "empty mask",
+			want: []string{"title"},
+		},
+		{
+			name: "wildcard",
+			mask: []string{"*"},
+			want: []string{"title"},
+		},
+		{
+			name: "single field",
+			mask: []string{" title "},
+			want: []string{"title"},
+		},
+		{
+			name:    "wildcard along with a field",
+			mask:    []string{"*", "title"},
+			wantErr: true,
+		},
+		{
+			name:    "immutable field",
+			mask:    []string{"user_id"},
+			wantErr: true,
+		},
+		{
+			name:    "output only field",
+			mask:    []string{"updated_at"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			mask:    []string{"name"},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate field",
+			mask:    []string{"title", "title"},
+			wantErr: true,
+		},
+		{
+			name:    "empty field",
+			mask:    []string{"title", ""},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseUpdateMask(tt.mask, updatables)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseUpdateMask() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var fieldErr *FieldError
+				if !errors.As(err, &fieldErr) || fieldErr.Field != "update_mask" {
+					t.Errorf("parseUpdateMask() error = %v, want a field error of update_mask", err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseUpdateMask() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_UpdateOptions_columns(t *testing.T) {
+	t.Run("masked title", func(t *testing.T) {
+		got, err := (&UpdateOptions{UpdateMask: []string{"title"}, Title: "Title"}).columns()
+		if err != nil {
+			t.Fatalf("columns() error = %v", err)
+		}
+		if want := map[string]any{"title": "Title"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("columns() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("masked empty title", func(t *testing.T) {
+		_, err := (&UpdateOptions{UpdateMask: []string{"title"}}).columns()
+		if !errors.Is(err, ErrInvalidTitle) {
+			t.Errorf("columns() error = %v, want %v", err, ErrInvalidTitle)
+		}
+	})
+}
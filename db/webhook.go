@@ -0,0 +1,230 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// newSecret generates a random secret for a webhook.
+func newSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(key), nil
+}
+
+// privateAddr reports whether the address is a loopback, private, link-local or unspecified one, which the webhooks
+// must not target: they would let the owners of the webhooks reach the internal services of the network.
+func privateAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast()
+}
+
+// privateHost reports whether the host of a URL is "localhost" or a private address.
+//
+// The names are not resolved: the deliverer checks the addresses they resolve to when it connects to them.
+func privateHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && privateAddr(addr)
+}
+
+// webhooks returns the query of the webhooks, subject to the Row Level Security (RLS) checks of the context.
+func (db *sqldb) webhooks(ctx context.Context) *gorm.DB {
+	txn := db.session(ctx).Model(&model.Webhook{})
+
+	// If the request context contains JWT claims, apply Row Level Security (RLS) checks.
+	claims, exists := ctx.Value(middleware.XJWTClaims).(middleware.JWTClaims)
+	if exists {
+
+		// 1. Only the user who created the webhook can see it.
+		txn = txn.Where(&model.Webhook{
+			UserID: claims.XUserID,
+		})
+	}
+	return txn
+}
+
+// CreateWebhook operation creates a new webhook in the database.
+//
+// It returns the webhook along with its secret, which is generated when the options have none. The secret is never
+// returned again.
+func (db *sqldb) CreateWebhook(ctx context.Context, options *CreateWebhookOptions) (*model.Webhook, error) {
+	if options == nil {
+		return nil, ErrInvalidOptions
+	}
+	if err := options.validate(db.allowPrivateWebhooks); err != nil {
+		return nil, err
+	}
+
+	//
+	// This method has no Row Level Security (RLS) checks.
+	//
+
+	payload := model.Webhook{
+		ID:     uuid.Must(uuid.NewV7()),
+		UserID: options.UserID,
+		URL:    options.URL,
+		Events: options.Events,
+		Secret: options.Secret,
+	}
+	if payload.Events == nil {
+		payload.Events = []string{}
+	}
+	if payload.Secret == "" {
+		secret, err := newSecret()
+		if err != nil {
+			return nil, err
+		}
+		payload.Secret = secret
+	}
+
+	if result := db.session(ctx).Create(&payload); result.Error != nil {
+		return nil, result.Error
+	}
+	return &payload, nil
+}
+
+// ListWebhooks operation fetches a page of the webhooks, oldest first, along with the token of the next page.
+//
+// The secrets of the webhooks are not returned.
+func (db *sqldb) ListWebhooks(ctx context.Context, options *ListWebhooksOptions) ([]*model.Webhook, string, error) {
+	if options == nil {
+		options = &ListWebhooksOptions{}
+	}
+	if err := options.validate(); err != nil {
+		return nil, "", err
+	}
+
+	query := db.webhooks(ctx)
+
+	// The page token holds the ID of the last webhook of the previous page.
+	const description = "webhooks"
+	if options.PageToken != "" {
+		cursor, err := decodePageToken(db.pageTokenKey(), options.PageToken)
+		if err != nil {
+			return nil, "", err
+		}
+		if cursor.Query != description {
+			return nil, "", ErrInvalidPageToken
+		}
+		query = query.Where(clause.Gt{Column: clause.Column{Name: "id"}, Value: cursor.ID})
+	}
+
+	// Fetch one more webhook than the page size, to know whether there is a next page.
+	var payload []*model.Webhook
+	size := pageSize(options.PageSize)
+	if result := query.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}}).Limit(size + 1).Find(&payload); result.Error != nil {
+		return nil, "", result.Error
+	}
+	for _, webhook := range payload {
+		webhook.Secret = ""
+	}
+	if len(payload) <= size {
+		return payload, "", nil
+	}
+
+	payload = payload[:size]
+	token, err := encodePageToken(db.pageTokenKey(), cursor{
+		Query: description,
+		ID:    payload[size-1].ID,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, token, nil
+}
+
+// DeleteWebhook operation deletes a webhook, along with its deliveries, so they are not attempted anymore.
+//
+// Only the user who created the webhook can delete it.
+func (db *sqldb) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	if id == uuid.Nil {
+		return ErrInvalidWebhookID
+	}
+
+	return db.RunInTx(ctx, func(ctx context.Context) error {
+		result := db.webhooks(ctx).Where(&model.Webhook{ID: id}).Delete(&model.Webhook{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return db.session(ctx).Where(&model.Delivery{WebhookID: id}).Delete(&model.Delivery{}).Error
+	})
+}
+
+// ListDeliveries operation fetches a page of the deliveries of the webhooks, most recent first, along with the
+// token of the next page.
+//
+// Only the users who can see the webhooks can see their deliveries.
+func (db *sqldb) ListDeliveries(ctx context.Context, options *ListDeliveriesOptions) ([]*model.Delivery, string, error) {
+	if options == nil {
+		options = &ListDeliveriesOptions{}
+	}
+	if err := options.validate(); err != nil {
+		return nil, "", err
+	}
+
+	// The deliveries are subject to the Row Level Security (RLS) checks of their webhook.
+	webhooks := db.webhooks(ctx).Select("id")
+	if options.WebhookID != uuid.Nil {
+		webhooks = webhooks.Where(&model.Webhook{ID: options.WebhookID})
+	}
+	query := db.session(ctx).Where("webhook_id IN (?)", webhooks)
+	if options.Status != "" {
+		query = query.Where(&model.Delivery{Status: options.Status})
+	}
+
+	// The page token holds the ID of the last delivery of the previous page.
+	description := fmt.Sprintf("deliveries;webhook_id=%s;status=%s", options.WebhookID, options.Status)
+	if options.PageToken != "" {
+		cursor, err := decodePageToken(db.pageTokenKey(), options.PageToken)
+		if err != nil {
+			return nil, "", err
+		}
+		if cursor.Query != description {
+			return nil, "", ErrInvalidPageToken
+		}
+		query = query.Where(clause.Lt{Column: clause.Column{Name: "id"}, Value: cursor.ID})
+	}
+
+	// Fetch one more delivery than the page size, to know whether there is a next page.
+	var payload []*model.Delivery
+	size := pageSize(options.PageSize)
+	query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: true})
+	if err := db.scoped(ctx, query, func(query *gorm.DB) error {
+		return query.Limit(size + 1).Find(&payload).Error
+	}); err != nil {
+		return nil, "", err
+	}
+	if len(payload) <= size {
+		return payload, "", nil
+	}
+
+	payload = payload[:size]
+	token, err := encodePageToken(db.pageTokenKey(), cursor{
+		Query: description,
+		ID:    payload[size-1].ID,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, token, nil
+}
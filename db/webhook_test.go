@@ -0,0 +1,184 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"gorm.io/gorm"
+)
+
+func Test_Database_Webhooks(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Initialize the database.
+	db := &sqldb{
+		conn: config.conn,
+	}
+
+	owner, other := uuid.New(), uuid.New()
+	claims := func(user uuid.UUID) context.Context {
+		return context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+			XUserID: user,
+		})
+	}
+
+	t.Run("create webhook w/ invalid options", func(t *testing.T) {
+
+		tests := map[string]struct {
+			options *CreateWebhookOptions
+			want    error
+		}{
+			"nil options":        {nil, ErrInvalidOptions},
+			"missing user":       {&CreateWebhookOptions{URL: "https://example.com"}, ErrInvalidUserID},
+			"relative url":       {&CreateWebhookOptions{UserID: owner, URL: "/hooks"}, ErrInvalidURL},
+			"unsupported scheme": {&CreateWebhookOptions{UserID: owner, URL: "ftp://example.com"}, ErrInvalidURL},
+			"unknown event":      {&CreateWebhookOptions{UserID: owner, URL: "https://example.com", Events: []string{"record.read"}}, ErrInvalidEventType},
+			"short secret":       {&CreateWebhookOptions{UserID: owner, URL: "https://example.com", Secret: "short"}, ErrInvalidSecret},
+			"localhost":          {&CreateWebhookOptions{UserID: owner, URL: "http://localhost:8080/hooks"}, ErrPrivateURL},
+			"localhost domain":   {&CreateWebhookOptions{UserID: owner, URL: "http://api.localhost./hooks"}, ErrPrivateURL},
+			"loopback address":   {&CreateWebhookOptions{UserID: owner, URL: "http://127.0.0.1/hooks"}, ErrPrivateURL},
+			"ipv6 loopback":      {&CreateWebhookOptions{UserID: owner, URL: "http://[::1]/hooks"}, ErrPrivateURL},
+			"mapped loopback":    {&CreateWebhookOptions{UserID: owner, URL: "http://[::ffff:127.0.0.1]/hooks"}, ErrPrivateURL},
+			"private address":    {&CreateWebhookOptions{UserID: owner, URL: "https://10.0.0.1/hooks"}, ErrPrivateURL},
+			"link-local address": {&CreateWebhookOptions{UserID: owner, URL: "http://169.254.169.254/latest/meta-data"}, ErrPrivateURL},
+			"unspecified":        {&CreateWebhookOptions{UserID: owner, URL: "http://0.0.0.0/hooks"}, ErrPrivateURL},
+		}
+		for name, test := range tests {
+			if _, err := db.CreateWebhook(context.Background(), test.options); err != test.want {
+				t.Errorf("%s: db.CreateWebhook() error = %v, want %v", name, err, test.want)
+			}
+		}
+	})
+
+	t.Run("create webhook w/ a private url when allowed", func(t *testing.T) {
+
+		allowed := &sqldb{
+			conn:                 config.conn,
+			allowPrivateWebhooks: true,
+		}
+		webhook, err := allowed.CreateWebhook(context.Background(), &CreateWebhookOptions{UserID: owner, URL: "http://127.0.0.1:8080/hooks"})
+		if err != nil {
+			t.Fatalf("db.CreateWebhook() error = %v", err)
+		}
+		if err := config.conn.Delete(webhook).Error; err != nil {
+			t.Fatalf("failed to delete the webhook: %v", err)
+		}
+	})
+
+	var webhooks []*model.Webhook
+	t.Run("create webhooks", func(t *testing.T) {
+
+		for _, options := range []*CreateWebhookOptions{
+			{UserID: owner, URL: "https://example.com/hooks"},
+			{UserID: owner, URL: "https://example.com/deletions", Events: []string{model.EventRecordDeleted}, Secret: "a-secret-of-sixteen"},
+			{UserID: other, URL: "https://example.org/hooks"},
+		} {
+			webhook, err := db.CreateWebhook(context.Background(), options)
+			if err != nil {
+				t.Fatalf("db.CreateWebhook() error = %v", err)
+			}
+			webhooks = append(webhooks, webhook)
+		}
+
+		// The secret is generated when none is supplied.
+		if !strings.HasPrefix(webhooks[0].Secret, "whsec_") || webhooks[1].Secret != "a-secret-of-sixteen" {
+			t.Errorf("db.CreateWebhook() secrets = %q, %q", webhooks[0].Secret, webhooks[1].Secret)
+		}
+		if webhooks[0].Events == nil || !webhooks[0].Subscribes(model.EventRecordCreated) {
+			t.Errorf("expected the webhook without events to subscribe to every event, got %v", webhooks[0].Events)
+		}
+		if webhooks[1].Subscribes(model.EventRecordCreated) || !webhooks[1].Subscribes(model.EventRecordDeleted) {
+			t.Errorf("expected the webhook to only subscribe to its events, got %v", webhooks[1].Events)
+		}
+	})
+
+	t.Run("list the webhooks of the user", func(t *testing.T) {
+
+		first, token, err := db.ListWebhooks(claims(owner), &ListWebhooksOptions{PageSize: 1})
+		if err != nil {
+			t.Fatalf("db.ListWebhooks() error = %v", err)
+		}
+		if len(first) != 1 || first[0].ID != webhooks[0].ID || token == "" {
+			t.Fatalf("expected the first webhook and a next page token, got %d webhooks", len(first))
+		}
+		second, token, err := db.ListWebhooks(claims(owner), &ListWebhooksOptions{PageSize: 1, PageToken: token})
+		if err != nil {
+			t.Fatalf("db.ListWebhooks() error = %v", err)
+		}
+		if len(second) != 1 || second[0].ID != webhooks[1].ID || token != "" {
+			t.Fatalf("expected the second webhook on the last page, got %d webhooks", len(second))
+		}
+		for _, webhook := range append(first, second...) {
+			if webhook.Secret != "" {
+				t.Errorf("expected the secret of webhook %v to be hidden", webhook.ID)
+			}
+		}
+	})
+
+	t.Run("list the deliveries of the webhooks of the user", func(t *testing.T) {
+
+		publisher := NewWebhookPublisher(config.conn)
+		for _, user := range []uuid.UUID{owner, other} {
+			event := &model.Event{
+				ID:   uuid.New(),
+				Type: model.EventRecordCreated,
+				Data: model.Record{UserID: user},
+			}
+			if err := publisher.Publish(context.Background(), event); err != nil {
+				t.Fatalf("WebhookPublisher.Publish() error = %v", err)
+			}
+
+			// An event is only delivered once per webhook.
+			if err := publisher.Publish(context.Background(), event); err != nil {
+				t.Fatalf("WebhookPublisher.Publish() error = %v", err)
+			}
+		}
+
+		// The owner only sees the delivery to their webhook which subscribes to the creations.
+		deliveries, _, err := db.ListDeliveries(claims(owner), nil)
+		if err != nil {
+			t.Fatalf("db.ListDeliveries() error = %v", err)
+		}
+		if len(deliveries) != 1 || deliveries[0].WebhookID != webhooks[0].ID || deliveries[0].Status != model.DeliveryPending {
+			t.Fatalf("expected a pending delivery to the first webhook, got %d deliveries", len(deliveries))
+		}
+
+		deliveries, _, err = db.ListDeliveries(claims(owner), &ListDeliveriesOptions{WebhookID: webhooks[2].ID})
+		if err != nil || len(deliveries) != 0 {
+			t.Errorf("expected no delivery of the webhook of another user, got %d, error = %v", len(deliveries), err)
+		}
+		deliveries, _, err = db.ListDeliveries(claims(owner), &ListDeliveriesOptions{Status: model.DeliverySucceeded})
+		if err != nil || len(deliveries) != 0 {
+			t.Errorf("expected no succeeded delivery, got %d, error = %v", len(deliveries), err)
+		}
+
+		var field *FieldError
+		if _, _, err := db.ListDeliveries(claims(owner), &ListDeliveriesOptions{Status: "lost"}); !errors.As(err, &field) || field.Field != "status" {
+			t.Errorf("db.ListDeliveries() error = %v, want a status field error", err)
+		}
+	})
+
+	t.Run("delete the webhooks of the user", func(t *testing.T) {
+
+		if err := db.DeleteWebhook(claims(owner), webhooks[2].ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("db.DeleteWebhook() error = %v, want %v", err, gorm.ErrRecordNotFound)
+		}
+		if err := db.DeleteWebhook(claims(owner), webhooks[0].ID); err != nil {
+			t.Fatalf("db.DeleteWebhook() error = %v", err)
+		}
+
+		// The deliveries of the webhook are deleted along with it.
+		var count int64
+		config.conn.Model(&model.Delivery{}).Where(&model.Delivery{WebhookID: webhooks[0].ID}).Count(&count)
+		if count != 0 {
+			t.Errorf("expected the deliveries of the webhook to be deleted, %d left", count)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Types of the domain events of the records.
+const (
+	EventRecordCreated = "record.created"
+	EventRecordUpdated = "record.updated"
+	EventRecordDeleted = "record.deleted"
+)
+
+// Event is a domain event of a record.
+//
+// The events are written to the outbox in the transaction of the change they describe, and published from there,
+// so an event is published if, and only if, its change is committed.
+type Event struct {
+
+	// ID is the unique identifier of the event.
+	// The events are delivered at least once, so consumers should use it to ignore the duplicates.
+	//
+	// Example: "550e8400-e29b-41d4-a716-446655440000"
+	ID uuid.UUID `json:"id" gorm:"primaryKey;not null;type:uuid"`
+
+	// Type of the event.
+	//
+	// Example: "record.updated"
+	Type string `json:"type" gorm:"not null"`
+
+	// RecordID is the ID of the record which changed.
+	//
+	// Example: "550e8400-e29b-41d4-a716-446655440000"
+	RecordID uuid.UUID `json:"record_id" gorm:"not null;type:uuid"`
+
+	// Data is the record, as it was right after the change.
+	Data Record `json:"data" gorm:"not null;type:jsonb;serializer:json"`
+
+	// ActorID is the ID of the user who changed the record, if the change was made on behalf of a user.
+	//
+	// Example: "550e8400-e29b-41d4-a716-446655440000"
+	ActorID *uuid.UUID `json:"actor_id,omitempty" gorm:"type:uuid"`
+
+	// RequestID is the ID of the request which changed the record, if any.
+	//
+	// Example: "3f1c1e7a-5b7e-4a8e-9d0c-6a3c2f7b8e1d"
+	RequestID string `json:"request_id,omitempty"`
+
+	// CreatedAt is the time when the record was changed.
+	//
+	// Example: "2021-07-01T12:00:00Z"
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// Attempts is the number of times the event has been published, successfully or not.
+	Attempts int `json:"-" gorm:"not null;default:0"`
+
+	// NextAttemptAt is the earliest time the event can be published again, after a failed attempt.
+	NextAttemptAt time.Time `json:"-" gorm:"autoCreateTime;index:idx_outbox_pending,where:delivered_at IS NULL AND failed_at IS NULL"`
+
+	// DeliveredAt is the time when the event was published successfully.
+	DeliveredAt *time.Time `json:"-"`
+
+	// FailedAt is the time when the event was abandoned, after too many failed attempts to publish it.
+	FailedAt *time.Time `json:"-"`
+
+	// LastError is the error of the last failed attempt, if any.
+	LastError string `json:"-"`
+}
+
+// TableName returns the name of the outbox table, which holds the events until they are published.
+func (Event) TableName() string {
+	return "outbox"
+}
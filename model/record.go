@@ -1,6 +1,11 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"strconv"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
 
 type Record struct {
 	Base
@@ -18,4 +23,55 @@ type Record struct {
 	//
 	//	It is a required field.
 	UserID uuid.UUID `json:"user_id" gorm:"not null;type:uuid"`
+
+	// Version of the record.
+	// It starts at 1 and is incremented every time the record is changed.
+	//
+	// Example: 3
+	Version int64 `json:"version" gorm:"not null;default:1"`
+
+	// ETag is the entity tag of the version of the record, as returned in the `ETag` HTTP header.
+	// It is set whenever the record is read or written, and it is not stored.
+	//
+	// Example: `"3"`
+	ETag string `json:"etag" gorm:"-"`
+}
+
+// AfterFind hook for gorm.
+// This function is called by gorm after reading a record.
+//
+// It sets the entity tag of the record.
+func (r *Record) AfterFind(tx *gorm.DB) error {
+	r.ETag = ETag(r.Version)
+	return nil
+}
+
+// AfterCreate hook for gorm.
+// This function is called by gorm after creating a record.
+//
+// It sets the entity tag of the record.
+func (r *Record) AfterCreate(tx *gorm.DB) error {
+	r.ETag = ETag(r.Version)
+	return nil
+}
+
+// ETag returns the entity tag of a version of a record.
+//
+// Example: `ETag(3)` returns `"3"`, quotes included.
+func ETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// ParseETag returns the version of a record from its entity tag.
+//
+// Weak entity tags, e.g. `W/"3"`, are rejected, because versions are compared strongly.
+func ParseETag(tag string) (int64, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
 }
@@ -0,0 +1,96 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Actions which create the revisions of the records.
+const (
+	RevisionCreate   = "create"
+	RevisionUpdate   = "update"
+	RevisionDelete   = "delete"
+	RevisionUndelete = "undelete"
+	RevisionRestore  = "restore"
+)
+
+// Revision is a snapshot of a record, taken every time the record is changed.
+type Revision struct {
+
+	// RecordID is the ID of the record.
+	//
+	// Example: "550e8400-e29b-41d4-a716-446655440000"
+	RecordID uuid.UUID `json:"record_id" gorm:"primaryKey;not null;type:uuid"`
+
+	// Revision is the number of the revision, which is the version of the record it is a snapshot of.
+	//
+	// Example: 3
+	Revision int64 `json:"revision" gorm:"primaryKey;not null;autoIncrement:false"`
+
+	// Action is the change of the record which created the revision.
+	//
+	// Example: "update"
+	Action string `json:"action" gorm:"not null"`
+
+	// Snapshot is the record, as it was right after the change.
+	Snapshot Record `json:"snapshot" gorm:"not null;type:jsonb;serializer:json"`
+
+	// ActorID is the ID of the user who changed the record, if the change was made on behalf of a user.
+	//
+	// Example: "550e8400-e29b-41d4-a716-446655440000"
+	ActorID *uuid.UUID `json:"actor_id,omitempty" gorm:"type:uuid"`
+
+	// RequestID is the ID of the request which changed the record, if any.
+	//
+	// Example: "3f1c1e7a-5b7e-4a8e-9d0c-6a3c2f7b8e1d"
+	RequestID string `json:"request_id,omitempty"`
+
+	// CreatedAt is the time when the record was changed.
+	//
+	// Example: "2021-07-01T12:00:00Z"
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Change is the change of a field of a record between two of its revisions.
+type Change struct {
+
+	// Field is the name of the field, as in the JSON representation of the records.
+	//
+	// Example: "title"
+	Field string `json:"field"`
+
+	// From is the value of the field in the revision the diff is against, or null when there is none.
+	//
+	// Example: "Old Title"
+	From json.RawMessage `json:"from"`
+
+	// To is the value of the field in the revision.
+	//
+	// Example: "New Title"
+	To json.RawMessage `json:"to"`
+}
+
+// Diff is the comparison of two revisions of a record.
+type Diff struct {
+
+	// RecordID is the ID of the record.
+	//
+	// Example: "550e8400-e29b-41d4-a716-446655440000"
+	RecordID uuid.UUID `json:"record_id"`
+
+	// Revision is the number of the revision which is compared.
+	//
+	// Example: 3
+	Revision int64 `json:"revision"`
+
+	// Against is the number of the revision it is compared against, or 0 for the state before the record was
+	// created.
+	//
+	// Example: 2
+	Against int64 `json:"against"`
+
+	// Changes are the changes of the fields of the record from `Against` to `Revision`, sorted by field.
+	Changes []Change `json:"changes"`
+}
@@ -0,0 +1,18 @@
+package model
+
+// SearchResult is a record matched by a full-text search.
+type SearchResult struct {
+	Record
+
+	// Rank is the relevance of the record to the search query.
+	// The higher it is, the more relevant the record is. It is only comparable within the results of the same search.
+	//
+	// Example: 0.0607927
+	Rank float64 `json:"rank"`
+
+	// Snippet is the title of the record, with the matched words wrapped in `<mark>` and `</mark>`.
+	// The rest of the title is HTML-escaped, so the snippet can be rendered as HTML.
+	//
+	// Example: "Quarterly <mark>report</mark>"
+	Snippet string `json:"snippet"`
+}
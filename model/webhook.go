@@ -0,0 +1,133 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses of the deliveries of the webhooks.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is a subscription of a user to the events of their records, which are delivered by HTTP to its URL.
+type Webhook struct {
+
+	// ID is the unique identifier of the webhook.
+	// It is a version 7 UUID, so the webhooks sort by creation time.
+	//
+	// Example: "01928a4e-6f3b-7c1d-9e2f-3a4b5c6d7e8f"
+	ID uuid.UUID `json:"id" gorm:"primaryKey;not null;type:uuid"`
+
+	// UserID is the ID of the user who owns the webhook. Only the events of their records are delivered to it.
+	//
+	// Example: "550e8400-e29b-41d4-a716-446655440000"
+	UserID uuid.UUID `json:"user_id" gorm:"not null;type:uuid;index"`
+
+	// URL the events are posted to.
+	//
+	// Example: "https://example.com/hooks/records"
+	URL string `json:"url" gorm:"not null"`
+
+	// Events are the types of the events delivered to the webhook. When it is empty, every event is delivered.
+	//
+	// Example: ["record.created", "record.deleted"]
+	Events []string `json:"events" gorm:"not null;type:jsonb;serializer:json"`
+
+	// Secret is the key of the HMAC-SHA256 signatures of the deliveries.
+	// It is only returned when the webhook is created.
+	Secret string `json:"secret,omitempty" gorm:"not null"`
+
+	// Failures is the number of consecutive failed delivery attempts. It is reset by every successful delivery.
+	//
+	// Example: 0
+	Failures int `json:"failures" gorm:"not null;default:0"`
+
+	// DisabledAt is the time when the webhook was disabled, after too many consecutive failed delivery attempts.
+	// The disabled webhooks receive no more deliveries.
+	//
+	// Example: "2021-07-01T12:00:00Z"
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+
+	// CreatedAt is the time when the webhook was created.
+	//
+	// Example: "2021-07-01T12:00:00Z"
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// UpdatedAt is the time when the webhook was last updated.
+	//
+	// Example: "2021-07-01T12:00:00Z"
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// Subscribes reports whether the events of the type are delivered to the webhook.
+func (w *Webhook) Subscribes(eventType string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, eventType)
+}
+
+// Delivery is the delivery of an event to a webhook, along with the outcome of its last attempt.
+type Delivery struct {
+
+	// ID is the unique identifier of the delivery, sent in the `X-Webhook-ID` header.
+	// It is a version 7 UUID, so the deliveries sort by creation time.
+	//
+	// Example: "01928a4e-6f3b-7c1d-9e2f-3a4b5c6d7e8f"
+	ID uuid.UUID `json:"id" gorm:"primaryKey;not null;type:uuid"`
+
+	// WebhookID is the ID of the webhook the event is delivered to.
+	//
+	// Example: "01928a4e-6f3b-7c1d-9e2f-3a4b5c6d7e8f"
+	WebhookID uuid.UUID `json:"webhook_id" gorm:"not null;type:uuid;uniqueIndex:idx_deliveries_event"`
+
+	// EventID is the ID of the event. An event is delivered at most once to every webhook.
+	//
+	// Example: "550e8400-e29b-41d4-a716-446655440000"
+	EventID uuid.UUID `json:"event_id" gorm:"not null;type:uuid;uniqueIndex:idx_deliveries_event"`
+
+	// Event is the event delivered, which is the body of the requests.
+	Event Event `json:"event" gorm:"not null;type:jsonb;serializer:json"`
+
+	// Status of the delivery: "pending" until it succeeds, or until it fails for good.
+	//
+	// Example: "succeeded"
+	Status string `json:"status" gorm:"not null"`
+
+	// Attempts is the number of times the event has been sent to the webhook.
+	//
+	// Example: 1
+	Attempts int `json:"attempts" gorm:"not null;default:0"`
+
+	// ResponseStatus is the HTTP status code of the response to the last attempt, if any.
+	//
+	// Example: 200
+	ResponseStatus int `json:"response_status,omitempty"`
+
+	// LastError is the error of the last failed attempt, if any.
+	//
+	// Example: "unexpected status 500 Internal Server Error"
+	LastError string `json:"last_error,omitempty"`
+
+	// NextAttemptAt is the earliest time of the next attempt of a pending delivery.
+	//
+	// Example: "2021-07-01T12:00:00Z"
+	NextAttemptAt time.Time `json:"next_attempt_at" gorm:"autoCreateTime;index:idx_deliveries_pending,where:status = 'pending'"`
+
+	// DeliveredAt is the time when the event was delivered successfully.
+	//
+	// Example: "2021-07-01T12:00:00Z"
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+
+	// CreatedAt is the time when the delivery was created.
+	//
+	// Example: "2021-07-01T12:00:00Z"
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// UpdatedAt is the time of the last attempt of the delivery.
+	//
+	// Example: "2021-07-01T12:00:00Z"
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
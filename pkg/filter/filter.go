@@ -0,0 +1,112 @@
+// Package filter parses the AIP-160 filter expressions of the listings into an abstract syntax tree.
+//
+// The package only deals with the syntax of the expressions. Which fields can be filtered on, and what their
+// values mean, is up to the layer which translates the tree, e.g. into SQL.
+//
+// Link: https://google.aip.dev/160
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Operator is the comparator of a restriction.
+type Operator string
+
+// Operators of the restrictions.
+const (
+	Equals        Operator = "="
+	NotEquals     Operator = "!="
+	LessThan      Operator = "<"
+	LessEquals    Operator = "<="
+	GreaterThan   Operator = ">"
+	GreaterEquals Operator = ">="
+
+	// Has is the `:` operator: the field has the value, e.g. a string contains a substring.
+	Has Operator = ":"
+)
+
+// Expr is a node of the abstract syntax tree of a filter.
+//
+// It is one of `*And`, `*Or`, `*Not` and `*Restriction`.
+type Expr interface {
+
+	// String returns the node in its canonical filter syntax.
+	String() string
+}
+
+// And matches when all of its expressions match.
+type And struct {
+	Exprs []Expr
+}
+
+func (e *And) String() string {
+	return join(e.Exprs, " AND ")
+}
+
+// Or matches when any of its expressions match.
+type Or struct {
+	Exprs []Expr
+}
+
+func (e *Or) String() string {
+	return join(e.Exprs, " OR ")
+}
+
+// Not matches when its expression does not match.
+type Not struct {
+	Expr Expr
+}
+
+func (e *Not) String() string {
+	return "NOT " + e.Expr.String()
+}
+
+// Restriction compares a field to a value.
+//
+// Example: `title = "Test Record"`
+type Restriction struct {
+
+	// Field is the name of the compared field.
+	//
+	// Example: "title"
+	Field string
+
+	// Operator compares the field to the value.
+	Operator Operator
+
+	// Value is the literal the field is compared to, unquoted.
+	// Its type depends on the field, e.g. a timestamp or a UUID.
+	//
+	// Example: "Test Record"
+	Value string
+}
+
+func (r *Restriction) String() string {
+	return fmt.Sprintf("%s %s %s", r.Field, r.Operator, strconv.Quote(r.Value))
+}
+
+// join returns the expressions in parentheses, joined by the supplied separator.
+func join(exprs []Expr, separator string) string {
+	parts := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		parts = append(parts, expr.String())
+	}
+	return "(" + strings.Join(parts, separator) + ")"
+}
+
+// SyntaxError is the error of a filter which does not follow the grammar.
+type SyntaxError struct {
+
+	// Offset is the byte offset of the error in the filter.
+	Offset int
+
+	// Message describes the error.
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at offset %d: %s", e.Offset, e.Message)
+}
@@ -0,0 +1,314 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+
+	// MaxLength is the maximum length of a filter, in bytes.
+	MaxLength = 2048
+
+	// MaxDepth is the maximum nesting depth of the parentheses and negations of a filter.
+	MaxDepth = 32
+)
+
+// Parse parses an AIP-160 filter into its abstract syntax tree.
+//
+// It returns a nil expression for an empty filter. The supported grammar is:
+//
+//	expression  = sequence { "AND" sequence }
+//	sequence    = factor { factor }
+//	factor      = term { "OR" term }
+//	term        = [ "NOT" | "-" ] simple
+//	simple      = restriction | "(" expression ")"
+//	restriction = field comparator value
+//	comparator  = "=" | "!=" | "<" | "<=" | ">" | ">=" | ":"
+//	value       = quoted string | bare word
+//
+// As in AIP-160, "OR" binds tighter than "AND", and the factors of a sequence are implicitly joined by "AND".
+// Example: `created_at >= "2024-01-01T00:00:00Z" AND (title:report OR title:invoice)`
+func Parse(filter string) (Expr, error) {
+	if len(filter) > MaxLength {
+		return nil, &SyntaxError{Offset: MaxLength, Message: fmt.Sprintf("filter is longer than %d bytes", MaxLength)}
+	}
+
+	p := parser{
+		input: filter,
+	}
+	p.skip()
+	if p.eof() {
+		return nil, nil
+	}
+
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	p.skip()
+	if !p.eof() {
+		return nil, p.errorf("unexpected %q", p.input[p.offset])
+	}
+	return expr, nil
+}
+
+// parser is a recursive descent parser of the filters.
+type parser struct {
+	input  string
+	offset int
+	depth  int
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &SyntaxError{
+		Offset:  p.offset,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (p *parser) eof() bool {
+	return p.offset >= len(p.input)
+}
+
+// peek returns the next byte of the input, or 0 at its end.
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.input[p.offset]
+}
+
+// skip skips the whitespaces.
+func (p *parser) skip() {
+	for !p.eof() && isSpace(p.input[p.offset]) {
+		p.offset++
+	}
+}
+
+// keyword consumes the next token if it is the supplied keyword, and reports whether it did.
+func (p *parser) keyword(word string) bool {
+	p.skip()
+	if !strings.HasPrefix(p.input[p.offset:], word) {
+		return false
+	}
+	end := p.offset + len(word)
+	if end < len(p.input) && !isSpace(p.input[end]) && p.input[end] != '(' {
+		return false
+	}
+	p.offset = end
+	return true
+}
+
+// nest increments the nesting depth, failing when it exceeds `MaxDepth`.
+func (p *parser) nest() error {
+	p.depth++
+	if p.depth > MaxDepth {
+		return p.errorf("filter is nested deeper than %d levels", MaxDepth)
+	}
+	return nil
+}
+
+func (p *parser) expression() (Expr, error) {
+	var exprs []Expr
+	for {
+		expr, err := p.sequence()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		if !p.keyword("AND") {
+			break
+		}
+	}
+	return and(exprs), nil
+}
+
+func (p *parser) sequence() (Expr, error) {
+	var exprs []Expr
+	for {
+		expr, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+
+		// The sequence ends with its enclosing expression, or before an "AND".
+		p.skip()
+		if p.eof() || p.peek() == ')' {
+			break
+		}
+		offset := p.offset
+		if p.keyword("AND") {
+			p.offset = offset
+			break
+		}
+	}
+	return and(exprs), nil
+}
+
+func (p *parser) factor() (Expr, error) {
+	var exprs []Expr
+	for {
+		expr, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		if !p.keyword("OR") {
+			break
+		}
+	}
+	if len(exprs) == 1 {
+		return exprs[0], nil
+	}
+	return &Or{Exprs: exprs}, nil
+}
+
+func (p *parser) term() (Expr, error) {
+	p.skip()
+	negated := p.keyword("NOT")
+	if !negated && p.peek() == '-' {
+		p.offset++
+		negated = true
+	}
+	if !negated {
+		return p.simple()
+	}
+
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	expr, err := p.simple()
+	if err != nil {
+		return nil, err
+	}
+	p.depth--
+	return &Not{Expr: expr}, nil
+}
+
+func (p *parser) simple() (Expr, error) {
+	p.skip()
+	if p.peek() != '(' {
+		return p.restriction()
+	}
+
+	p.offset++
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	p.skip()
+	if p.peek() != ')' {
+		return nil, p.errorf("expected ')'")
+	}
+	p.offset++
+	p.depth--
+	return expr, nil
+}
+
+func (p *parser) restriction() (Expr, error) {
+	start := p.offset
+	for !p.eof() && isFieldChar(p.input[p.offset]) {
+		p.offset++
+	}
+	field := p.input[start:p.offset]
+	if field == "" {
+		if p.eof() {
+			return nil, p.errorf("expected a field, got the end of the filter")
+		}
+		return nil, p.errorf("expected a field, got %q", p.input[p.offset])
+	}
+
+	p.skip()
+	operator := p.comparator()
+	if operator == "" {
+		return nil, p.errorf("expected a comparator after %q", field)
+	}
+
+	p.skip()
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	return &Restriction{
+		Field:    field,
+		Operator: operator,
+		Value:    value,
+	}, nil
+}
+
+// comparators are the operators of the restrictions, longest first.
+var comparators = []Operator{LessEquals, GreaterEquals, NotEquals, LessThan, GreaterThan, Equals, Has}
+
+func (p *parser) comparator() Operator {
+	for _, operator := range comparators {
+		if strings.HasPrefix(p.input[p.offset:], string(operator)) {
+			p.offset += len(operator)
+			return operator
+		}
+	}
+	return ""
+}
+
+// value parses a quoted string, in which a backslash escapes the next character, or a bare word, which ends
+// with a whitespace or a parenthesis. Bare words may hold colons, e.g. `2024-01-01T00:00:00Z`.
+func (p *parser) value() (string, error) {
+	if p.eof() {
+		return "", p.errorf("expected a value, got the end of the filter")
+	}
+
+	quote := p.peek()
+	if quote != '"' && quote != '\'' {
+		start := p.offset
+		for !p.eof() && !isSpace(p.peek()) && p.peek() != '(' && p.peek() != ')' {
+			p.offset++
+		}
+		if p.offset == start {
+			return "", p.errorf("expected a value, got %q", p.peek())
+		}
+		return p.input[start:p.offset], nil
+	}
+
+	start := p.offset
+	p.offset++
+	var value strings.Builder
+	for !p.eof() {
+		c := p.input[p.offset]
+		p.offset++
+		switch {
+		case c == quote:
+			return value.String(), nil
+		case c == '\\' && !p.eof():
+			value.WriteByte(p.input[p.offset])
+			p.offset++
+		default:
+			value.WriteByte(c)
+		}
+	}
+	p.offset = start
+	return "", p.errorf("unterminated string")
+}
+
+// and returns the conjunction of the expressions, or the expression itself when there is only one.
+func and(exprs []Expr) Expr {
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+	return &And{Exprs: exprs}
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// isFieldChar reports whether the character can be part of a field name, e.g. `user_id` or `author.name`.
+func isFieldChar(c byte) bool {
+	return c == '_' || c == '.' ||
+		'a' <= c && c <= 'z' ||
+		'A' <= c && c <= 'Z' ||
+		'0' <= c && c <= '9'
+}
@@ -0,0 +1,135 @@
+package filter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "empty filter",
+			filter: " ",
+			want:   "",
+		},
+		{
+			name:   "restriction",
+			filter: `title = "Test Record"`,
+			want:   `title = "Test Record"`,
+		},
+		{
+			name:   "restriction without whitespaces",
+			filter: `title!=abc`,
+			want:   `title != "abc"`,
+		},
+		{
+			name:   "every comparator",
+			filter: `a=1 b!=2 c<3 d<=4 e>5 f>=6 g:7`,
+			want:   `(a = "1" AND b != "2" AND c < "3" AND d <= "4" AND e > "5" AND f >= "6" AND g : "7")`,
+		},
+		{
+			name:   "bare timestamp",
+			filter: `created_at >= 2024-01-01T00:00:00Z`,
+			want:   `created_at >= "2024-01-01T00:00:00Z"`,
+		},
+		{
+			name:   "quoted strings with escapes",
+			filter: `title = 'it\'s' OR title = "say \"hi\" \\o/"`,
+			want:   `(title = "it's" OR title = "say \"hi\" \\o/")`,
+		},
+		{
+			name:   "OR binds tighter than AND",
+			filter: `a = 1 AND b = 2 OR c = 3`,
+			want:   `(a = "1" AND (b = "2" OR c = "3"))`,
+		},
+		{
+			name:   "parentheses",
+			filter: `(a = 1 AND b = 2) OR c = 3`,
+			want:   `((a = "1" AND b = "2") OR c = "3")`,
+		},
+		{
+			name:   "negations",
+			filter: `NOT a = 1 -b = 2 NOT(c:3)`,
+			want:   `(NOT a = "1" AND NOT b = "2" AND NOT c : "3")`,
+		},
+		{
+			name:   "keywords as prefixes of fields",
+			filter: `ANDROID = 1 ORDER = 2 NOTE = 3`,
+			want:   `(ANDROID = "1" AND ORDER = "2" AND NOTE = "3")`,
+		},
+		{
+			name:    "missing comparator",
+			filter:  `title`,
+			wantErr: true,
+		},
+		{
+			name:    "missing value",
+			filter:  `title =`,
+			wantErr: true,
+		},
+		{
+			name:    "missing field",
+			filter:  `= 1`,
+			wantErr: true,
+		},
+		{
+			name:    "unterminated string",
+			filter:  `title = "abc`,
+			wantErr: true,
+		},
+		{
+			name:    "unbalanced parentheses",
+			filter:  `(title = abc`,
+			wantErr: true,
+		},
+		{
+			name:    "unexpected closing parenthesis",
+			filter:  `title = abc)`,
+			wantErr: true,
+		},
+		{
+			name:    "dangling AND",
+			filter:  `title = abc AND`,
+			wantErr: true,
+		},
+		{
+			name:    "too deep",
+			filter:  strings.Repeat("(", MaxDepth+1) + "a = 1" + strings.Repeat(")", MaxDepth+1),
+			wantErr: true,
+		},
+		{
+			name:    "too long",
+			filter:  `title = "` + strings.Repeat("a", MaxLength) + `"`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.filter)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var syntaxErr *SyntaxError
+				if !errors.As(err, &syntaxErr) {
+					t.Errorf("Parse() error = %v, want a syntax error", err)
+				}
+				return
+			}
+
+			var got string
+			if expr != nil {
+				got = expr.String()
+			}
+			if got != tt.want {
+				t.Errorf("Parse() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
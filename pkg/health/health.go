@@ -0,0 +1,269 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the duration a check has to complete in when it does not set its own timeout.
+const DefaultTimeout = 2 * time.Second
+
+// Statuses of a check and of a report.
+const (
+
+	// StatusUp means the dependency is healthy.
+	StatusUp = "up"
+
+	// StatusDegraded means a non-critical dependency is unhealthy.
+	// The service keeps serving traffic.
+	StatusDegraded = "degraded"
+
+	// StatusDown means a critical dependency is unhealthy.
+	// The service should not receive traffic.
+	StatusDown = "down"
+)
+
+// Check is a named probe of a dependency of the service.
+type Check struct {
+
+	// Name of the dependency.
+	// It must be unique within a registry.
+	//
+	// Example: "database"
+	//
+	// This field is mandatory.
+	Name string
+
+	// Func probes the dependency.
+	// It returns a short description of the state of the dependency, which may be empty, or an error if the dependency
+	// is unhealthy. It must return once its context is done.
+	//
+	// This field is mandatory.
+	Func func(context.Context) (string, error)
+
+	// Timeout is the duration the check has to complete in.
+	// Default: `DefaultTimeout`
+	//
+	// This field is optional.
+	Timeout time.Duration
+
+	// Critical reports whether the service can not serve traffic while the dependency is unhealthy.
+	// A failing critical check takes the service down, a failing non-critical check only degrades it.
+	// Default: false
+	//
+	// This field is optional.
+	Critical bool
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Message  string `json:"message,omitempty"`
+	Error    string `json:"error,omitempty"`
+
+	// Duration of the check.
+	//
+	// Example: "1.2ms"
+	Duration string `json:"duration"`
+}
+
+// Report is the outcome of every check of a registry.
+type Report struct {
+
+	// Status is `StatusDown` if a critical check failed, `StatusDegraded` if a non-critical check failed,
+	// and `StatusUp` otherwise.
+	Status string `json:"status"`
+
+	// Checks are the results of the checks, in the order they were registered.
+	Checks []Result `json:"checks,omitempty"`
+
+	// Build is the build information of the binary.
+	Build *Build `json:"build,omitempty"`
+}
+
+// Registry holds the checks of the dependencies of the service.
+//
+// It is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	checks []Check
+}
+
+// NewRegistry creates a new, empty instance of `Registry`.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds the supplied checks to the registry.
+//
+// It panics if a check has no name or function, or if its name is already registered.
+func (r *Registry) Register(checks ...Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, check := range checks {
+		if check.Name == "" || check.Func == nil {
+			panic("failed to register the health check: missing name or function")
+		}
+		for _, registered := range r.checks {
+			if registered.Name == check.Name {
+				panic(fmt.Sprintf("failed to register the health check: %q is already registered", check.Name))
+			}
+		}
+		r.checks = append(r.checks, check)
+	}
+}
+
+// Run runs every registered check concurrently and reports their results.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make([]Check, len(r.checks))
+	copy(checks, r.checks)
+	r.mu.RUnlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{
+		Status: StatusUp,
+		Checks: results,
+	}
+	for _, result := range results {
+		switch {
+		case result.Status == StatusUp:
+		case result.Critical:
+			report.Status = StatusDown
+		case report.Status == StatusUp:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// run runs a single check within its timeout.
+func run(ctx context.Context, check Check) Result {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := Result{
+		Name:     check.Name,
+		Status:   StatusUp,
+		Critical: check.Critical,
+	}
+
+	// Run the check in its own goroutine, so a check which ignores its context can not hold the probe past its timeout.
+	type outcome struct {
+		message string
+		err     error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		message, err := check.Func(ctx)
+		done <- outcome{message, err}
+	}()
+
+	var err error
+	select {
+	case o := <-done:
+		result.Message, err = o.message, o.err
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result.Duration = time.Since(start).String()
+
+	if err != nil {
+		result.Status = StatusDown
+		if !check.Critical {
+			result.Status = StatusDegraded
+		}
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Dial returns a check function which opens, and immediately closes, a connection to the supplied address.
+//
+// It is meant for dependencies the service has no client for yet, e.g. the cache.
+func Dial(network, address string) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, network, address)
+		if err != nil {
+			return "", err
+		}
+		return "", conn.Close()
+	}
+}
+
+// Build is the build information embedded in the binary by the Go toolchain.
+type Build struct {
+
+	// GoVersion is the version of the Go toolchain that built the binary.
+	//
+	// Example: "go1.22.2"
+	GoVersion string `json:"go_version"`
+
+	// Path is the path of the main package.
+	//
+	// Example: "github.com/mrinalwahal/service/cmd/main"
+	Path string `json:"path"`
+
+	// Version of the main module.
+	// It is "(devel)" when the binary was not built from a tagged module.
+	Version string `json:"version"`
+
+	// Revision is the version control revision the binary was built from.
+	Revision string `json:"revision,omitempty"`
+
+	// Time is the time of the revision, formatted in RFC 3339.
+	Time string `json:"time,omitempty"`
+
+	// Modified reports whether the working tree had uncommitted changes.
+	Modified bool `json:"modified,omitempty"`
+}
+
+// ReadBuild returns the build information of the running binary.
+//
+// The fields are empty if the binary was built without module support.
+func ReadBuild() Build {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return Build{}
+	}
+
+	build := Build{
+		GoVersion: info.GoVersion,
+		Path:      info.Path,
+		Version:   info.Main.Version,
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			build.Revision = setting.Value
+		case "vcs.time":
+			build.Time = setting.Value
+		case "vcs.modified":
+			build.Modified = setting.Value == "true"
+		}
+	}
+	return build
+}
@@ -0,0 +1,116 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func Test_Registry(t *testing.T) {
+
+	t.Run("run w/o checks", func(t *testing.T) {
+
+		report := NewRegistry().Run(context.Background())
+		if report.Status != StatusUp {
+			t.Errorf("Run() status = %v, want %v", report.Status, StatusUp)
+		}
+	})
+
+	t.Run("run w/ passing and failing checks", func(t *testing.T) {
+
+		registry := NewRegistry()
+		registry.Register(
+			Check{
+				Name:     "database",
+				Critical: true,
+				Func: func(ctx context.Context) (string, error) {
+					return "version 1", nil
+				},
+			},
+			Check{
+				Name: "cache",
+				Func: func(ctx context.Context) (string, error) {
+					return "", errors.New("connection refused")
+				},
+			},
+		)
+
+		report := registry.Run(context.Background())
+		if report.Status != StatusDegraded {
+			t.Errorf("Run() status = %v, want %v", report.Status, StatusDegraded)
+		}
+		if got := report.Checks[0]; got.Name != "database" || got.Status != StatusUp || got.Message != "version 1" {
+			t.Errorf("Run() checks[0] = %+v", got)
+		}
+		if got := report.Checks[1]; got.Name != "cache" || got.Status != StatusDegraded || got.Error != "connection refused" {
+			t.Errorf("Run() checks[1] = %+v", got)
+		}
+	})
+
+	t.Run("run w/ check exceeding its timeout", func(t *testing.T) {
+
+		registry := NewRegistry()
+		registry.Register(Check{
+			Name:     "database",
+			Critical: true,
+			Timeout:  10 * time.Millisecond,
+			Func: func(ctx context.Context) (string, error) {
+
+				// Ignore the context on purpose.
+				time.Sleep(time.Second)
+				return "", nil
+			},
+		})
+
+		start := time.Now()
+		report := registry.Run(context.Background())
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("Run() took %v, want it to stop at the timeout", elapsed)
+		}
+		if report.Status != StatusDown {
+			t.Errorf("Run() status = %v, want %v", report.Status, StatusDown)
+		}
+		if got := report.Checks[0].Error; got != context.DeadlineExceeded.Error() {
+			t.Errorf("Run() error = %v, want %v", got, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("register duplicate check", func(t *testing.T) {
+
+		defer func() {
+			if recover() == nil {
+				t.Errorf("Register() did not panic")
+			}
+		}()
+
+		check := Check{
+			Name: "database",
+			Func: func(ctx context.Context) (string, error) {
+				return "", nil
+			},
+		}
+		registry := NewRegistry()
+		registry.Register(check)
+		registry.Register(check)
+	})
+}
+
+func Test_Dial(t *testing.T) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	address := listener.Addr().String()
+
+	if _, err := Dial("tcp", address)(context.Background()); err != nil {
+		t.Errorf("Dial() error = %v", err)
+	}
+
+	listener.Close()
+	if _, err := Dial("tcp", address)(context.Background()); err == nil {
+		t.Errorf("Dial() expected an error once the listener is closed")
+	}
+}
@@ -0,0 +1,37 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// HTTPServer returns the hook that manages the lifetime of an HTTP server.
+//
+// The listener is opened on start, so address errors fail the startup, and in-flight requests are drained
+// with `http.Server.Shutdown` on stop.
+func HTTPServer(name string, server *http.Server) Hook {
+	var listener net.Listener
+	return Hook{
+		Name: name,
+		OnStart: func(ctx context.Context) error {
+			var config net.ListenConfig
+			ln, err := config.Listen(ctx, "tcp", server.Addr)
+			if err != nil {
+				return err
+			}
+			listener = ln
+			return nil
+		},
+		Run: func(ctx context.Context) error {
+			if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return server.Shutdown(ctx)
+		},
+	}
+}
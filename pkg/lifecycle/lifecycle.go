@@ -0,0 +1,227 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Hook is a component of the service whose lifetime is managed by the `Manager`.
+//
+// Every function is optional.
+type Hook struct {
+
+	// Name of the component.
+	// It is only used in logs and errors.
+	//
+	// Example: "database"
+	Name string
+
+	// OnStart prepares the component.
+	// It is called in the order the hooks were appended and it must not block.
+	//
+	// If it fails, the components which have already started are stopped in reverse order.
+	OnStart func(context.Context) error
+
+	// Run is the long running work of the component, like serving HTTP requests or polling a queue.
+	// It is called in its own goroutine once every component has started.
+	//
+	// It must return once its context is cancelled, which happens right before `OnStop` is called.
+	// If it returns an error before that, the manager shuts the service down.
+	Run func(context.Context) error
+
+	// OnStop releases the resources of the component.
+	// It is called in the reverse order of `OnStart` with a context that expires with the shutdown timeout.
+	OnStop func(context.Context) error
+}
+
+type ManagerConfig struct {
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+
+	// ShutdownTimeout is the maximum duration all the components have to stop in.
+	// Default: `30s`
+	//
+	// This field is optional.
+	ShutdownTimeout time.Duration
+
+	// ShutdownDelay is the duration to wait after readiness starts failing and before the components are stopped.
+	// It gives load balancers the time to stop routing new requests to this instance.
+	// Default: `0`
+	//
+	// This field is optional.
+	ShutdownDelay time.Duration
+
+	// Signals are the OS signals that trigger the shutdown.
+	// Default: `[]os.Signal{os.Interrupt, syscall.SIGTERM}`
+	//
+	// This field is optional.
+	Signals []os.Signal
+}
+
+// Manager starts the components of the service in order and stops them in reverse order.
+type Manager struct {
+	hooks []Hook
+
+	// ready reports whether every component has started and the shutdown has not begun.
+	ready atomic.Bool
+
+	log             *slog.Logger
+	shutdownTimeout time.Duration
+	shutdownDelay   time.Duration
+	signals         []os.Signal
+}
+
+// NewManager creates a new instance of `Manager`.
+func NewManager(config *ManagerConfig) *Manager {
+	if config == nil {
+		config = &ManagerConfig{}
+	}
+
+	manager := Manager{
+		log:             config.Logger,
+		shutdownTimeout: config.ShutdownTimeout,
+		shutdownDelay:   config.ShutdownDelay,
+		signals:         config.Signals,
+	}
+
+	if manager.log == nil {
+		manager.log = slog.Default()
+	}
+	manager.log = manager.log.With("layer", "lifecycle")
+
+	if manager.shutdownTimeout <= 0 {
+		manager.shutdownTimeout = 30 * time.Second
+	}
+
+	if manager.signals == nil {
+		manager.signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	return &manager
+}
+
+// Append registers the hooks.
+// The components are started in the order they are appended.
+func (m *Manager) Append(hooks ...Hook) {
+	m.hooks = append(m.hooks, hooks...)
+}
+
+// Ready reports whether every component has started and the shutdown has not begun.
+func (m *Manager) Ready() bool {
+	return m.ready.Load()
+}
+
+// running tracks a started component.
+type running struct {
+	hook   Hook
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Run starts every component and blocks until the context is cancelled, one of the configured signals is received
+// or a component fails. It then stops every started component in reverse order.
+func (m *Manager) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, m.signals...)
+	defer stop()
+
+	failures := make(chan error, len(m.hooks))
+	started := make([]*running, 0, len(m.hooks))
+
+	// Start the components in order.
+	for _, hook := range m.hooks {
+		m.log.LogAttrs(ctx, slog.LevelInfo, "starting component", slog.String("component", hook.Name))
+		if hook.OnStart != nil {
+			if err := hook.OnStart(ctx); err != nil {
+				err = fmt.Errorf("start %s: %w", hook.Name, err)
+				return errors.Join(err, m.stop(started))
+			}
+		}
+		started = append(started, &running{
+			hook: hook,
+			done: make(chan struct{}),
+		})
+	}
+
+	// Run the long running work of the components.
+	base := context.WithoutCancel(ctx)
+	for _, item := range started {
+		item := item
+		runCtx, cancel := context.WithCancel(base)
+		item.cancel = cancel
+		if item.hook.Run == nil {
+			close(item.done)
+			continue
+		}
+		go func() {
+			defer close(item.done)
+			if err := item.hook.Run(runCtx); err != nil && runCtx.Err() == nil {
+				failures <- fmt.Errorf("run %s: %w", item.hook.Name, err)
+			}
+		}()
+	}
+
+	m.ready.Store(true)
+	m.log.LogAttrs(ctx, slog.LevelInfo, "all components started")
+
+	// Wait for a reason to shut down.
+	var err error
+	select {
+	case <-ctx.Done():
+		m.log.LogAttrs(ctx, slog.LevelInfo, "shutdown requested", slog.String("reason", context.Cause(ctx).Error()))
+	case err = <-failures:
+		m.log.LogAttrs(ctx, slog.LevelError, "component failed", slog.String("error", err.Error()))
+	}
+
+	// Restore the default behaviour of the signals, so a second one terminates the process immediately.
+	stop()
+
+	// Fail readiness first, so no new traffic is routed to this instance while it drains.
+	m.ready.Store(false)
+	if m.shutdownDelay > 0 {
+		time.Sleep(m.shutdownDelay)
+	}
+
+	return errors.Join(err, m.stop(started))
+}
+
+// stop stops the started components in reverse order.
+func (m *Manager) stop(started []*running) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(started) - 1; i >= 0; i-- {
+		item := started[i]
+		m.log.LogAttrs(ctx, slog.LevelInfo, "stopping component", slog.String("component", item.hook.Name))
+
+		if item.cancel != nil {
+			item.cancel()
+		}
+		if item.hook.OnStop != nil {
+			if err := item.hook.OnStop(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("stop %s: %w", item.hook.Name, err))
+			}
+		}
+
+		// Wait for the long running work to return.
+		if item.cancel != nil {
+			select {
+			case <-item.done:
+			case <-ctx.Done():
+				errs = append(errs, fmt.Errorf("stop %s: %w", item.hook.Name, ctx.Err()))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
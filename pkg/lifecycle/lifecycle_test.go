@@ -0,0 +1,240 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder records the order in which the hooks are called.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.events)
+}
+
+// hook returns a hook which records its calls.
+func (r *recorder) hook(name string) Hook {
+	return Hook{
+		Name: name,
+		OnStart: func(ctx context.Context) error {
+			r.record("start " + name)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			r.record("stop " + name)
+			return nil
+		},
+	}
+}
+
+func Test_Manager_Run(t *testing.T) {
+
+	t.Run("start in order and stop in reverse order", func(t *testing.T) {
+
+		var r recorder
+		manager := NewManager(nil)
+		manager.Append(r.hook("database"), r.hook("migrations"), r.hook("http"))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		manager.Append(Hook{
+			Name: "trigger",
+			OnStart: func(context.Context) error {
+				cancel()
+				return nil
+			},
+		})
+
+		if err := manager.Run(ctx); err != nil {
+			t.Fatalf("Manager.Run() error = %v", err)
+		}
+
+		want := []string{
+			"start database",
+			"start migrations",
+			"start http",
+			"stop http",
+			"stop migrations",
+			"stop database",
+		}
+		if got := r.list(); !slices.Equal(got, want) {
+			t.Errorf("Manager.Run() events = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("stop the started components when one fails to start", func(t *testing.T) {
+
+		var r recorder
+		manager := NewManager(nil)
+		manager.Append(r.hook("database"), Hook{
+			Name: "migrations",
+			OnStart: func(context.Context) error {
+				return errors.New("boom")
+			},
+		}, r.hook("http"))
+
+		if err := manager.Run(context.Background()); err == nil {
+			t.Fatal("expected Manager.Run() to fail, got nil")
+		}
+
+		want := []string{
+			"start database",
+			"stop database",
+		}
+		if got := r.list(); !slices.Equal(got, want) {
+			t.Errorf("Manager.Run() events = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("shut down when a component fails while running", func(t *testing.T) {
+
+		var r recorder
+		manager := NewManager(nil)
+		manager.Append(r.hook("database"), Hook{
+			Name: "worker",
+			Run: func(context.Context) error {
+				return errors.New("boom")
+			},
+		})
+
+		if err := manager.Run(context.Background()); err == nil {
+			t.Fatal("expected Manager.Run() to fail, got nil")
+		}
+
+		if got := r.list(); !slices.Contains(got, "stop database") {
+			t.Errorf("expected the database to be stopped, got events %v", got)
+		}
+	})
+
+	t.Run("fail readiness before stopping the components", func(t *testing.T) {
+
+		manager := NewManager(nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var readyWhileRunning, readyWhileStopping bool
+		manager.Append(Hook{
+			Name: "probe",
+			Run: func(ctx context.Context) error {
+				readyWhileRunning = manager.Ready()
+				cancel()
+				<-ctx.Done()
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				readyWhileStopping = manager.Ready()
+				return nil
+			},
+		})
+
+		if err := manager.Run(ctx); err != nil {
+			t.Fatalf("Manager.Run() error = %v", err)
+		}
+
+		if !readyWhileRunning {
+			t.Error("expected the manager to be ready while running")
+		}
+		if readyWhileStopping {
+			t.Error("expected the manager to not be ready while stopping")
+		}
+	})
+
+	t.Run("report components which do not stop within the shutdown timeout", func(t *testing.T) {
+
+		manager := NewManager(&ManagerConfig{
+			ShutdownTimeout: 10 * time.Millisecond,
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		manager.Append(Hook{
+			Name: "stuck",
+			Run: func(context.Context) error {
+				cancel()
+				time.Sleep(time.Second)
+				return nil
+			},
+		})
+
+		if err := manager.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Manager.Run() error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	})
+}
+
+func Test_HTTPServer(t *testing.T) {
+
+	t.Run("drain in-flight requests on shutdown", func(t *testing.T) {
+
+		// Reserve a free address for the server.
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to reserve an address: %v", err)
+		}
+		address := ln.Addr().String()
+		ln.Close()
+
+		started := make(chan struct{})
+		server := &http.Server{
+			Addr: address,
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				time.Sleep(50 * time.Millisecond)
+				w.WriteHeader(http.StatusOK)
+			}),
+		}
+
+		var status int
+		var requestErr error
+		done := make(chan struct{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		manager := NewManager(nil)
+		manager.Append(HTTPServer("http", server), Hook{
+			Name: "client",
+			Run: func(context.Context) error {
+				go func() {
+					defer close(done)
+					response, err := http.Get("http://" + address + "/")
+					if err != nil {
+						requestErr = err
+						return
+					}
+					response.Body.Close()
+					status = response.StatusCode
+				}()
+
+				// Shut down while the request is in-flight.
+				<-started
+				cancel()
+				return nil
+			},
+		})
+
+		if err := manager.Run(ctx); err != nil {
+			t.Fatalf("Manager.Run() error = %v", err)
+		}
+		<-done
+
+		if requestErr != nil {
+			t.Fatalf("in-flight request failed: %v", requestErr)
+		}
+		if status != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, status)
+		}
+	})
+}
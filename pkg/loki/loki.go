@@ -0,0 +1,253 @@
+package loki
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of the writer.
+const (
+	DefaultInterval  = time.Second
+	DefaultBatchSize = 100
+	DefaultTimeout   = 5 * time.Second
+)
+
+// pushPath is the path of the push API of Loki.
+const pushPath = "/loki/api/v1/push"
+
+type Config struct {
+
+	// Address of Loki, either a `host:port`, or a URL whose scheme picks the transport.
+	//
+	// Example: "localhost:3100"
+	//
+	// This field is mandatory.
+	Address string
+
+	// Labels are the labels of the stream of the lines.
+	// Default: none.
+	//
+	// This field is optional.
+	Labels map[string]string
+
+	// Interval is the maximum time a line waits before it is pushed.
+	// Default: `DefaultInterval`
+	//
+	// This field is optional.
+	Interval time.Duration
+
+	// BatchSize is the number of lines after which they are pushed without waiting for the interval.
+	// Default: `DefaultBatchSize`
+	//
+	// This field is optional.
+	BatchSize int
+
+	// Client pushes the lines.
+	// Default: a client which gives up after `DefaultTimeout`.
+	//
+	// This field is optional.
+	Client *http.Client
+
+	// Fallback receives the lines which could not be pushed, and those written once the writer is closed, so they
+	// are not lost.
+	// Default: `os.Stderr`
+	//
+	// This field is optional.
+	Fallback io.Writer
+}
+
+// entry is a line, along with the time it was written at.
+type entry struct {
+	at   time.Time
+	line string
+}
+
+// Writer pushes the lines written to it, e.g. by a `slog.JSONHandler`, to the push API of Loki, in batches.
+//
+// Every call to `Write` is a line. The lines are pushed in the background, so `Write` never waits for Loki, and
+// `Close` pushes the remaining ones.
+type Writer struct {
+	url       string
+	labels    map[string]string
+	interval  time.Duration
+	batchSize int
+	client    *http.Client
+	fallback  io.Writer
+
+	mu      sync.Mutex
+	entries []entry
+	closed  bool
+
+	// full is signalled when a batch is full.
+	full chan struct{}
+
+	// done is closed by `Close`, and stopped once the remaining lines are pushed.
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewWriter creates a new instance of `Writer`, which pushes its lines until it is closed.
+func NewWriter(config *Config) *Writer {
+	if config == nil || config.Address == "" {
+		panic("loki: nil config")
+	}
+
+	address := config.Address
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+
+	writer := Writer{
+		url:       strings.TrimSuffix(address, "/") + pushPath,
+		labels:    config.Labels,
+		interval:  config.Interval,
+		batchSize: config.BatchSize,
+		client:    config.Client,
+		fallback:  config.Fallback,
+		full:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	if writer.labels == nil {
+		writer.labels = map[string]string{}
+	}
+	if writer.interval <= 0 {
+		writer.interval = DefaultInterval
+	}
+	if writer.batchSize <= 0 {
+		writer.batchSize = DefaultBatchSize
+	}
+	if writer.client == nil {
+		writer.client = &http.Client{
+			Timeout: DefaultTimeout,
+		}
+	}
+	if writer.fallback == nil {
+		writer.fallback = os.Stderr
+	}
+
+	go writer.run()
+	return &writer
+}
+
+// Write queues the line to be pushed. Once the writer is closed, the line goes to the fallback instead.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return w.fallback.Write(p)
+	}
+	w.entries = append(w.entries, entry{
+		at:   time.Now(),
+		line: strings.TrimSuffix(string(p), "\n"),
+	})
+	full := len(w.entries) >= w.batchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.full <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Close pushes the remaining lines, and sends the lines written afterwards to the fallback.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+
+	close(w.done)
+	<-w.stopped
+	return nil
+}
+
+// run pushes the lines every interval, or as soon as a batch is full, until the writer is closed.
+func (w *Writer) run() {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			w.flush()
+			return
+		case <-ticker.C:
+		case <-w.full:
+		}
+		w.flush()
+	}
+}
+
+// flush pushes the queued lines, or writes them to the fallback if they cannot be pushed.
+func (w *Writer) flush() {
+	w.mu.Lock()
+	entries := w.entries
+	w.entries = nil
+	w.mu.Unlock()
+
+	for len(entries) > 0 {
+		batch := entries[:min(len(entries), w.batchSize)]
+		entries = entries[len(batch):]
+		if err := w.push(batch); err != nil {
+			fmt.Fprintf(w.fallback, "loki: failed to push %d lines: %v\n", len(batch), err)
+			for _, item := range batch {
+				fmt.Fprintln(w.fallback, item.line)
+			}
+		}
+	}
+}
+
+// stream is a stream of the push API of Loki.
+type stream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// push pushes the lines to Loki, in a single stream.
+func (w *Writer) push(entries []entry) error {
+	values := make([][2]string, len(entries))
+	for i, item := range entries {
+		values[i] = [2]string{strconv.FormatInt(item.at.UnixNano(), 10), item.line}
+	}
+	body, err := json.Marshal(map[string][]stream{
+		"streams": {{Stream: w.labels, Values: values}},
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+	return nil
+}
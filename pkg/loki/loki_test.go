@@ -0,0 +1,132 @@
+package loki
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver is a Loki push API which records the streams it receives.
+type receiver struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	streams []stream
+}
+
+func newReceiver(t *testing.T, status int) *receiver {
+	r := &receiver{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != pushPath || req.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var payload struct {
+			Streams []stream `json:"streams"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.mu.Lock()
+		r.streams = append(r.streams, payload.Streams...)
+		r.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// lines returns the lines received, in order.
+func (r *receiver) lines() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var lines []string
+	for _, item := range r.streams {
+		for _, value := range item.Values {
+			lines = append(lines, value[1])
+		}
+	}
+	return lines
+}
+
+func TestWriter(t *testing.T) {
+
+	t.Run("nil config", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("NewWriter() did not panic")
+			}
+		}()
+		NewWriter(nil)
+	})
+
+	t.Run("push the lines with the labels of the stream", func(t *testing.T) {
+
+		server := newReceiver(t, http.StatusNoContent)
+		writer := NewWriter(&Config{
+			Address:  strings.TrimPrefix(server.URL, "http://"),
+			Labels:   map[string]string{"service": "record"},
+			Interval: time.Hour,
+		})
+		writer.Write([]byte("first\n"))
+		writer.Write([]byte("second\n"))
+		writer.Close()
+
+		if got := server.lines(); len(got) != 2 || got[0] != "first" || got[1] != "second" {
+			t.Fatalf("expected the lines to be pushed on close, got %v", got)
+		}
+		if labels := server.streams[0].Stream; labels["service"] != "record" {
+			t.Errorf("expected the labels of the stream, got %v", labels)
+		}
+	})
+
+	t.Run("push the lines as soon as a batch is full", func(t *testing.T) {
+
+		server := newReceiver(t, http.StatusNoContent)
+		writer := NewWriter(&Config{
+			Address:   server.URL,
+			Interval:  time.Hour,
+			BatchSize: 2,
+		})
+		defer writer.Close()
+		writer.Write([]byte("first\n"))
+		writer.Write([]byte("second\n"))
+
+		deadline := time.Now().Add(5 * time.Second)
+		for len(server.lines()) < 2 {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected the full batch to be pushed, got %v", server.lines())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("write the lines which cannot be pushed to the fallback", func(t *testing.T) {
+
+		server := newReceiver(t, http.StatusInternalServerError)
+		var fallback bytes.Buffer
+		writer := NewWriter(&Config{
+			Address:  server.URL,
+			Interval: time.Hour,
+			Fallback: &fallback,
+		})
+		writer.Write([]byte("lost\n"))
+		writer.Close()
+
+		if !strings.Contains(fallback.String(), "lost\n") {
+			t.Errorf("expected the line in the fallback, got %q", fallback.String())
+		}
+
+		// The lines written once the writer is closed go to the fallback too.
+		writer.Write([]byte("late\n"))
+		if !strings.Contains(fallback.String(), "late\n") {
+			t.Errorf("expected the late line in the fallback, got %q", fallback.String())
+		}
+	})
+}
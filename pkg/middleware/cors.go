@@ -15,18 +15,24 @@ type CORSConfig struct {
 	AllowedOrigins []string
 
 	// AllowedMethods is the list of methods that are allowed to access the resource.
-	// Default: `[]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}`
+	// Default: `[]string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}`
 	//
 	// This field is optional.
 	AllowedMethods []string
 
 	// AllowedHeaders is the list of headers that are allowed to access the resource.
 	// Default: `[]string{"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization
-	// "accept", "origin", "Cache-Control", "X-Requested-With"}`
+	// "accept", "origin", "Cache-Control", "X-Requested-With", "If-Match"}`
 	//
 	// This field is optional.
 	AllowedHeaders []string
 
+	// ExposedHeaders is the list of response headers that the scripts of the allowed origins can read.
+	// Default: `[]string{"ETag"}`
+	//
+	// This field is optional.
+	ExposedHeaders []string
+
 	// AllowCredentials is the flag that determines if the resource allows credentials.
 	// Default: `false`
 	//
@@ -47,7 +53,7 @@ func CORS(config *CORSConfig) Middleware {
 	}
 
 	if config.AllowedMethods == nil {
-		config.AllowedMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+		config.AllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
 	}
 
 	if config.AllowedHeaders == nil {
@@ -61,15 +67,21 @@ func CORS(config *CORSConfig) Middleware {
 			"origin",
 			"Cache-Control",
 			"X-Requested-With",
+			"If-Match",
 		}
 	}
 
+	if config.ExposedHeaders == nil {
+		config.ExposedHeaders = []string{"ETag"}
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Add("Access-Control-Allow-Origin", strings.Join(config.AllowedOrigins, ","))
 			w.Header().Add("Access-Control-Allow-Credentials", fmt.Sprint(config.AllowCredentials))
 			w.Header().Add("Access-Control-Allow-Headers", strings.Join(config.AllowedHeaders, ","))
 			w.Header().Add("Access-Control-Allow-Methods", strings.Join(config.AllowedMethods, ","))
+			w.Header().Add("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ","))
 
 			if r.Method == http.MethodOptions {
 				http.Error(w, http.StatusText(http.StatusNoContent), http.StatusNoContent)
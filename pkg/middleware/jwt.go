@@ -71,6 +71,13 @@ type JWTConfig struct {
 	// This field is optional.
 	ExceptionalRoutes []string
 
+	// Skip reports whether the request is excluded from the JWT validation.
+	// It complements `ExceptionalRoutes` for routes whose path is not known upfront,
+	// like the probes of a router which can be mounted anywhere.
+	//
+	// This field is optional.
+	Skip func(r *http.Request) bool
+
 	// Header is the request header that will be used to extract the JWT from.
 	// Default: `Authorization`
 	//
@@ -115,6 +122,10 @@ func JWT(config *JWTConfig) Middleware {
 					return
 				}
 			}
+			if config.Skip != nil && config.Skip(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
 
 			// Extract the JWT from the appropriate header.
 			header := r.Header.Get(config.Header)
@@ -67,4 +67,36 @@ func TestJWT(t *testing.T) {
 			t.Errorf("ServeHTTP() = %v, want %v", status, http.StatusOK)
 		}
 	})
+
+	t.Run("jwt middleware w/ skipped request", func(t *testing.T) {
+
+		// Initialize the JWT middleware.
+		middleware := JWT(&JWTConfig{
+			Key: "secret",
+			Skip: func(r *http.Request) bool {
+				return r.URL.Path == "/public"
+			},
+		})
+		handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		for path, want := range map[string]int{
+			"/public":    http.StatusOK,
+			"/protected": http.StatusUnauthorized,
+		} {
+
+			// Initialize test r and response recorder, without a JWT.
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+			w := httptest.NewRecorder()
+
+			// Serve the request.
+			handler.ServeHTTP(w, r)
+
+			// Validate the status code.
+			if status := w.Code; status != want {
+				t.Errorf("ServeHTTP(%s) = %v, want %v", path, status, want)
+			}
+		}
+	})
 }
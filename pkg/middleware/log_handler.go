@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ContextHandler is a `slog.Handler` that stamps every log record with the request metadata found in its context.
+//
+// The metadata is set by the middlewares of this package: the request, trace and correlation IDs, and the ID of the
+// authenticated user. Wrap the handler of the root logger with it, and every layer which logs with a context,
+// e.g. with `LogAttrs` or `DebugContext`, gets the metadata without any change.
+type ContextHandler struct {
+	slog.Handler
+}
+
+// contextAttrs are the context keys stamped on the log records, along with their attribute keys.
+var contextAttrs = []struct {
+	key       Key
+	attribute string
+}{
+	{XRequestID, "request_id"},
+	{XTraceID, "trace_id"},
+	{XCorrelationID, "correlation_id"},
+}
+
+// NewContextHandler wraps the supplied handler with a `ContextHandler`.
+func NewContextHandler(handler slog.Handler) *ContextHandler {
+	return &ContextHandler{
+		Handler: handler,
+	}
+}
+
+// Handle adds the request metadata of the context to the record, unless the record already has them,
+// and passes it to the wrapped handler.
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx == nil {
+		return h.Handler.Handle(ctx, record)
+	}
+
+	// Collect the keys of the record, to avoid duplicating the attributes which were set explicitly.
+	existing := make(map[string]bool, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		existing[attr.Key] = true
+		return true
+	})
+
+	var attrs []slog.Attr
+	for _, item := range contextAttrs {
+		if existing[item.attribute] {
+			continue
+		}
+		if value, ok := ctx.Value(item.key).(string); ok && value != "" {
+			attrs = append(attrs, slog.String(item.attribute, value))
+		}
+	}
+	if !existing["user_id"] {
+		if claims, ok := ctx.Value(XJWTClaims).(JWTClaims); ok {
+			attrs = append(attrs, slog.String("user_id", claims.XUserID.String()))
+		}
+	}
+
+	if len(attrs) > 0 {
+		record = record.Clone()
+		record.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs returns a `ContextHandler` wrapping the handler returned by the wrapped handler.
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return NewContextHandler(h.Handler.WithAttrs(attrs))
+}
+
+// WithGroup returns a `ContextHandler` wrapping the handler returned by the wrapped handler.
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return NewContextHandler(h.Handler.WithGroup(name))
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestContextHandler(t *testing.T) {
+
+	// decode returns the attributes of the last logged line.
+	decode := func(t *testing.T, b *bytes.Buffer) map[string]any {
+		var line map[string]any
+		if err := json.Unmarshal(b.Bytes(), &line); err != nil {
+			t.Fatalf("failed to decode the log line: %v", err)
+		}
+		b.Reset()
+		return line
+	}
+
+	userID := uuid.New()
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, XRequestID, "request")
+	ctx = context.WithValue(ctx, XTraceID, "trace")
+	ctx = context.WithValue(ctx, XCorrelationID, "correlation")
+	ctx = context.WithValue(ctx, XJWTClaims, JWTClaims{XUserID: userID})
+
+	t.Run("stamp the request metadata", func(t *testing.T) {
+
+		var b bytes.Buffer
+		logger := slog.New(NewContextHandler(slog.NewJSONHandler(&b, nil))).With("layer", "service")
+		logger.InfoContext(ctx, "creating a new record")
+
+		line := decode(t, &b)
+		for key, want := range map[string]string{
+			"request_id":     "request",
+			"trace_id":       "trace",
+			"correlation_id": "correlation",
+			"user_id":        userID.String(),
+			"layer":          "service",
+		} {
+			if line[key] != want {
+				t.Errorf("expected %s to be %q, got %v", key, want, line[key])
+			}
+		}
+	})
+
+	t.Run("keep the explicit attributes", func(t *testing.T) {
+
+		var b bytes.Buffer
+		logger := slog.New(NewContextHandler(slog.NewJSONHandler(&b, nil)))
+		logger.LogAttrs(ctx, slog.LevelInfo, "incoming request", slog.String("request_id", "explicit"))
+
+		if got := bytes.Count(b.Bytes(), []byte(`"request_id"`)); got != 1 {
+			t.Errorf("expected request_id to be logged once, got %d times", got)
+		}
+		if line := decode(t, &b); line["request_id"] != "explicit" {
+			t.Errorf("expected request_id to be 'explicit', got %v", line["request_id"])
+		}
+	})
+
+	t.Run("log w/o metadata", func(t *testing.T) {
+
+		var b bytes.Buffer
+		logger := slog.New(NewContextHandler(slog.NewJSONHandler(&b, nil)))
+		logger.Info("starting")
+
+		line := decode(t, &b)
+		for _, key := range []string{"request_id", "trace_id", "correlation_id", "user_id"} {
+			if _, ok := line[key]; ok {
+				t.Errorf("expected %s not to be logged", key)
+			}
+		}
+	})
+}
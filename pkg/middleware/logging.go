@@ -60,9 +60,12 @@ func Logging(config *LoggingConfig) Middleware {
 			// For our use case, we are going to log the request.
 			//
 
+			// The request ID is missing when the `RequestID` middleware is not in the chain.
+			requestID, _ := r.Context().Value(XRequestID).(string)
+
 			attributes := []slog.Attr{
 				{Key: "timestamp", Value: slog.StringValue(start.String())},
-				{Key: "request_id", Value: slog.StringValue(r.Context().Value(XRequestID).(string))},
+				{Key: "request_id", Value: slog.StringValue(requestID)},
 				{Key: "status", Value: slog.IntValue(writer.Status())},
 				{Key: "hostname", Value: slog.StringValue(r.Host)},
 				{Key: "method", Value: slog.StringValue(r.Method)},
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mrinalwahal/service/pkg/writer"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unmatched is the route label of the requests which did not match any route.
+const unmatched = "unmatched"
+
+type MetricsConfig struct {
+
+	// Registerer is the Prometheus registry the metrics will be registered with.
+	// Default: `prometheus.DefaultRegisterer`
+	//
+	// This field is optional.
+	Registerer prometheus.Registerer
+
+	// Buckets are the upper bounds, in seconds, of the request duration histogram.
+	// Default: `prometheus.DefBuckets`
+	//
+	// This field is optional.
+	Buckets []float64
+}
+
+// Metrics is a middleware that records the rate, errors and duration of the requests.
+//
+// The requests are labelled with the `http.ServeMux` pattern of the route that handled them, e.g. "GET /v1/{id}",
+// which the router records on the `writer.Writer`. The raw path is never used, so the number of series stays bounded.
+func Metrics(config *MetricsConfig) Middleware {
+
+	// Set the default configuration.
+	if config == nil {
+		config = &MetricsConfig{}
+	}
+
+	if config.Registerer == nil {
+		config.Registerer = prometheus.DefaultRegisterer
+	}
+
+	if config.Buckets == nil {
+		config.Buckets = prometheus.DefBuckets
+	}
+
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests, by route and status code.",
+	}, []string{"method", "route", "status"})
+
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of the HTTP requests, by route and status code.",
+		Buckets: config.Buckets,
+	}, []string{"method", "route", "status"})
+
+	inflight := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "http_requests_in_flight",
+		Help: "Number of HTTP requests being served.",
+	})
+
+	config.Registerer.MustRegister(requests, duration, inflight)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			inflight.Inc()
+			defer inflight.Dec()
+
+			writer := writer.NewWriter(w)
+			next.ServeHTTP(writer, r)
+
+			route := writer.Pattern()
+			if route == "" {
+				route = unmatched
+			}
+			status := writer.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			labels := prometheus.Labels{
+				"method": method(r.Method),
+				"route":  route,
+				"status": strconv.Itoa(status),
+			}
+			requests.With(labels).Inc()
+			duration.With(labels).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// method returns the label of the request method.
+// Non-standard methods share a single label, so clients can not create new series at will.
+func method(m string) string {
+	switch m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return m
+	}
+	return "OTHER"
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mrinalwahal/service/pkg/writer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+
+	t.Run("metrics middleware", func(t *testing.T) {
+
+		registry := prometheus.NewRegistry()
+
+		// Initialize a new router, which records the pattern of the matched route like `HTTPRouter` does.
+		router := http.NewServeMux()
+		router.HandleFunc("GET /v1/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, pattern := router.Handler(r); pattern != "" {
+				writer.SetPattern(w, pattern)
+			}
+			router.ServeHTTP(w, r)
+		})
+
+		// The logging middleware wraps the writer once more, so the pattern must go through it.
+		chain := Chain(
+			Metrics(&MetricsConfig{
+				Registerer: registry,
+			}),
+			RequestID(nil),
+			Logging(nil),
+		)(handler)
+
+		for _, path := range []string{"/v1/1", "/v1/2", "/v2"} {
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+			w := httptest.NewRecorder()
+			chain.ServeHTTP(w, r)
+		}
+
+		expected := `
+# HELP http_requests_total Total number of HTTP requests, by route and status code.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="GET /v1/{id}",status="404"} 2
+http_requests_total{method="GET",route="unmatched",status="404"} 1
+`
+		if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "http_requests_total"); err != nil {
+			t.Errorf("unexpected metrics: %v", err)
+		}
+	})
+}
+
+func Test_method(t *testing.T) {
+	for m, want := range map[string]string{
+		http.MethodGet: http.MethodGet,
+		"PROPFIND":     "OTHER",
+	} {
+		if got := method(m); got != want {
+			t.Errorf("method(%s) = %v, want %v", m, got, want)
+		}
+	}
+}
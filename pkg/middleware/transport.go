@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Transport is an `http.RoundTripper` that propagates the IDs of the incoming request on the outbound requests.
+//
+// The request and correlation IDs are copied from the context of the outbound request, and the trace context is
+// injected with the global propagator, e.g. as W3C `traceparent` and `tracestate` headers. Headers that are
+// already set on the outbound request are left untouched.
+//
+// Share a single instance between the HTTP clients of the service, e.g. with `http.Client{Transport: transport}`.
+type Transport struct {
+
+	// Base is the transport which sends the requests.
+	// Default: `http.DefaultTransport`
+	//
+	// This field is optional.
+	Base http.RoundTripper
+}
+
+// NewTransport creates a new instance of `Transport` on top of the supplied transport.
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{
+		Base: base,
+	}
+}
+
+// RoundTrip adds the ID headers to a copy of the request and sends it with the base transport.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	// A round tripper must not modify the original request.
+	ctx := r.Context()
+	r = r.Clone(ctx)
+
+	for _, key := range []Key{XRequestID, XCorrelationID, XTraceID} {
+		if r.Header.Get(string(key)) != "" {
+			continue
+		}
+		if value, ok := ctx.Value(key).(string); ok && value != "" {
+			r.Header.Set(string(key), value)
+		}
+	}
+	if r.Header.Get("traceparent") == "" {
+		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r.Header))
+	}
+
+	return base.RoundTrip(r)
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+
+	t.Run("propagate the ids", func(t *testing.T) {
+
+		var received http.Header
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header.Clone()
+		}))
+		defer server.Close()
+
+		client := &http.Client{
+			Transport: NewTransport(nil),
+		}
+
+		ctx := context.Background()
+		ctx = context.WithValue(ctx, XRequestID, "request")
+		ctx = context.WithValue(ctx, XCorrelationID, "correlation")
+
+		r, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatalf("failed to create the request: %v", err)
+		}
+
+		// Explicit headers must win.
+		r.Header.Set(string(XCorrelationID), "explicit")
+
+		response, err := client.Do(r)
+		if err != nil {
+			t.Fatalf("failed to send the request: %v", err)
+		}
+		response.Body.Close()
+
+		if got := received.Get(string(XRequestID)); got != "request" {
+			t.Errorf("expected the request id 'request', got %q", got)
+		}
+		if got := received.Get(string(XCorrelationID)); got != "explicit" {
+			t.Errorf("expected the correlation id 'explicit', got %q", got)
+		}
+		if got := received.Get(string(XTraceID)); got != "" {
+			t.Errorf("expected no trace id, got %q", got)
+		}
+	})
+}
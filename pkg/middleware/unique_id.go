@@ -2,32 +2,113 @@ package middleware
 
 import (
 	"context"
+	"crypto/rand"
 	"net/http"
+	"regexp"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/pkg/writer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// X-Request-ID is the key used to store the request ID in the context and the response header.
+// IDConfig configures the middlewares which identify the requests: `RequestID`, `TraceID` and `CorrelationID`.
+type IDConfig struct {
+
+	// Header is the request and response header which carries the ID.
+	// Default: the context key of the middleware, e.g. `X-Request-ID`
+	//
+	// This field is optional.
+	Header string
+
+	// Validate reports whether the ID sent by the caller is acceptable.
+	// When it returns false, the inbound ID is discarded and a new one is generated.
+	// To never accept an inbound ID, return false unconditionally.
+	// Default: `ValidID`, or `ValidTraceID` for `TraceID`
+	//
+	// This field is optional.
+	Validate func(string) bool
+
+	// Generate returns a new ID.
+	// Default: a random UUID, or a random W3C trace ID for `TraceID`
+	//
+	// This field is optional.
+	Generate func() string
+}
+
+// id matches the IDs accepted by `ValidID`.
+var id = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// ValidID reports whether the ID is between 1 and 128 characters long and only made of letters, digits,
+// dots, underscores, colons and hyphens.
 //
-// The request ID is used to uniquely identify the request.
-const XRequestID Key = "X-Request-ID"
+// It accepts the usual formats, like UUIDs and ULIDs, and rejects anything that could forge a log line or a header.
+func ValidID(value string) bool {
+	return id.MatchString(value)
+}
 
-// RequestID middleware adds a unique UUID to the request context and response headers.
-func RequestID(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
-		id := uuid.New().String()
+// ValidTraceID reports whether the ID is a W3C trace ID: 32 lowercase hexadecimal characters, not all zeros.
+func ValidTraceID(value string) bool {
+	id, err := trace.TraceIDFromHex(value)
+	return err == nil && id.IsValid() && strings.ToLower(value) == value
+}
 
-		// Add the request ID to the request context.
-		ctx = context.WithValue(ctx, XRequestID, id)
+// withDefaults returns a copy of the configuration with the defaults of the middleware filled in.
+func (c *IDConfig) withDefaults(key Key, validate func(string) bool, generate func() string) IDConfig {
+	var config IDConfig
+	if c != nil {
+		config = *c
+	}
+	if config.Header == "" {
+		config.Header = string(key)
+	}
+	if config.Validate == nil {
+		config.Validate = validate
+	}
+	if config.Generate == nil {
+		config.Generate = generate
+	}
+	return config
+}
 
-		// Update the request with the new context.
-		r = r.WithContext(ctx)
+// identify returns a middleware which adds the inbound ID, if it is valid, or a new ID to the request context
+// and response headers.
+func identify(key Key, config IDConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+
+			// Honour the ID sent by the caller, or the gateway, if it is valid.
+			id := r.Header.Get(config.Header)
+			if id == "" || !config.Validate(id) {
+				id = config.Generate()
+			}
+
+			// Add the ID to the request context.
+			ctx = context.WithValue(ctx, key, id)
+
+			// Update the request with the new context.
+			r = r.WithContext(ctx)
+
+			// Add the ID to the response headers.
+			w.Header().Set(config.Header, id)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
-		// Add the request ID to the response headers.
-		w.Header().Set(string(XRequestID), id)
-		next.ServeHTTP(w, r)
-	})
+// X-Request-ID is the key used to store the request ID in the context and the response header.
+//
+// The request ID is used to uniquely identify the request.
+const XRequestID Key = "X-Request-ID"
+
+// RequestID middleware adds the request ID sent by the caller, or a new UUID, to the request context and response headers.
+func RequestID(config *IDConfig) Middleware {
+	return identify(XRequestID, config.withDefaults(XRequestID, ValidID, uuid.NewString))
 }
 
 // X-Trace-ID is the key used to store the trace ID in the context and the response header.
@@ -35,22 +116,111 @@ func RequestID(next http.Handler) http.Handler {
 // The trace ID is used to trace the request through multiple services.
 const XTraceID Key = "X-Trace-ID"
 
-// TraceID middleware adds a unique UUID to the request context and response headers.
-func TraceID(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
-		id := uuid.New().String()
+// tracer creates the server spans.
+//
+// It is resolved on every request, so it follows the global tracer provider even if it is replaced after the
+// middleware chain has been built.
+func tracer() trace.Tracer {
+	return otel.Tracer("github.com/mrinalwahal/service/pkg/middleware")
+}
 
-		// Add the trace ID to the request context.
-		ctx = context.WithValue(ctx, XTraceID, id)
+// TraceID middleware starts a server span for the request and adds its trace ID to the request context and response headers.
+//
+// The W3C `traceparent` and `tracestate` headers of the request are extracted with the global propagator,
+// so the span continues the trace of the caller when there is one. Otherwise, the trace ID sent in the
+// configured header is honoured if it is valid. The span is exported by the global tracer provider.
+func TraceID(config *IDConfig) Middleware {
+	cfg := config.withDefaults(XTraceID, ValidTraceID, newTraceID)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			// Without a trace context, continue the trace of the trace ID header.
+			// Its parent span is unknown, so a random one stands in for it.
+			if !trace.SpanContextFromContext(ctx).IsValid() {
+				if inbound := r.Header.Get(cfg.Header); inbound != "" && cfg.Validate(inbound) {
+					if traceID, err := trace.TraceIDFromHex(inbound); err == nil {
+						ctx = trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+							TraceID:    traceID,
+							SpanID:     newSpanID(),
+							TraceFlags: trace.FlagsSampled,
+							Remote:     true,
+						}))
+					}
+				}
+			}
+
+			// Start the server span.
+			// It is renamed after the route pattern once the router has matched the request.
+			ctx, span := tracer().Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(method(r.Method)),
+					semconv.URLPath(r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			// The trace ID is all zeros when tracing is disabled.
+			// Fall back to the parent's one or to a new one, so the request can still be traced in the logs.
+			id := span.SpanContext().TraceID().String()
+			if !span.SpanContext().HasTraceID() {
+				id = cfg.Generate()
+				if parent := trace.SpanContextFromContext(ctx); parent.HasTraceID() {
+					id = parent.TraceID().String()
+				}
+			}
+
+			// Add the trace ID to the request context.
+			ctx = context.WithValue(ctx, XTraceID, id)
+
+			// Update the request with the new context.
+			r = r.WithContext(ctx)
+
+			// Add the trace ID to the response headers.
+			w.Header().Set(cfg.Header, id)
+
+			writer := writer.NewWriter(w)
+			next.ServeHTTP(writer, r)
+
+			// The pattern is the method followed by the route, e.g. "GET /v1/{id}".
+			if pattern := writer.Pattern(); pattern != "" {
+				_, route, _ := strings.Cut(pattern, " ")
+				if route == "" {
+					route = pattern
+				}
+				span.SetName(pattern)
+				span.SetAttributes(semconv.HTTPRoute(route))
+			}
+			status := writer.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
 
-		// Update the request with the new context.
-		r = r.WithContext(ctx)
+// newTraceID returns a random W3C trace ID.
+func newTraceID() string {
+	var id trace.TraceID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id.String()
+}
 
-		// Add the trace ID to the response headers.
-		w.Header().Set(string(XTraceID), id)
-		next.ServeHTTP(w, r)
-	})
+// newSpanID returns a random W3C span ID.
+func newSpanID() trace.SpanID {
+	var id trace.SpanID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
 }
 
 // X-Correlation-ID is the key used to store the correlation ID in the context and the response header.
@@ -58,20 +228,7 @@ func TraceID(next http.Handler) http.Handler {
 // The correlation ID is used to correlate the request with other requests.
 const XCorrelationID Key = "X-Correlation-ID"
 
-// CorrelationID middleware adds a unique UUID to the request context and response headers.
-func CorrelationID(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
-		id := uuid.New().String()
-
-		// Add the correlation ID to the request context.
-		ctx = context.WithValue(ctx, XCorrelationID, id)
-
-		// Update the request with the new context.
-		r = r.WithContext(ctx)
-
-		// Add the correlation ID to the response headers.
-		w.Header().Set(string(XCorrelationID), id)
-		next.ServeHTTP(w, r)
-	})
+// CorrelationID middleware adds the correlation ID sent by the caller, or a new UUID, to the request context and response headers.
+func CorrelationID(config *IDConfig) Middleware {
+	return identify(XCorrelationID, config.withDefaults(XCorrelationID, ValidID, uuid.NewString))
 }
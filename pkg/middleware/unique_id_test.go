@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mrinalwahal/service/pkg/writer"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceID(t *testing.T) {
+
+	// Install an in-memory tracer provider, and restore the global one after the test.
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(propagator)
+	})
+
+	handler := TraceID(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writer.SetPattern(w, "GET /v1/{id}")
+
+		// The span of the request must be in its context.
+		if !trace.SpanContextFromContext(r.Context()).IsValid() {
+			t.Errorf("expected the request context to hold the server span")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Run("trace id middleware w/ traceparent", func(t *testing.T) {
+		exporter.Reset()
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/1", nil)
+		r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got := w.Header().Get(string(XTraceID)); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("expected the trace id of the traceparent, got %q", got)
+		}
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("expected 1 span, got %d", len(spans))
+		}
+		span := spans[0]
+		if span.Name != "GET /v1/{id}" {
+			t.Errorf("expected the span to be named after the route, got %q", span.Name)
+		}
+		if span.Parent.SpanID().String() != "00f067aa0ba902b7" {
+			t.Errorf("expected the span to be a child of the caller's span, got %s", span.Parent.SpanID())
+		}
+		if span.SpanKind != trace.SpanKindServer {
+			t.Errorf("expected a server span, got %s", span.SpanKind)
+		}
+	})
+
+	t.Run("trace id middleware w/o traceparent", func(t *testing.T) {
+		exporter.Reset()
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/1", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("expected 1 span, got %d", len(spans))
+		}
+		if got, want := w.Header().Get(string(XTraceID)), spans[0].SpanContext.TraceID().String(); got != want {
+			t.Errorf("expected the trace id of the new span %q, got %q", want, got)
+		}
+	})
+
+	t.Run("trace id middleware w/ x-trace-id", func(t *testing.T) {
+		exporter.Reset()
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/1", nil)
+		r.Header.Set(string(XTraceID), "4bf92f3577b34da6a3ce929d0e0e4736")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got := w.Header().Get(string(XTraceID)); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+			t.Errorf("expected the inbound trace id, got %q", got)
+		}
+	})
+
+	t.Run("trace id middleware w/ invalid x-trace-id", func(t *testing.T) {
+		exporter.Reset()
+
+		r := httptest.NewRequest(http.MethodGet, "/v1/1", nil)
+		r.Header.Set(string(XTraceID), "not-a-trace-id")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got := w.Header().Get(string(XTraceID)); !ValidTraceID(got) {
+			t.Errorf("expected a new trace id, got %q", got)
+		}
+	})
+}
+
+func TestRequestID(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		inbound string
+		keep    bool
+	}{
+		{
+			name:    "w/ valid inbound id",
+			inbound: "01HV3K4ZK3M9E5RZ4T5C8WQ7XJ",
+			keep:    true,
+		},
+		{
+			name:    "w/ too long inbound id",
+			inbound: strings.Repeat("a", 129),
+		},
+		{
+			name:    "w/ forged inbound id",
+			inbound: "abc\nlevel=ERROR",
+		},
+		{
+			name: "w/o inbound id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var got string
+			handler := RequestID(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, _ = r.Context().Value(XRequestID).(string)
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.inbound != "" {
+				r.Header.Set(string(XRequestID), tt.inbound)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if tt.keep && got != tt.inbound {
+				t.Errorf("expected the inbound id %q, got %q", tt.inbound, got)
+			}
+			if !tt.keep && (got == tt.inbound || !ValidID(got)) {
+				t.Errorf("expected a new id, got %q", got)
+			}
+			if echoed := w.Header().Get(string(XRequestID)); echoed != got {
+				t.Errorf("expected the id %q to be echoed, got %q", got, echoed)
+			}
+		})
+	}
+
+	t.Run("w/ custom header and validation", func(t *testing.T) {
+
+		var got string
+		handler := CorrelationID(&IDConfig{
+			Header: "X-Amzn-Trace-Id",
+			Validate: func(string) bool {
+				return false
+			},
+			Generate: func() string {
+				return "generated"
+			},
+		})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got, _ = r.Context().Value(XCorrelationID).(string)
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Amzn-Trace-Id", "inbound")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if got != "generated" || w.Header().Get("X-Amzn-Trace-Id") != "generated" {
+			t.Errorf("expected the generated id, got %q", got)
+		}
+	})
+}
+
+func TestLogging(t *testing.T) {
+
+	t.Run("logging middleware w/o request id", func(t *testing.T) {
+
+		handler := Logging(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("ServeHTTP() = %v, want %v", w.Code, http.StatusOK)
+		}
+	})
+}
@@ -1,6 +1,14 @@
 package middleware
 
-import "net/http"
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
 
 // X-Webhook-Token is the key used to store the webhook token in the request header.
 //
@@ -44,3 +52,60 @@ func Webhook(config *WebhookConfig) Middleware {
 		})
 	}
 }
+
+// Headers of the webhook deliveries sent by the service.
+const (
+
+	// XWebhookID is the header of the ID of the delivery, which is the same for every attempt of the delivery.
+	XWebhookID Key = "X-Webhook-ID"
+
+	// XWebhookEvent is the header of the type of the event delivered.
+	XWebhookEvent Key = "X-Webhook-Event"
+
+	// XWebhookTimestamp is the header of the time the delivery was signed at, in seconds since the Unix epoch.
+	XWebhookTimestamp Key = "X-Webhook-Timestamp"
+
+	// XWebhookSignature is the header of the signature of the delivery, as returned by `SignWebhook`.
+	XWebhookSignature Key = "X-Webhook-Signature"
+)
+
+var (
+	ErrInvalidWebhookSignature = fmt.Errorf("invalid webhook signature")
+	ErrExpiredWebhookTimestamp = fmt.Errorf("webhook timestamp outside of the tolerance")
+)
+
+// SignWebhook returns the signature of the body of a webhook delivery signed at the timestamp.
+//
+// The signature is the HMAC-SHA256 of the timestamp, in seconds since the Unix epoch, a dot, and the body, keyed
+// with the secret of the webhook, hex encoded and prefixed with "sha256=". Signing the timestamp along with the
+// body lets the receivers reject the replays of old deliveries.
+//
+// Example: "sha256=5257a869e7ecebeda32affa62cdca3fa51cad7e77a0e56ff536d0ce8e108d8bd"
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook verifies the signature of a webhook delivery, from its headers and its body, for the receivers.
+//
+// It returns `ErrExpiredWebhookTimestamp` if the delivery was signed more than the tolerance away from now, and
+// `ErrInvalidWebhookSignature` if its signature does not match.
+func VerifyWebhook(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	seconds, err := strconv.ParseInt(header.Get(string(XWebhookTimestamp)), 10, 64)
+	if err != nil {
+		return ErrInvalidWebhookSignature
+	}
+	timestamp := time.Unix(seconds, 0)
+	if elapsed := time.Since(timestamp); elapsed > tolerance || elapsed < -tolerance {
+		return ErrExpiredWebhookTimestamp
+	}
+
+	signature := header.Get(string(XWebhookSignature))
+	if !hmac.Equal([]byte(signature), []byte(SignWebhook(secret, timestamp, body))) {
+		return ErrInvalidWebhookSignature
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifyWebhook(t *testing.T) {
+
+	secret, body := "whsec_test", []byte(`{"type":"record.created"}`)
+	signed := func(timestamp time.Time, signature string) http.Header {
+		header := http.Header{}
+		header.Set(string(XWebhookTimestamp), strconv.FormatInt(timestamp.Unix(), 10))
+		header.Set(string(XWebhookSignature), signature)
+		return header
+	}
+
+	t.Run("verify webhook w/ valid signature", func(t *testing.T) {
+
+		now := time.Now()
+		if err := VerifyWebhook(secret, signed(now, SignWebhook(secret, now, body)), body, time.Minute); err != nil {
+			t.Errorf("VerifyWebhook() error = %v", err)
+		}
+	})
+
+	t.Run("verify webhook w/ tampered body", func(t *testing.T) {
+
+		now := time.Now()
+		header := signed(now, SignWebhook(secret, now, body))
+		if err := VerifyWebhook(secret, header, []byte(`{"type":"record.deleted"}`), time.Minute); err != ErrInvalidWebhookSignature {
+			t.Errorf("VerifyWebhook() error = %v, want %v", err, ErrInvalidWebhookSignature)
+		}
+	})
+
+	t.Run("verify webhook w/ another secret", func(t *testing.T) {
+
+		now := time.Now()
+		header := signed(now, SignWebhook("whsec_other", now, body))
+		if err := VerifyWebhook(secret, header, body, time.Minute); err != ErrInvalidWebhookSignature {
+			t.Errorf("VerifyWebhook() error = %v, want %v", err, ErrInvalidWebhookSignature)
+		}
+	})
+
+	t.Run("verify webhook w/ replayed timestamp", func(t *testing.T) {
+
+		past := time.Now().Add(-time.Hour)
+		header := signed(past, SignWebhook(secret, past, body))
+		if err := VerifyWebhook(secret, header, body, time.Minute); err != ErrExpiredWebhookTimestamp {
+			t.Errorf("VerifyWebhook() error = %v, want %v", err, ErrExpiredWebhookTimestamp)
+		}
+	})
+
+	t.Run("verify webhook w/o timestamp", func(t *testing.T) {
+
+		header := http.Header{}
+		header.Set(string(XWebhookSignature), SignWebhook(secret, time.Now(), body))
+		if err := VerifyWebhook(secret, header, body, time.Minute); err != ErrInvalidWebhookSignature {
+			t.Errorf("VerifyWebhook() error = %v, want %v", err, ErrInvalidWebhookSignature)
+		}
+	})
+}
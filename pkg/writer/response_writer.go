@@ -10,12 +10,23 @@ type ResponseWriter interface {
 type Writer struct {
 	http.ResponseWriter
 	status int
+
+	// pattern is the `http.ServeMux` pattern of the route which handled the request.
+	pattern string
 }
 
 func (w *Writer) Status() int {
 	return w.status
 }
 
+// Pattern returns the `http.ServeMux` pattern of the route which handled the request, as recorded with `SetPattern`.
+// It is empty if no route matched the request.
+//
+// Example: "GET /v1/{id}"
+func (w *Writer) Pattern() string {
+	return w.pattern
+}
+
 func (w *Writer) WriteHeader(status int) {
 	w.status = status
 	w.ResponseWriter.WriteHeader(status)
@@ -28,6 +39,37 @@ func (w *Writer) Write(data []byte) (int, error) {
 	return w.ResponseWriter.Write(data)
 }
 
+// Flush sends the buffered data to the client, if the wrapped writer supports it.
+// It lets the handlers which stream their responses, e.g. Server-Sent Events, flush through the middlewares.
+func (w *Writer) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the wrapped response writer.
+// It lets `http.ResponseController` reach the optional interfaces of the original writer.
+func (w *Writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func NewWriter(w http.ResponseWriter) *Writer {
 	return &Writer{ResponseWriter: w}
 }
+
+// SetPattern records the route pattern on every `Writer` in the chain of wrapped response writers.
+//
+// It is meant to be called by the router, so the middlewares which wrapped the writer can read it with `Pattern`.
+func SetPattern(w http.ResponseWriter, pattern string) {
+	for w != nil {
+		if writer, ok := w.(*Writer); ok {
+			writer.pattern = pattern
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = unwrapper.Unwrap()
+	}
+}
@@ -0,0 +1,42 @@
+package writer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriter_Flush(t *testing.T) {
+
+	t.Run("flush the wrapped writer", func(t *testing.T) {
+
+		recorder := httptest.NewRecorder()
+
+		// Wrap the writer twice, like the middlewares do.
+		var w http.ResponseWriter = NewWriter(NewWriter(recorder))
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatalf("expected the writer to implement http.Flusher")
+		}
+		w.Write([]byte("data"))
+		flusher.Flush()
+
+		if !recorder.Flushed {
+			t.Errorf("expected the wrapped writer to be flushed")
+		}
+		if status := w.(*Writer).Status(); status != http.StatusOK {
+			t.Errorf("Writer.Status() = %d, want %d", status, http.StatusOK)
+		}
+	})
+
+	t.Run("flush a writer which does not support it", func(t *testing.T) {
+
+		w := NewWriter(struct{ http.ResponseWriter }{httptest.NewRecorder()})
+
+		// It must not panic.
+		w.Flush()
+		if w.Status() != http.StatusOK {
+			t.Errorf("Writer.Status() = %d, want %d", w.Status(), http.StatusOK)
+		}
+	})
+}
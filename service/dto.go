@@ -1,6 +1,9 @@
 package service
 
 import (
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 )
 
@@ -28,21 +31,58 @@ type ListOptions struct {
 
 	//	Title of the record.
 	Title string
-	//	Skip for pagination.
-	Skip int
-	//	Limit for pagination.
-	Limit int
-	//	Order by field.
+
+	//	Filter is an AIP-160 filter expression.
+	//
+	//	Example: `created_at >= "2024-01-01T00:00:00Z" AND title:report`
+	Filter string
+
+	//	PageSize is the maximum number of records to return.
+	//	Default: `db.DefaultPageSize`. Page sizes larger than `db.MaxPageSize` are coerced to it.
+	PageSize int
+
+	//	PageToken is the `next_page_token` of the previous page.
+	PageToken string
+
+	//	OrderBy is an AIP-132 `order_by` expression.
+	//
+	//	Example: "title desc, created_at"
 	OrderBy string
-	//	Order by direction.
-	OrderDirection string
+
+	//	ShowDeleted includes the soft-deleted records.
+	ShowDeleted bool
 }
 
 func (o *ListOptions) validate() error {
-	if o.Skip < 0 {
+	if o.PageSize < 0 {
 		return ErrInvalidFilters
 	}
-	if o.Limit < 0 || o.Limit > 100 {
+	return nil
+}
+
+type GetOptions struct {
+
+	//	ShowDeleted returns the record even if it has been soft-deleted.
+	ShowDeleted bool
+}
+
+type SearchOptions struct {
+
+	//	Query is the text to search for in the titles of the records.
+	//
+	//	Example: "quarterly report"
+	Query string
+
+	//	PageSize is the maximum number of records to return.
+	//	Default: `db.DefaultPageSize`. Page sizes larger than `db.MaxPageSize` are coerced to it.
+	PageSize int
+}
+
+func (o *SearchOptions) validate() error {
+	if strings.TrimSpace(o.Query) == "" {
+		return ErrInvalidQuery
+	}
+	if o.PageSize < 0 {
 		return ErrInvalidFilters
 	}
 	return nil
@@ -52,11 +92,303 @@ type UpdateOptions struct {
 
 	//	Title of the record.
 	Title string
+
+	//	UpdateMask is the AIP-134 field mask of the fields to update. The other fields of the options are ignored.
+	//	The immutable fields, "id", "user_id" and "created_at", cannot be updated.
+	//	Default: every updatable field, like `[]string{"*"}`.
+	//
+	//	Example: `[]string{"title"}`
+	UpdateMask []string
+
+	//	Version is the version of the record the update is based on.
+	//	If it is set and the record is at another version, `ErrVersionMismatch` is returned.
+	//	Default: 0, which updates any version.
+	Version int64
 }
 
 func (o *UpdateOptions) validate() error {
-	if o.Title == "" {
+	if o.masks("title") && o.Title == "" {
 		return ErrInvalidTitle
 	}
+	if o.Version < 0 {
+		return ErrInvalidOptions
+	}
+	return nil
+}
+
+// masks reports whether the update mask includes the field.
+//
+// The mask itself is validated by the database layer, which owns the list of the updatable fields.
+func (o *UpdateOptions) masks(field string) bool {
+	if len(o.UpdateMask) == 0 {
+		return true
+	}
+	for _, path := range o.UpdateMask {
+		if path = strings.TrimSpace(path); path == field || path == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+type DeleteOptions struct {
+
+	//	Force permanently deletes the record, instead of soft-deleting it.
+	Force bool
+
+	//	Version is the version of the record the deletion is based on.
+	//	If it is set and the record is at another version, `ErrVersionMismatch` is returned.
+	//	Default: 0, which deletes any version.
+	Version int64
+}
+
+type PurgeOptions struct {
+
+	//	DeletedBefore selects the records soft-deleted before this time.
+	DeletedBefore time.Time
+}
+
+func (o *PurgeOptions) validate() error {
+	if o.DeletedBefore.IsZero() {
+		return ErrInvalidFilters
+	}
+	return nil
+}
+
+type ListRevisionsOptions struct {
+
+	//	PageSize is the maximum number of revisions to return.
+	//	Default: `db.DefaultPageSize`. Page sizes larger than `db.MaxPageSize` are coerced to it.
+	PageSize int
+
+	//	PageToken is the `next_page_token` of the previous page.
+	PageToken string
+}
+
+func (o *ListRevisionsOptions) validate() error {
+	if o.PageSize < 0 {
+		return ErrInvalidFilters
+	}
+	return nil
+}
+
+type DiffRevisionsOptions struct {
+
+	//	Against is the revision to compare the revision with.
+	//	Default: 0, which compares it with the previous revision, or with nothing for the first revision.
+	Against int64
+}
+
+func (o *DiffRevisionsOptions) validate() error {
+	if o.Against < 0 {
+		return ErrInvalidRevision
+	}
+	return nil
+}
+
+type RestoreOptions struct {
+
+	//	Revision to restore the record to.
+	Revision int64
+
+	//	Version is the version of the record the restoration is based on.
+	//	If it is set and the record is at another version, `ErrVersionMismatch` is returned.
+	//	Default: 0, which restores any version.
+	Version int64
+}
+
+func (o *RestoreOptions) validate() error {
+	if o.Revision < 1 {
+		return ErrInvalidRevision
+	}
+	if o.Version < 0 {
+		return ErrInvalidOptions
+	}
+	return nil
+}
+
+type BatchCreateOptions struct {
+
+	//	Requests are the options of the records to create, in order.
+	Requests []*CreateOptions
+}
+
+func (o *BatchCreateOptions) validate(max int) error {
+	if err := validateBatchSize(len(o.Requests), max); err != nil {
+		return err
+	}
+	var items []*ItemError
+	for i, request := range o.Requests {
+		if request == nil {
+			items = append(items, &ItemError{Index: i, Err: ErrInvalidOptions})
+		} else if err := request.validate(); err != nil {
+			items = append(items, &ItemError{Index: i, Err: err})
+		}
+	}
+	return batchError(items)
+}
+
+type BatchGetOptions struct {
+
+	//	IDs of the records to get, in order.
+	IDs []uuid.UUID
+
+	//	ShowDeleted also returns the soft-deleted records.
+	ShowDeleted bool
+}
+
+func (o *BatchGetOptions) validate(max int) error {
+	if err := validateBatchSize(len(o.IDs), max); err != nil {
+		return err
+	}
+	return validateBatchIDs(o.IDs)
+}
+
+type BatchUpdateRequest struct {
+
+	//	ID of the record to update.
+	ID uuid.UUID
+
+	UpdateOptions
+}
+
+type BatchUpdateOptions struct {
+
+	//	Requests are the updates of the records, in order.
+	Requests []*BatchUpdateRequest
+}
+
+func (o *BatchUpdateOptions) validate(max int) error {
+	if err := validateBatchSize(len(o.Requests), max); err != nil {
+		return err
+	}
+	var items []*ItemError
+	for i, request := range o.Requests {
+		switch {
+		case request == nil:
+			items = append(items, &ItemError{Index: i, Err: ErrInvalidOptions})
+		case request.ID == uuid.Nil:
+			items = append(items, &ItemError{Index: i, Err: ErrInvalidRecordID})
+		default:
+			if err := request.validate(); err != nil {
+				items = append(items, &ItemError{Index: i, Err: err})
+			}
+		}
+	}
+	return batchError(items)
+}
+
+type BatchDeleteOptions struct {
+
+	//	IDs of the records to delete.
+	IDs []uuid.UUID
+
+	//	Force permanently deletes the records, instead of soft-deleting them.
+	Force bool
+}
+
+func (o *BatchDeleteOptions) validate(max int) error {
+	if err := validateBatchSize(len(o.IDs), max); err != nil {
+		return err
+	}
+	return validateBatchIDs(o.IDs)
+}
+
+// validateBatchSize checks that a batch has at least one item, and at most `max` items.
+func validateBatchSize(size, max int) error {
+	if size == 0 {
+		return ErrInvalidOptions
+	}
+	if size > max {
+		return ErrBatchTooLarge
+	}
+	return nil
+}
+
+// validateBatchIDs checks the IDs of the records of a batch.
+func validateBatchIDs(ids []uuid.UUID) error {
+	var items []*ItemError
+	for i, id := range ids {
+		if id == uuid.Nil {
+			items = append(items, &ItemError{Index: i, Err: ErrInvalidRecordID})
+		}
+	}
+	return batchError(items)
+}
+
+// batchError returns the batch error of the supplied items, or nil if there are none.
+func batchError(items []*ItemError) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return &BatchError{Items: items}
+}
+
+type CreateWebhookOptions struct {
+
+	//	ID of the user who owns the webhook.
+	UserID uuid.UUID
+
+	//	URL the events are posted to. It must be an absolute `http` or `https` URL.
+	URL string
+
+	//	Events are the types of the events delivered to the webhook.
+	//	Default: every event.
+	Events []string
+
+	//	Secret is the key of the signatures of the deliveries, of at least `db.MinSecretLength` characters.
+	//	Default: a random secret.
+	Secret string
+}
+
+func (o *CreateWebhookOptions) validate() error {
+	if o.UserID == uuid.Nil {
+		return ErrInvalidUserID
+	}
+	if o.URL == "" {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+type ListWebhooksOptions struct {
+
+	//	PageSize is the maximum number of webhooks to return.
+	//	Default: `db.DefaultPageSize`. Page sizes larger than `db.MaxPageSize` are coerced to it.
+	PageSize int
+
+	//	PageToken is the `next_page_token` of the previous page.
+	PageToken string
+}
+
+func (o *ListWebhooksOptions) validate() error {
+	if o.PageSize < 0 {
+		return ErrInvalidFilters
+	}
+	return nil
+}
+
+type ListDeliveriesOptions struct {
+
+	//	WebhookID restricts the deliveries to those of a webhook.
+	//	Default: the deliveries of every webhook.
+	WebhookID uuid.UUID
+
+	//	Status restricts the deliveries to those with the status, e.g. `model.DeliveryFailed`.
+	//	Default: every status.
+	Status string
+
+	//	PageSize is the maximum number of deliveries to return.
+	//	Default: `db.DefaultPageSize`. Page sizes larger than `db.MaxPageSize` are coerced to it.
+	PageSize int
+
+	//	PageToken is the `next_page_token` of the previous page.
+	PageToken string
+}
+
+func (o *ListDeliveriesOptions) validate() error {
+	if o.PageSize < 0 {
+		return ErrInvalidFilters
+	}
 	return nil
 }
@@ -1,6 +1,10 @@
 package service
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/mrinalwahal/service/db"
+)
 
 var (
 	ErrInvalidOptions  = fmt.Errorf("invalid options")
@@ -8,5 +12,49 @@ var (
 	ErrInvalidUserID   = fmt.Errorf("invalid user_id")
 	ErrInvalidTitle    = fmt.Errorf("invalid title")
 	ErrInvalidFilters  = fmt.Errorf("invalid filters")
+	ErrInvalidQuery    = fmt.Errorf("invalid query")
 	ErrInvalidDB       = fmt.Errorf("invalid db")
+	ErrBatchTooLarge   = fmt.Errorf("batch too large")
+
+	// ErrHubClosed is returned by `Hub.Subscribe` once the hub has been closed.
+	ErrHubClosed = fmt.Errorf("hub closed")
+
+	// ErrEventNotFound is returned by `Hub.Subscribe` when the last event seen by the subscriber is no longer in
+	// the history of the hub, so the events it missed cannot be replayed.
+	ErrEventNotFound = fmt.Errorf("event not found")
+
+	// ErrSearchUnavailable is returned by `Search` when the database does not support full-text search.
+	ErrSearchUnavailable = db.ErrSearchUnavailable
+
+	// ErrVersionMismatch is returned by `Update` and `Delete` when the record is not at the expected version.
+	ErrVersionMismatch = db.ErrVersionMismatch
+
+	// ErrInvalidRevision is returned by the revision operations when the revision number is not positive.
+	ErrInvalidRevision = db.ErrInvalidRevision
+
+	// ErrInvalidWebhookID is returned by `DeleteWebhook` when the webhook ID is missing.
+	ErrInvalidWebhookID = db.ErrInvalidWebhookID
+
+	// ErrInvalidURL is returned by `CreateWebhook` when the URL is not an absolute `http` or `https` URL.
+	ErrInvalidURL = db.ErrInvalidURL
+
+	// ErrPrivateURL is returned by `CreateWebhook` when the host of the URL is "localhost" or a loopback, private,
+	// link-local or unspecified address.
+	ErrPrivateURL = db.ErrPrivateURL
+
+	// ErrInvalidEventType is returned by `CreateWebhook` when it is given an unknown event type.
+	ErrInvalidEventType = db.ErrInvalidEventType
+
+	// ErrInvalidSecret is returned by `CreateWebhook` when the secret is too short.
+	ErrInvalidSecret = db.ErrInvalidSecret
+)
+
+type (
+
+	// BatchError is returned by the batch operations when any of their items fails, in which case none is applied.
+	// It holds the errors of the failed items, by index.
+	BatchError = db.BatchError
+
+	// ItemError is the error of a single item of a batch.
+	ItemError = db.ItemError
 )
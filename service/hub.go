@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mrinalwahal/service/model"
+)
+
+// Defaults of the hub of the events.
+const (
+	DefaultHubHistory = 1000
+	DefaultHubBuffer  = 64
+)
+
+// Broadcaster sends the events of the records to the hubs of every instance of the service.
+type Broadcaster interface {
+
+	// Broadcast sends the event to every listener, including the ones of this instance.
+	Broadcast(context.Context, *model.Event) error
+
+	// Listen calls the function with every event broadcast, until the context is cancelled or the connection fails.
+	Listen(context.Context, func(*model.Event)) error
+}
+
+type HubConfig struct {
+
+	// Broadcaster sends the events published on this instance to the hubs of every instance, and receives theirs.
+	// Default: none, so the subscribers only receive the events published on this instance.
+	//
+	// This field is optional.
+	Broadcaster Broadcaster
+
+	// History is the number of the most recent events kept to be replayed to the subscribers which resume.
+	// Default: `DefaultHubHistory`
+	//
+	// This field is optional.
+	History int
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// Hub fans out the events of the records to its subscribers, e.g. the watch streams.
+//
+// The events are delivered at most once: a subscriber which does not keep up is unsubscribed, and it is expected to
+// subscribe again from the last event it received.
+type Hub struct {
+	broadcaster Broadcaster
+	size        int
+	log         *slog.Logger
+
+	mu          sync.Mutex
+	history     []*model.Event
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewHub creates a new instance of `Hub`.
+func NewHub(config *HubConfig) *Hub {
+	if config == nil {
+		config = &HubConfig{}
+	}
+
+	hub := Hub{
+		broadcaster: config.Broadcaster,
+		size:        config.History,
+		log:         config.Logger,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+
+	if hub.size <= 0 {
+		hub.size = DefaultHubHistory
+	}
+
+	if hub.log == nil {
+		hub.log = slog.Default()
+	}
+	hub.log = hub.log.With("component", "hub")
+
+	return &hub
+}
+
+// Subscription receives the events published after it was created.
+type Subscription struct {
+	hub    *Hub
+	events chan *model.Event
+}
+
+// Events returns the channel of the events of the subscription.
+//
+// It is closed when the subscription is closed, when the hub is closed, or when the subscriber falls behind.
+func (s *Subscription) Events() <-chan *model.Event {
+	return s.events
+}
+
+// Close unsubscribes from the hub.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.unsubscribe(s)
+}
+
+// Subscribe subscribes to the events published from now on.
+//
+// If `lastEventID` is set, it also returns the events of the history published after that one, so a subscriber can
+// resume without missing any. If that event is no longer in the history, it subscribes anyway, and returns
+// `ErrEventNotFound` along with the subscription: the subscriber must catch up by other means.
+func (h *Hub) Subscribe(lastEventID string) (*Subscription, []*model.Event, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, nil, ErrHubClosed
+	}
+
+	subscription := &Subscription{
+		hub:    h,
+		events: make(chan *model.Event, DefaultHubBuffer),
+	}
+	h.subscribers[subscription] = struct{}{}
+
+	if lastEventID == "" {
+		return subscription, nil, nil
+	}
+	for i := len(h.history) - 1; i >= 0; i-- {
+		if h.history[i].ID.String() == lastEventID {
+			return subscription, append([]*model.Event(nil), h.history[i+1:]...), nil
+		}
+	}
+	return subscription, nil, ErrEventNotFound
+}
+
+// Publish publishes the event to the subscribers of every instance, through the broadcaster if there is one.
+//
+// It implements `db.Publisher`, so the dispatcher of the outbox publishes the events of the records to the hub, with
+// the IDs they are stored with. It never fails: if the broadcast fails, the event is only delivered to the
+// subscribers of this instance.
+func (h *Hub) Publish(ctx context.Context, event *model.Event) error {
+	if h.broadcaster != nil {
+		err := h.broadcaster.Broadcast(ctx, event)
+		if err == nil {
+			return nil
+		}
+		h.log.ErrorContext(ctx, "failed to broadcast an event", "event_id", event.ID, "error", err)
+	}
+	h.deliver(event)
+	return nil
+}
+
+// deliver adds the event to the history, and sends it to the subscribers of this instance.
+func (h *Hub) deliver(event *model.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+
+	h.history = append(h.history, event)
+	if len(h.history) > h.size {
+		h.history = append(h.history[:0], h.history[len(h.history)-h.size:]...)
+	}
+
+	for subscription := range h.subscribers {
+		select {
+		case subscription.events <- event:
+		default:
+
+			// The subscriber does not keep up: drop it, rather than block the others.
+			h.log.Warn("dropped a subscriber which fell behind")
+			h.unsubscribe(subscription)
+		}
+	}
+}
+
+// unsubscribe removes the subscription, and closes its channel.
+//
+// The caller must hold the lock of the hub.
+func (h *Hub) unsubscribe(subscription *Subscription) {
+	if _, ok := h.subscribers[subscription]; ok {
+		delete(h.subscribers, subscription)
+		close(subscription.events)
+	}
+}
+
+// Run receives the events broadcast by every instance, until the context is cancelled.
+//
+// Without a broadcaster, it just waits for the context to be cancelled. When the broadcaster fails, it is logged
+// and listened to again after a second, so it never stops the service.
+func (h *Hub) Run(ctx context.Context) error {
+	if h.broadcaster == nil {
+		<-ctx.Done()
+		return nil
+	}
+
+	for {
+		err := h.broadcaster.Listen(ctx, h.deliver)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil && !errors.Is(err, context.Canceled) {
+			h.log.ErrorContext(ctx, "failed to listen to the events", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// Close unsubscribes every subscriber, and stops delivering the events.
+//
+// It is meant to be called when the server shuts down, so the streams of the subscribers end.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for subscription := range h.subscribers {
+		h.unsubscribe(subscription)
+	}
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/db"
+	"github.com/mrinalwahal/service/model"
+	"github.com/mrinalwahal/service/pkg/middleware"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// loopback is a broadcaster which delivers the events to the listeners of the same process, like the hubs of
+// several instances sharing a database.
+type loopback struct {
+	mu        sync.Mutex
+	listeners []func(*model.Event)
+	err       error
+}
+
+func (l *loopback) Broadcast(ctx context.Context, event *model.Event) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.err != nil {
+		return l.err
+	}
+	for _, listener := range l.listeners {
+		listener(event)
+	}
+	return nil
+}
+
+func (l *loopback) Listen(ctx context.Context, fn func(*model.Event)) error {
+	l.mu.Lock()
+	l.listeners = append(l.listeners, fn)
+	l.mu.Unlock()
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// listening reports whether the number of listeners has been reached.
+func (l *loopback) listening(count int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.listeners) >= count
+}
+
+// receive returns the next event of the subscription, or fails after a second.
+func receive(t *testing.T, subscription *Subscription) *model.Event {
+	t.Helper()
+
+	select {
+	case event, ok := <-subscription.Events():
+		if !ok {
+			t.Fatalf("expected an event, the subscription was closed")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatalf("expected an event, got none")
+	}
+	return nil
+}
+
+func Test_Hub(t *testing.T) {
+
+	event := func() *model.Event {
+		return &model.Event{ID: uuid.New(), Type: model.EventRecordCreated}
+	}
+
+	t.Run("deliver the events to every subscriber", func(t *testing.T) {
+
+		hub := NewHub(nil)
+		first, _, err := hub.Subscribe("")
+		if err != nil {
+			t.Fatalf("Hub.Subscribe() error = %v", err)
+		}
+		second, _, _ := hub.Subscribe("")
+
+		published := event()
+		hub.Publish(context.Background(), published)
+
+		if got := receive(t, first); got.ID != published.ID {
+			t.Errorf("expected the first subscriber to receive %v, got %v", published.ID, got.ID)
+		}
+		if got := receive(t, second); got.ID != published.ID {
+			t.Errorf("expected the second subscriber to receive %v, got %v", published.ID, got.ID)
+		}
+
+		// The closed subscriptions receive nothing more.
+		first.Close()
+		hub.Publish(context.Background(), event())
+		if _, ok := <-first.Events(); ok {
+			t.Errorf("expected the closed subscription to receive nothing")
+		}
+	})
+
+	t.Run("replay the events after the last one seen", func(t *testing.T) {
+
+		hub := NewHub(&HubConfig{History: 2})
+		events := []*model.Event{event(), event(), event()}
+		for _, event := range events {
+			hub.Publish(context.Background(), event)
+		}
+
+		_, replay, err := hub.Subscribe(events[1].ID.String())
+		if err != nil {
+			t.Fatalf("Hub.Subscribe() error = %v", err)
+		}
+		if len(replay) != 1 || replay[0].ID != events[2].ID {
+			t.Errorf("expected the last event to be replayed, got %d events", len(replay))
+		}
+
+		// The first event is no longer in the history.
+		subscription, replay, err := hub.Subscribe(events[0].ID.String())
+		if !errors.Is(err, ErrEventNotFound) || subscription == nil || len(replay) != 0 {
+			t.Errorf("Hub.Subscribe() error = %v, want %v along with a subscription", err, ErrEventNotFound)
+		}
+	})
+
+	t.Run("drop the subscribers which fall behind", func(t *testing.T) {
+
+		hub := NewHub(nil)
+		slow, _, _ := hub.Subscribe("")
+		for i := 0; i <= DefaultHubBuffer; i++ {
+			hub.Publish(context.Background(), event())
+		}
+
+		var received int
+		for range slow.Events() {
+			received++
+		}
+		if received != DefaultHubBuffer {
+			t.Errorf("expected the slow subscriber to receive %d events before being dropped, got %d", DefaultHubBuffer, received)
+		}
+	})
+
+	t.Run("end the subscriptions when the hub closes", func(t *testing.T) {
+
+		hub := NewHub(nil)
+		subscription, _, _ := hub.Subscribe("")
+		hub.Close()
+
+		if _, ok := <-subscription.Events(); ok {
+			t.Errorf("expected the subscription to be closed")
+		}
+		if _, _, err := hub.Subscribe(""); err != ErrHubClosed {
+			t.Errorf("Hub.Subscribe() error = %v, want %v", err, ErrHubClosed)
+		}
+	})
+
+	t.Run("deliver the events of every instance through the broadcaster", func(t *testing.T) {
+
+		broadcaster := &loopback{}
+		hubs := []*Hub{
+			NewHub(&HubConfig{Broadcaster: broadcaster}),
+			NewHub(&HubConfig{Broadcaster: broadcaster}),
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		var wg sync.WaitGroup
+		for _, hub := range hubs {
+			wg.Add(1)
+			go func(hub *Hub) {
+				defer wg.Done()
+				hub.Run(ctx)
+			}(hub)
+		}
+		defer func() {
+			cancel()
+			wg.Wait()
+		}()
+		for !broadcaster.listening(len(hubs)) {
+			time.Sleep(time.Millisecond)
+		}
+
+		subscription, _, _ := hubs[1].Subscribe("")
+		published := event()
+		hubs[0].Publish(context.Background(), published)
+		if got := receive(t, subscription); got.ID != published.ID {
+			t.Errorf("expected the event of the other instance, got %v", got.ID)
+		}
+
+		// When the broadcast fails, the event is still delivered on its own instance.
+		broadcaster.mu.Lock()
+		broadcaster.err = errors.New("connection lost")
+		broadcaster.mu.Unlock()
+
+		local, _, _ := hubs[0].Subscribe("")
+		published = event()
+		hubs[0].Publish(context.Background(), published)
+		if got := receive(t, local); got.ID != published.ID {
+			t.Errorf("expected the event to be delivered locally, got %v", got.ID)
+		}
+	})
+
+	t.Run("deliver the events dispatched from the outbox", func(t *testing.T) {
+
+		// Open an in-memory database connection with SQLite.
+		conn, err := gorm.Open(sqlite.Open("file:hub?mode=memory&cache=shared"), &gorm.Config{})
+		if err != nil {
+			t.Fatalf("failed to open the database connection: %v", err)
+		}
+		if err := conn.AutoMigrate(&model.Record{}, &model.Revision{}, &model.Event{}); err != nil {
+			t.Fatalf("failed to migrate the schema: %v", err)
+		}
+		t.Cleanup(func() {
+			if sqlDB, err := conn.DB(); err == nil {
+				sqlDB.Close()
+			}
+		})
+
+		user := uuid.New()
+		ctx := context.WithValue(context.Background(), middleware.XJWTClaims, middleware.JWTClaims{
+			XUserID: user,
+		})
+		database := db.NewSQLDB(&db.SQLDBConfig{DB: conn})
+		record, err := database.Create(ctx, &db.CreateOptions{Title: "Test Record", UserID: user})
+		if err != nil {
+			t.Fatalf("failed to create record: %v", err)
+		}
+		if err := database.Delete(ctx, record.ID, &db.DeleteOptions{Force: true}); err != nil {
+			t.Fatalf("failed to delete record: %v", err)
+		}
+
+		hub := NewHub(nil)
+		subscription, _, _ := hub.Subscribe("")
+		dispatcher := db.NewDispatcher(&db.DispatcherConfig{
+			DB:        conn,
+			Publisher: hub,
+		})
+		if _, err := dispatcher.Dispatch(context.Background()); err != nil {
+			t.Fatalf("Dispatcher.Dispatch() error = %v", err)
+		}
+
+		// The subscribers receive the events of the outbox, with their IDs.
+		var stored []*model.Event
+		if err := conn.Order("created_at, id").Find(&stored).Error; err != nil {
+			t.Fatalf("failed to get the events: %v", err)
+		}
+		if len(stored) != 2 {
+			t.Fatalf("expected 2 events in the outbox, got %d", len(stored))
+		}
+		for _, want := range stored {
+			if got := receive(t, subscription); got.ID != want.ID || got.Type != want.Type {
+				t.Errorf("expected the %s event %v, got the %s event %v", want.Type, want.ID, got.Type, got.ID)
+			}
+		}
+
+		// The event of the forced deletion holds the record as it was before.
+		if stored[1].Type != model.EventRecordDeleted || stored[1].Data.Title != record.Title {
+			t.Errorf("expected the deleted record in the event of the deletion, got %+v", stored[1].Data)
+		}
+	})
+}
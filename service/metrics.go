@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type MetricsConfig struct {
+
+	// Service is the service layer whose operations will be measured.
+	//
+	// This field is mandatory.
+	Service Service
+
+	// Registerer is the Prometheus registry the metrics will be registered with.
+	// Default: `prometheus.DefaultRegisterer`
+	//
+	// This field is optional.
+	Registerer prometheus.Registerer
+}
+
+// WithMetrics wraps the service layer to count its operations and measure their latency.
+//
+// It also counts the records created, deleted and purged, which are the business metrics of the service.
+func WithMetrics(config *MetricsConfig) Service {
+
+	if config == nil || config.Service == nil {
+		panic("service: nil metrics config")
+	}
+
+	registerer := config.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	svc := metrics{
+		next: config.Service,
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "service_operations_total",
+			Help: "Total number of service operations, by operation and result.",
+		}, []string{"operation", "result"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "service_operation_duration_seconds",
+			Help:    "Duration of the service operations, by operation.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"operation"}),
+		created: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "records_created_total",
+			Help: "Total number of records created.",
+		}),
+		deleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "records_deleted_total",
+			Help: "Total number of records deleted.",
+		}),
+		purged: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "records_purged_total",
+			Help: "Total number of soft-deleted records purged permanently.",
+		}),
+	}
+
+	registerer.MustRegister(svc.operations, svc.duration, svc.created, svc.deleted, svc.purged)
+
+	return &svc
+}
+
+// metrics is the service layer decorator created by `WithMetrics`.
+type metrics struct {
+
+	//	Measured service layer.
+	next Service
+
+	operations *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+	created    prometheus.Counter
+	deleted    prometheus.Counter
+	purged     prometheus.Counter
+}
+
+// observe records the outcome of an operation which started at the supplied time.
+func (m *metrics) observe(operation string, start time.Time, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.operations.WithLabelValues(operation, result).Inc()
+	m.duration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
+
+func (m *metrics) Create(ctx context.Context, options *CreateOptions) (*model.Record, error) {
+	start := time.Now()
+	record, err := m.next.Create(ctx, options)
+	m.observe("create", start, err)
+	if err == nil {
+		m.created.Inc()
+	}
+	return record, err
+}
+
+func (m *metrics) List(ctx context.Context, options *ListOptions) ([]*model.Record, string, error) {
+	start := time.Now()
+	records, token, err := m.next.List(ctx, options)
+	m.observe("list", start, err)
+	return records, token, err
+}
+
+func (m *metrics) Search(ctx context.Context, options *SearchOptions) ([]*model.SearchResult, error) {
+	start := time.Now()
+	results, err := m.next.Search(ctx, options)
+	m.observe("search", start, err)
+	return results, err
+}
+
+func (m *metrics) Get(ctx context.Context, id uuid.UUID, options *GetOptions) (*model.Record, error) {
+	start := time.Now()
+	record, err := m.next.Get(ctx, id, options)
+	m.observe("get", start, err)
+	return record, err
+}
+
+func (m *metrics) Update(ctx context.Context, id uuid.UUID, options *UpdateOptions) (*model.Record, error) {
+	start := time.Now()
+	record, err := m.next.Update(ctx, id, options)
+	m.observe("update", start, err)
+	return record, err
+}
+
+func (m *metrics) Delete(ctx context.Context, id uuid.UUID, options *DeleteOptions) error {
+	start := time.Now()
+	err := m.next.Delete(ctx, id, options)
+	m.observe("delete", start, err)
+	if err == nil {
+		m.deleted.Inc()
+	}
+	return err
+}
+
+func (m *metrics) Undelete(ctx context.Context, id uuid.UUID) (*model.Record, error) {
+	start := time.Now()
+	record, err := m.next.Undelete(ctx, id)
+	m.observe("undelete", start, err)
+	return record, err
+}
+
+func (m *metrics) Purge(ctx context.Context, options *PurgeOptions) (int64, error) {
+	start := time.Now()
+	purged, err := m.next.Purge(ctx, options)
+	m.observe("purge", start, err)
+	m.purged.Add(float64(purged))
+	return purged, err
+}
+
+func (m *metrics) BatchCreate(ctx context.Context, options *BatchCreateOptions) ([]*model.Record, error) {
+	start := time.Now()
+	records, err := m.next.BatchCreate(ctx, options)
+	m.observe("batch_create", start, err)
+	if err == nil {
+		m.created.Add(float64(len(records)))
+	}
+	return records, err
+}
+
+func (m *metrics) BatchGet(ctx context.Context, options *BatchGetOptions) ([]*model.Record, error) {
+	start := time.Now()
+	records, err := m.next.BatchGet(ctx, options)
+	m.observe("batch_get", start, err)
+	return records, err
+}
+
+func (m *metrics) BatchUpdate(ctx context.Context, options *BatchUpdateOptions) ([]*model.Record, error) {
+	start := time.Now()
+	records, err := m.next.BatchUpdate(ctx, options)
+	m.observe("batch_update", start, err)
+	return records, err
+}
+
+func (m *metrics) BatchDelete(ctx context.Context, options *BatchDeleteOptions) error {
+	start := time.Now()
+	err := m.next.BatchDelete(ctx, options)
+	m.observe("batch_delete", start, err)
+	if err == nil {
+		m.deleted.Add(float64(len(options.IDs)))
+	}
+	return err
+}
+
+func (m *metrics) ListRevisions(ctx context.Context, id uuid.UUID, options *ListRevisionsOptions) ([]*model.Revision, string, error) {
+	start := time.Now()
+	revisions, token, err := m.next.ListRevisions(ctx, id, options)
+	m.observe("list_revisions", start, err)
+	return revisions, token, err
+}
+
+func (m *metrics) GetRevision(ctx context.Context, id uuid.UUID, revision int64) (*model.Revision, error) {
+	start := time.Now()
+	payload, err := m.next.GetRevision(ctx, id, revision)
+	m.observe("get_revision", start, err)
+	return payload, err
+}
+
+func (m *metrics) DiffRevisions(ctx context.Context, id uuid.UUID, revision int64, options *DiffRevisionsOptions) (*model.Diff, error) {
+	start := time.Now()
+	payload, err := m.next.DiffRevisions(ctx, id, revision, options)
+	m.observe("diff_revisions", start, err)
+	return payload, err
+}
+
+func (m *metrics) Restore(ctx context.Context, id uuid.UUID, options *RestoreOptions) (*model.Record, error) {
+	start := time.Now()
+	record, err := m.next.Restore(ctx, id, options)
+	m.observe("restore", start, err)
+	return record, err
+}
+
+func (m *metrics) CreateWebhook(ctx context.Context, options *CreateWebhookOptions) (*model.Webhook, error) {
+	start := time.Now()
+	webhook, err := m.next.CreateWebhook(ctx, options)
+	m.observe("create_webhook", start, err)
+	return webhook, err
+}
+
+func (m *metrics) ListWebhooks(ctx context.Context, options *ListWebhooksOptions) ([]*model.Webhook, string, error) {
+	start := time.Now()
+	webhooks, token, err := m.next.ListWebhooks(ctx, options)
+	m.observe("list_webhooks", start, err)
+	return webhooks, token, err
+}
+
+func (m *metrics) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	start := time.Now()
+	err := m.next.DeleteWebhook(ctx, id)
+	m.observe("delete_webhook", start, err)
+	return err
+}
+
+func (m *metrics) ListDeliveries(ctx context.Context, options *ListDeliveriesOptions) ([]*model.Delivery, string, error) {
+	start := time.Now()
+	deliveries, token, err := m.next.ListDeliveries(ctx, options)
+	m.observe("list_deliveries", start, err)
+	return deliveries, token, err
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_WithMetrics(t *testing.T) {
+
+	t.Run("nil config", func(t *testing.T) {
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("WithMetrics() did not panic")
+			}
+		}()
+
+		WithMetrics(nil)
+	})
+
+	t.Run("count operations and records", func(t *testing.T) {
+
+		config := configure(t)
+		registry := prometheus.NewRegistry()
+		s := WithMetrics(&MetricsConfig{
+			Service:    NewService(&Config{DB: config.db, Logger: config.log}),
+			Registerer: registry,
+		})
+		m := s.(*metrics)
+
+		id := uuid.New()
+		config.db.EXPECT().Delete(gomock.Any(), id, gomock.Any()).Return(nil)
+		config.db.EXPECT().Delete(gomock.Any(), id, gomock.Any()).Return(errors.New("boom"))
+
+		if err := s.Delete(context.Background(), id, nil); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if err := s.Delete(context.Background(), id, nil); err == nil {
+			t.Fatalf("Delete() expected an error")
+		}
+
+		if got := testutil.ToFloat64(m.operations.WithLabelValues("delete", "ok")); got != 1 {
+			t.Errorf("successful deletes = %v, want 1", got)
+		}
+		if got := testutil.ToFloat64(m.operations.WithLabelValues("delete", "error")); got != 1 {
+			t.Errorf("failed deletes = %v, want 1", got)
+		}
+		if got := testutil.ToFloat64(m.deleted); got != 1 {
+			t.Errorf("records deleted = %v, want 1", got)
+		}
+		if got := testutil.CollectAndCount(m.duration); got != 1 {
+			t.Errorf("duration series = %v, want 1", got)
+		}
+	})
+	t.Run("count purged records", func(t *testing.T) {
+
+		config := configure(t)
+		s := WithMetrics(&MetricsConfig{
+			Service:    NewService(&Config{DB: config.db, Logger: config.log}),
+			Registerer: prometheus.NewRegistry(),
+		})
+		m := s.(*metrics)
+
+		config.db.EXPECT().Purge(gomock.Any(), gomock.Any()).Return(int64(3), nil)
+
+		if _, err := s.Purge(context.Background(), &PurgeOptions{DeletedBefore: time.Now()}); err != nil {
+			t.Fatalf("Purge() error = %v", err)
+		}
+		if got := testutil.ToFloat64(m.purged); got != 3 {
+			t.Errorf("records purged = %v, want 3", got)
+		}
+	})
+
+	t.Run("count records created and deleted in batches", func(t *testing.T) {
+
+		config := configure(t)
+		s := WithMetrics(&MetricsConfig{
+			Service:    NewService(&Config{DB: config.db, Logger: config.log}),
+			Registerer: prometheus.NewRegistry(),
+		})
+		m := s.(*metrics)
+
+		config.db.EXPECT().BatchCreate(gomock.Any(), gomock.Any()).Return([]*model.Record{{}, {}}, nil)
+		config.db.EXPECT().BatchDelete(gomock.Any(), gomock.Any()).Return(nil)
+
+		userID := uuid.New()
+		if _, err := s.BatchCreate(context.Background(), &BatchCreateOptions{
+			Requests: []*CreateOptions{{Title: "First", UserID: userID}, {Title: "Second", UserID: userID}},
+		}); err != nil {
+			t.Fatalf("BatchCreate() error = %v", err)
+		}
+		if err := s.BatchDelete(context.Background(), &BatchDeleteOptions{
+			IDs: []uuid.UUID{uuid.New(), uuid.New(), uuid.New()},
+		}); err != nil {
+			t.Fatalf("BatchDelete() error = %v", err)
+		}
+		if got := testutil.ToFloat64(m.created); got != 2 {
+			t.Errorf("records created = %v, want 2", got)
+		}
+		if got := testutil.ToFloat64(m.deleted); got != 3 {
+			t.Errorf("records deleted = %v, want 3", got)
+		}
+	})
+}
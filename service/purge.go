@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mrinalwahal/service/db"
+)
+
+type PurgerConfig struct {
+
+	// Service is the service layer which purges the records.
+	//
+	// This field is mandatory.
+	Service Service
+
+	// Retention is how long the soft-deleted records are kept before they are purged permanently.
+	//
+	// This field is mandatory.
+	Retention time.Duration
+
+	// Interval is the time between two purges.
+	// Default: `time.Hour`
+	//
+	// This field is optional.
+	Interval time.Duration
+
+	// Logger is the `log/slog` instance that will be used to log messages.
+	// Default: `slog.DefaultLogger`
+	//
+	// This field is optional.
+	Logger *slog.Logger
+}
+
+// Purger permanently deletes the records which have been soft-deleted for longer than the retention period.
+//
+// It is a background job: `Run` purges the expired records at regular intervals until its context is cancelled.
+type Purger struct {
+	service   Service
+	retention time.Duration
+	interval  time.Duration
+	log       *slog.Logger
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// NewPurger creates a new instance of `Purger`.
+func NewPurger(config *PurgerConfig) *Purger {
+	if config == nil || config.Service == nil {
+		panic("service: nil purger config")
+	}
+	if config.Retention <= 0 {
+		panic("service: purger retention must be positive")
+	}
+
+	purger := Purger{
+		service:   config.Service,
+		retention: config.Retention,
+		interval:  config.Interval,
+		log:       config.Logger,
+		now:       time.Now,
+	}
+
+	if purger.interval <= 0 {
+		purger.interval = time.Hour
+	}
+
+	if purger.log == nil {
+		purger.log = slog.Default()
+	}
+	purger.log = purger.log.With("job", "purge")
+
+	return &purger
+}
+
+// Run purges the expired records right away, and then once every interval, until the context is cancelled.
+//
+// A failed purge is logged and retried at the next interval, so it never stops the service.
+func (p *Purger) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.Purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge permanently deletes the records soft-deleted before the retention period, and returns how many it deleted.
+//
+// It purges the records of every user, so it bypasses the row level security of the database.
+func (p *Purger) Purge(ctx context.Context) int64 {
+	cutoff := p.now().Add(-p.retention)
+	purged, err := p.service.Purge(db.WithBypassRLS(ctx), &PurgeOptions{
+		DeletedBefore: cutoff,
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			p.log.ErrorContext(ctx, "failed to purge the deleted records", "error", err)
+		}
+		return 0
+	}
+	if purged > 0 {
+		p.log.InfoContext(ctx, "purged the deleted records", "count", purged, "deleted_before", cutoff)
+	}
+	return purged
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mrinalwahal/service/db"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_NewPurger(t *testing.T) {
+
+	t.Run("nil config", func(t *testing.T) {
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("NewPurger() did not panic")
+			}
+		}()
+
+		NewPurger(nil)
+	})
+
+	t.Run("w/o retention", func(t *testing.T) {
+
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("NewPurger() did not panic")
+			}
+		}()
+
+		NewPurger(&PurgerConfig{
+			Service: NewMockService(gomock.NewController(t)),
+		})
+	})
+
+	t.Run("default interval", func(t *testing.T) {
+
+		purger := NewPurger(&PurgerConfig{
+			Service:   NewMockService(gomock.NewController(t)),
+			Retention: time.Hour,
+		})
+		if purger.interval != time.Hour {
+			t.Errorf("NewPurger() interval = %v, want %v", purger.interval, time.Hour)
+		}
+	})
+}
+
+func Test_Purger_Run(t *testing.T) {
+
+	t.Run("purge the records deleted before the retention period", func(t *testing.T) {
+
+		service := NewMockService(gomock.NewController(t))
+		purger := NewPurger(&PurgerConfig{
+			Service:   service,
+			Retention: 24 * time.Hour,
+			Interval:  time.Millisecond,
+		})
+		now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+		purger.now = func() time.Time {
+			return now
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// The first purge fails, which must not stop the job. The second one succeeds and stops it.
+		gomock.InOrder(
+			service.EXPECT().Purge(gomock.Any(), &PurgeOptions{
+				DeletedBefore: now.Add(-24 * time.Hour),
+			}).Return(int64(0), errors.New("boom")),
+			service.EXPECT().Purge(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, _ *PurgeOptions) (int64, error) {
+				if !db.BypassesRLS(ctx) {
+					t.Errorf("expected the purge to bypass the row level security")
+				}
+				cancel()
+				return 2, nil
+			}),
+		)
+
+		done := make(chan error)
+		go func() {
+			done <- purger.Run(ctx)
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Purger.Run() error = %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Purger.Run() did not return after its context was cancelled")
+		}
+	})
+}
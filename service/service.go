@@ -12,10 +12,25 @@ import (
 
 type Service interface {
 	Create(context.Context, *CreateOptions) (*model.Record, error)
-	List(context.Context, *ListOptions) ([]*model.Record, error)
-	Get(context.Context, uuid.UUID) (*model.Record, error)
+	List(context.Context, *ListOptions) ([]*model.Record, string, error)
+	Search(context.Context, *SearchOptions) ([]*model.SearchResult, error)
+	Get(context.Context, uuid.UUID, *GetOptions) (*model.Record, error)
 	Update(context.Context, uuid.UUID, *UpdateOptions) (*model.Record, error)
-	Delete(context.Context, uuid.UUID) error
+	Delete(context.Context, uuid.UUID, *DeleteOptions) error
+	Undelete(context.Context, uuid.UUID) (*model.Record, error)
+	Purge(context.Context, *PurgeOptions) (int64, error)
+	BatchCreate(context.Context, *BatchCreateOptions) ([]*model.Record, error)
+	BatchGet(context.Context, *BatchGetOptions) ([]*model.Record, error)
+	BatchUpdate(context.Context, *BatchUpdateOptions) ([]*model.Record, error)
+	BatchDelete(context.Context, *BatchDeleteOptions) error
+	ListRevisions(context.Context, uuid.UUID, *ListRevisionsOptions) ([]*model.Revision, string, error)
+	GetRevision(context.Context, uuid.UUID, int64) (*model.Revision, error)
+	DiffRevisions(context.Context, uuid.UUID, int64, *DiffRevisionsOptions) (*model.Diff, error)
+	Restore(context.Context, uuid.UUID, *RestoreOptions) (*model.Record, error)
+	CreateWebhook(context.Context, *CreateWebhookOptions) (*model.Webhook, error)
+	ListWebhooks(context.Context, *ListWebhooksOptions) ([]*model.Webhook, string, error)
+	DeleteWebhook(context.Context, uuid.UUID) error
+	ListDeliveries(context.Context, *ListDeliveriesOptions) ([]*model.Delivery, string, error)
 }
 
 type Config struct {
@@ -25,8 +40,15 @@ type Config struct {
 
 	//	Logger.
 	Logger *slog.Logger
+
+	//	MaxBatchSize is the maximum number of items of the batch operations.
+	//	Default: `DefaultMaxBatchSize`
+	MaxBatchSize int
 }
 
+// DefaultMaxBatchSize is the default maximum number of items of the batch operations.
+const DefaultMaxBatchSize = 100
+
 // Initializes and gets the service with the supplied database connection.
 func NewService(config *Config) Service {
 
@@ -35,8 +57,9 @@ func NewService(config *Config) Service {
 	}
 
 	svc := service{
-		db:     config.DB,
-		logger: config.Logger,
+		db:           config.DB,
+		logger:       config.Logger,
+		maxBatchSize: config.MaxBatchSize,
 	}
 
 	if svc.logger == nil {
@@ -55,6 +78,9 @@ type service struct {
 
 	//	Logger.
 	logger *slog.Logger
+
+	//	Maximum number of items of the batch operations.
+	maxBatchSize int
 }
 
 func (s *service) Create(ctx context.Context, options *CreateOptions) (*model.Record, error) {
@@ -74,10 +100,31 @@ func (s *service) Create(ctx context.Context, options *CreateOptions) (*model.Re
 	})
 }
 
-func (s *service) List(ctx context.Context, options *ListOptions) ([]*model.Record, error) {
+func (s *service) List(ctx context.Context, options *ListOptions) ([]*model.Record, string, error) {
 	s.logger.LogAttrs(ctx, slog.LevelDebug, "listing all records",
 		slog.String("function", "list"),
 	)
+	if options == nil {
+		return nil, "", ErrInvalidOptions
+	}
+	if err := options.validate(); err != nil {
+		return nil, "", err
+	}
+
+	return s.db.List(ctx, &db.ListOptions{
+		Title:       options.Title,
+		Filter:      options.Filter,
+		PageSize:    options.PageSize,
+		PageToken:   options.PageToken,
+		OrderBy:     options.OrderBy,
+		ShowDeleted: options.ShowDeleted,
+	})
+}
+
+func (s *service) Search(ctx context.Context, options *SearchOptions) ([]*model.SearchResult, error) {
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "searching records",
+		slog.String("function", "search"),
+	)
 	if options == nil {
 		return nil, ErrInvalidOptions
 	}
@@ -85,23 +132,25 @@ func (s *service) List(ctx context.Context, options *ListOptions) ([]*model.Reco
 		return nil, err
 	}
 
-	return s.db.List(ctx, &db.ListOptions{
-		Title:          options.Title,
-		Skip:           options.Skip,
-		Limit:          options.Limit,
-		OrderBy:        options.OrderBy,
-		OrderDirection: options.OrderDirection,
+	return s.db.Search(ctx, &db.SearchOptions{
+		Query:    options.Query,
+		PageSize: options.PageSize,
 	})
 }
 
-func (s *service) Get(ctx context.Context, ID uuid.UUID) (*model.Record, error) {
+func (s *service) Get(ctx context.Context, ID uuid.UUID, options *GetOptions) (*model.Record, error) {
 	s.logger.LogAttrs(ctx, slog.LevelDebug, "retrieving a record",
 		slog.String("function", "get"),
 	)
 	if ID == uuid.Nil {
 		return nil, ErrInvalidOptions
 	}
-	return s.db.Get(ctx, ID)
+	if options == nil {
+		options = &GetOptions{}
+	}
+	return s.db.Get(ctx, ID, &db.GetOptions{
+		ShowDeleted: options.ShowDeleted,
+	})
 }
 
 func (s *service) Update(ctx context.Context, ID uuid.UUID, options *UpdateOptions) (*model.Record, error) {
@@ -118,16 +167,273 @@ func (s *service) Update(ctx context.Context, ID uuid.UUID, options *UpdateOptio
 		return nil, err
 	}
 	return s.db.Update(ctx, ID, &db.UpdateOptions{
-		Title: options.Title,
+		Title:      options.Title,
+		UpdateMask: options.UpdateMask,
+		Version:    options.Version,
 	})
 }
 
-func (s *service) Delete(ctx context.Context, ID uuid.UUID) error {
+func (s *service) Delete(ctx context.Context, ID uuid.UUID, options *DeleteOptions) error {
 	s.logger.LogAttrs(ctx, slog.LevelDebug, "deleting a record",
 		slog.String("function", "delete"),
 	)
 	if ID == uuid.Nil {
 		return ErrInvalidRecordID
 	}
-	return s.db.Delete(ctx, ID)
+	if options == nil {
+		options = &DeleteOptions{}
+	}
+	return s.db.Delete(ctx, ID, &db.DeleteOptions{
+		Force:   options.Force,
+		Version: options.Version,
+	})
+}
+
+func (s *service) Undelete(ctx context.Context, ID uuid.UUID) (*model.Record, error) {
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "undeleting a record",
+		slog.String("function", "undelete"),
+	)
+	if ID == uuid.Nil {
+		return nil, ErrInvalidRecordID
+	}
+	return s.db.Undelete(ctx, ID)
+}
+
+func (s *service) Purge(ctx context.Context, options *PurgeOptions) (int64, error) {
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "purging the deleted records",
+		slog.String("function", "purge"),
+	)
+	if options == nil {
+		return 0, ErrInvalidOptions
+	}
+	if err := options.validate(); err != nil {
+		return 0, err
+	}
+	return s.db.Purge(ctx, &db.PurgeOptions{
+		DeletedBefore: options.DeletedBefore,
+	})
+}
+
+// batchSize returns the maximum number of items of the batch operations.
+func (s *service) batchSize() int {
+	if s.maxBatchSize <= 0 {
+		return DefaultMaxBatchSize
+	}
+	return s.maxBatchSize
+}
+
+func (s *service) BatchCreate(ctx context.Context, options *BatchCreateOptions) ([]*model.Record, error) {
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "creating a batch of records",
+		slog.String("function", "batch_create"),
+	)
+	if options == nil {
+		return nil, ErrInvalidOptions
+	}
+	if err := options.validate(s.batchSize()); err != nil {
+		return nil, err
+	}
+
+	requests := make([]*db.CreateOptions, len(options.Requests))
+	for i, request := range options.Requests {
+		requests[i] = &db.CreateOptions{
+			Title:  request.Title,
+			UserID: request.UserID,
+		}
+	}
+	return s.db.BatchCreate(ctx, &db.BatchCreateOptions{
+		Requests: requests,
+	})
+}
+
+func (s *service) BatchGet(ctx context.Context, options *BatchGetOptions) ([]*model.Record, error) {
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "getting a batch of records",
+		slog.String("function", "batch_get"),
+	)
+	if options == nil {
+		return nil, ErrInvalidOptions
+	}
+	if err := options.validate(s.batchSize()); err != nil {
+		return nil, err
+	}
+	return s.db.BatchGet(ctx, &db.BatchGetOptions{
+		IDs:         options.IDs,
+		ShowDeleted: options.ShowDeleted,
+	})
+}
+
+func (s *service) BatchUpdate(ctx context.Context, options *BatchUpdateOptions) ([]*model.Record, error) {
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "updating a batch of records",
+		slog.String("function", "batch_update"),
+	)
+	if options == nil {
+		return nil, ErrInvalidOptions
+	}
+	if err := options.validate(s.batchSize()); err != nil {
+		return nil, err
+	}
+
+	requests := make([]*db.BatchUpdateRequest, len(options.Requests))
+	for i, request := range options.Requests {
+		requests[i] = &db.BatchUpdateRequest{
+			ID: request.ID,
+			UpdateOptions: db.UpdateOptions{
+				Title:      request.Title,
+				UpdateMask: request.UpdateMask,
+				Version:    request.Version,
+			},
+		}
+	}
+	return s.db.BatchUpdate(ctx, &db.BatchUpdateOptions{
+		Requests: requests,
+	})
+}
+
+func (s *service) BatchDelete(ctx context.Context, options *BatchDeleteOptions) error {
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "deleting a batch of records",
+		slog.String("function", "batch_delete"),
+	)
+	if options == nil {
+		return ErrInvalidOptions
+	}
+	if err := options.validate(s.batchSize()); err != nil {
+		return err
+	}
+	return s.db.BatchDelete(ctx, &db.BatchDeleteOptions{
+		IDs:   options.IDs,
+		Force: options.Force,
+	})
+}
+
+func (s *service) ListRevisions(ctx context.Context, ID uuid.UUID, options *ListRevisionsOptions) ([]*model.Revision, string, error) {
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "listing the revisions of a record",
+		slog.String("function", "list_revisions"),
+	)
+	if ID == uuid.Nil {
+		return nil, "", ErrInvalidRecordID
+	}
+	if options == nil {
+		options = &ListRevisionsOptions{}
+	}
+	if err := options.validate(); err != nil {
+		return nil, "", err
+	}
+	return s.db.ListRevisions(ctx, ID, &db.ListRevisionsOptions{
+		PageSize:  options.PageSize,
+		PageToken: options.PageToken,
+	})
+}
+
+func (s *service) GetRevision(ctx context.Context, ID uuid.UUID, revision int64) (*model.Revision, error) {
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "getting a revision of a record",
+		slog.String("function", "get_revision"),
+	)
+	if ID == uuid.Nil {
+		return nil, ErrInvalidRecordID
+	}
+	if revision < 1 {
+		return nil, ErrInvalidRevision
+	}
+	return s.db.GetRevision(ctx, ID, revision)
+}
+
+func (s *service) DiffRevisions(ctx context.Context, ID uuid.UUID, revision int64, options *DiffRevisionsOptions) (*model.Diff, error) {
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "comparing the revisions of a record",
+		slog.String("function", "diff_revisions"),
+	)
+	if ID == uuid.Nil {
+		return nil, ErrInvalidRecordID
+	}
+	if revision < 1 {
+		return nil, ErrInvalidRevision
+	}
+	if options == nil {
+		options = &DiffRevisionsOptions{}
+	}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	return s.db.DiffRevisions(ctx, ID, revision, &db.DiffRevisionsOptions{
+		Against: options.Against,
+	})
+}
+
+func (s *service) Restore(ctx context.Context, ID uuid.UUID, options *RestoreOptions) (*model.Record, error) {
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "restoring a record",
+		slog.String("function", "restore"),
+	)
+	if ID == uuid.Nil {
+		return nil, ErrInvalidRecordID
+	}
+	if options == nil {
+		return nil, ErrInvalidOptions
+	}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	return s.db.Restore(ctx, ID, &db.RestoreOptions{
+		Revision: options.Revision,
+		Version:  options.Version,
+	})
+}
+
+func (s *service) CreateWebhook(ctx context.Context, options *CreateWebhookOptions) (*model.Webhook, error) {
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "creating a new webhook",
+		slog.String("function", "create_webhook"),
+	)
+	if options == nil {
+		return nil, ErrInvalidOptions
+	}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+	return s.db.CreateWebhook(ctx, &db.CreateWebhookOptions{
+		UserID: options.UserID,
+		URL:    options.URL,
+		Events: options.Events,
+		Secret: options.Secret,
+	})
+}
+
+func (s *service) ListWebhooks(ctx context.Context, options *ListWebhooksOptions) ([]*model.Webhook, string, error) {
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "listing the webhooks",
+		slog.String("function", "list_webhooks"),
+	)
+	if options == nil {
+		options = &ListWebhooksOptions{}
+	}
+	if err := options.validate(); err != nil {
+		return nil, "", err
+	}
+	return s.db.ListWebhooks(ctx, &db.ListWebhooksOptions{
+		PageSize:  options.PageSize,
+		PageToken: options.PageToken,
+	})
+}
+
+func (s *service) DeleteWebhook(ctx context.Context, ID uuid.UUID) error {
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "deleting a webhook",
+		slog.String("function", "delete_webhook"),
+	)
+	if ID == uuid.Nil {
+		return ErrInvalidWebhookID
+	}
+	return s.db.DeleteWebhook(ctx, ID)
+}
+
+func (s *service) ListDeliveries(ctx context.Context, options *ListDeliveriesOptions) ([]*model.Delivery, string, error) {
+	s.logger.LogAttrs(ctx, slog.LevelDebug, "listing the deliveries of the webhooks",
+		slog.String("function", "list_deliveries"),
+	)
+	if options == nil {
+		options = &ListDeliveriesOptions{}
+	}
+	if err := options.validate(); err != nil {
+		return nil, "", err
+	}
+	return s.db.ListDeliveries(ctx, &db.ListDeliveriesOptions{
+		WebhookID: options.WebhookID,
+		Status:    options.Status,
+		PageSize:  options.PageSize,
+		PageToken: options.PageToken,
+	})
 }
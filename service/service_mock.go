@@ -41,6 +41,65 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// BatchCreate mocks base method.
+func (m *MockService) BatchCreate(arg0 context.Context, arg1 *BatchCreateOptions) ([]*model.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchCreate", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchCreate indicates an expected call of BatchCreate.
+func (mr *MockServiceMockRecorder) BatchCreate(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchCreate", reflect.TypeOf((*MockService)(nil).BatchCreate), arg0, arg1)
+}
+
+// BatchDelete mocks base method.
+func (m *MockService) BatchDelete(arg0 context.Context, arg1 *BatchDeleteOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchDelete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BatchDelete indicates an expected call of BatchDelete.
+func (mr *MockServiceMockRecorder) BatchDelete(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchDelete", reflect.TypeOf((*MockService)(nil).BatchDelete), arg0, arg1)
+}
+
+// BatchGet mocks base method.
+func (m *MockService) BatchGet(arg0 context.Context, arg1 *BatchGetOptions) ([]*model.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchGet", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchGet indicates an expected call of BatchGet.
+func (mr *MockServiceMockRecorder) BatchGet(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchGet", reflect.TypeOf((*MockService)(nil).BatchGet), arg0, arg1)
+}
+
+// BatchUpdate mocks base method.
+func (m *MockService) BatchUpdate(arg0 context.Context, arg1 *BatchUpdateOptions) ([]*model.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchUpdate", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchUpdate indicates an expected call of BatchUpdate.
+func (mr *MockServiceMockRecorder) BatchUpdate(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchUpdate", reflect.TypeOf((*MockService)(nil).BatchUpdate), arg0, arg1)
+}
+
 // Create mocks base method.
 func (m *MockService) Create(arg0 context.Context, arg1 *CreateOptions) (*model.Record, error) {
 	m.ctrl.T.Helper()
@@ -56,42 +115,102 @@ func (mr *MockServiceMockRecorder) Create(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockService)(nil).Create), arg0, arg1)
 }
 
+// CreateWebhook mocks base method.
+func (m *MockService) CreateWebhook(arg0 context.Context, arg1 *CreateWebhookOptions) (*model.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", arg0, arg1)
+	ret0, _ := ret[0].(*model.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockServiceMockRecorder) CreateWebhook(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockService)(nil).CreateWebhook), arg0, arg1)
+}
+
 // Delete mocks base method.
-func (m *MockService) Delete(arg0 context.Context, arg1 uuid.UUID) error {
+func (m *MockService) Delete(arg0 context.Context, arg1 uuid.UUID, arg2 *DeleteOptions) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockServiceMockRecorder) Delete(arg0, arg1 any) *gomock.Call {
+func (mr *MockServiceMockRecorder) Delete(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockService)(nil).Delete), arg0, arg1, arg2)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockService) DeleteWebhook(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockServiceMockRecorder) DeleteWebhook(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockService)(nil).Delete), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockService)(nil).DeleteWebhook), arg0, arg1)
+}
+
+// DiffRevisions mocks base method.
+func (m *MockService) DiffRevisions(arg0 context.Context, arg1 uuid.UUID, arg2 int64, arg3 *DiffRevisionsOptions) (*model.Diff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DiffRevisions", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*model.Diff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DiffRevisions indicates an expected call of DiffRevisions.
+func (mr *MockServiceMockRecorder) DiffRevisions(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiffRevisions", reflect.TypeOf((*MockService)(nil).DiffRevisions), arg0, arg1, arg2, arg3)
 }
 
 // Get mocks base method.
-func (m *MockService) Get(arg0 context.Context, arg1 uuid.UUID) (*model.Record, error) {
+func (m *MockService) Get(arg0 context.Context, arg1 uuid.UUID, arg2 *GetOptions) (*model.Record, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", arg0, arg1)
+	ret := m.ctrl.Call(m, "Get", arg0, arg1, arg2)
 	ret0, _ := ret[0].(*model.Record)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockServiceMockRecorder) Get(arg0, arg1 any) *gomock.Call {
+func (mr *MockServiceMockRecorder) Get(arg0, arg1, arg2 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockService)(nil).Get), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockService)(nil).Get), arg0, arg1, arg2)
+}
+
+// GetRevision mocks base method.
+func (m *MockService) GetRevision(arg0 context.Context, arg1 uuid.UUID, arg2 int64) (*model.Revision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRevision", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.Revision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRevision indicates an expected call of GetRevision.
+func (mr *MockServiceMockRecorder) GetRevision(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRevision", reflect.TypeOf((*MockService)(nil).GetRevision), arg0, arg1, arg2)
 }
 
 // List mocks base method.
-func (m *MockService) List(arg0 context.Context, arg1 *ListOptions) ([]*model.Record, error) {
+func (m *MockService) List(arg0 context.Context, arg1 *ListOptions) ([]*model.Record, string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "List", arg0, arg1)
 	ret0, _ := ret[0].([]*model.Record)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // List indicates an expected call of List.
@@ -100,6 +219,114 @@ func (mr *MockServiceMockRecorder) List(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockService)(nil).List), arg0, arg1)
 }
 
+// ListDeliveries mocks base method.
+func (m *MockService) ListDeliveries(arg0 context.Context, arg1 *ListDeliveriesOptions) ([]*model.Delivery, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeliveries", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Delivery)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListDeliveries indicates an expected call of ListDeliveries.
+func (mr *MockServiceMockRecorder) ListDeliveries(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeliveries", reflect.TypeOf((*MockService)(nil).ListDeliveries), arg0, arg1)
+}
+
+// ListRevisions mocks base method.
+func (m *MockService) ListRevisions(arg0 context.Context, arg1 uuid.UUID, arg2 *ListRevisionsOptions) ([]*model.Revision, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRevisions", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*model.Revision)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListRevisions indicates an expected call of ListRevisions.
+func (mr *MockServiceMockRecorder) ListRevisions(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRevisions", reflect.TypeOf((*MockService)(nil).ListRevisions), arg0, arg1, arg2)
+}
+
+// ListWebhooks mocks base method.
+func (m *MockService) ListWebhooks(arg0 context.Context, arg1 *ListWebhooksOptions) ([]*model.Webhook, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhooks", arg0, arg1)
+	ret0, _ := ret[0].([]*model.Webhook)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListWebhooks indicates an expected call of ListWebhooks.
+func (mr *MockServiceMockRecorder) ListWebhooks(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhooks", reflect.TypeOf((*MockService)(nil).ListWebhooks), arg0, arg1)
+}
+
+// Purge mocks base method.
+func (m *MockService) Purge(arg0 context.Context, arg1 *PurgeOptions) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Purge", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Purge indicates an expected call of Purge.
+func (mr *MockServiceMockRecorder) Purge(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Purge", reflect.TypeOf((*MockService)(nil).Purge), arg0, arg1)
+}
+
+// Restore mocks base method.
+func (m *MockService) Restore(arg0 context.Context, arg1 uuid.UUID, arg2 *RestoreOptions) (*model.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*model.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockServiceMockRecorder) Restore(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockService)(nil).Restore), arg0, arg1, arg2)
+}
+
+// Search mocks base method.
+func (m *MockService) Search(arg0 context.Context, arg1 *SearchOptions) ([]*model.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", arg0, arg1)
+	ret0, _ := ret[0].([]*model.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockServiceMockRecorder) Search(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockService)(nil).Search), arg0, arg1)
+}
+
+// Undelete mocks base method.
+func (m *MockService) Undelete(arg0 context.Context, arg1 uuid.UUID) (*model.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Undelete", arg0, arg1)
+	ret0, _ := ret[0].(*model.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Undelete indicates an expected call of Undelete.
+func (mr *MockServiceMockRecorder) Undelete(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Undelete", reflect.TypeOf((*MockService)(nil).Undelete), arg0, arg1)
+}
+
 // Update mocks base method.
 func (m *MockService) Update(arg0 context.Context, arg1 uuid.UUID, arg2 *UpdateOptions) (*model.Record, error) {
 	m.ctrl.T.Helper()
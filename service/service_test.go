@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mrinalwahal/service/db"
@@ -159,7 +161,7 @@ func Test_Service_List(t *testing.T) {
 		// Make sure the database layer is not expecting a call.
 		config.db.EXPECT().List(gomock.Any(), gomock.Any()).Times(0)
 
-		_, err := s.List(context.Background(), nil)
+		_, _, err := s.List(context.Background(), nil)
 		if err == nil || err != ErrInvalidOptions {
 			t.Errorf("service.List() error = %v, wantErr %v", err, true)
 		}
@@ -170,9 +172,8 @@ func Test_Service_List(t *testing.T) {
 		// Make sure the database layer is not expecting a call.
 		config.db.EXPECT().List(gomock.Any(), gomock.Any()).Times(0)
 
-		_, err := s.List(context.Background(), &ListOptions{
-			Skip:  -1,
-			Limit: -1,
+		_, _, err := s.List(context.Background(), &ListOptions{
+			PageSize: -1,
 		})
 		if err == nil {
 			t.Errorf("service.List() error = %v, wantErr %v", err, true)
@@ -191,11 +192,10 @@ func Test_Service_List(t *testing.T) {
 		}
 
 		// Set the expectation at the database layer.
-		config.db.EXPECT().List(gomock.Any(), gomock.Any()).Return(records, nil).Times(1)
+		config.db.EXPECT().List(gomock.Any(), gomock.Any()).Return(records, "next", nil).Times(1)
 
-		got, err := s.List(context.Background(), &ListOptions{
-			Skip:  0,
-			Limit: 10,
+		got, token, err := s.List(context.Background(), &ListOptions{
+			PageSize: 10,
 		})
 		if err != nil {
 			t.Errorf("service.List() error = %v, wantErr %v", err, false)
@@ -203,6 +203,77 @@ func Test_Service_List(t *testing.T) {
 		if len(got) != len(records) {
 			t.Errorf("service.List() = %v, want %v", len(got), len(records))
 		}
+		if token != "next" {
+			t.Errorf("service.List() token = %v, want %v", token, "next")
+		}
+	})
+}
+
+func Test_Service_Search(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Initialize the service.
+	s := &service{
+		db:     config.db,
+		logger: config.log,
+	}
+
+	t.Run("search records with nil options", func(t *testing.T) {
+
+		// Make sure the database layer is not expecting a call.
+		config.db.EXPECT().Search(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := s.Search(context.Background(), nil)
+		if err == nil || err != ErrInvalidOptions {
+			t.Errorf("service.Search() error = %v, wantErr %v", err, true)
+		}
+	})
+
+	t.Run("search records w/o query", func(t *testing.T) {
+
+		// Make sure the database layer is not expecting a call.
+		config.db.EXPECT().Search(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := s.Search(context.Background(), &SearchOptions{
+			Query: " ",
+		})
+		if err == nil || err != ErrInvalidQuery {
+			t.Errorf("service.Search() error = %v, wantErr %v", err, ErrInvalidQuery)
+		}
+	})
+
+	t.Run("search records with valid options", func(t *testing.T) {
+
+		results := []*model.SearchResult{
+			{
+				Record: model.Record{
+					Base: model.Base{
+						ID: uuid.New(),
+					},
+					Title: "Test Record",
+				},
+				Snippet: "<mark>Test</mark> Record",
+			},
+		}
+
+		// Set the expectation at the database layer.
+		config.db.EXPECT().Search(gomock.Any(), &db.SearchOptions{
+			Query:    "test",
+			PageSize: 10,
+		}).Return(results, nil).Times(1)
+
+		got, err := s.Search(context.Background(), &SearchOptions{
+			Query:    "test",
+			PageSize: 10,
+		})
+		if err != nil {
+			t.Errorf("service.Search() error = %v, wantErr %v", err, false)
+		}
+		if len(got) != len(results) {
+			t.Errorf("service.Search() = %v, want %v", len(got), len(results))
+		}
 	})
 }
 
@@ -223,9 +294,9 @@ func Test_Service_Get(t *testing.T) {
 	t.Run("get record with invalid ID", func(t *testing.T) {
 
 		// Make sure the database layer is not expecting a call.
-		config.db.EXPECT().Get(gomock.Any(), gomock.Any()).Times(0)
+		config.db.EXPECT().Get(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 
-		_, err := s.Get(context.Background(), uuid.Nil)
+		_, err := s.Get(context.Background(), uuid.Nil, nil)
 		if err == nil || err != ErrInvalidOptions {
 			t.Errorf("service.Get() error = %v, wantErr %v", err, true)
 		}
@@ -241,9 +312,9 @@ func Test_Service_Get(t *testing.T) {
 		}
 
 		// Set the expectation at the database layer.
-		config.db.EXPECT().Get(gomock.Any(), id).Return(&record, nil).Times(1)
+		config.db.EXPECT().Get(gomock.Any(), id, gomock.Any()).Return(&record, nil).Times(1)
 
-		got, err := s.Get(context.Background(), id)
+		got, err := s.Get(context.Background(), id, nil)
 		if err != nil {
 			t.Errorf("service.Get() error = %v, wantErr %v", err, false)
 		}
@@ -332,6 +403,52 @@ func Test_Service_Update(t *testing.T) {
 			t.Errorf("service.Update() = %v, want %v", got.Title, record.Title)
 		}
 	})
+
+	t.Run("update record w/ stale version", func(t *testing.T) {
+
+		// Set the expectation at the database layer.
+		config.db.EXPECT().Update(gomock.Any(), id, &db.UpdateOptions{
+			Title:   "Updated Record",
+			Version: 2,
+		}).Return(nil, db.ErrVersionMismatch).Times(1)
+
+		_, err := s.Update(context.Background(), id, &UpdateOptions{
+			Title:   "Updated Record",
+			Version: 2,
+		})
+		if !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("service.Update() error = %v, want %v", err, ErrVersionMismatch)
+		}
+	})
+
+	t.Run("update record w/ update mask", func(t *testing.T) {
+
+		// The update mask must be passed through to the database layer.
+		config.db.EXPECT().Update(gomock.Any(), id, &db.UpdateOptions{
+			Title:      "Updated Record",
+			UpdateMask: []string{"title"},
+		}).Return(&model.Record{Base: model.Base{ID: id}, Title: "Updated Record"}, nil).Times(1)
+
+		if _, err := s.Update(context.Background(), id, &UpdateOptions{
+			Title:      "Updated Record",
+			UpdateMask: []string{"title"},
+		}); err != nil {
+			t.Errorf("service.Update() error = %v, wantErr %v", err, false)
+		}
+	})
+
+	t.Run("update record w/ masked empty title", func(t *testing.T) {
+
+		// Make sure the database layer is not expecting a call.
+		config.db.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := s.Update(context.Background(), id, &UpdateOptions{
+			UpdateMask: []string{"*"},
+		})
+		if !errors.Is(err, ErrInvalidTitle) {
+			t.Errorf("service.Update() error = %v, want %v", err, ErrInvalidTitle)
+		}
+	})
 }
 
 func Test_Service_Delete(t *testing.T) {
@@ -351,9 +468,9 @@ func Test_Service_Delete(t *testing.T) {
 	t.Run("delete record with invalid ID", func(t *testing.T) {
 
 		// Make sure the database layer is not expecting a call.
-		config.db.EXPECT().Delete(gomock.Any(), gomock.Any()).Times(0)
+		config.db.EXPECT().Delete(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 
-		err := s.Delete(context.Background(), uuid.Nil)
+		err := s.Delete(context.Background(), uuid.Nil, nil)
 		if err == nil || err != ErrInvalidRecordID {
 			t.Errorf("service.Delete() error = %v, wantErr %v", err, true)
 		}
@@ -362,11 +479,361 @@ func Test_Service_Delete(t *testing.T) {
 	t.Run("delete record with valid ID", func(t *testing.T) {
 
 		// Set the expectation at the database layer.
-		config.db.EXPECT().Delete(gomock.Any(), id).Return(nil).Times(1)
+		config.db.EXPECT().Delete(gomock.Any(), id, gomock.Any()).Return(nil).Times(1)
 
-		err := s.Delete(context.Background(), id)
+		err := s.Delete(context.Background(), id, nil)
 		if err != nil {
 			t.Errorf("service.Delete() error = %v, wantErr %v", err, false)
 		}
 	})
+	t.Run("force delete record", func(t *testing.T) {
+
+		// Set the expectation at the database layer.
+		config.db.EXPECT().Delete(gomock.Any(), id, &db.DeleteOptions{Force: true}).Return(nil).Times(1)
+
+		err := s.Delete(context.Background(), id, &DeleteOptions{Force: true})
+		if err != nil {
+			t.Errorf("service.Delete() error = %v, wantErr %v", err, false)
+		}
+	})
+}
+
+func Test_Service_Undelete(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Initialize the service.
+	s := &service{
+		db:     config.db,
+		logger: config.log,
+	}
+
+	t.Run("undelete record with invalid ID", func(t *testing.T) {
+
+		// Make sure the database layer is not expecting a call.
+		config.db.EXPECT().Undelete(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := s.Undelete(context.Background(), uuid.Nil)
+		if err == nil || err != ErrInvalidRecordID {
+			t.Errorf("service.Undelete() error = %v, wantErr %v", err, true)
+		}
+	})
+
+	t.Run("undelete record with valid ID", func(t *testing.T) {
+
+		record := model.Record{
+			Base: model.Base{
+				ID: uuid.New(),
+			},
+			Title: "Test Record",
+		}
+
+		// Set the expectation at the database layer.
+		config.db.EXPECT().Undelete(gomock.Any(), record.ID).Return(&record, nil).Times(1)
+
+		got, err := s.Undelete(context.Background(), record.ID)
+		if err != nil {
+			t.Errorf("service.Undelete() error = %v, wantErr %v", err, false)
+		}
+		if got.ID != record.ID {
+			t.Errorf("service.Undelete() = %v, want %v", got.ID, record.ID)
+		}
+	})
+}
+
+func Test_Service_Purge(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Initialize the service.
+	s := &service{
+		db:     config.db,
+		logger: config.log,
+	}
+
+	t.Run("purge records with nil options", func(t *testing.T) {
+
+		// Make sure the database layer is not expecting a call.
+		config.db.EXPECT().Purge(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := s.Purge(context.Background(), nil)
+		if err == nil || err != ErrInvalidOptions {
+			t.Errorf("service.Purge() error = %v, wantErr %v", err, true)
+		}
+	})
+
+	t.Run("purge records w/o cutoff", func(t *testing.T) {
+
+		// Make sure the database layer is not expecting a call.
+		config.db.EXPECT().Purge(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := s.Purge(context.Background(), &PurgeOptions{})
+		if err == nil {
+			t.Errorf("service.Purge() error = %v, wantErr %v", err, true)
+		}
+	})
+
+	t.Run("purge records with valid options", func(t *testing.T) {
+
+		cutoff := time.Now().Add(-time.Hour)
+
+		// Set the expectation at the database layer.
+		config.db.EXPECT().Purge(gomock.Any(), &db.PurgeOptions{DeletedBefore: cutoff}).Return(int64(3), nil).Times(1)
+
+		purged, err := s.Purge(context.Background(), &PurgeOptions{DeletedBefore: cutoff})
+		if err != nil {
+			t.Errorf("service.Purge() error = %v, wantErr %v", err, false)
+		}
+		if purged != 3 {
+			t.Errorf("service.Purge() = %v, want %v", purged, 3)
+		}
+	})
+}
+
+func Test_Service_Batch(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Initialize the service, with at most 2 items per batch.
+	s := &service{
+		db:           config.db,
+		logger:       config.log,
+		maxBatchSize: 2,
+	}
+
+	userID := uuid.New()
+
+	t.Run("batch create records", func(t *testing.T) {
+
+		// Set the expectation at the database layer.
+		config.db.EXPECT().BatchCreate(gomock.Any(), &db.BatchCreateOptions{
+			Requests: []*db.CreateOptions{
+				{Title: "First", UserID: userID},
+				{Title: "Second", UserID: userID},
+			},
+		}).Return([]*model.Record{{Title: "First"}, {Title: "Second"}}, nil).Times(1)
+
+		got, err := s.BatchCreate(context.Background(), &BatchCreateOptions{
+			Requests: []*CreateOptions{
+				{Title: "First", UserID: userID},
+				{Title: "Second", UserID: userID},
+			},
+		})
+		if err != nil {
+			t.Fatalf("service.BatchCreate() error = %v, wantErr %v", err, false)
+		}
+		if len(got) != 2 {
+			t.Errorf("service.BatchCreate() = %v, want 2 records", got)
+		}
+	})
+
+	t.Run("batch create w/ invalid records", func(t *testing.T) {
+
+		// Make sure the database layer is not expecting a call.
+		config.db.EXPECT().BatchCreate(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := s.BatchCreate(context.Background(), &BatchCreateOptions{
+			Requests: []*CreateOptions{
+				{Title: "", UserID: userID},
+				{Title: "Second"},
+			},
+		})
+		var batch *BatchError
+		if !errors.As(err, &batch) || len(batch.Items) != 2 {
+			t.Fatalf("service.BatchCreate() error = %v, want a batch error with 2 items", err)
+		}
+		if !errors.Is(batch.Items[0], ErrInvalidTitle) || !errors.Is(batch.Items[1], ErrInvalidUserID) {
+			t.Errorf("service.BatchCreate() error = %v, want the errors of both items", err)
+		}
+	})
+
+	t.Run("batch w/o items or w/ too many items", func(t *testing.T) {
+
+		// Make sure the database layer is not expecting a call.
+		config.db.EXPECT().BatchGet(gomock.Any(), gomock.Any()).Times(0)
+		config.db.EXPECT().BatchDelete(gomock.Any(), gomock.Any()).Times(0)
+
+		if _, err := s.BatchGet(context.Background(), &BatchGetOptions{}); err != ErrInvalidOptions {
+			t.Errorf("service.BatchGet() error = %v, want %v", err, ErrInvalidOptions)
+		}
+		if _, err := s.BatchGet(context.Background(), nil); err != ErrInvalidOptions {
+			t.Errorf("service.BatchGet() error = %v, want %v", err, ErrInvalidOptions)
+		}
+		if err := s.BatchDelete(context.Background(), &BatchDeleteOptions{
+			IDs: []uuid.UUID{uuid.New(), uuid.New(), uuid.New()},
+		}); err != ErrBatchTooLarge {
+			t.Errorf("service.BatchDelete() error = %v, want %v", err, ErrBatchTooLarge)
+		}
+	})
+
+	t.Run("batch update records", func(t *testing.T) {
+
+		id := uuid.New()
+
+		// The update masks and versions must be passed through to the database layer.
+		config.db.EXPECT().BatchUpdate(gomock.Any(), &db.BatchUpdateOptions{
+			Requests: []*db.BatchUpdateRequest{
+				{ID: id, UpdateOptions: db.UpdateOptions{Title: "Updated", UpdateMask: []string{"title"}, Version: 2}},
+			},
+		}).Return(nil, &db.BatchError{Items: []*db.ItemError{{Index: 0, Err: db.ErrVersionMismatch}}}).Times(1)
+
+		_, err := s.BatchUpdate(context.Background(), &BatchUpdateOptions{
+			Requests: []*BatchUpdateRequest{
+				{ID: id, UpdateOptions: UpdateOptions{Title: "Updated", UpdateMask: []string{"title"}, Version: 2}},
+			},
+		})
+		if !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("service.BatchUpdate() error = %v, want %v", err, ErrVersionMismatch)
+		}
+	})
+
+	t.Run("batch update w/ invalid requests", func(t *testing.T) {
+
+		// Make sure the database layer is not expecting a call.
+		config.db.EXPECT().BatchUpdate(gomock.Any(), gomock.Any()).Times(0)
+
+		_, err := s.BatchUpdate(context.Background(), &BatchUpdateOptions{
+			Requests: []*BatchUpdateRequest{
+				{UpdateOptions: UpdateOptions{Title: "Updated"}},
+				nil,
+			},
+		})
+		var batch *BatchError
+		if !errors.As(err, &batch) || len(batch.Items) != 2 || !errors.Is(batch.Items[0], ErrInvalidRecordID) {
+			t.Errorf("service.BatchUpdate() error = %v, want a batch error with 2 items", err)
+		}
+	})
+}
+
+func Test_Service_Revisions(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Initialize the service.
+	s := &service{
+		db:     config.db,
+		logger: config.log,
+	}
+
+	t.Run("revisions w/ invalid ID or revision", func(t *testing.T) {
+
+		// Make sure the database layer is not expecting a call.
+		config.db.EXPECT().ListRevisions(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		config.db.EXPECT().GetRevision(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		config.db.EXPECT().DiffRevisions(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		config.db.EXPECT().Restore(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		if _, _, err := s.ListRevisions(context.Background(), uuid.Nil, nil); err != ErrInvalidRecordID {
+			t.Errorf("service.ListRevisions() error = %v, want %v", err, ErrInvalidRecordID)
+		}
+		if _, err := s.GetRevision(context.Background(), uuid.New(), 0); err != ErrInvalidRevision {
+			t.Errorf("service.GetRevision() error = %v, want %v", err, ErrInvalidRevision)
+		}
+		if _, err := s.DiffRevisions(context.Background(), uuid.New(), 0, nil); err != ErrInvalidRevision {
+			t.Errorf("service.DiffRevisions() error = %v, want %v", err, ErrInvalidRevision)
+		}
+		if _, err := s.DiffRevisions(context.Background(), uuid.New(), 2, &DiffRevisionsOptions{Against: -1}); err != ErrInvalidRevision {
+			t.Errorf("service.DiffRevisions() error = %v, want %v", err, ErrInvalidRevision)
+		}
+		if _, err := s.Restore(context.Background(), uuid.New(), nil); err != ErrInvalidOptions {
+			t.Errorf("service.Restore() error = %v, want %v", err, ErrInvalidOptions)
+		}
+		if _, err := s.Restore(context.Background(), uuid.New(), &RestoreOptions{}); err != ErrInvalidRevision {
+			t.Errorf("service.Restore() error = %v, want %v", err, ErrInvalidRevision)
+		}
+	})
+
+	t.Run("list the revisions of a record", func(t *testing.T) {
+
+		id := uuid.New()
+
+		// Set the expectation at the database layer.
+		config.db.EXPECT().ListRevisions(gomock.Any(), id, &db.ListRevisionsOptions{PageSize: 2, PageToken: "token"}).Return([]*model.Revision{{RecordID: id, Revision: 1}}, "", nil).Times(1)
+
+		revisions, _, err := s.ListRevisions(context.Background(), id, &ListRevisionsOptions{PageSize: 2, PageToken: "token"})
+		if err != nil {
+			t.Fatalf("service.ListRevisions() error = %v", err)
+		}
+		if len(revisions) != 1 {
+			t.Errorf("service.ListRevisions() returned %d revisions, want 1", len(revisions))
+		}
+	})
+
+	t.Run("restore a record", func(t *testing.T) {
+
+		record := model.Record{
+			Base: model.Base{
+				ID: uuid.New(),
+			},
+			Title: "Test Record",
+		}
+
+		// The revision and the version must be passed through to the database layer.
+		config.db.EXPECT().Restore(gomock.Any(), record.ID, &db.RestoreOptions{Revision: 1, Version: 3}).Return(&record, nil).Times(1)
+
+		got, err := s.Restore(context.Background(), record.ID, &RestoreOptions{Revision: 1, Version: 3})
+		if err != nil {
+			t.Fatalf("service.Restore() error = %v", err)
+		}
+		if got.ID != record.ID {
+			t.Errorf("service.Restore() = %v, want %v", got.ID, record.ID)
+		}
+	})
+}
+
+func Test_Service_CreateWebhook(t *testing.T) {
+
+	// Setup the test config.
+	config := configure(t)
+
+	// Initialize the service.
+	s := &service{
+		db:     config.db,
+		logger: config.log,
+	}
+
+	t.Run("create webhook with invalid options", func(t *testing.T) {
+
+		// Make sure the database layer is not expecting a call.
+		config.db.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Times(0)
+
+		tests := map[*CreateWebhookOptions]error{
+			nil:                           ErrInvalidOptions,
+			{URL: "https://example.com"}:  ErrInvalidUserID,
+			{UserID: uuid.New(), URL: ""}: ErrInvalidURL,
+		}
+		for options, want := range tests {
+			if _, err := s.CreateWebhook(context.Background(), options); err != want {
+				t.Errorf("service.CreateWebhook() error = %v, want %v", err, want)
+			}
+		}
+	})
+
+	t.Run("create webhook with valid options", func(t *testing.T) {
+
+		options := CreateWebhookOptions{
+			UserID: uuid.New(),
+			URL:    "https://example.com/hooks",
+			Events: []string{model.EventRecordCreated},
+		}
+
+		// Set the expectation at the database layer.
+		config.db.EXPECT().CreateWebhook(gomock.Any(), &db.CreateWebhookOptions{
+			UserID: options.UserID,
+			URL:    options.URL,
+			Events: options.Events,
+		}).Return(&model.Webhook{UserID: options.UserID, URL: options.URL}, nil).Times(1)
+
+		webhook, err := s.CreateWebhook(context.Background(), &options)
+		if err != nil {
+			t.Errorf("service.CreateWebhook() error = %v, wantErr %v", err, false)
+		}
+		if webhook.URL != options.URL {
+			t.Errorf("service.CreateWebhook() = %v, want %v", webhook.URL, options.URL)
+		}
+	})
 }
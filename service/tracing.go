@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mrinalwahal/service/model"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type TracingConfig struct {
+
+	// Service is the service layer whose operations will be traced.
+	//
+	// This field is mandatory.
+	Service Service
+
+	// TracerProvider creates the tracer of the service layer.
+	// Default: `otel.GetTracerProvider()`
+	//
+	// This field is optional.
+	TracerProvider trace.TracerProvider
+}
+
+// WithTracing wraps the service layer to trace its operations.
+//
+// Every operation is recorded as a child span of the span in its context, e.g. the server span of the HTTP request.
+func WithTracing(config *TracingConfig) Service {
+
+	if config == nil || config.Service == nil {
+		panic("service: nil tracing config")
+	}
+
+	provider := config.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return &tracing{
+		next:   config.Service,
+		tracer: provider.Tracer("github.com/mrinalwahal/service/service"),
+	}
+}
+
+// tracing is the service layer decorator created by `WithTracing`.
+type tracing struct {
+
+	//	Traced service layer.
+	next Service
+
+	tracer trace.Tracer
+}
+
+// endSpan records the outcome of the operation on its span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (t *tracing) Create(ctx context.Context, options *CreateOptions) (*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "service.Create")
+	record, err := t.next.Create(ctx, options)
+	if err == nil {
+		span.SetAttributes(attribute.String("record.id", record.ID.String()))
+	}
+	endSpan(span, err)
+	return record, err
+}
+
+func (t *tracing) List(ctx context.Context, options *ListOptions) ([]*model.Record, string, error) {
+	ctx, span := t.tracer.Start(ctx, "service.List")
+	records, token, err := t.next.List(ctx, options)
+	if err == nil {
+		span.SetAttributes(attribute.Int("records.count", len(records)))
+	}
+	endSpan(span, err)
+	return records, token, err
+}
+
+func (t *tracing) Search(ctx context.Context, options *SearchOptions) ([]*model.SearchResult, error) {
+	ctx, span := t.tracer.Start(ctx, "service.Search")
+	results, err := t.next.Search(ctx, options)
+	if err == nil {
+		span.SetAttributes(attribute.Int("records.count", len(results)))
+	}
+	endSpan(span, err)
+	return results, err
+}
+
+func (t *tracing) Get(ctx context.Context, id uuid.UUID, options *GetOptions) (*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "service.Get", trace.WithAttributes(attribute.String("record.id", id.String())))
+	record, err := t.next.Get(ctx, id, options)
+	endSpan(span, err)
+	return record, err
+}
+
+func (t *tracing) Update(ctx context.Context, id uuid.UUID, options *UpdateOptions) (*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "service.Update", trace.WithAttributes(attribute.String("record.id", id.String())))
+	record, err := t.next.Update(ctx, id, options)
+	endSpan(span, err)
+	return record, err
+}
+
+func (t *tracing) Delete(ctx context.Context, id uuid.UUID, options *DeleteOptions) error {
+	ctx, span := t.tracer.Start(ctx, "service.Delete", trace.WithAttributes(attribute.String("record.id", id.String())))
+	err := t.next.Delete(ctx, id, options)
+	endSpan(span, err)
+	return err
+}
+
+func (t *tracing) Undelete(ctx context.Context, id uuid.UUID) (*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "service.Undelete", trace.WithAttributes(attribute.String("record.id", id.String())))
+	record, err := t.next.Undelete(ctx, id)
+	endSpan(span, err)
+	return record, err
+}
+
+func (t *tracing) Purge(ctx context.Context, options *PurgeOptions) (int64, error) {
+	ctx, span := t.tracer.Start(ctx, "service.Purge")
+	purged, err := t.next.Purge(ctx, options)
+	if err == nil {
+		span.SetAttributes(attribute.Int64("records.count", purged))
+	}
+	endSpan(span, err)
+	return purged, err
+}
+
+func (t *tracing) BatchCreate(ctx context.Context, options *BatchCreateOptions) ([]*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "service.BatchCreate")
+	records, err := t.next.BatchCreate(ctx, options)
+	if err == nil {
+		span.SetAttributes(attribute.Int("records.count", len(records)))
+	}
+	endSpan(span, err)
+	return records, err
+}
+
+func (t *tracing) BatchGet(ctx context.Context, options *BatchGetOptions) ([]*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "service.BatchGet")
+	records, err := t.next.BatchGet(ctx, options)
+	if err == nil {
+		span.SetAttributes(attribute.Int("records.count", len(records)))
+	}
+	endSpan(span, err)
+	return records, err
+}
+
+func (t *tracing) BatchUpdate(ctx context.Context, options *BatchUpdateOptions) ([]*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "service.BatchUpdate")
+	records, err := t.next.BatchUpdate(ctx, options)
+	if err == nil {
+		span.SetAttributes(attribute.Int("records.count", len(records)))
+	}
+	endSpan(span, err)
+	return records, err
+}
+
+func (t *tracing) BatchDelete(ctx context.Context, options *BatchDeleteOptions) error {
+	ctx, span := t.tracer.Start(ctx, "service.BatchDelete")
+	err := t.next.BatchDelete(ctx, options)
+	if err == nil {
+		span.SetAttributes(attribute.Int("records.count", len(options.IDs)))
+	}
+	endSpan(span, err)
+	return err
+}
+
+func (t *tracing) ListRevisions(ctx context.Context, id uuid.UUID, options *ListRevisionsOptions) ([]*model.Revision, string, error) {
+	ctx, span := t.tracer.Start(ctx, "service.ListRevisions", trace.WithAttributes(attribute.String("record.id", id.String())))
+	revisions, token, err := t.next.ListRevisions(ctx, id, options)
+	if err == nil {
+		span.SetAttributes(attribute.Int("revisions.count", len(revisions)))
+	}
+	endSpan(span, err)
+	return revisions, token, err
+}
+
+func (t *tracing) GetRevision(ctx context.Context, id uuid.UUID, revision int64) (*model.Revision, error) {
+	ctx, span := t.tracer.Start(ctx, "service.GetRevision", trace.WithAttributes(
+		attribute.String("record.id", id.String()),
+		attribute.Int64("record.revision", revision),
+	))
+	payload, err := t.next.GetRevision(ctx, id, revision)
+	endSpan(span, err)
+	return payload, err
+}
+
+func (t *tracing) DiffRevisions(ctx context.Context, id uuid.UUID, revision int64, options *DiffRevisionsOptions) (*model.Diff, error) {
+	ctx, span := t.tracer.Start(ctx, "service.DiffRevisions", trace.WithAttributes(
+		attribute.String("record.id", id.String()),
+		attribute.Int64("record.revision", revision),
+	))
+	payload, err := t.next.DiffRevisions(ctx, id, revision, options)
+	endSpan(span, err)
+	return payload, err
+}
+
+func (t *tracing) Restore(ctx context.Context, id uuid.UUID, options *RestoreOptions) (*model.Record, error) {
+	ctx, span := t.tracer.Start(ctx, "service.Restore", trace.WithAttributes(attribute.String("record.id", id.String())))
+	record, err := t.next.Restore(ctx, id, options)
+	endSpan(span, err)
+	return record, err
+}
+
+func (t *tracing) CreateWebhook(ctx context.Context, options *CreateWebhookOptions) (*model.Webhook, error) {
+	ctx, span := t.tracer.Start(ctx, "service.CreateWebhook")
+	webhook, err := t.next.CreateWebhook(ctx, options)
+	if err == nil {
+		span.SetAttributes(attribute.String("webhook.id", webhook.ID.String()))
+	}
+	endSpan(span, err)
+	return webhook, err
+}
+
+func (t *tracing) ListWebhooks(ctx context.Context, options *ListWebhooksOptions) ([]*model.Webhook, string, error) {
+	ctx, span := t.tracer.Start(ctx, "service.ListWebhooks")
+	webhooks, token, err := t.next.ListWebhooks(ctx, options)
+	if err == nil {
+		span.SetAttributes(attribute.Int("webhooks.count", len(webhooks)))
+	}
+	endSpan(span, err)
+	return webhooks, token, err
+}
+
+func (t *tracing) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	ctx, span := t.tracer.Start(ctx, "service.DeleteWebhook", trace.WithAttributes(attribute.String("webhook.id", id.String())))
+	err := t.next.DeleteWebhook(ctx, id)
+	endSpan(span, err)
+	return err
+}
+
+func (t *tracing) ListDeliveries(ctx context.Context, options *ListDeliveriesOptions) ([]*model.Delivery, string, error) {
+	ctx, span := t.tracer.Start(ctx, "service.ListDeliveries")
+	deliveries, token, err := t.next.ListDeliveries(ctx, options)
+	if err == nil {
+		span.SetAttributes(attribute.Int("deliveries.count", len(deliveries)))
+	}
+	endSpan(span, err)
+	return deliveries, token, err
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_WithTracing(t *testing.T) {
+
+	config := configure(t)
+	exporter := tracetest.NewInMemoryExporter()
+	s := WithTracing(&TracingConfig{
+		Service:        NewService(&Config{DB: config.db, Logger: config.log}),
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)),
+	})
+
+	id := uuid.New()
+	config.db.EXPECT().Delete(gomock.Any(), id, gomock.Any()).Return(errors.New("boom"))
+	if err := s.Delete(context.Background(), id, nil); err == nil {
+		t.Fatalf("Delete() expected an error")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name != "service.Delete" {
+		t.Errorf("expected the span to be named 'service.Delete', got %q", spans[0].Name)
+	}
+	if spans[0].Status.Code != codes.Error {
+		t.Errorf("expected the span to record the error, got status %v", spans[0].Status)
+	}
+}